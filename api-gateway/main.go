@@ -7,39 +7,189 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"html"
 	"html/template"
 	"net/http"
+	"os"
+	"shared/authz"
 	"strconv"
+	"strings"
 	"time"
 
 	pbInventory "api-gateway/pb/inventory"
+	pbMoney "api-gateway/pb/money"
 	pbOrder "api-gateway/pb/order"
 	pbUser "api-gateway/pb/user"
 
 	"github.com/gin-gonic/gin"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/emptypb"
 )
 
+// Money is an exact amount in the currency's minor unit: {"minor_units":
+// 199999, "currency_code": "USD"} is 1999.99 USD.
+type Money struct {
+	MinorUnits   int64  `json:"minor_units"`
+	CurrencyCode string `json:"currency_code"`
+}
+
+// String formats m for display with the currency's decimal places, e.g.
+// "1999.99 USD", "1999 JPY" or "19.999 KWD".
+func (m Money) String() string {
+	return formatAmount(m.MinorUnits, m.CurrencyCode) + " " + m.CurrencyCode
+}
+
+// currencyExponents lists the ISO 4217 currencies whose minor unit is not a
+// hundredth of the major unit, with the number of decimal places they have.
+var currencyExponents = map[string]int{
+	"BIF": 0, "CLP": 0, "DJF": 0, "GNF": 0, "ISK": 0, "JPY": 0, "KMF": 0, "KRW": 0,
+	"PYG": 0, "RWF": 0, "UGX": 0, "UYI": 0, "VND": 0, "VUV": 0, "XAF": 0, "XOF": 0, "XPF": 0,
+	"BHD": 3, "IQD": 3, "JOD": 3, "KWD": 3, "LYD": 3, "OMR": 3, "TND": 3,
+	"CLF": 4, "UYW": 4,
+}
+
+// currencyExponent is the number of decimal places of currency; unknown
+// currencies are assumed to have two.
+func currencyExponent(currency string) int {
+	if exp, ok := currencyExponents[strings.ToUpper(currency)]; ok {
+		return exp
+	}
+	return 2
+}
+
+// Product.Category is the category name; on writes without a category_id
+// it may be a category slug or name instead.
 type Product struct {
-	ID       int     `json:"id"`
-	Name     string  `json:"name"`
-	Category string  `json:"category"`
-	Stock    int     `json:"stock"`
-	Price    float64 `json:"price"`
+	ID          int    `json:"id"`
+	Name        string `json:"name"`
+	CategoryID  int    `json:"category_id"`
+	Category    string `json:"category"`
+	Stock       int    `json:"stock"`
+	Price       Money  `json:"price"`
+	Description string `json:"description"`
+
+	// Variants is set when a single product is read or created.
+	Variants []Variant `json:"variants,omitempty"`
+}
+
+// Variant is a stocked and orderable version of a product, e.g. size M in
+// red. Price overrides the product price and is omitted when it does not.
+type Variant struct {
+	ID        int               `json:"id"`
+	ProductID int               `json:"product_id"`
+	SKU       string            `json:"sku"`
+	Options   map[string]string `json:"options"`
+	Price     *Money            `json:"price,omitempty"`
+	Stock     int               `json:"stock"`
+	Barcode   string            `json:"barcode,omitempty"`
+}
+
+// Warehouse holds stock; the one with the lowest priority is the primary
+// warehouse, which product and variant stock writes go to.
+type Warehouse struct {
+	ID       int    `json:"id"`
+	Code     string `json:"code"`
+	Name     string `json:"name"`
+	Country  string `json:"country"`
+	Region   string `json:"region"`
+	Priority int    `json:"priority"`
+}
+
+// StockLevel is the stock of a variant in a warehouse.
+type StockLevel struct {
+	WarehouseID   int    `json:"warehouse_id"`
+	WarehouseCode string `json:"warehouse_code"`
+	ProductID     int    `json:"product_id"`
+	VariantID     int    `json:"variant_id"`
+	SKU           string `json:"sku"`
+	Stock         int    `json:"stock"`
+}
+
+type Category struct {
+	ID       int    `json:"id"`
+	ParentID int    `json:"parent_id"`
+	Name     string `json:"name"`
+	Slug     string `json:"slug"`
+	Depth    int    `json:"depth"`
+	Path     string `json:"path"`
 }
 
 type OrderItem struct {
-	ProductID int `json:"product_id"`
-	Quantity  int `json:"quantity"`
+	ProductID      int    `json:"product_id"`
+	VariantID      int    `json:"variant_id,omitempty"`
+	SKU            string `json:"sku,omitempty"`
+	Quantity       int    `json:"quantity"`
+	ProductName    string `json:"product_name"`
+	VariantOptions string `json:"variant_options,omitempty"`
+	UnitPrice      Money  `json:"unit_price"`
+	LineTotal      Money  `json:"line_total"`
+	WarehouseID    int    `json:"warehouse_id,omitempty"`
 }
 
 type Order struct {
-	ID        int         `json:"id"`
-	UserID    string      `json:"user_id"`
-	Status    string      `json:"status"`
-	CreatedAt string      `json:"created_at"`
-	Items     []OrderItem `json:"items"`
+	ID          int         `json:"id"`
+	UserID      string      `json:"user_id"`
+	Status      string      `json:"status"`
+	CreatedAt   string      `json:"created_at"`
+	Items       []OrderItem `json:"items"`
+	Subtotal    Money       `json:"subtotal"`
+	Tax         Money       `json:"tax"`
+	TotalAmount Money       `json:"total_amount"`
+
+	ShippingAddress *OrderAddress `json:"shipping_address,omitempty"`
+	BillingAddress  *OrderAddress `json:"billing_address,omitempty"`
+}
+
+// OrderAddress is the address as it was when the order was placed.
+type OrderAddress struct {
+	AddressID     int    `json:"address_id"`
+	RecipientName string `json:"recipient_name"`
+	Line1         string `json:"line1"`
+	Line2         string `json:"line2,omitempty"`
+	City          string `json:"city"`
+	Region        string `json:"region,omitempty"`
+	PostalCode    string `json:"postal_code"`
+	Country       string `json:"country"`
+	Phone         string `json:"phone,omitempty"`
+}
+
+type Address struct {
+	ID            int    `json:"id"`
+	Label         string `json:"label"`
+	RecipientName string `json:"recipient_name"`
+	Line1         string `json:"line1"`
+	Line2         string `json:"line2"`
+	City          string `json:"city"`
+	Region        string `json:"region"`
+	PostalCode    string `json:"postal_code"`
+	Country       string `json:"country"`
+	Phone         string `json:"phone"`
+	IsDefault     bool   `json:"is_default"`
+}
+
+type StatusChange struct {
+	From      string `json:"from"`
+	To        string `json:"to"`
+	Reason    string `json:"reason,omitempty"`
+	ChangedAt string `json:"changed_at"`
+}
+
+type TokenResponse struct {
+	AccessToken      string `json:"access_token"`
+	TokenType        string `json:"token_type"`
+	ExpiresIn        int64  `json:"expires_in"`
+	RefreshToken     string `json:"refresh_token"`
+	RefreshExpiresIn int64  `json:"refresh_expires_in"`
+}
+
+type Role struct {
+	Name        string   `json:"name"`
+	Description string   `json:"description"`
+	Permissions []string `json:"permissions"`
 }
 
 type User struct {
@@ -60,6 +210,11 @@ func main() {
 	redisClient := cache.NewRedisClient()
 
 	r := gin.Default()
+	// Only honour X-Forwarded-For from TRUSTED_PROXIES (comma-separated), so
+	// clients cannot pick the address failed logins are throttled by.
+	if err := r.SetTrustedProxies(trustedProxies()); err != nil {
+		logger.Log.Fatalf("Invalid TRUSTED_PROXIES: %v", err)
+	}
 	r.Static("/static", "./static")
 	funcMap := template.FuncMap{
 		"sub": func(a, b int) int {
@@ -77,6 +232,13 @@ func main() {
 	r.SetHTMLTemplate(tmpl)
 
 	r.Use(middleware.RequestLogger())
+	r.Use(middleware.ForwardClientIP())
+
+	jwksURL := os.Getenv("JWKS_URL")
+	if jwksURL == "" {
+		jwksURL = "http://user-service:8083/.well-known/jwks.json"
+	}
+	r.Use(middleware.Authenticate(authz.NewVerifier(jwksURL)))
 
 	invConn, _ := grpc.Dial("inventory-service:50053", grpc.WithInsecure())
 	defer invConn.Close()
@@ -94,50 +256,241 @@ func main() {
 		c.HTML(200, "index.html", nil)
 	})
 
+	// Filtering, sorting and paging happen in the inventory service; the page
+	// token in the "Next" link continues the same listing
 	r.GET("/products", func(c *gin.Context) {
-		page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
-		limit, _ := strconv.Atoi(c.DefaultQuery("limit", "5"))
-		offset := (page - 1) * limit
+		req, err := listProductsRequest(c)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
 
-		// Call gRPC to get all products
-		res, err := inventoryClient.ListProducts(c, &pbInventory.Empty{})
+		res, err := inventoryClient.ListProducts(c.Request.Context(), req)
 		if err != nil {
-			c.String(500, "Error loading products: %v", err)
+			if strings.Contains(c.GetHeader("Accept"), "application/json") {
+				c.JSON(httpStatusFromGRPC(err), gin.H{"error": "Failed to list products", "details": err.Error()})
+				return
+			}
+			c.String(httpStatusFromGRPC(err), "Error loading products: %v", err)
 			return
 		}
 
-		// Convert to local struct
-		var allProducts []Product
+		products := []Product{}
 		for _, p := range res.Products {
-			allProducts = append(allProducts, Product{
-				ID:       int(p.Id),
-				Name:     p.Name,
-				Category: p.Category,
-				Stock:    int(p.Stock),
-				Price:    float64(p.Price),
+			products = append(products, toProduct(p))
+		}
+
+		if strings.Contains(c.GetHeader("Accept"), "application/json") {
+			c.JSON(http.StatusOK, gin.H{
+				"products":        products,
+				"next_page_token": res.NextPageToken,
+				"total_count":     res.TotalCount,
 			})
+			return
 		}
 
-		// Apply pagination
-		total := len(allProducts)
-		end := offset + limit
-		if end > total {
-			end = total
+		filters := c.Request.URL.Query()
+		filters.Del("page_token")
+		next := ""
+		if res.NextPageToken != "" {
+			query := c.Request.URL.Query()
+			query.Set("page_token", res.NextPageToken)
+			next = "?" + query.Encode()
 		}
-		if offset > total {
-			offset = total
+		c.HTML(http.StatusOK, "products.html", gin.H{
+			"Products":  products,
+			"Total":     res.TotalCount,
+			"Filters":   c.Request.URL.Query(),
+			"FirstPage": "?" + filters.Encode(),
+			"NextPage":  next,
+			"Paged":     req.PageToken != "",
+		})
+	})
+
+	// Full-text product search; an empty query shows just the search box
+	r.GET("/search", func(c *gin.Context) {
+		wantsJSON := strings.Contains(c.GetHeader("Accept"), "application/json")
+		req, err := searchProductsRequest(c)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		if strings.TrimSpace(req.Query) == "" && !wantsJSON {
+			c.HTML(http.StatusOK, "search.html", gin.H{})
+			return
 		}
-		products := allProducts[offset:end]
 
-		c.HTML(http.StatusOK, "products.html", gin.H{
-			"Products": products,
-			"Page":     page,
-			"Limit":    limit,
-			"Total":    total,
+		res, err := inventoryClient.SearchProducts(c.Request.Context(), req)
+		if err != nil {
+			if wantsJSON {
+				c.JSON(httpStatusFromGRPC(err), gin.H{"error": "Search failed", "details": err.Error()})
+				return
+			}
+			c.HTML(httpStatusFromGRPC(err), "search.html", gin.H{"Query": req.Query, "Error": status.Convert(err).Message()})
+			return
+		}
+
+		if wantsJSON {
+			hits := []gin.H{}
+			for _, h := range res.Hits {
+				hits = append(hits, gin.H{
+					"product":          toProduct(h.Product),
+					"highlighted_name": h.HighlightedName,
+					"snippet":          h.Snippet,
+					"rank":             h.Rank,
+				})
+			}
+			categories := []gin.H{}
+			for _, f := range res.Categories {
+				categories = append(categories, gin.H{
+					"category_id": f.CategoryId,
+					"category":    f.Category,
+					"slug":        f.Slug,
+					"count":       f.Count,
+				})
+			}
+			prices := []gin.H{}
+			for _, f := range res.Prices {
+				prices = append(prices, gin.H{
+					"min":   Money{MinorUnits: f.MinMinorUnits, CurrencyCode: f.CurrencyCode},
+					"max":   Money{MinorUnits: f.MaxMinorUnits, CurrencyCode: f.CurrencyCode},
+					"count": f.Count,
+				})
+			}
+			c.JSON(http.StatusOK, gin.H{
+				"hits":            hits,
+				"next_page_token": res.NextPageToken,
+				"total_count":     res.TotalCount,
+				"fuzzy":           res.Fuzzy,
+				"facets":          gin.H{"categories": categories, "prices": prices},
+			})
+			return
+		}
+
+		hits := []searchHit{}
+		for _, h := range res.Hits {
+			hits = append(hits, searchHit{
+				Product:         toProduct(h.Product),
+				HighlightedName: highlight(h.HighlightedName),
+				Snippet:         highlight(h.Snippet),
+			})
+		}
+
+		var categories, prices []facetLink
+		for _, f := range res.Categories {
+			// Uncategorized products cannot be filtered for, so they get no link
+			link := facetLink{Label: f.Category, Count: f.Count}
+			if f.CategoryId == 0 {
+				link.Label = "Uncategorized"
+			} else {
+				link.URL = facetURL(c, map[string]string{"category": f.Slug, "category_id": ""})
+				link.Selected = f.CategoryId == req.CategoryId ||
+					req.Category != "" && (strings.EqualFold(req.Category, f.Slug) || strings.EqualFold(req.Category, f.Category))
+			}
+			categories = append(categories, link)
+		}
+		for _, f := range res.Prices {
+			min := Money{MinorUnits: f.MinMinorUnits, CurrencyCode: f.CurrencyCode}
+			params := map[string]string{"min_price": formatAmount(f.MinMinorUnits, f.CurrencyCode), "max_price": "", "currency": f.CurrencyCode}
+			label := "from " + min.String()
+			if f.MaxMinorUnits > 0 {
+				// Facet bounds are exclusive at the top, price filters inclusive
+				params["max_price"] = formatAmount(f.MaxMinorUnits-1, f.CurrencyCode)
+				label = fmt.Sprintf("%s – %s", min, Money{MinorUnits: f.MaxMinorUnits, CurrencyCode: f.CurrencyCode})
+			}
+			prices = append(prices, facetLink{Label: label, Count: f.Count, URL: facetURL(c, params)})
+		}
+
+		next := ""
+		if res.NextPageToken != "" {
+			query := c.Request.URL.Query()
+			query.Set("page_token", res.NextPageToken)
+			next = "/search?" + query.Encode()
+		}
+		c.HTML(http.StatusOK, "search.html", gin.H{
+			"Query":      req.Query,
+			"Hits":       hits,
+			"Total":      res.TotalCount,
+			"Fuzzy":      res.Fuzzy,
+			"Categories": categories,
+			"Prices":     prices,
+			"Filtered":   req.Category != "" || req.CategoryId != 0 || req.MinPrice != nil || req.MaxPrice != nil,
+			"ClearURL":   facetURL(c, map[string]string{"category": "", "category_id": "", "min_price": "", "max_price": "", "currency": ""}),
+			"NextPage":   next,
+		})
+	})
+
+	// Category tree; a product filtered by category includes its subcategories
+	r.GET("/categories", func(c *gin.Context) {
+		rootID, err := queryID(c, "root_id")
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		res, err := inventoryClient.ListCategories(c.Request.Context(), &pbInventory.ListCategoriesRequest{RootId: rootID})
+		if err != nil {
+			c.JSON(httpStatusFromGRPC(err), gin.H{"error": "Failed to list categories", "details": err.Error()})
+			return
+		}
+
+		categories := []Category{}
+		for _, cat := range res.Categories {
+			categories = append(categories, toCategory(cat))
+		}
+		c.JSON(http.StatusOK, categories)
+	})
+
+	r.POST("/categories", middleware.RequirePermission(authz.PermInventoryWrite), func(c *gin.Context) {
+		var input struct {
+			Name     string `json:"name" binding:"required"`
+			Slug     string `json:"slug"`
+			ParentID int    `json:"parent_id"`
+		}
+		if err := c.ShouldBindJSON(&input); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid category data"})
+			return
+		}
+
+		res, err := inventoryClient.CreateCategory(c.Request.Context(), &pbInventory.CreateCategoryRequest{
+			Name:     input.Name,
+			Slug:     input.Slug,
+			ParentId: int64(input.ParentID),
+		})
+		if err != nil {
+			c.JSON(httpStatusFromGRPC(err), gin.H{"error": "Failed to create category", "details": err.Error()})
+			return
+		}
+		c.JSON(http.StatusCreated, toCategory(res))
+	})
+
+	r.POST("/categories/:id/move", middleware.RequirePermission(authz.PermInventoryWrite), func(c *gin.Context) {
+		id, err := strconv.Atoi(c.Param("id"))
+		if err != nil {
+			c.JSON(400, gin.H{"error": "Invalid category ID"})
+			return
+		}
+
+		var input struct {
+			ParentID int `json:"parent_id"`
+		}
+		if err := c.ShouldBindJSON(&input); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid move request"})
+			return
+		}
+
+		res, err := inventoryClient.MoveCategory(c.Request.Context(), &pbInventory.MoveCategoryRequest{
+			Id:       int64(id),
+			ParentId: int64(input.ParentID),
 		})
+		if err != nil {
+			c.JSON(httpStatusFromGRPC(err), gin.H{"error": "Failed to move category", "details": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, toCategory(res))
 	})
 
-	r.POST("/products", func(c *gin.Context) {
+	r.POST("/products", middleware.RequirePermission(authz.PermInventoryWrite), func(c *gin.Context) {
 		var req Product
 		if err := c.ShouldBindJSON(&req); err != nil {
 			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid product data"})
@@ -145,26 +498,22 @@ func main() {
 		}
 
 		grpcReq := &pbInventory.Product{
-			Name:     req.Name,
-			Category: req.Category,
-			Stock:    int32(req.Stock),
-			Price:    float32(req.Price),
+			Name:       req.Name,
+			CategoryId: int64(req.CategoryID),
+			Category:   req.Category,
+			Stock:      int32(req.Stock),
+			Price:      toProtoMoney(req.Price),
+
+			Description: req.Description,
 		}
 
-		grpcRes, err := inventoryClient.CreateProduct(context.Background(), grpcReq)
+		grpcRes, err := inventoryClient.CreateProduct(withIdempotencyKey(c), grpcReq)
 		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create product", "details": err.Error()})
+			c.JSON(httpStatusFromGRPC(err), gin.H{"error": "Failed to create product", "details": err.Error()})
 			return
 		}
 
-		response := Product{
-			ID:       int(grpcRes.Id),
-			Name:     grpcRes.Name,
-			Category: grpcRes.Category,
-			Stock:    int(grpcRes.Stock),
-			Price:    float64(grpcRes.Price),
-		}
-		c.JSON(http.StatusCreated, response)
+		c.JSON(http.StatusCreated, toProduct(grpcRes))
 	})
 
 	r.GET("/products/:id", func(c *gin.Context) {
@@ -175,22 +524,16 @@ func main() {
 			return
 		}
 
-		res, err := inventoryClient.GetProduct(context.Background(), &pbInventory.ProductID{Id: int64(id)})
+		res, err := inventoryClient.GetProduct(c.Request.Context(), &pbInventory.ProductID{Id: int64(id)})
 		if err != nil {
 			c.JSON(500, gin.H{"error": "Failed to get product", "details": err.Error()})
 			return
 		}
 
-		c.JSON(200, Product{
-			ID:       int(res.Id),
-			Name:     res.Name,
-			Category: res.Category,
-			Stock:    int(res.Stock),
-			Price:    float64(res.Price),
-		})
+		c.JSON(200, toProduct(res))
 	})
 
-	r.PUT("/products/:id", func(c *gin.Context) {
+	r.PUT("/products/:id", middleware.RequirePermission(authz.PermInventoryWrite), func(c *gin.Context) {
 		idParam := c.Param("id")
 		id, err := strconv.Atoi(idParam)
 		if err != nil {
@@ -205,14 +548,17 @@ func main() {
 		}
 
 		req := &pbInventory.Product{
-			Id:       int64(id),
-			Name:     input.Name,
-			Category: input.Category,
-			Stock:    int32(input.Stock),
-			Price:    float32(input.Price),
+			Id:         int64(id),
+			Name:       input.Name,
+			CategoryId: int64(input.CategoryID),
+			Category:   input.Category,
+			Stock:      int32(input.Stock),
+			Price:      toProtoMoney(input.Price),
+
+			Description: input.Description,
 		}
 
-		_, err = inventoryClient.UpdateProduct(context.Background(), req)
+		_, err = inventoryClient.UpdateProduct(c.Request.Context(), req)
 		if err != nil {
 			c.JSON(500, gin.H{"error": "Failed to update product", "details": err.Error()})
 			return
@@ -221,7 +567,7 @@ func main() {
 		c.JSON(200, gin.H{"message": "Product updated"})
 	})
 
-	r.DELETE("/products/:id", func(c *gin.Context) {
+	r.DELETE("/products/:id", middleware.RequirePermission(authz.PermInventoryWrite), func(c *gin.Context) {
 		idParam := c.Param("id")
 		id, err := strconv.Atoi(idParam)
 		if err != nil {
@@ -229,7 +575,7 @@ func main() {
 			return
 		}
 
-		_, err = inventoryClient.DeleteProduct(context.Background(), &pbInventory.ProductID{Id: int64(id)})
+		_, err = inventoryClient.DeleteProduct(c.Request.Context(), &pbInventory.ProductID{Id: int64(id)})
 		if err != nil {
 			c.JSON(500, gin.H{"error": "Failed to delete product", "details": err.Error()})
 			return
@@ -238,202 +584,523 @@ func main() {
 		c.JSON(200, gin.H{"message": "Product deleted"})
 	})
 
-	r.GET("/orders", func(c *gin.Context) {
-		page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
-		limit, _ := strconv.Atoi(c.DefaultQuery("limit", "5"))
-		offset := (page - 1) * limit
+	r.GET("/products/:id/variants", func(c *gin.Context) {
+		id, err := strconv.Atoi(c.Param("id"))
+		if err != nil {
+			c.JSON(400, gin.H{"error": "Invalid product ID"})
+			return
+		}
 
-		res, err := orderClient.ListOrders(c, &pbOrder.UserOrdersRequest{})
+		res, err := inventoryClient.ListVariants(c.Request.Context(), &pbInventory.ProductID{Id: int64(id)})
 		if err != nil {
-			c.String(500, "Error loading orders: %v", err)
+			c.JSON(httpStatusFromGRPC(err), gin.H{"error": "Failed to list variants", "details": err.Error()})
 			return
 		}
 
-		var allOrders []Order
-		for _, o := range res.Orders {
-			var items []OrderItem
-			for _, item := range o.Items {
-				items = append(items, OrderItem{
-					ProductID: int(item.ProductId),
-					Quantity:  int(item.Quantity),
-				})
-			}
-			allOrders = append(allOrders, Order{
-				ID:        int(o.Id),
-				UserID:    fmt.Sprint(o.UserId),
-				Status:    o.Status,
-				CreatedAt: o.CreatedAt,
-				Items:     items,
-			})
+		variants := []Variant{}
+		for _, v := range res.Variants {
+			variants = append(variants, toVariant(v))
 		}
+		c.JSON(http.StatusOK, variants)
+	})
 
-		// Apply pagination
-		total := len(allOrders)
-		end := offset + limit
-		if end > total {
-			end = total
+	r.POST("/products/:id/variants", middleware.RequirePermission(authz.PermInventoryWrite), func(c *gin.Context) {
+		id, err := strconv.Atoi(c.Param("id"))
+		if err != nil {
+			c.JSON(400, gin.H{"error": "Invalid product ID"})
+			return
 		}
-		if offset > total {
-			offset = total
+
+		var input Variant
+		if err := c.ShouldBindJSON(&input); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid variant data"})
+			return
 		}
-		orders := allOrders[offset:end]
+		input.ProductID = id
 
-		c.HTML(http.StatusOK, "orders.html", gin.H{
-			"Orders": orders,
-			"Page":   page,
-			"Limit":  limit,
-			"Total":  total,
-		})
+		res, err := inventoryClient.CreateVariant(c.Request.Context(), fromVariant(input))
+		if err != nil {
+			c.JSON(httpStatusFromGRPC(err), gin.H{"error": "Failed to create variant", "details": err.Error()})
+			return
+		}
+		c.JSON(http.StatusCreated, toVariant(res))
 	})
 
-	r.POST("/orders", func(c *gin.Context) {
-		var input struct {
-			UserID string `json:"user_id"`
-			Items  []struct {
-				ProductID int `json:"product_id"`
-				Quantity  int `json:"quantity"`
-			} `json:"items"`
+	// A variant stays with its product; product_id in the body is ignored
+	r.PUT("/variants/:id", middleware.RequirePermission(authz.PermInventoryWrite), func(c *gin.Context) {
+		id, err := strconv.Atoi(c.Param("id"))
+		if err != nil {
+			c.JSON(400, gin.H{"error": "Invalid variant ID"})
+			return
 		}
 
+		var input Variant
 		if err := c.ShouldBindJSON(&input); err != nil {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid order format"})
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid variant data"})
 			return
 		}
+		input.ID, input.ProductID = id, 0
 
-		userIDInt, err := strconv.ParseInt(input.UserID, 10, 64)
+		res, err := inventoryClient.UpdateVariant(c.Request.Context(), fromVariant(input))
 		if err != nil {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+			c.JSON(httpStatusFromGRPC(err), gin.H{"error": "Failed to update variant", "details": err.Error()})
 			return
 		}
+		c.JSON(http.StatusOK, toVariant(res))
+	})
 
-		var items []*pbOrder.OrderItem
-		for _, item := range input.Items {
-			items = append(items, &pbOrder.OrderItem{
-				ProductId: int64(item.ProductID),
-				Quantity:  int32(item.Quantity),
-			})
+	r.DELETE("/variants/:id", middleware.RequirePermission(authz.PermInventoryWrite), func(c *gin.Context) {
+		id, err := strconv.Atoi(c.Param("id"))
+		if err != nil {
+			c.JSON(400, gin.H{"error": "Invalid variant ID"})
+			return
 		}
 
-		req := &pbOrder.OrderRequest{
-			UserId: userIDInt,
-			Items:  items,
+		if _, err := inventoryClient.DeleteVariant(c.Request.Context(), &pbInventory.VariantID{Id: int64(id)}); err != nil {
+			c.JSON(httpStatusFromGRPC(err), gin.H{"error": "Failed to delete variant", "details": err.Error()})
+			return
 		}
+		c.JSON(http.StatusOK, gin.H{"message": "Variant deleted"})
+	})
 
-		res, err := orderClient.CreateOrder(context.Background(), req)
+	r.GET("/warehouses", func(c *gin.Context) {
+		res, err := inventoryClient.ListWarehouses(c.Request.Context(), &pbInventory.Empty{})
 		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create order", "details": err.Error()})
+			c.JSON(httpStatusFromGRPC(err), gin.H{"error": "Failed to list warehouses", "details": err.Error()})
 			return
 		}
 
-		c.JSON(http.StatusCreated, gin.H{
-			"id":         res.Id,
-			"user_id":    res.UserId,
-			"status":     res.Status,
-			"created_at": res.CreatedAt,
-			"items":      res.Items,
-		})
+		warehouses := []Warehouse{}
+		for _, w := range res.Warehouses {
+			warehouses = append(warehouses, toWarehouse(w))
+		}
+		c.JSON(http.StatusOK, warehouses)
 	})
 
-	r.GET("/orders/:id", func(c *gin.Context) {
-		idParam := c.Param("id")
-		id, err := strconv.Atoi(idParam)
-		if err != nil {
-			c.JSON(400, gin.H{"error": "Invalid order ID"})
+	r.POST("/warehouses", middleware.RequirePermission(authz.PermInventoryWrite), func(c *gin.Context) {
+		var input Warehouse
+		if err := c.ShouldBindJSON(&input); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid warehouse data"})
 			return
 		}
 
-		res, err := orderClient.GetOrder(context.Background(), &pbOrder.OrderID{Id: int64(id)})
+		res, err := inventoryClient.CreateWarehouse(c.Request.Context(), &pbInventory.Warehouse{
+			Code:     input.Code,
+			Name:     input.Name,
+			Country:  input.Country,
+			Region:   input.Region,
+			Priority: int32(input.Priority),
+		})
 		if err != nil {
-			c.JSON(500, gin.H{"error": "Failed to get order", "details": err.Error()})
+			c.JSON(httpStatusFromGRPC(err), gin.H{"error": "Failed to create warehouse", "details": err.Error()})
 			return
 		}
+		c.JSON(http.StatusCreated, toWarehouse(res))
+	})
 
-		var items []OrderItem
-		for _, item := range res.Items {
-			items = append(items, OrderItem{
-				ProductID: int(item.ProductId),
-				Quantity:  int(item.Quantity),
-			})
+	// Stock per warehouse of a product's variants, or of one variant:
+	// ?product_id= or ?variant_id=, optionally narrowed by &warehouse_id=
+	r.GET("/stock-levels", func(c *gin.Context) {
+		var req pbInventory.GetStockLevelsRequest
+		for _, param := range []struct {
+			name string
+			dst  *int64
+		}{{"product_id", &req.ProductId}, {"variant_id", &req.VariantId}, {"warehouse_id", &req.WarehouseId}} {
+			id, err := queryID(c, param.name)
+			if err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+				return
+			}
+			*param.dst = id
 		}
 
-		c.JSON(200, Order{
-			ID:        int(res.Id),
-			UserID:    fmt.Sprint(res.UserId),
-			Status:    res.Status,
-			CreatedAt: res.CreatedAt,
-			Items:     items,
-		})
+		res, err := inventoryClient.GetStockLevels(c.Request.Context(), &req)
+		if err != nil {
+			c.JSON(httpStatusFromGRPC(err), gin.H{"error": "Failed to get stock levels", "details": err.Error()})
+			return
+		}
+
+		levels := []StockLevel{}
+		for _, l := range res.Levels {
+			levels = append(levels, toStockLevel(l))
+		}
+		c.JSON(http.StatusOK, levels)
 	})
 
-	r.PATCH("/orders/:id/status", func(c *gin.Context) {
-		var input struct {
-			Status string `json:"status"`
+	// Sets the stock of a variant in a warehouse: {"stock": 10}
+	r.PUT("/warehouses/:id/stock/:variant_id", middleware.RequirePermission(authz.PermInventoryWrite), func(c *gin.Context) {
+		warehouseID, err := strconv.Atoi(c.Param("id"))
+		if err != nil {
+			c.JSON(400, gin.H{"error": "Invalid warehouse ID"})
+			return
 		}
-		if err := c.ShouldBindJSON(&input); err != nil || input.Status == "" {
-			c.JSON(400, gin.H{"error": "Missing or invalid status"})
+		variantID, err := strconv.Atoi(c.Param("variant_id"))
+		if err != nil {
+			c.JSON(400, gin.H{"error": "Invalid variant ID"})
 			return
 		}
 
-		idParam := c.Param("id")
-		id, err := strconv.Atoi(idParam)
-		if err != nil {
-			c.JSON(400, gin.H{"error": "Invalid order ID"})
+		var input struct {
+			Stock *int `json:"stock"`
+		}
+		if err := c.ShouldBindJSON(&input); err != nil || input.Stock == nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid stock level data"})
 			return
 		}
 
-		_, err = orderClient.UpdateOrderStatus(context.Background(), &pbOrder.StatusUpdate{
-			Id:     int64(id),
-			Status: input.Status,
+		res, err := inventoryClient.SetStockLevel(c.Request.Context(), &pbInventory.StockLevel{
+			WarehouseId: int64(warehouseID),
+			VariantId:   int64(variantID),
+			Stock:       int32(*input.Stock),
 		})
 		if err != nil {
-			c.JSON(500, gin.H{"error": "Failed to update order status", "details": err.Error()})
+			c.JSON(httpStatusFromGRPC(err), gin.H{"error": "Failed to set stock level", "details": err.Error()})
 			return
 		}
-
-		c.JSON(200, gin.H{"message": "Order status updated"})
+		c.JSON(http.StatusOK, toStockLevel(res))
 	})
 
-	r.GET("/users", func(c *gin.Context) {
-		id := c.Query("id")
-		if id == "" {
-			c.String(400, "Missing user ID")
+	// Address book; user-service scopes every call to the caller's own addresses
+	r.GET("/addresses", middleware.RequireAuth(), func(c *gin.Context) {
+		res, err := userClient.ListAddresses(c.Request.Context(), &emptypb.Empty{})
+		if err != nil {
+			c.JSON(httpStatusFromGRPC(err), gin.H{"error": "Failed to list addresses", "details": err.Error()})
 			return
 		}
-		c.Redirect(http.StatusFound, "/users/"+id)
+
+		addresses := []Address{}
+		for _, a := range res.Addresses {
+			addresses = append(addresses, toAddress(a))
+		}
+		c.JSON(200, addresses)
 	})
 
-	r.GET("/users/:id", func(c *gin.Context) {
-		id := c.Param("id")
-		cacheKey := "user:" + id
-		fmt.Println("Looking for key in Redis:", cacheKey)
+	r.POST("/addresses", middleware.RequireAuth(), func(c *gin.Context) {
+		var input Address
+		if err := c.ShouldBindJSON(&input); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid address format"})
+			return
+		}
 
-		// Try to get user from Redis
-		cached, err := cache.GetCache(redisClient, cacheKey)
-		if err == nil {
-			fmt.Println("Cache HIT for:", cacheKey)
-			var user User
-			if err := json.Unmarshal([]byte(cached), &user); err == nil {
-				fmt.Println("Unmarshalled cached user:", user)
-				c.HTML(200, "users.html", gin.H{"User": user})
-				return
-			} else {
-				fmt.Println("Failed to unmarshal cached user:", err)
-			}
-		} else {
-			fmt.Println("Cache MISS for:", cacheKey, "→", err)
+		res, err := userClient.AddAddress(c.Request.Context(), fromAddress(input))
+		if err != nil {
+			c.JSON(httpStatusFromGRPC(err), gin.H{"error": "Failed to add address", "details": err.Error()})
+			return
 		}
+		c.JSON(http.StatusCreated, toAddress(res))
+	})
 
-		// If not in cache, call gRPC
-		fmt.Println("📡 Fetching user from gRPC service...")
-		u64, _ := strconv.ParseInt(id, 10, 64)
-		res, err := userClient.GetUserProfile(c, &pbUser.UserID{Id: u64})
+	r.PUT("/addresses/:id", middleware.RequireAuth(), func(c *gin.Context) {
+		id, err := strconv.Atoi(c.Param("id"))
 		if err != nil {
-			fmt.Printf("gRPC error for user %s: %v\n", id, err)
-			c.String(500, "Error loading user: %v", err)
+			c.JSON(400, gin.H{"error": "Invalid address ID"})
 			return
 		}
 
-		user := User{ID: int(res.Id), Email: res.Email, Name: res.Name}
+		var input Address
+		if err := c.ShouldBindJSON(&input); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid address format"})
+			return
+		}
+		input.ID = id
+
+		res, err := userClient.UpdateAddress(c.Request.Context(), fromAddress(input))
+		if err != nil {
+			c.JSON(httpStatusFromGRPC(err), gin.H{"error": "Failed to update address", "details": err.Error()})
+			return
+		}
+		c.JSON(200, toAddress(res))
+	})
+
+	r.DELETE("/addresses/:id", middleware.RequireAuth(), func(c *gin.Context) {
+		id, err := strconv.Atoi(c.Param("id"))
+		if err != nil {
+			c.JSON(400, gin.H{"error": "Invalid address ID"})
+			return
+		}
+
+		if _, err := userClient.DeleteAddress(c.Request.Context(), &pbUser.AddressID{Id: int64(id)}); err != nil {
+			c.JSON(httpStatusFromGRPC(err), gin.H{"error": "Failed to delete address", "details": err.Error()})
+			return
+		}
+		c.JSON(200, gin.H{"message": "Address deleted"})
+	})
+
+	r.POST("/addresses/:id/default", middleware.RequireAuth(), func(c *gin.Context) {
+		id, err := strconv.Atoi(c.Param("id"))
+		if err != nil {
+			c.JSON(400, gin.H{"error": "Invalid address ID"})
+			return
+		}
+
+		res, err := userClient.SetDefaultAddress(c.Request.Context(), &pbUser.AddressID{Id: int64(id)})
+		if err != nil {
+			c.JSON(httpStatusFromGRPC(err), gin.H{"error": "Failed to set default address", "details": err.Error()})
+			return
+		}
+		c.JSON(200, toAddress(res))
+	})
+
+	r.GET("/orders", middleware.RequireAuth(), func(c *gin.Context) {
+		page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+		limit, _ := strconv.Atoi(c.DefaultQuery("limit", "5"))
+		offset := (page - 1) * limit
+
+		// Order managers see every order, everyone else only their own
+		req := &pbOrder.UserOrdersRequest{}
+		if p, _ := middleware.PrincipalFrom(c); !p.HasPermission(authz.PermOrdersManage) {
+			req.UserId = int64(p.UserID)
+		}
+
+		res, err := orderClient.ListOrders(c.Request.Context(), req)
+		if err != nil {
+			c.String(500, "Error loading orders: %v", err)
+			return
+		}
+
+		var allOrders []Order
+		for _, o := range res.Orders {
+			allOrders = append(allOrders, toOrder(o))
+		}
+
+		// Apply pagination
+		total := len(allOrders)
+		end := offset + limit
+		if end > total {
+			end = total
+		}
+		if offset > total {
+			offset = total
+		}
+		orders := allOrders[offset:end]
+
+		c.HTML(http.StatusOK, "orders.html", gin.H{
+			"Orders": orders,
+			"Page":   page,
+			"Limit":  limit,
+			"Total":  total,
+		})
+	})
+
+	// The order is placed for the authenticated user; a user_id in the body
+	// is ignored. Items name a variant by variant_id or sku, or a product
+	// with a single variant by product_id
+	r.POST("/orders", middleware.RequireAuth(), func(c *gin.Context) {
+		var input struct {
+			Items []struct {
+				ProductID int    `json:"product_id"`
+				VariantID int    `json:"variant_id"`
+				SKU       string `json:"sku"`
+				Quantity  int    `json:"quantity"`
+			} `json:"items"`
+			ShippingAddressID int `json:"shipping_address_id"`
+			BillingAddressID  int `json:"billing_address_id"`
+		}
+
+		if err := c.ShouldBindJSON(&input); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid order format"})
+			return
+		}
+
+		principal, _ := middleware.PrincipalFrom(c)
+
+		var items []*pbOrder.OrderItem
+		for _, item := range input.Items {
+			items = append(items, &pbOrder.OrderItem{
+				ProductId: int64(item.ProductID),
+				VariantId: int64(item.VariantID),
+				Sku:       item.SKU,
+				Quantity:  int32(item.Quantity),
+			})
+		}
+
+		req := &pbOrder.OrderRequest{
+			UserId:            int64(principal.UserID),
+			Items:             items,
+			ShippingAddressId: int64(input.ShippingAddressID),
+			BillingAddressId:  int64(input.BillingAddressID),
+		}
+
+		res, err := orderClient.CreateOrder(withIdempotencyKey(c), req)
+		if err != nil {
+			c.JSON(httpStatusFromGRPC(err), gin.H{"error": "Failed to create order", "details": err.Error()})
+			return
+		}
+
+		order := toOrder(res)
+		c.JSON(http.StatusCreated, gin.H{
+			"id":               res.Id,
+			"user_id":          res.UserId,
+			"status":           orderStatusName(res.Status),
+			"created_at":       res.CreatedAt,
+			"items":            order.Items,
+			"subtotal":         order.Subtotal,
+			"tax":              order.Tax,
+			"total_amount":     order.TotalAmount,
+			"shipping_address": order.ShippingAddress,
+			"billing_address":  order.BillingAddress,
+		})
+	})
+
+	r.GET("/orders/:id", middleware.RequireAuth(), func(c *gin.Context) {
+		idParam := c.Param("id")
+		id, err := strconv.Atoi(idParam)
+		if err != nil {
+			c.JSON(400, gin.H{"error": "Invalid order ID"})
+			return
+		}
+
+		res, err := orderClient.GetOrder(c.Request.Context(), &pbOrder.OrderID{Id: int64(id)})
+		if err != nil {
+			c.JSON(500, gin.H{"error": "Failed to get order", "details": err.Error()})
+			return
+		}
+		if !middleware.CanAccess(c, res.UserId, authz.PermOrdersManage) {
+			return
+		}
+
+		c.JSON(200, toOrder(res))
+	})
+
+	r.PATCH("/orders/:id/status", middleware.RequirePermission(authz.PermOrdersManage), func(c *gin.Context) {
+		var input struct {
+			Status string `json:"status"`
+		}
+		if err := c.ShouldBindJSON(&input); err != nil || input.Status == "" {
+			c.JSON(400, gin.H{"error": "Missing or invalid status"})
+			return
+		}
+
+		newStatus, ok := parseOrderStatus(input.Status)
+		if !ok {
+			c.JSON(400, gin.H{"error": "Unknown order status", "status": input.Status})
+			return
+		}
+
+		idParam := c.Param("id")
+		id, err := strconv.Atoi(idParam)
+		if err != nil {
+			c.JSON(400, gin.H{"error": "Invalid order ID"})
+			return
+		}
+
+		_, err = orderClient.UpdateOrderStatus(c.Request.Context(), &pbOrder.StatusUpdate{
+			Id:     int64(id),
+			Status: newStatus,
+		})
+		if err != nil {
+			c.JSON(httpStatusFromGRPC(err), gin.H{"error": "Failed to update order status", "details": err.Error()})
+			return
+		}
+
+		c.JSON(200, gin.H{"message": "Order status updated"})
+	})
+
+	r.PATCH("/orders/:id/cancel", middleware.RequireAuth(), func(c *gin.Context) {
+		idParam := c.Param("id")
+		id, err := strconv.Atoi(idParam)
+		if err != nil {
+			c.JSON(400, gin.H{"error": "Invalid order ID"})
+			return
+		}
+		if !authorizeOrder(c, orderClient, int64(id)) {
+			return
+		}
+
+		// The reason is optional, and so is the body
+		var input struct {
+			Reason string `json:"reason"`
+		}
+		if c.Request.ContentLength != 0 {
+			if err := c.ShouldBindJSON(&input); err != nil {
+				c.JSON(400, gin.H{"error": "Invalid request body"})
+				return
+			}
+		}
+
+		res, err := orderClient.CancelOrder(c.Request.Context(), &pbOrder.CancelOrderRequest{
+			Id:     int64(id),
+			Reason: input.Reason,
+		})
+		if err != nil {
+			c.JSON(httpStatusFromGRPC(err), gin.H{"error": "Failed to cancel order", "details": err.Error()})
+			return
+		}
+
+		c.JSON(200, gin.H{"message": "Order cancelled", "order_id": res.Id, "status": orderStatusName(res.Status)})
+	})
+
+	r.GET("/orders/:id/history", middleware.RequireAuth(), func(c *gin.Context) {
+		idParam := c.Param("id")
+		id, err := strconv.Atoi(idParam)
+		if err != nil {
+			c.JSON(400, gin.H{"error": "Invalid order ID"})
+			return
+		}
+		if !authorizeOrder(c, orderClient, int64(id)) {
+			return
+		}
+
+		res, err := orderClient.GetOrderHistory(c.Request.Context(), &pbOrder.OrderID{Id: int64(id)})
+		if err != nil {
+			c.JSON(httpStatusFromGRPC(err), gin.H{"error": "Failed to get order history", "details": err.Error()})
+			return
+		}
+
+		history := []StatusChange{}
+		for _, change := range res.Changes {
+			history = append(history, StatusChange{
+				From:      orderStatusName(change.FromStatus),
+				To:        orderStatusName(change.ToStatus),
+				Reason:    change.Reason,
+				ChangedAt: change.ChangedAt,
+			})
+		}
+
+		c.JSON(200, gin.H{"order_id": res.OrderId, "history": history})
+	})
+
+	r.GET("/users", func(c *gin.Context) {
+		id := c.Query("id")
+		if id == "" {
+			c.String(400, "Missing user ID")
+			return
+		}
+		c.Redirect(http.StatusFound, "/users/"+id)
+	})
+
+	r.GET("/users/:id", middleware.RequireSelfOr("id", authz.PermUsersManage), func(c *gin.Context) {
+		id := c.Param("id")
+		cacheKey := "user:" + id
+		fmt.Println("Looking for key in Redis:", cacheKey)
+
+		// Try to get user from Redis
+		cached, err := cache.GetCache(redisClient, cacheKey)
+		if err == nil {
+			fmt.Println("Cache HIT for:", cacheKey)
+			var user User
+			if err := json.Unmarshal([]byte(cached), &user); err == nil {
+				fmt.Println("Unmarshalled cached user:", user)
+				c.HTML(200, "users.html", gin.H{"User": user})
+				return
+			} else {
+				fmt.Println("Failed to unmarshal cached user:", err)
+			}
+		} else {
+			fmt.Println("Cache MISS for:", cacheKey, "→", err)
+		}
+
+		// If not in cache, call gRPC
+		fmt.Println("📡 Fetching user from gRPC service...")
+		u64, _ := strconv.ParseInt(id, 10, 64)
+		res, err := userClient.GetUserProfile(c.Request.Context(), &pbUser.UserID{Id: u64})
+		if err != nil {
+			fmt.Printf("gRPC error for user %s: %v\n", id, err)
+			c.String(500, "Error loading user: %v", err)
+			return
+		}
+
+		user := User{ID: int(res.Id), Email: res.Email, Name: res.Name}
 		fmt.Println("gRPC returned user:", user)
 
 		// Marshal and store in Redis
@@ -459,47 +1126,339 @@ func main() {
 			Password string `form:"password" binding:"required,min=6"`
 		}
 		if err := c.ShouldBind(&input); err != nil {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input"})
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input"})
+			return
+		}
+
+		req := &pbUser.UserRequest{
+			Name:     input.Name,
+			Email:    input.Email,
+			Password: input.Password,
+		}
+
+		res, err := userClient.RegisterUser(c.Request.Context(), req)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to register user", "details": err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusCreated, gin.H{
+			"message": res.GetMessage(),
+		})
+	})
+
+	r.GET("/users/verify/resend", func(c *gin.Context) {
+		c.HTML(200, "resend_verification.html", nil)
+	})
+
+	// The answer is the same whether or not the email has a pending registration
+	r.POST("/users/verify/resend", func(c *gin.Context) {
+		var input struct {
+			Email string `form:"email" json:"email" binding:"required"`
+		}
+		if err := c.ShouldBind(&input); err != nil {
+			c.String(400, "Missing email")
+			return
+		}
+
+		_, err := userClient.ResendVerification(c.Request.Context(), &pbUser.ResendVerificationRequest{Email: input.Email})
+		if err != nil {
+			c.JSON(httpStatusFromGRPC(err), gin.H{"error": "Failed to resend verification email", "details": err.Error()})
+			return
+		}
+
+		message := "If a registration is waiting for this email, a new verification link has been sent."
+		if c.ContentType() == "application/json" {
+			c.JSON(200, gin.H{"message": message})
+			return
+		}
+		c.HTML(200, "resend_verification.html", gin.H{"Message": message})
+	})
+
+	r.GET("/users/login", func(c *gin.Context) {
+		c.HTML(200, "login.html", nil)
+	})
+
+	r.POST("/users/login", func(c *gin.Context) {
+		var input struct {
+			Email    string `form:"email" json:"email" binding:"required"`
+			Password string `form:"password" json:"password" binding:"required"`
+		}
+		if err := c.ShouldBind(&input); err != nil {
+			c.String(400, "Missing email or password")
+			return
+		}
+
+		var header metadata.MD
+		res, err := userClient.AuthenticateUser(c.Request.Context(), &pbUser.AuthRequest{
+			Email:    input.Email,
+			Password: input.Password,
+		}, grpc.Header(&header))
+		if err != nil {
+			logger.Log.WithFields(map[string]interface{}{
+				"email":    input.Email,
+				"clientIP": c.ClientIP(),
+				"code":     status.Code(err).String(),
+			}).Warn("Login failed")
+
+			switch status.Code(err) {
+			case codes.ResourceExhausted:
+				if retryAfter := header.Get("retry-after"); len(retryAfter) > 0 {
+					c.Header("Retry-After", retryAfter[0])
+				}
+				c.String(429, "Too many failed login attempts, try again later")
+			case codes.Unauthenticated:
+				c.String(401, "Invalid credentials")
+			default:
+				c.String(httpStatusFromGRPC(err), "Login failed")
+			}
+			return
+		}
+
+		isJSON := c.ContentType() == "application/json"
+		if res.SecondFactorRequired {
+			if isJSON {
+				c.JSON(200, gin.H{"second_factor_required": true, "challenge_token": res.ChallengeToken})
+				return
+			}
+			c.HTML(200, "login_2fa.html", gin.H{"ChallengeToken": res.ChallengeToken})
+			return
+		}
+
+		setSessionCookies(c, res.Tokens)
+		if isJSON {
+			c.JSON(200, toTokenResponse(res.Tokens))
+			return
+		}
+		c.Redirect(302, "/")
+	})
+
+	// Second step of a login with two-factor authentication
+	r.POST("/users/login/2fa", func(c *gin.Context) {
+		var input struct {
+			ChallengeToken string `form:"challenge_token" json:"challenge_token" binding:"required"`
+			Code           string `form:"code" json:"code" binding:"required"`
+		}
+		if err := c.ShouldBind(&input); err != nil {
+			c.String(400, "Missing challenge token or code")
+			return
+		}
+
+		res, err := userClient.VerifySecondFactor(c.Request.Context(), &pbUser.SecondFactorRequest{
+			ChallengeToken: input.ChallengeToken,
+			Code:           input.Code,
+		})
+		isJSON := c.ContentType() == "application/json"
+		if err != nil {
+			logger.Log.WithFields(map[string]interface{}{
+				"clientIP": c.ClientIP(),
+				"code":     status.Code(err).String(),
+			}).Warn("Second factor failed")
+
+			if isJSON {
+				c.JSON(httpStatusFromGRPC(err), gin.H{"error": "Invalid code", "details": err.Error()})
+				return
+			}
+			c.HTML(httpStatusFromGRPC(err), "login_2fa.html", gin.H{
+				"ChallengeToken": input.ChallengeToken,
+				"Error":          status.Convert(err).Message(),
+			})
+			return
+		}
+
+		setSessionCookies(c, res.Tokens)
+		if isJSON {
+			c.JSON(200, toTokenResponse(res.Tokens))
+			return
+		}
+		c.Redirect(302, "/")
+	})
+
+	// Two-factor settings of the signed-in user
+	r.GET("/users/2fa", middleware.RequireAuth(), func(c *gin.Context) {
+		res, err := userClient.GetTOTPStatus(c.Request.Context(), &emptypb.Empty{})
+		if err != nil {
+			c.String(httpStatusFromGRPC(err), "Failed to load two-factor settings: %v", err)
+			return
+		}
+		if strings.Contains(c.GetHeader("Accept"), "application/json") {
+			c.JSON(200, gin.H{"enabled": res.Enabled, "recovery_codes_left": res.RecoveryCodesLeft})
+			return
+		}
+		c.HTML(200, "two_factor.html", gin.H{"Enabled": res.Enabled, "RecoveryCodesLeft": res.RecoveryCodesLeft})
+	})
+
+	r.POST("/users/2fa/enroll", middleware.RequireAuth(), func(c *gin.Context) {
+		res, err := userClient.EnrollTOTP(c.Request.Context(), &emptypb.Empty{})
+		isJSON := c.ContentType() == "application/json"
+		if err != nil {
+			if isJSON {
+				c.JSON(httpStatusFromGRPC(err), gin.H{"error": "Failed to enroll", "details": err.Error()})
+				return
+			}
+			c.HTML(httpStatusFromGRPC(err), "two_factor.html", gin.H{"Error": status.Convert(err).Message()})
+			return
+		}
+
+		if isJSON {
+			c.JSON(200, gin.H{"secret": res.Secret, "otpauth_uri": res.OtpauthUri})
+			return
+		}
+		// html/template would blank the otpauth: scheme; the URI comes from the user service
+		c.HTML(200, "two_factor.html", gin.H{"Secret": res.Secret, "URI": template.URL(res.OtpauthUri)})
+	})
+
+	r.POST("/users/2fa/confirm", middleware.RequireAuth(), func(c *gin.Context) {
+		var input struct {
+			Code string `form:"code" json:"code" binding:"required"`
+		}
+		if err := c.ShouldBind(&input); err != nil {
+			c.String(400, "Missing code")
+			return
+		}
+
+		res, err := userClient.ConfirmTOTP(c.Request.Context(), &pbUser.TOTPCode{Code: input.Code})
+		isJSON := c.ContentType() == "application/json"
+		if err != nil {
+			if isJSON {
+				c.JSON(httpStatusFromGRPC(err), gin.H{"error": "Failed to enable two-factor authentication", "details": err.Error()})
+				return
+			}
+			c.HTML(httpStatusFromGRPC(err), "two_factor.html", gin.H{"Error": status.Convert(err).Message()})
+			return
+		}
+
+		if isJSON {
+			c.JSON(200, gin.H{"recovery_codes": res.Codes})
+			return
+		}
+		c.HTML(200, "two_factor.html", gin.H{"Enabled": true, "RecoveryCodes": res.Codes})
+	})
+
+	r.POST("/users/2fa/disable", middleware.RequireAuth(), func(c *gin.Context) {
+		var input struct {
+			Code string `form:"code" json:"code"`
+		}
+		if err := c.ShouldBind(&input); err != nil {
+			c.String(400, "Invalid request")
+			return
+		}
+
+		_, err := userClient.DisableTOTP(c.Request.Context(), &pbUser.TOTPCode{Code: input.Code})
+		isJSON := c.ContentType() == "application/json"
+		if err != nil {
+			if isJSON {
+				c.JSON(httpStatusFromGRPC(err), gin.H{"error": "Failed to disable two-factor authentication", "details": err.Error()})
+				return
+			}
+			c.HTML(httpStatusFromGRPC(err), "two_factor.html", gin.H{"Enabled": true, "Error": status.Convert(err).Message()})
+			return
+		}
+
+		if isJSON {
+			c.JSON(200, gin.H{"message": "Two-factor authentication disabled"})
+			return
+		}
+		c.Redirect(302, "/users/2fa")
+	})
+
+	r.GET("/users/password/forgot", func(c *gin.Context) {
+		c.HTML(200, "forgot_password.html", nil)
+	})
+
+	// The answer is the same whether or not the email is registered
+	r.POST("/users/password/forgot", func(c *gin.Context) {
+		var input struct {
+			Email string `form:"email" json:"email" binding:"required"`
+		}
+		if err := c.ShouldBind(&input); err != nil {
+			c.String(400, "Missing email")
+			return
+		}
+
+		_, err := userClient.RequestPasswordReset(c.Request.Context(), &pbUser.PasswordResetRequest{Email: input.Email})
+		if err != nil {
+			c.JSON(httpStatusFromGRPC(err), gin.H{"error": "Failed to request password reset", "details": err.Error()})
+			return
+		}
+
+		message := "If an account exists for this email, a link to reset the password has been sent."
+		if c.ContentType() == "application/json" {
+			c.JSON(200, gin.H{"message": message})
+			return
+		}
+		c.HTML(200, "forgot_password.html", gin.H{"Message": message})
+	})
+
+	r.GET("/users/password/reset", func(c *gin.Context) {
+		c.HTML(200, "reset_password.html", gin.H{"Token": c.Query("token")})
+	})
+
+	r.POST("/users/password/reset", func(c *gin.Context) {
+		var input struct {
+			Token    string `form:"token" json:"token" binding:"required"`
+			Password string `form:"password" json:"password" binding:"required"`
+		}
+		if err := c.ShouldBind(&input); err != nil {
+			c.String(400, "Missing token or password")
 			return
 		}
 
-		req := &pbUser.UserRequest{
-			Name:     input.Name,
-			Email:    input.Email,
-			Password: input.Password,
-		}
-
-		res, err := userClient.RegisterUser(context.Background(), req)
+		_, err := userClient.ResetPassword(c.Request.Context(), &pbUser.ResetPasswordRequest{
+			Token:       input.Token,
+			NewPassword: input.Password,
+		})
+		isJSON := c.ContentType() == "application/json"
 		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to register user", "details": err.Error()})
+			if isJSON {
+				c.JSON(httpStatusFromGRPC(err), gin.H{"error": "Failed to reset password", "details": err.Error()})
+				return
+			}
+			c.HTML(httpStatusFromGRPC(err), "reset_password.html", gin.H{
+				"Token": input.Token,
+				"Error": status.Convert(err).Message(),
+			})
 			return
 		}
 
-		c.JSON(http.StatusCreated, gin.H{
-			"message": res.GetMessage(),
-		})
+		// The reset ended every session, including this browser's
+		clearSessionCookies(c)
+		if isJSON {
+			c.JSON(200, gin.H{"message": "Password changed"})
+			return
+		}
+		c.Redirect(302, "/users/login")
 	})
 
-	r.GET("/users/login", func(c *gin.Context) {
-		c.HTML(200, "login.html", nil)
-	})
+	// The refresh token is read from the JSON body or, for browser sessions,
+	// from the refresh_token cookie
+	r.POST("/users/refresh", func(c *gin.Context) {
+		res, err := userClient.RefreshToken(c.Request.Context(), &pbUser.RefreshTokenRequest{RefreshToken: refreshTokenFrom(c)})
+		if err != nil {
+			clearSessionCookies(c)
+			c.JSON(httpStatusFromGRPC(err), gin.H{"error": "Failed to refresh token", "details": err.Error()})
+			return
+		}
 
-	r.POST("/users/login", func(c *gin.Context) {
-		email := c.PostForm("email")
-		password := c.PostForm("password")
+		setSessionCookies(c, res)
+		c.JSON(200, toTokenResponse(res))
+	})
 
-		_, err := userClient.AuthenticateUser(c, &pbUser.AuthRequest{
-			Email:    email,
-			Password: password,
+	r.POST("/users/logout", func(c *gin.Context) {
+		_, err := userClient.Logout(c.Request.Context(), &pbUser.LogoutRequest{
+			RefreshToken: refreshTokenFrom(c),
+			AllSessions:  c.Query("all") == "true",
 		})
+		clearSessionCookies(c)
 		if err != nil {
-			c.String(401, "Invalid credentials")
+			c.JSON(httpStatusFromGRPC(err), gin.H{"error": "Failed to log out", "details": err.Error()})
 			return
 		}
-		c.Redirect(302, "/")
+		c.JSON(200, gin.H{"message": "Logged out"})
 	})
 
-	r.PATCH("/users/:id", func(c *gin.Context) {
+	// Profile fields only; the email and the password have their own routes
+	r.PATCH("/users/:id", middleware.RequireSelfOr("id", authz.PermUsersManage), func(c *gin.Context) {
 		var input struct {
 			Email    string `json:"email"`
 			Name     string `json:"name"`
@@ -509,6 +1468,10 @@ func main() {
 			c.JSON(400, gin.H{"error": "Invalid input"})
 			return
 		}
+		if input.Email != "" || input.Password != "" {
+			c.JSON(400, gin.H{"error": "Use POST /users/email to change the email and POST /users/password to change the password"})
+			return
+		}
 
 		idParam := c.Param("id")
 		id, err := strconv.Atoi(idParam)
@@ -517,16 +1480,12 @@ func main() {
 			return
 		}
 
-		req := &pbUser.UpdateUserRequest{
-			Id:       int64(id),
-			Email:    input.Email,
-			Name:     input.Name,
-			Password: input.Password,
-		}
-
-		res, err := userClient.UpdateUser(context.Background(), req)
+		res, err := userClient.UpdateUser(c.Request.Context(), &pbUser.UpdateUserRequest{
+			Id:   int64(id),
+			Name: input.Name,
+		})
 		if err != nil {
-			c.JSON(500, gin.H{"error": "Failed to update user", "details": err.Error()})
+			c.JSON(httpStatusFromGRPC(err), gin.H{"error": "Failed to update user", "details": err.Error()})
 			return
 		}
 
@@ -540,7 +1499,67 @@ func main() {
 		})
 	})
 
-	r.DELETE("/users/:id", func(c *gin.Context) {
+	// The email changes once the link sent to the new address is opened
+	r.POST("/users/email", middleware.RequireAuth(), func(c *gin.Context) {
+		var input struct {
+			Email string `form:"email" json:"email" binding:"required"`
+		}
+		if err := c.ShouldBind(&input); err != nil {
+			c.JSON(400, gin.H{"error": "Missing email"})
+			return
+		}
+
+		_, err := userClient.ChangeEmail(c.Request.Context(), &pbUser.ChangeEmailRequest{NewEmail: input.Email})
+		if err != nil {
+			c.JSON(httpStatusFromGRPC(err), gin.H{"error": "Failed to change email", "details": err.Error()})
+			return
+		}
+
+		c.JSON(200, gin.H{"message": "Confirmation link sent to the new address"})
+	})
+
+	r.GET("/users/email/confirm", func(c *gin.Context) {
+		token := c.Query("token")
+		if token == "" {
+			c.String(http.StatusBadRequest, "Missing token")
+			return
+		}
+
+		res, err := userClient.ConfirmEmailChange(c.Request.Context(), &pbUser.ConfirmEmailChangeRequest{Token: token})
+		if err != nil {
+			c.String(httpStatusFromGRPC(err), "Email change failed: %v", status.Convert(err).Message())
+			return
+		}
+
+		cache.DeleteCache(redisClient, "user:"+strconv.FormatInt(res.Id, 10))
+		c.String(http.StatusOK, "Your email is now %s.", res.Email)
+	})
+
+	// Changing the password ends every session, including this one
+	r.POST("/users/password", middleware.RequireAuth(), func(c *gin.Context) {
+		var input struct {
+			CurrentPassword string `form:"current_password" json:"current_password" binding:"required"`
+			NewPassword     string `form:"new_password" json:"new_password" binding:"required"`
+		}
+		if err := c.ShouldBind(&input); err != nil {
+			c.JSON(400, gin.H{"error": "Missing current or new password"})
+			return
+		}
+
+		_, err := userClient.ChangePassword(c.Request.Context(), &pbUser.ChangePasswordRequest{
+			CurrentPassword: input.CurrentPassword,
+			NewPassword:     input.NewPassword,
+		})
+		if err != nil {
+			c.JSON(httpStatusFromGRPC(err), gin.H{"error": "Failed to change password", "details": err.Error()})
+			return
+		}
+
+		clearSessionCookies(c)
+		c.JSON(200, gin.H{"message": "Password changed, please log in again"})
+	})
+
+	r.DELETE("/users/:id", middleware.RequireSelfOr("id", authz.PermUsersManage), func(c *gin.Context) {
 		idParam := c.Param("id")
 		id, err := strconv.Atoi(idParam)
 		if err != nil {
@@ -548,7 +1567,7 @@ func main() {
 			return
 		}
 
-		_, err = userClient.DeleteUser(context.Background(), &pbUser.UserID{Id: int64(id)})
+		_, err = userClient.DeleteUser(c.Request.Context(), &pbUser.UserID{Id: int64(id)})
 		if err != nil {
 			c.JSON(500, gin.H{"error": "Failed to delete user", "details": err.Error()})
 			return
@@ -560,6 +1579,79 @@ func main() {
 		c.JSON(200, gin.H{"message": "User deleted"})
 	})
 
+	// Lifts a lockout after repeated failed logins
+	r.POST("/users/:id/unlock", middleware.RequirePermission(authz.PermUsersManage), func(c *gin.Context) {
+		id, err := strconv.Atoi(c.Param("id"))
+		if err != nil {
+			c.JSON(400, gin.H{"error": "Invalid user ID"})
+			return
+		}
+
+		if _, err := userClient.UnlockUser(c.Request.Context(), &pbUser.UserID{Id: int64(id)}); err != nil {
+			c.JSON(httpStatusFromGRPC(err), gin.H{"error": "Failed to unlock user", "details": err.Error()})
+			return
+		}
+
+		c.JSON(200, gin.H{"message": "User unlocked"})
+	})
+
+	// Role changes apply to the user's next access token
+	r.GET("/users/:id/roles", middleware.RequireSelfOr("id", authz.PermUsersManage), func(c *gin.Context) {
+		id, err := strconv.Atoi(c.Param("id"))
+		if err != nil {
+			c.JSON(400, gin.H{"error": "Invalid user ID"})
+			return
+		}
+
+		res, err := userClient.ListUserRoles(c.Request.Context(), &pbUser.UserID{Id: int64(id)})
+		if err != nil {
+			c.JSON(httpStatusFromGRPC(err), gin.H{"error": "Failed to list roles", "details": err.Error()})
+			return
+		}
+
+		c.JSON(200, gin.H{"user_id": res.UserId, "roles": toRoles(res.Roles)})
+	})
+
+	r.POST("/users/:id/roles", middleware.RequirePermission(authz.PermUsersManage), func(c *gin.Context) {
+		id, err := strconv.Atoi(c.Param("id"))
+		if err != nil {
+			c.JSON(400, gin.H{"error": "Invalid user ID"})
+			return
+		}
+
+		var input struct {
+			Role string `json:"role" binding:"required"`
+		}
+		if err := c.ShouldBindJSON(&input); err != nil {
+			c.JSON(400, gin.H{"error": "Missing role"})
+			return
+		}
+
+		res, err := userClient.AssignRole(c.Request.Context(), &pbUser.RoleAssignment{UserId: int64(id), Role: input.Role})
+		if err != nil {
+			c.JSON(httpStatusFromGRPC(err), gin.H{"error": "Failed to assign role", "details": err.Error()})
+			return
+		}
+
+		c.JSON(200, gin.H{"user_id": res.UserId, "roles": toRoles(res.Roles)})
+	})
+
+	r.DELETE("/users/:id/roles/:role", middleware.RequirePermission(authz.PermUsersManage), func(c *gin.Context) {
+		id, err := strconv.Atoi(c.Param("id"))
+		if err != nil {
+			c.JSON(400, gin.H{"error": "Invalid user ID"})
+			return
+		}
+
+		res, err := userClient.RevokeRole(c.Request.Context(), &pbUser.RoleAssignment{UserId: int64(id), Role: c.Param("role")})
+		if err != nil {
+			c.JSON(httpStatusFromGRPC(err), gin.H{"error": "Failed to revoke role", "details": err.Error()})
+			return
+		}
+
+		c.JSON(200, gin.H{"user_id": res.UserId, "roles": toRoles(res.Roles)})
+	})
+
 	// Добавляем маршрут для верификации по ссылке
 	r.GET("/verify", func(c *gin.Context) {
 		// 1) Читаем токен из query-параметра
@@ -570,7 +1662,7 @@ func main() {
 		}
 
 		// 2) Вызываем gRPC-метод VerifyUser
-		resp, err := userClient.VerifyUser(context.Background(), &pbUser.VerifyRequest{
+		resp, err := userClient.VerifyUser(c.Request.Context(), &pbUser.VerifyRequest{
 			Token: token,
 		})
 		if err != nil || !resp.GetSuccess() {
@@ -584,3 +1676,489 @@ func main() {
 
 	r.Run(":8080")
 }
+
+func toProduct(p *pbInventory.Product) Product {
+	product := Product{
+		ID:          int(p.Id),
+		Name:        p.Name,
+		CategoryID:  int(p.CategoryId),
+		Category:    p.Category,
+		Stock:       int(p.Stock),
+		Price:       fromProtoMoney(p.Price),
+		Description: p.Description,
+	}
+	for _, v := range p.Variants {
+		product.Variants = append(product.Variants, toVariant(v))
+	}
+	return product
+}
+
+func toVariant(v *pbInventory.Variant) Variant {
+	variant := Variant{
+		ID:        int(v.Id),
+		ProductID: int(v.ProductId),
+		SKU:       v.Sku,
+		Options:   v.Options,
+		Stock:     int(v.Stock),
+		Barcode:   v.Barcode,
+	}
+	if v.Price != nil {
+		price := fromProtoMoney(v.Price)
+		variant.Price = &price
+	}
+	return variant
+}
+
+func fromVariant(v Variant) *pbInventory.Variant {
+	variant := &pbInventory.Variant{
+		Id:        int64(v.ID),
+		ProductId: int64(v.ProductID),
+		Sku:       v.SKU,
+		Options:   v.Options,
+		Stock:     int32(v.Stock),
+		Barcode:   v.Barcode,
+	}
+	if v.Price != nil {
+		variant.Price = toProtoMoney(*v.Price)
+	}
+	return variant
+}
+
+func toWarehouse(w *pbInventory.Warehouse) Warehouse {
+	return Warehouse{
+		ID:       int(w.Id),
+		Code:     w.Code,
+		Name:     w.Name,
+		Country:  w.Country,
+		Region:   w.Region,
+		Priority: int(w.Priority),
+	}
+}
+
+func toStockLevel(l *pbInventory.StockLevel) StockLevel {
+	return StockLevel{
+		WarehouseID:   int(l.WarehouseId),
+		WarehouseCode: l.WarehouseCode,
+		ProductID:     int(l.ProductId),
+		VariantID:     int(l.VariantId),
+		SKU:           l.Sku,
+		Stock:         int(l.Stock),
+	}
+}
+
+func toCategory(c *pbInventory.Category) Category {
+	return Category{
+		ID:       int(c.Id),
+		ParentID: int(c.ParentId),
+		Name:     c.Name,
+		Slug:     c.Slug,
+		Depth:    int(c.Depth),
+		Path:     c.Path,
+	}
+}
+
+func toOrder(o *pbOrder.OrderResponse) Order {
+	var items []OrderItem
+	for _, item := range o.Items {
+		items = append(items, OrderItem{
+			ProductID:      int(item.ProductId),
+			VariantID:      int(item.VariantId),
+			SKU:            item.Sku,
+			Quantity:       int(item.Quantity),
+			ProductName:    item.ProductName,
+			VariantOptions: item.VariantOptions,
+			UnitPrice:      fromProtoMoney(item.UnitPrice),
+			LineTotal:      fromProtoMoney(item.LineTotal),
+			WarehouseID:    int(item.WarehouseId),
+		})
+	}
+	return Order{
+		ID:          int(o.Id),
+		UserID:      fmt.Sprint(o.UserId),
+		Status:      orderStatusName(o.Status),
+		CreatedAt:   o.CreatedAt,
+		Items:       items,
+		Subtotal:    fromProtoMoney(o.Subtotal),
+		Tax:         fromProtoMoney(o.Tax),
+		TotalAmount: fromProtoMoney(o.TotalAmount),
+
+		ShippingAddress: toOrderAddress(o.ShippingAddress),
+		BillingAddress:  toOrderAddress(o.BillingAddress),
+	}
+}
+
+func toOrderAddress(a *pbOrder.OrderAddress) *OrderAddress {
+	if a == nil {
+		return nil
+	}
+	return &OrderAddress{
+		AddressID:     int(a.AddressId),
+		RecipientName: a.RecipientName,
+		Line1:         a.Line1,
+		Line2:         a.Line2,
+		City:          a.City,
+		Region:        a.Region,
+		PostalCode:    a.PostalCode,
+		Country:       a.Country,
+		Phone:         a.Phone,
+	}
+}
+
+func toAddress(a *pbUser.Address) Address {
+	return Address{
+		ID:            int(a.Id),
+		Label:         a.Label,
+		RecipientName: a.RecipientName,
+		Line1:         a.Line1,
+		Line2:         a.Line2,
+		City:          a.City,
+		Region:        a.Region,
+		PostalCode:    a.PostalCode,
+		Country:       a.Country,
+		Phone:         a.Phone,
+		IsDefault:     a.IsDefault,
+	}
+}
+
+func fromAddress(a Address) *pbUser.Address {
+	return &pbUser.Address{
+		Id:            int64(a.ID),
+		Label:         a.Label,
+		RecipientName: a.RecipientName,
+		Line1:         a.Line1,
+		Line2:         a.Line2,
+		City:          a.City,
+		Region:        a.Region,
+		PostalCode:    a.PostalCode,
+		Country:       a.Country,
+		Phone:         a.Phone,
+	}
+}
+
+func toRoles(roles []*pbUser.Role) []Role {
+	result := []Role{}
+	for _, r := range roles {
+		result = append(result, Role{Name: r.Name, Description: r.Description, Permissions: r.Permissions})
+	}
+	return result
+}
+
+const refreshTokenCookie = "refresh_token"
+
+func toTokenResponse(t *pbUser.TokenPair) TokenResponse {
+	return TokenResponse{
+		AccessToken:      t.GetAccessToken(),
+		TokenType:        t.GetTokenType(),
+		ExpiresIn:        t.GetExpiresIn(),
+		RefreshToken:     t.GetRefreshToken(),
+		RefreshExpiresIn: t.GetRefreshExpiresIn(),
+	}
+}
+
+// setSessionCookies stores the tokens in HttpOnly cookies for browser
+// sessions. The refresh token is only sent to the /users endpoints.
+func setSessionCookies(c *gin.Context, t *pbUser.TokenPair) {
+	secure := c.Request.TLS != nil
+	c.SetSameSite(http.SameSiteLaxMode)
+	c.SetCookie(middleware.AccessTokenCookie, t.GetAccessToken(), int(t.GetExpiresIn()), "/", "", secure, true)
+	c.SetCookie(refreshTokenCookie, t.GetRefreshToken(), int(t.GetRefreshExpiresIn()), "/users", "", secure, true)
+}
+
+func clearSessionCookies(c *gin.Context) {
+	secure := c.Request.TLS != nil
+	c.SetSameSite(http.SameSiteLaxMode)
+	c.SetCookie(middleware.AccessTokenCookie, "", -1, "/", "", secure, true)
+	c.SetCookie(refreshTokenCookie, "", -1, "/users", "", secure, true)
+}
+
+func refreshTokenFrom(c *gin.Context) string {
+	var input struct {
+		RefreshToken string `json:"refresh_token"`
+	}
+	if c.ContentType() == "application/json" && c.ShouldBindJSON(&input) == nil && input.RefreshToken != "" {
+		return input.RefreshToken
+	}
+	token, _ := c.Cookie(refreshTokenCookie)
+	return token
+}
+
+// authorizeOrder looks up the order's owner and lets only the owner or an
+// order manager through; otherwise the request is rejected.
+func authorizeOrder(c *gin.Context, client pbOrder.OrderServiceClient, id int64) bool {
+	res, err := client.GetOrder(c.Request.Context(), &pbOrder.OrderID{Id: id})
+	if err != nil {
+		c.AbortWithStatusJSON(httpStatusFromGRPC(err), gin.H{"error": "Failed to get order", "details": err.Error()})
+		return false
+	}
+	return middleware.CanAccess(c, res.UserId, authz.PermOrdersManage)
+}
+
+var productSorts = map[string]pbInventory.ProductSort{
+	"":      pbInventory.ProductSort_PRODUCT_SORT_UNSPECIFIED,
+	"id":    pbInventory.ProductSort_PRODUCT_SORT_UNSPECIFIED,
+	"name":  pbInventory.ProductSort_PRODUCT_SORT_NAME,
+	"price": pbInventory.ProductSort_PRODUCT_SORT_PRICE,
+	"stock": pbInventory.ProductSort_PRODUCT_SORT_STOCK,
+}
+
+// listProductsRequest reads the /products query string: category, min_price
+// and max_price (decimal amounts in currency), in_stock, q, sort, order
+// (asc or desc), limit and page_token.
+func listProductsRequest(c *gin.Context) (*pbInventory.ListProductsRequest, error) {
+	req := &pbInventory.ListProductsRequest{
+		Category:  c.Query("category"),
+		Query:     c.Query("q"),
+		PageToken: c.Query("page_token"),
+	}
+
+	categoryID, err := queryID(c, "category_id")
+	if err != nil {
+		return nil, err
+	}
+	req.CategoryId = categoryID
+
+	sort, ok := productSorts[c.Query("sort")]
+	if !ok {
+		return nil, fmt.Errorf("unknown sort %q", c.Query("sort"))
+	}
+	req.Sort = sort
+
+	switch c.DefaultQuery("order", "asc") {
+	case "asc":
+	case "desc":
+		req.Descending = true
+	default:
+		return nil, fmt.Errorf("order must be asc or desc")
+	}
+
+	if v := c.Query("in_stock"); v != "" {
+		inStock, err := strconv.ParseBool(v)
+		if err != nil {
+			return nil, fmt.Errorf("invalid in_stock %q", v)
+		}
+		req.InStock = inStock
+	}
+
+	if v := c.Query("limit"); v != "" {
+		limit, err := strconv.Atoi(v)
+		if err != nil || limit < 1 {
+			return nil, fmt.Errorf("invalid limit %q", v)
+		}
+		req.PageSize = int32(limit)
+	}
+
+	req.MinPrice, req.MaxPrice, err = priceBounds(c)
+	if err != nil {
+		return nil, err
+	}
+	return req, nil
+}
+
+// searchProductsRequest reads the /search query string: q, category,
+// min_price, max_price, currency, limit and page_token.
+func searchProductsRequest(c *gin.Context) (*pbInventory.SearchProductsRequest, error) {
+	req := &pbInventory.SearchProductsRequest{
+		Query:     c.Query("q"),
+		Category:  c.Query("category"),
+		PageToken: c.Query("page_token"),
+	}
+
+	categoryID, err := queryID(c, "category_id")
+	if err != nil {
+		return nil, err
+	}
+	req.CategoryId = categoryID
+
+	if v := c.Query("limit"); v != "" {
+		limit, err := strconv.Atoi(v)
+		if err != nil || limit < 1 {
+			return nil, fmt.Errorf("invalid limit %q", v)
+		}
+		req.PageSize = int32(limit)
+	}
+
+	req.MinPrice, req.MaxPrice, err = priceBounds(c)
+	if err != nil {
+		return nil, err
+	}
+	return req, nil
+}
+
+// queryID reads an optional ID query parameter; 0 when absent.
+func queryID(c *gin.Context, param string) (int64, error) {
+	v := c.Query(param)
+	if v == "" {
+		return 0, nil
+	}
+	id, err := strconv.ParseInt(v, 10, 64)
+	if err != nil || id < 1 {
+		return 0, fmt.Errorf("invalid %s %q", param, v)
+	}
+	return id, nil
+}
+
+// priceBounds reads min_price and max_price, decimal amounts in currency.
+func priceBounds(c *gin.Context) (min, max *pbMoney.Money, err error) {
+	currency := strings.ToUpper(c.Query("currency"))
+	for _, bound := range []struct {
+		param string
+		dst   **pbMoney.Money
+	}{{"min_price", &min}, {"max_price", &max}} {
+		v := c.Query(bound.param)
+		if v == "" {
+			continue
+		}
+		minor, err := parseAmount(v, currency)
+		if err != nil {
+			return nil, nil, fmt.Errorf("invalid %s %q", bound.param, v)
+		}
+		*bound.dst = &pbMoney.Money{MinorUnits: minor, CurrencyCode: currency}
+	}
+	return min, max, nil
+}
+
+// formatAmount writes minor units of currency as a decimal amount with the
+// currency's decimal places; it is the inverse of parseAmount.
+func formatAmount(minor int64, currency string) string {
+	sign, abs := "", uint64(minor)
+	if minor < 0 {
+		sign, abs = "-", -abs
+	}
+	digits := strconv.FormatUint(abs, 10)
+	exp := currencyExponent(currency)
+	if exp == 0 {
+		return sign + digits
+	}
+	if len(digits) <= exp {
+		digits = strings.Repeat("0", exp-len(digits)+1) + digits
+	}
+	return sign + digits[:len(digits)-exp] + "." + digits[len(digits)-exp:]
+}
+
+// highlight makes a search highlight safe to render: the inventory service
+// escapes the product text, but it is escaped again here from scratch so
+// that only the <mark> tags are ever markup.
+func highlight(s string) template.HTML {
+	escaped := template.HTMLEscapeString(html.UnescapeString(s))
+	escaped = strings.ReplaceAll(escaped, "&lt;mark&gt;", "<mark>")
+	escaped = strings.ReplaceAll(escaped, "&lt;/mark&gt;", "</mark>")
+	return template.HTML(escaped)
+}
+
+type searchHit struct {
+	Product
+	HighlightedName template.HTML
+	Snippet         template.HTML
+}
+
+// facetLink narrows the current search to one facet value.
+type facetLink struct {
+	Label    string
+	Count    int64
+	URL      string
+	Selected bool
+}
+
+// facetURL is the current /search URL with params replaced, back on the
+// first page.
+func facetURL(c *gin.Context, params map[string]string) string {
+	query := c.Request.URL.Query()
+	query.Del("page_token")
+	for k, v := range params {
+		if v == "" {
+			query.Del(k)
+		} else {
+			query.Set(k, v)
+		}
+	}
+	return "/search?" + query.Encode()
+}
+
+// parseAmount reads a decimal amount of currency with at most the
+// currency's decimal places, such as "12" or "12.5" USD or "1200" JPY, into
+// minor units; see Money.String.
+func parseAmount(s, currency string) (int64, error) {
+	exp := currencyExponent(currency)
+	units, fraction, found := strings.Cut(s, ".")
+	if units == "" || len(fraction) > exp || found && fraction == "" {
+		return 0, fmt.Errorf("invalid amount %q", s)
+	}
+	fraction += strings.Repeat("0", exp-len(fraction))
+	minor, err := strconv.ParseUint(units+fraction, 10, 63)
+	if err != nil {
+		return 0, err
+	}
+	return int64(minor), nil
+}
+
+// withIdempotencyKey forwards the client's Idempotency-Key header to the
+// backend, which replays its first response for retries with the same key.
+func withIdempotencyKey(c *gin.Context) context.Context {
+	ctx := c.Request.Context()
+	if key := c.GetHeader("Idempotency-Key"); key != "" {
+		ctx = metadata.AppendToOutgoingContext(ctx, "idempotency-key", key)
+	}
+	return ctx
+}
+
+func fromProtoMoney(m *pbMoney.Money) Money {
+	return Money{MinorUnits: m.GetMinorUnits(), CurrencyCode: m.GetCurrencyCode()}
+}
+
+func toProtoMoney(m Money) *pbMoney.Money {
+	return &pbMoney.Money{MinorUnits: m.MinorUnits, CurrencyCode: m.CurrencyCode}
+}
+
+// orderStatusName turns ORDER_STATUS_PAID into "paid"; the initial entry of an
+// order's history has no previous status and maps to "".
+func orderStatusName(s pbOrder.OrderStatus) string {
+	if s == pbOrder.OrderStatus_ORDER_STATUS_UNSPECIFIED {
+		return ""
+	}
+	return strings.ToLower(strings.TrimPrefix(s.String(), "ORDER_STATUS_"))
+}
+
+func parseOrderStatus(name string) (pbOrder.OrderStatus, bool) {
+	value, ok := pbOrder.OrderStatus_value["ORDER_STATUS_"+strings.ToUpper(strings.TrimSpace(name))]
+	if !ok || value == int32(pbOrder.OrderStatus_ORDER_STATUS_UNSPECIFIED) {
+		return pbOrder.OrderStatus_ORDER_STATUS_UNSPECIFIED, false
+	}
+	return pbOrder.OrderStatus(value), true
+}
+
+// trustedProxies returns the proxies named in TRUSTED_PROXIES; nil means
+// the client address is the peer address of the connection.
+func trustedProxies() []string {
+	var proxies []string
+	for _, p := range strings.Split(os.Getenv("TRUSTED_PROXIES"), ",") {
+		if p = strings.TrimSpace(p); p != "" {
+			proxies = append(proxies, p)
+		}
+	}
+	return proxies
+}
+
+// httpStatusFromGRPC maps a backend error to the HTTP status returned to the
+// client.
+func httpStatusFromGRPC(err error) int {
+	switch status.Code(err) {
+	case codes.InvalidArgument:
+		return http.StatusBadRequest
+	case codes.NotFound:
+		return http.StatusNotFound
+	case codes.AlreadyExists:
+		return http.StatusConflict
+	case codes.FailedPrecondition, codes.Aborted:
+		return http.StatusConflict
+	case codes.Unauthenticated:
+		return http.StatusUnauthorized
+	case codes.PermissionDenied:
+		return http.StatusForbidden
+	case codes.ResourceExhausted:
+		return http.StatusTooManyRequests
+	case codes.Unavailable:
+		return http.StatusServiceUnavailable
+	default:
+		return http.StatusInternalServerError
+	}
+}
@@ -0,0 +1,152 @@
+package middleware
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"shared/authz"
+
+	"github.com/gin-gonic/gin"
+	"google.golang.org/grpc/metadata"
+)
+
+const (
+	principalKey = "principal"
+
+	// AccessTokenCookie holds the access token of browser sessions.
+	AccessTokenCookie = "access_token"
+
+	// gRPC metadata the principal is forwarded to backends with.
+	MetadataAuthorization = "authorization"
+	MetadataUserID        = "x-user-id"
+	MetadataUserRoles     = "x-user-roles"
+)
+
+// Authenticate identifies the caller from an "Authorization: Bearer" header
+// or, for browser sessions, the access_token cookie. A valid token puts the
+// principal in the gin context and adds it to the outgoing gRPC metadata of
+// c.Request.Context(). Requests without a token continue anonymously; an
+// invalid bearer token is rejected, an invalid cookie is ignored.
+func Authenticate(verifier *authz.Verifier) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		token, fromHeader := bearerToken(c)
+		if token == "" {
+			token, _ = c.Cookie(AccessTokenCookie)
+		}
+		if token == "" {
+			c.Next()
+			return
+		}
+
+		principal, err := verifier.Verify(token)
+		if err != nil {
+			if fromHeader {
+				c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Invalid access token"})
+				return
+			}
+			c.Next()
+			return
+		}
+
+		c.Set(principalKey, principal)
+		ctx := metadata.AppendToOutgoingContext(c.Request.Context(),
+			MetadataAuthorization, "Bearer "+token,
+			MetadataUserID, strconv.Itoa(principal.UserID),
+			MetadataUserRoles, strings.Join(principal.Roles, ","),
+		)
+		c.Request = c.Request.WithContext(ctx)
+		c.Next()
+	}
+}
+
+// PrincipalFrom returns the authenticated caller, if any.
+func PrincipalFrom(c *gin.Context) (*authz.Principal, bool) {
+	v, ok := c.Get(principalKey)
+	if !ok {
+		return nil, false
+	}
+	p, ok := v.(*authz.Principal)
+	return p, ok
+}
+
+// RequireAuth rejects anonymous requests.
+func RequireAuth() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if _, ok := PrincipalFrom(c); !ok {
+			unauthorized(c)
+			return
+		}
+		c.Next()
+	}
+}
+
+// RequirePermission only lets callers with the permission through.
+func RequirePermission(permission string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		p, ok := PrincipalFrom(c)
+		if !ok {
+			unauthorized(c)
+			return
+		}
+		if !p.HasPermission(permission) {
+			forbidden(c)
+			return
+		}
+		c.Next()
+	}
+}
+
+// RequireSelfOr only lets the user named by the route parameter param, or a
+// caller with the permission, through.
+func RequireSelfOr(param, permission string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		p, ok := PrincipalFrom(c)
+		if !ok {
+			unauthorized(c)
+			return
+		}
+		if !p.HasPermission(permission) && c.Param(param) != strconv.Itoa(p.UserID) {
+			forbidden(c)
+			return
+		}
+		c.Next()
+	}
+}
+
+// CanAccess reports whether the caller owns the resource owned by ownerID or
+// has the permission, and rejects the request if not.
+func CanAccess(c *gin.Context, ownerID int64, permission string) bool {
+	p, ok := PrincipalFrom(c)
+	if !ok {
+		unauthorized(c)
+		return false
+	}
+	if !p.HasPermission(permission) && int64(p.UserID) != ownerID {
+		forbidden(c)
+		return false
+	}
+	return true
+}
+
+func forbidden(c *gin.Context) {
+	c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "Forbidden"})
+}
+
+// unauthorized sends browsers to the login page and API clients a 401.
+func unauthorized(c *gin.Context) {
+	if c.Request.Method == http.MethodGet && strings.Contains(c.GetHeader("Accept"), "text/html") {
+		c.Redirect(http.StatusFound, "/users/login")
+		c.Abort()
+		return
+	}
+	c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Authentication required"})
+}
+
+func bearerToken(c *gin.Context) (string, bool) {
+	header := c.GetHeader("Authorization")
+	if len(header) > 7 && strings.EqualFold(header[:7], "Bearer ") {
+		return strings.TrimSpace(header[7:]), true
+	}
+	return "", false
+}
@@ -0,0 +1,21 @@
+package middleware
+
+import (
+	"github.com/gin-gonic/gin"
+	"google.golang.org/grpc/metadata"
+)
+
+// MetadataClientIP carries the caller's address to backends, which use it to
+// throttle failed logins per client.
+const MetadataClientIP = "x-client-ip"
+
+// ForwardClientIP adds c.ClientIP() to the outgoing gRPC metadata of
+// c.Request.Context(). The address is only as trustworthy as the proxies
+// configured with gin's SetTrustedProxies.
+func ForwardClientIP() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx := metadata.AppendToOutgoingContext(c.Request.Context(), MetadataClientIP, c.ClientIP())
+		c.Request = c.Request.WithContext(ctx)
+		c.Next()
+	}
+}
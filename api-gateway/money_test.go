@@ -0,0 +1,65 @@
+package main
+
+import "testing"
+
+func TestMoneyString(t *testing.T) {
+	tests := []struct {
+		money Money
+		want  string
+	}{
+		{Money{MinorUnits: 199999, CurrencyCode: "USD"}, "1999.99 USD"},
+		{Money{MinorUnits: 5, CurrencyCode: "EUR"}, "0.05 EUR"},
+		{Money{MinorUnits: -1050, CurrencyCode: "USD"}, "-10.50 USD"},
+		{Money{MinorUnits: 1999, CurrencyCode: "JPY"}, "1999 JPY"},
+		{Money{MinorUnits: -300, CurrencyCode: "KRW"}, "-300 KRW"},
+		{Money{MinorUnits: 1999, CurrencyCode: "KWD"}, "1.999 KWD"},
+		{Money{MinorUnits: 7, CurrencyCode: "BHD"}, "0.007 BHD"},
+		{Money{MinorUnits: 12345, CurrencyCode: "CLF"}, "1.2345 CLF"},
+	}
+	for _, tt := range tests {
+		if got := tt.money.String(); got != tt.want {
+			t.Errorf("%+v.String() = %q, want %q", tt.money, got, tt.want)
+		}
+	}
+}
+
+func TestParseAmount(t *testing.T) {
+	tests := []struct {
+		amount   string
+		currency string
+		want     int64
+		wantErr  bool
+	}{
+		{"12", "USD", 1200, false},
+		{"12.5", "USD", 1250, false},
+		{"12.55", "", 1255, false},
+		{"12.555", "USD", 0, true},
+		{"1200", "JPY", 1200, false},
+		{"1200.5", "JPY", 0, true},
+		{"12.", "JPY", 0, true},
+		{"1.5", "KWD", 1500, false},
+		{"1.999", "kwd", 1999, false},
+		{"1.9999", "KWD", 0, true},
+		{"", "USD", 0, true},
+		{".5", "USD", 0, true},
+		{"-1", "USD", 0, true},
+	}
+	for _, tt := range tests {
+		got, err := parseAmount(tt.amount, tt.currency)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("parseAmount(%q, %q) = %d, %v; want %d, error %v", tt.amount, tt.currency, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
+
+func TestFormatAmountRoundTrips(t *testing.T) {
+	for _, currency := range []string{"USD", "JPY", "KWD"} {
+		for _, minor := range []int64{0, 1, 99, 1000, 123456} {
+			amount := formatAmount(minor, currency)
+			got, err := parseAmount(amount, currency)
+			if err != nil || got != minor {
+				t.Errorf("parseAmount(formatAmount(%d, %s) = %q) = %d, %v", minor, currency, amount, got, err)
+			}
+		}
+	}
+}
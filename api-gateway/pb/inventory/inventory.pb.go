@@ -7,6 +7,7 @@
 package inventory
 
 import (
+	money "api-gateway/pb/money"
 	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
 	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
 	reflect "reflect"
@@ -21,13 +22,75 @@ const (
 	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
 )
 
+type ProductSort int32
+
+const (
+	ProductSort_PRODUCT_SORT_UNSPECIFIED ProductSort = 0 // by id
+	ProductSort_PRODUCT_SORT_NAME        ProductSort = 1
+	ProductSort_PRODUCT_SORT_PRICE       ProductSort = 2
+	ProductSort_PRODUCT_SORT_STOCK       ProductSort = 3
+)
+
+// Enum value maps for ProductSort.
+var (
+	ProductSort_name = map[int32]string{
+		0: "PRODUCT_SORT_UNSPECIFIED",
+		1: "PRODUCT_SORT_NAME",
+		2: "PRODUCT_SORT_PRICE",
+		3: "PRODUCT_SORT_STOCK",
+	}
+	ProductSort_value = map[string]int32{
+		"PRODUCT_SORT_UNSPECIFIED": 0,
+		"PRODUCT_SORT_NAME":        1,
+		"PRODUCT_SORT_PRICE":       2,
+		"PRODUCT_SORT_STOCK":       3,
+	}
+)
+
+func (x ProductSort) Enum() *ProductSort {
+	p := new(ProductSort)
+	*p = x
+	return p
+}
+
+func (x ProductSort) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (ProductSort) Descriptor() protoreflect.EnumDescriptor {
+	return file_proto_inventory_proto_enumTypes[0].Descriptor()
+}
+
+func (ProductSort) Type() protoreflect.EnumType {
+	return &file_proto_inventory_proto_enumTypes[0]
+}
+
+func (x ProductSort) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use ProductSort.Descriptor instead.
+func (ProductSort) EnumDescriptor() ([]byte, []int) {
+	return file_proto_inventory_proto_rawDescGZIP(), []int{0}
+}
+
 type Product struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
-	Id            int64                  `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
-	Name          string                 `protobuf:"bytes,2,opt,name=name,proto3" json:"name,omitempty"`
-	Category      string                 `protobuf:"bytes,3,opt,name=category,proto3" json:"category,omitempty"`
-	Stock         int32                  `protobuf:"varint,4,opt,name=stock,proto3" json:"stock,omitempty"`
-	Price         float32                `protobuf:"fixed32,5,opt,name=price,proto3" json:"price,omitempty"`
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Id    int64                  `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	Name  string                 `protobuf:"bytes,2,opt,name=name,proto3" json:"name,omitempty"`
+	// Name of the category. When writing a product without category_id, a
+	// category slug or name selects the category.
+	Category string `protobuf:"bytes,3,opt,name=category,proto3" json:"category,omitempty"`
+	// Total over the variants. Writing it sets the stock of a product with a
+	// single variant; CreateProduct adds that variant, without options. The
+	// difference is made up in the primary warehouse.
+	Stock       int32        `protobuf:"varint,4,opt,name=stock,proto3" json:"stock,omitempty"`
+	Price       *money.Money `protobuf:"bytes,6,opt,name=price,proto3" json:"price,omitempty"`
+	Description string       `protobuf:"bytes,7,opt,name=description,proto3" json:"description,omitempty"`
+	CategoryId  int64        `protobuf:"varint,8,opt,name=category_id,json=categoryId,proto3" json:"category_id,omitempty"` // 0 for none
+	// Set by GetProduct and CreateProduct, and by ReserveStock to the
+	// reserved variants.
+	Variants      []*Variant `protobuf:"bytes,9,rep,name=variants,proto3" json:"variants,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
@@ -90,34 +153,65 @@ func (x *Product) GetStock() int32 {
 	return 0
 }
 
-func (x *Product) GetPrice() float32 {
+func (x *Product) GetPrice() *money.Money {
 	if x != nil {
 		return x.Price
 	}
+	return nil
+}
+
+func (x *Product) GetDescription() string {
+	if x != nil {
+		return x.Description
+	}
+	return ""
+}
+
+func (x *Product) GetCategoryId() int64 {
+	if x != nil {
+		return x.CategoryId
+	}
 	return 0
 }
 
-type ProductID struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
-	Id            int64                  `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+func (x *Product) GetVariants() []*Variant {
+	if x != nil {
+		return x.Variants
+	}
+	return nil
+}
+
+// Variant is the unit that is stocked and ordered, e.g. size M in red.
+type Variant struct {
+	state     protoimpl.MessageState `protogen:"open.v1"`
+	Id        int64                  `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	ProductId int64                  `protobuf:"varint,2,opt,name=product_id,json=productId,proto3" json:"product_id,omitempty"`                                                     // fixed when the variant is created
+	Sku       string                 `protobuf:"bytes,3,opt,name=sku,proto3" json:"sku,omitempty"`                                                                                   // unique
+	Options   map[string]string      `protobuf:"bytes,4,rep,name=options,proto3" json:"options,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"` // e.g. size=M, colour=red
+	// Overrides the product price when set; always in the product's currency.
+	Price *money.Money `protobuf:"bytes,5,opt,name=price,proto3" json:"price,omitempty"`
+	// Total over the warehouses; writing it changes the stock in the primary
+	// warehouse. See SetStockLevel.
+	Stock         int32  `protobuf:"varint,6,opt,name=stock,proto3" json:"stock,omitempty"`
+	Barcode       string `protobuf:"bytes,7,opt,name=barcode,proto3" json:"barcode,omitempty"` // unique when set
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *ProductID) Reset() {
-	*x = ProductID{}
+func (x *Variant) Reset() {
+	*x = Variant{}
 	mi := &file_proto_inventory_proto_msgTypes[1]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *ProductID) String() string {
+func (x *Variant) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*ProductID) ProtoMessage() {}
+func (*Variant) ProtoMessage() {}
 
-func (x *ProductID) ProtoReflect() protoreflect.Message {
+func (x *Variant) ProtoReflect() protoreflect.Message {
 	mi := &file_proto_inventory_proto_msgTypes[1]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
@@ -129,39 +223,81 @@ func (x *ProductID) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use ProductID.ProtoReflect.Descriptor instead.
-func (*ProductID) Descriptor() ([]byte, []int) {
+// Deprecated: Use Variant.ProtoReflect.Descriptor instead.
+func (*Variant) Descriptor() ([]byte, []int) {
 	return file_proto_inventory_proto_rawDescGZIP(), []int{1}
 }
 
-func (x *ProductID) GetId() int64 {
+func (x *Variant) GetId() int64 {
 	if x != nil {
 		return x.Id
 	}
 	return 0
 }
 
-type ProductList struct {
+func (x *Variant) GetProductId() int64 {
+	if x != nil {
+		return x.ProductId
+	}
+	return 0
+}
+
+func (x *Variant) GetSku() string {
+	if x != nil {
+		return x.Sku
+	}
+	return ""
+}
+
+func (x *Variant) GetOptions() map[string]string {
+	if x != nil {
+		return x.Options
+	}
+	return nil
+}
+
+func (x *Variant) GetPrice() *money.Money {
+	if x != nil {
+		return x.Price
+	}
+	return nil
+}
+
+func (x *Variant) GetStock() int32 {
+	if x != nil {
+		return x.Stock
+	}
+	return 0
+}
+
+func (x *Variant) GetBarcode() string {
+	if x != nil {
+		return x.Barcode
+	}
+	return ""
+}
+
+type VariantID struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
-	Products      []*Product             `protobuf:"bytes,1,rep,name=products,proto3" json:"products,omitempty"`
+	Id            int64                  `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *ProductList) Reset() {
-	*x = ProductList{}
+func (x *VariantID) Reset() {
+	*x = VariantID{}
 	mi := &file_proto_inventory_proto_msgTypes[2]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *ProductList) String() string {
+func (x *VariantID) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*ProductList) ProtoMessage() {}
+func (*VariantID) ProtoMessage() {}
 
-func (x *ProductList) ProtoReflect() protoreflect.Message {
+func (x *VariantID) ProtoReflect() protoreflect.Message {
 	mi := &file_proto_inventory_proto_msgTypes[2]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
@@ -173,38 +309,39 @@ func (x *ProductList) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use ProductList.ProtoReflect.Descriptor instead.
-func (*ProductList) Descriptor() ([]byte, []int) {
+// Deprecated: Use VariantID.ProtoReflect.Descriptor instead.
+func (*VariantID) Descriptor() ([]byte, []int) {
 	return file_proto_inventory_proto_rawDescGZIP(), []int{2}
 }
 
-func (x *ProductList) GetProducts() []*Product {
+func (x *VariantID) GetId() int64 {
 	if x != nil {
-		return x.Products
+		return x.Id
 	}
-	return nil
+	return 0
 }
 
-type Empty struct {
+type VariantList struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
+	Variants      []*Variant             `protobuf:"bytes,1,rep,name=variants,proto3" json:"variants,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *Empty) Reset() {
-	*x = Empty{}
+func (x *VariantList) Reset() {
+	*x = VariantList{}
 	mi := &file_proto_inventory_proto_msgTypes[3]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *Empty) String() string {
+func (x *VariantList) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*Empty) ProtoMessage() {}
+func (*VariantList) ProtoMessage() {}
 
-func (x *Empty) ProtoReflect() protoreflect.Message {
+func (x *VariantList) ProtoReflect() protoreflect.Message {
 	mi := &file_proto_inventory_proto_msgTypes[3]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
@@ -216,34 +353,1778 @@ func (x *Empty) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use Empty.ProtoReflect.Descriptor instead.
-func (*Empty) Descriptor() ([]byte, []int) {
+// Deprecated: Use VariantList.ProtoReflect.Descriptor instead.
+func (*VariantList) Descriptor() ([]byte, []int) {
 	return file_proto_inventory_proto_rawDescGZIP(), []int{3}
 }
 
-var File_proto_inventory_proto protoreflect.FileDescriptor
+func (x *VariantList) GetVariants() []*Variant {
+	if x != nil {
+		return x.Variants
+	}
+	return nil
+}
 
-const file_proto_inventory_proto_rawDesc = "" +
-	"\n" +
-	"\x15proto/inventory.proto\x12\tinventory\"u\n" +
-	"\aProduct\x12\x0e\n" +
-	"\x02id\x18\x01 \x01(\x03R\x02id\x12\x12\n" +
-	"\x04name\x18\x02 \x01(\tR\x04name\x12\x1a\n" +
-	"\bcategory\x18\x03 \x01(\tR\bcategory\x12\x14\n" +
-	"\x05stock\x18\x04 \x01(\x05R\x05stock\x12\x14\n" +
-	"\x05price\x18\x05 \x01(\x02R\x05price\"\x1b\n" +
-	"\tProductID\x12\x0e\n" +
-	"\x02id\x18\x01 \x01(\x03R\x02id\"=\n" +
-	"\vProductList\x12.\n" +
-	"\bproducts\x18\x01 \x03(\v2\x12.inventory.ProductR\bproducts\"\a\n" +
-	"\x05Empty2\xaf\x02\n" +
+type Category struct {
+	state    protoimpl.MessageState `protogen:"open.v1"`
+	Id       int64                  `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	ParentId int64                  `protobuf:"varint,2,opt,name=parent_id,json=parentId,proto3" json:"parent_id,omitempty"` // 0 for a root
+	Name     string                 `protobuf:"bytes,3,opt,name=name,proto3" json:"name,omitempty"`
+	Slug     string                 `protobuf:"bytes,4,opt,name=slug,proto3" json:"slug,omitempty"`
+	// Set in listings: depth below the root, and slugs from the root
+	// joined by "/".
+	Depth         int32  `protobuf:"varint,5,opt,name=depth,proto3" json:"depth,omitempty"`
+	Path          string `protobuf:"bytes,6,opt,name=path,proto3" json:"path,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Category) Reset() {
+	*x = Category{}
+	mi := &file_proto_inventory_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Category) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Category) ProtoMessage() {}
+
+func (x *Category) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_inventory_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Category.ProtoReflect.Descriptor instead.
+func (*Category) Descriptor() ([]byte, []int) {
+	return file_proto_inventory_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *Category) GetId() int64 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+func (x *Category) GetParentId() int64 {
+	if x != nil {
+		return x.ParentId
+	}
+	return 0
+}
+
+func (x *Category) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *Category) GetSlug() string {
+	if x != nil {
+		return x.Slug
+	}
+	return ""
+}
+
+func (x *Category) GetDepth() int32 {
+	if x != nil {
+		return x.Depth
+	}
+	return 0
+}
+
+func (x *Category) GetPath() string {
+	if x != nil {
+		return x.Path
+	}
+	return ""
+}
+
+type CreateCategoryRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Name          string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	Slug          string                 `protobuf:"bytes,2,opt,name=slug,proto3" json:"slug,omitempty"` // derived from the name when empty
+	ParentId      int64                  `protobuf:"varint,3,opt,name=parent_id,json=parentId,proto3" json:"parent_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CreateCategoryRequest) Reset() {
+	*x = CreateCategoryRequest{}
+	mi := &file_proto_inventory_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CreateCategoryRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CreateCategoryRequest) ProtoMessage() {}
+
+func (x *CreateCategoryRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_inventory_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CreateCategoryRequest.ProtoReflect.Descriptor instead.
+func (*CreateCategoryRequest) Descriptor() ([]byte, []int) {
+	return file_proto_inventory_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *CreateCategoryRequest) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *CreateCategoryRequest) GetSlug() string {
+	if x != nil {
+		return x.Slug
+	}
+	return ""
+}
+
+func (x *CreateCategoryRequest) GetParentId() int64 {
+	if x != nil {
+		return x.ParentId
+	}
+	return 0
+}
+
+type ListCategoriesRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	RootId        int64                  `protobuf:"varint,1,opt,name=root_id,json=rootId,proto3" json:"root_id,omitempty"` // 0 lists the whole tree
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListCategoriesRequest) Reset() {
+	*x = ListCategoriesRequest{}
+	mi := &file_proto_inventory_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListCategoriesRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListCategoriesRequest) ProtoMessage() {}
+
+func (x *ListCategoriesRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_inventory_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListCategoriesRequest.ProtoReflect.Descriptor instead.
+func (*ListCategoriesRequest) Descriptor() ([]byte, []int) {
+	return file_proto_inventory_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *ListCategoriesRequest) GetRootId() int64 {
+	if x != nil {
+		return x.RootId
+	}
+	return 0
+}
+
+type CategoryList struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Categories    []*Category            `protobuf:"bytes,1,rep,name=categories,proto3" json:"categories,omitempty"` // depth first
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CategoryList) Reset() {
+	*x = CategoryList{}
+	mi := &file_proto_inventory_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CategoryList) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CategoryList) ProtoMessage() {}
+
+func (x *CategoryList) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_inventory_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CategoryList.ProtoReflect.Descriptor instead.
+func (*CategoryList) Descriptor() ([]byte, []int) {
+	return file_proto_inventory_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *CategoryList) GetCategories() []*Category {
+	if x != nil {
+		return x.Categories
+	}
+	return nil
+}
+
+type MoveCategoryRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            int64                  `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	ParentId      int64                  `protobuf:"varint,2,opt,name=parent_id,json=parentId,proto3" json:"parent_id,omitempty"` // 0 makes the category a root
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *MoveCategoryRequest) Reset() {
+	*x = MoveCategoryRequest{}
+	mi := &file_proto_inventory_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *MoveCategoryRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*MoveCategoryRequest) ProtoMessage() {}
+
+func (x *MoveCategoryRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_inventory_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use MoveCategoryRequest.ProtoReflect.Descriptor instead.
+func (*MoveCategoryRequest) Descriptor() ([]byte, []int) {
+	return file_proto_inventory_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *MoveCategoryRequest) GetId() int64 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+func (x *MoveCategoryRequest) GetParentId() int64 {
+	if x != nil {
+		return x.ParentId
+	}
+	return 0
+}
+
+type ProductID struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            int64                  `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ProductID) Reset() {
+	*x = ProductID{}
+	mi := &file_proto_inventory_proto_msgTypes[9]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ProductID) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ProductID) ProtoMessage() {}
+
+func (x *ProductID) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_inventory_proto_msgTypes[9]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ProductID.ProtoReflect.Descriptor instead.
+func (*ProductID) Descriptor() ([]byte, []int) {
+	return file_proto_inventory_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *ProductID) GetId() int64 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+type ProductList struct {
+	state    protoimpl.MessageState `protogen:"open.v1"`
+	Products []*Product             `protobuf:"bytes,1,rep,name=products,proto3" json:"products,omitempty"`
+	// ReserveStock: the reserved items, by variant, with the warehouse each
+	// is taken from.
+	Allocations   []*StockItem `protobuf:"bytes,2,rep,name=allocations,proto3" json:"allocations,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ProductList) Reset() {
+	*x = ProductList{}
+	mi := &file_proto_inventory_proto_msgTypes[10]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ProductList) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ProductList) ProtoMessage() {}
+
+func (x *ProductList) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_inventory_proto_msgTypes[10]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ProductList.ProtoReflect.Descriptor instead.
+func (*ProductList) Descriptor() ([]byte, []int) {
+	return file_proto_inventory_proto_rawDescGZIP(), []int{10}
+}
+
+func (x *ProductList) GetProducts() []*Product {
+	if x != nil {
+		return x.Products
+	}
+	return nil
+}
+
+func (x *ProductList) GetAllocations() []*StockItem {
+	if x != nil {
+		return x.Allocations
+	}
+	return nil
+}
+
+type Empty struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Empty) Reset() {
+	*x = Empty{}
+	mi := &file_proto_inventory_proto_msgTypes[11]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Empty) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Empty) ProtoMessage() {}
+
+func (x *Empty) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_inventory_proto_msgTypes[11]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Empty.ProtoReflect.Descriptor instead.
+func (*Empty) Descriptor() ([]byte, []int) {
+	return file_proto_inventory_proto_rawDescGZIP(), []int{11}
+}
+
+type ListProductsRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// A category slug or name, or category_id; both include the
+	// category's descendants.
+	Category   string `protobuf:"bytes,1,opt,name=category,proto3" json:"category,omitempty"`
+	CategoryId int64  `protobuf:"varint,10,opt,name=category_id,json=categoryId,proto3" json:"category_id,omitempty"`
+	// Price bounds, inclusive. A bound only matches products priced in its
+	// currency (USD when unset); both bounds must use the same currency.
+	MinPrice      *money.Money `protobuf:"bytes,2,opt,name=min_price,json=minPrice,proto3" json:"min_price,omitempty"`
+	MaxPrice      *money.Money `protobuf:"bytes,3,opt,name=max_price,json=maxPrice,proto3" json:"max_price,omitempty"`
+	InStock       bool         `protobuf:"varint,4,opt,name=in_stock,json=inStock,proto3" json:"in_stock,omitempty"`
+	Query         string       `protobuf:"bytes,5,opt,name=query,proto3" json:"query,omitempty"` // case-insensitive substring of the name
+	Sort          ProductSort  `protobuf:"varint,6,opt,name=sort,proto3,enum=inventory.ProductSort" json:"sort,omitempty"`
+	Descending    bool         `protobuf:"varint,7,opt,name=descending,proto3" json:"descending,omitempty"`
+	PageSize      int32        `protobuf:"varint,8,opt,name=page_size,json=pageSize,proto3" json:"page_size,omitempty"`   // default 20, at most 100
+	PageToken     string       `protobuf:"bytes,9,opt,name=page_token,json=pageToken,proto3" json:"page_token,omitempty"` // next_page_token of the previous page
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListProductsRequest) Reset() {
+	*x = ListProductsRequest{}
+	mi := &file_proto_inventory_proto_msgTypes[12]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListProductsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListProductsRequest) ProtoMessage() {}
+
+func (x *ListProductsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_inventory_proto_msgTypes[12]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListProductsRequest.ProtoReflect.Descriptor instead.
+func (*ListProductsRequest) Descriptor() ([]byte, []int) {
+	return file_proto_inventory_proto_rawDescGZIP(), []int{12}
+}
+
+func (x *ListProductsRequest) GetCategory() string {
+	if x != nil {
+		return x.Category
+	}
+	return ""
+}
+
+func (x *ListProductsRequest) GetCategoryId() int64 {
+	if x != nil {
+		return x.CategoryId
+	}
+	return 0
+}
+
+func (x *ListProductsRequest) GetMinPrice() *money.Money {
+	if x != nil {
+		return x.MinPrice
+	}
+	return nil
+}
+
+func (x *ListProductsRequest) GetMaxPrice() *money.Money {
+	if x != nil {
+		return x.MaxPrice
+	}
+	return nil
+}
+
+func (x *ListProductsRequest) GetInStock() bool {
+	if x != nil {
+		return x.InStock
+	}
+	return false
+}
+
+func (x *ListProductsRequest) GetQuery() string {
+	if x != nil {
+		return x.Query
+	}
+	return ""
+}
+
+func (x *ListProductsRequest) GetSort() ProductSort {
+	if x != nil {
+		return x.Sort
+	}
+	return ProductSort_PRODUCT_SORT_UNSPECIFIED
+}
+
+func (x *ListProductsRequest) GetDescending() bool {
+	if x != nil {
+		return x.Descending
+	}
+	return false
+}
+
+func (x *ListProductsRequest) GetPageSize() int32 {
+	if x != nil {
+		return x.PageSize
+	}
+	return 0
+}
+
+func (x *ListProductsRequest) GetPageToken() string {
+	if x != nil {
+		return x.PageToken
+	}
+	return ""
+}
+
+type ListProductsResponse struct {
+	state    protoimpl.MessageState `protogen:"open.v1"`
+	Products []*Product             `protobuf:"bytes,1,rep,name=products,proto3" json:"products,omitempty"`
+	// Empty on the last page. Only valid with the same filters and sort.
+	NextPageToken string `protobuf:"bytes,2,opt,name=next_page_token,json=nextPageToken,proto3" json:"next_page_token,omitempty"`
+	TotalCount    int64  `protobuf:"varint,3,opt,name=total_count,json=totalCount,proto3" json:"total_count,omitempty"` // products matching the filters, on all pages
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListProductsResponse) Reset() {
+	*x = ListProductsResponse{}
+	mi := &file_proto_inventory_proto_msgTypes[13]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListProductsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListProductsResponse) ProtoMessage() {}
+
+func (x *ListProductsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_inventory_proto_msgTypes[13]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListProductsResponse.ProtoReflect.Descriptor instead.
+func (*ListProductsResponse) Descriptor() ([]byte, []int) {
+	return file_proto_inventory_proto_rawDescGZIP(), []int{13}
+}
+
+func (x *ListProductsResponse) GetProducts() []*Product {
+	if x != nil {
+		return x.Products
+	}
+	return nil
+}
+
+func (x *ListProductsResponse) GetNextPageToken() string {
+	if x != nil {
+		return x.NextPageToken
+	}
+	return ""
+}
+
+func (x *ListProductsResponse) GetTotalCount() int64 {
+	if x != nil {
+		return x.TotalCount
+	}
+	return 0
+}
+
+// StockItem names a variant by variant_id or sku, or by product_id alone
+// for a product with a single variant.
+type StockItem struct {
+	state     protoimpl.MessageState `protogen:"open.v1"`
+	ProductId int64                  `protobuf:"varint,1,opt,name=product_id,json=productId,proto3" json:"product_id,omitempty"`
+	Quantity  int32                  `protobuf:"varint,2,opt,name=quantity,proto3" json:"quantity,omitempty"`
+	VariantId int64                  `protobuf:"varint,3,opt,name=variant_id,json=variantId,proto3" json:"variant_id,omitempty"`
+	Sku       string                 `protobuf:"bytes,4,opt,name=sku,proto3" json:"sku,omitempty"`
+	// Where the stock is taken from or returned to. Set by ReserveStock;
+	// ReleaseStock without it uses the first warehouse in priority order.
+	WarehouseId   int64 `protobuf:"varint,5,opt,name=warehouse_id,json=warehouseId,proto3" json:"warehouse_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *StockItem) Reset() {
+	*x = StockItem{}
+	mi := &file_proto_inventory_proto_msgTypes[14]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *StockItem) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*StockItem) ProtoMessage() {}
+
+func (x *StockItem) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_inventory_proto_msgTypes[14]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use StockItem.ProtoReflect.Descriptor instead.
+func (*StockItem) Descriptor() ([]byte, []int) {
+	return file_proto_inventory_proto_rawDescGZIP(), []int{14}
+}
+
+func (x *StockItem) GetProductId() int64 {
+	if x != nil {
+		return x.ProductId
+	}
+	return 0
+}
+
+func (x *StockItem) GetQuantity() int32 {
+	if x != nil {
+		return x.Quantity
+	}
+	return 0
+}
+
+func (x *StockItem) GetVariantId() int64 {
+	if x != nil {
+		return x.VariantId
+	}
+	return 0
+}
+
+func (x *StockItem) GetSku() string {
+	if x != nil {
+		return x.Sku
+	}
+	return ""
+}
+
+func (x *StockItem) GetWarehouseId() int64 {
+	if x != nil {
+		return x.WarehouseId
+	}
+	return 0
+}
+
+type ReservationID struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ReservationID) Reset() {
+	*x = ReservationID{}
+	mi := &file_proto_inventory_proto_msgTypes[15]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ReservationID) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ReservationID) ProtoMessage() {}
+
+func (x *ReservationID) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_inventory_proto_msgTypes[15]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ReservationID.ProtoReflect.Descriptor instead.
+func (*ReservationID) Descriptor() ([]byte, []int) {
+	return file_proto_inventory_proto_rawDescGZIP(), []int{15}
+}
+
+func (x *ReservationID) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+// Reservation is a stock reservation as inventory-service recorded it.
+type Reservation struct {
+	state  protoimpl.MessageState `protogen:"open.v1"`
+	Id     string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	Status string                 `protobuf:"bytes,2,opt,name=status,proto3" json:"status,omitempty"` // "reserved" or "released"
+	// The reserved items, by variant, with the warehouse each was taken from.
+	Items         []*StockItem `protobuf:"bytes,3,rep,name=items,proto3" json:"items,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Reservation) Reset() {
+	*x = Reservation{}
+	mi := &file_proto_inventory_proto_msgTypes[16]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Reservation) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Reservation) ProtoMessage() {}
+
+func (x *Reservation) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_inventory_proto_msgTypes[16]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Reservation.ProtoReflect.Descriptor instead.
+func (*Reservation) Descriptor() ([]byte, []int) {
+	return file_proto_inventory_proto_rawDescGZIP(), []int{16}
+}
+
+func (x *Reservation) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *Reservation) GetStatus() string {
+	if x != nil {
+		return x.Status
+	}
+	return ""
+}
+
+func (x *Reservation) GetItems() []*StockItem {
+	if x != nil {
+		return x.Items
+	}
+	return nil
+}
+
+type StockRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Items []*StockItem           `protobuf:"bytes,1,rep,name=items,proto3" json:"items,omitempty"`
+	// Optional idempotency key; ReleaseStock restores the items stored with it.
+	ReservationId string `protobuf:"bytes,2,opt,name=reservation_id,json=reservationId,proto3" json:"reservation_id,omitempty"`
+	// Destination of the order, for the "nearest" allocation strategy.
+	ShipToCountry string `protobuf:"bytes,3,opt,name=ship_to_country,json=shipToCountry,proto3" json:"ship_to_country,omitempty"` // ISO 3166-1 alpha-2
+	ShipToRegion  string `protobuf:"bytes,4,opt,name=ship_to_region,json=shipToRegion,proto3" json:"ship_to_region,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *StockRequest) Reset() {
+	*x = StockRequest{}
+	mi := &file_proto_inventory_proto_msgTypes[17]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *StockRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*StockRequest) ProtoMessage() {}
+
+func (x *StockRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_inventory_proto_msgTypes[17]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use StockRequest.ProtoReflect.Descriptor instead.
+func (*StockRequest) Descriptor() ([]byte, []int) {
+	return file_proto_inventory_proto_rawDescGZIP(), []int{17}
+}
+
+func (x *StockRequest) GetItems() []*StockItem {
+	if x != nil {
+		return x.Items
+	}
+	return nil
+}
+
+func (x *StockRequest) GetReservationId() string {
+	if x != nil {
+		return x.ReservationId
+	}
+	return ""
+}
+
+func (x *StockRequest) GetShipToCountry() string {
+	if x != nil {
+		return x.ShipToCountry
+	}
+	return ""
+}
+
+func (x *StockRequest) GetShipToRegion() string {
+	if x != nil {
+		return x.ShipToRegion
+	}
+	return ""
+}
+
+// Warehouse holds stock of variants. Reservations take each item from one
+// warehouse, picked by the service's allocation strategy; ties go to the
+// lower priority number.
+type Warehouse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            int64                  `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	Code          string                 `protobuf:"bytes,2,opt,name=code,proto3" json:"code,omitempty"` // unique, e.g. "ALA-1"
+	Name          string                 `protobuf:"bytes,3,opt,name=name,proto3" json:"name,omitempty"`
+	Country       string                 `protobuf:"bytes,4,opt,name=country,proto3" json:"country,omitempty"` // ISO 3166-1 alpha-2
+	Region        string                 `protobuf:"bytes,5,opt,name=region,proto3" json:"region,omitempty"`
+	Priority      int32                  `protobuf:"varint,6,opt,name=priority,proto3" json:"priority,omitempty"` // lower is preferred; the first is the primary warehouse
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Warehouse) Reset() {
+	*x = Warehouse{}
+	mi := &file_proto_inventory_proto_msgTypes[18]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Warehouse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Warehouse) ProtoMessage() {}
+
+func (x *Warehouse) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_inventory_proto_msgTypes[18]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Warehouse.ProtoReflect.Descriptor instead.
+func (*Warehouse) Descriptor() ([]byte, []int) {
+	return file_proto_inventory_proto_rawDescGZIP(), []int{18}
+}
+
+func (x *Warehouse) GetId() int64 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+func (x *Warehouse) GetCode() string {
+	if x != nil {
+		return x.Code
+	}
+	return ""
+}
+
+func (x *Warehouse) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *Warehouse) GetCountry() string {
+	if x != nil {
+		return x.Country
+	}
+	return ""
+}
+
+func (x *Warehouse) GetRegion() string {
+	if x != nil {
+		return x.Region
+	}
+	return ""
+}
+
+func (x *Warehouse) GetPriority() int32 {
+	if x != nil {
+		return x.Priority
+	}
+	return 0
+}
+
+type WarehouseList struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Warehouses    []*Warehouse           `protobuf:"bytes,1,rep,name=warehouses,proto3" json:"warehouses,omitempty"` // in priority order
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *WarehouseList) Reset() {
+	*x = WarehouseList{}
+	mi := &file_proto_inventory_proto_msgTypes[19]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *WarehouseList) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*WarehouseList) ProtoMessage() {}
+
+func (x *WarehouseList) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_inventory_proto_msgTypes[19]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use WarehouseList.ProtoReflect.Descriptor instead.
+func (*WarehouseList) Descriptor() ([]byte, []int) {
+	return file_proto_inventory_proto_rawDescGZIP(), []int{19}
+}
+
+func (x *WarehouseList) GetWarehouses() []*Warehouse {
+	if x != nil {
+		return x.Warehouses
+	}
+	return nil
+}
+
+type GetStockLevelsRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// One of product_id and variant_id is required; warehouse_id narrows the
+	// levels down to one warehouse.
+	ProductId     int64 `protobuf:"varint,1,opt,name=product_id,json=productId,proto3" json:"product_id,omitempty"`
+	VariantId     int64 `protobuf:"varint,2,opt,name=variant_id,json=variantId,proto3" json:"variant_id,omitempty"`
+	WarehouseId   int64 `protobuf:"varint,3,opt,name=warehouse_id,json=warehouseId,proto3" json:"warehouse_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetStockLevelsRequest) Reset() {
+	*x = GetStockLevelsRequest{}
+	mi := &file_proto_inventory_proto_msgTypes[20]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetStockLevelsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetStockLevelsRequest) ProtoMessage() {}
+
+func (x *GetStockLevelsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_inventory_proto_msgTypes[20]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetStockLevelsRequest.ProtoReflect.Descriptor instead.
+func (*GetStockLevelsRequest) Descriptor() ([]byte, []int) {
+	return file_proto_inventory_proto_rawDescGZIP(), []int{20}
+}
+
+func (x *GetStockLevelsRequest) GetProductId() int64 {
+	if x != nil {
+		return x.ProductId
+	}
+	return 0
+}
+
+func (x *GetStockLevelsRequest) GetVariantId() int64 {
+	if x != nil {
+		return x.VariantId
+	}
+	return 0
+}
+
+func (x *GetStockLevelsRequest) GetWarehouseId() int64 {
+	if x != nil {
+		return x.WarehouseId
+	}
+	return 0
+}
+
+// StockLevel is the stock of a variant in a warehouse.
+type StockLevel struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	WarehouseId   int64                  `protobuf:"varint,1,opt,name=warehouse_id,json=warehouseId,proto3" json:"warehouse_id,omitempty"`
+	WarehouseCode string                 `protobuf:"bytes,2,opt,name=warehouse_code,json=warehouseCode,proto3" json:"warehouse_code,omitempty"`
+	ProductId     int64                  `protobuf:"varint,3,opt,name=product_id,json=productId,proto3" json:"product_id,omitempty"`
+	VariantId     int64                  `protobuf:"varint,4,opt,name=variant_id,json=variantId,proto3" json:"variant_id,omitempty"`
+	Sku           string                 `protobuf:"bytes,5,opt,name=sku,proto3" json:"sku,omitempty"`
+	Stock         int32                  `protobuf:"varint,6,opt,name=stock,proto3" json:"stock,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *StockLevel) Reset() {
+	*x = StockLevel{}
+	mi := &file_proto_inventory_proto_msgTypes[21]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *StockLevel) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*StockLevel) ProtoMessage() {}
+
+func (x *StockLevel) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_inventory_proto_msgTypes[21]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use StockLevel.ProtoReflect.Descriptor instead.
+func (*StockLevel) Descriptor() ([]byte, []int) {
+	return file_proto_inventory_proto_rawDescGZIP(), []int{21}
+}
+
+func (x *StockLevel) GetWarehouseId() int64 {
+	if x != nil {
+		return x.WarehouseId
+	}
+	return 0
+}
+
+func (x *StockLevel) GetWarehouseCode() string {
+	if x != nil {
+		return x.WarehouseCode
+	}
+	return ""
+}
+
+func (x *StockLevel) GetProductId() int64 {
+	if x != nil {
+		return x.ProductId
+	}
+	return 0
+}
+
+func (x *StockLevel) GetVariantId() int64 {
+	if x != nil {
+		return x.VariantId
+	}
+	return 0
+}
+
+func (x *StockLevel) GetSku() string {
+	if x != nil {
+		return x.Sku
+	}
+	return ""
+}
+
+func (x *StockLevel) GetStock() int32 {
+	if x != nil {
+		return x.Stock
+	}
+	return 0
+}
+
+type StockLevels struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Every variant in every warehouse, including empty ones; by variant,
+	// then warehouse priority.
+	Levels        []*StockLevel `protobuf:"bytes,1,rep,name=levels,proto3" json:"levels,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *StockLevels) Reset() {
+	*x = StockLevels{}
+	mi := &file_proto_inventory_proto_msgTypes[22]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *StockLevels) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*StockLevels) ProtoMessage() {}
+
+func (x *StockLevels) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_inventory_proto_msgTypes[22]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use StockLevels.ProtoReflect.Descriptor instead.
+func (*StockLevels) Descriptor() ([]byte, []int) {
+	return file_proto_inventory_proto_rawDescGZIP(), []int{22}
+}
+
+func (x *StockLevels) GetLevels() []*StockLevel {
+	if x != nil {
+		return x.Levels
+	}
+	return nil
+}
+
+type SearchProductsRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Query         string                 `protobuf:"bytes,1,opt,name=query,proto3" json:"query,omitempty"`       // words; the last one may be incomplete
+	Category      string                 `protobuf:"bytes,2,opt,name=category,proto3" json:"category,omitempty"` // slug or name; see ListProductsRequest
+	CategoryId    int64                  `protobuf:"varint,7,opt,name=category_id,json=categoryId,proto3" json:"category_id,omitempty"`
+	MinPrice      *money.Money           `protobuf:"bytes,3,opt,name=min_price,json=minPrice,proto3" json:"min_price,omitempty"` // inclusive; see ListProductsRequest
+	MaxPrice      *money.Money           `protobuf:"bytes,4,opt,name=max_price,json=maxPrice,proto3" json:"max_price,omitempty"`
+	PageSize      int32                  `protobuf:"varint,5,opt,name=page_size,json=pageSize,proto3" json:"page_size,omitempty"`   // default 20, at most 100
+	PageToken     string                 `protobuf:"bytes,6,opt,name=page_token,json=pageToken,proto3" json:"page_token,omitempty"` // next_page_token of the previous page
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SearchProductsRequest) Reset() {
+	*x = SearchProductsRequest{}
+	mi := &file_proto_inventory_proto_msgTypes[23]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SearchProductsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SearchProductsRequest) ProtoMessage() {}
+
+func (x *SearchProductsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_inventory_proto_msgTypes[23]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SearchProductsRequest.ProtoReflect.Descriptor instead.
+func (*SearchProductsRequest) Descriptor() ([]byte, []int) {
+	return file_proto_inventory_proto_rawDescGZIP(), []int{23}
+}
+
+func (x *SearchProductsRequest) GetQuery() string {
+	if x != nil {
+		return x.Query
+	}
+	return ""
+}
+
+func (x *SearchProductsRequest) GetCategory() string {
+	if x != nil {
+		return x.Category
+	}
+	return ""
+}
+
+func (x *SearchProductsRequest) GetCategoryId() int64 {
+	if x != nil {
+		return x.CategoryId
+	}
+	return 0
+}
+
+func (x *SearchProductsRequest) GetMinPrice() *money.Money {
+	if x != nil {
+		return x.MinPrice
+	}
+	return nil
+}
+
+func (x *SearchProductsRequest) GetMaxPrice() *money.Money {
+	if x != nil {
+		return x.MaxPrice
+	}
+	return nil
+}
+
+func (x *SearchProductsRequest) GetPageSize() int32 {
+	if x != nil {
+		return x.PageSize
+	}
+	return 0
+}
+
+func (x *SearchProductsRequest) GetPageToken() string {
+	if x != nil {
+		return x.PageToken
+	}
+	return ""
+}
+
+type SearchHit struct {
+	state   protoimpl.MessageState `protogen:"open.v1"`
+	Product *Product               `protobuf:"bytes,1,opt,name=product,proto3" json:"product,omitempty"`
+	// The name and a description snippet as HTML: the text is escaped and the
+	// matched words are wrapped in <mark></mark>.
+	HighlightedName string  `protobuf:"bytes,2,opt,name=highlighted_name,json=highlightedName,proto3" json:"highlighted_name,omitempty"`
+	Snippet         string  `protobuf:"bytes,3,opt,name=snippet,proto3" json:"snippet,omitempty"`
+	Rank            float64 `protobuf:"fixed64,4,opt,name=rank,proto3" json:"rank,omitempty"`
+	unknownFields   protoimpl.UnknownFields
+	sizeCache       protoimpl.SizeCache
+}
+
+func (x *SearchHit) Reset() {
+	*x = SearchHit{}
+	mi := &file_proto_inventory_proto_msgTypes[24]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SearchHit) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SearchHit) ProtoMessage() {}
+
+func (x *SearchHit) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_inventory_proto_msgTypes[24]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SearchHit.ProtoReflect.Descriptor instead.
+func (*SearchHit) Descriptor() ([]byte, []int) {
+	return file_proto_inventory_proto_rawDescGZIP(), []int{24}
+}
+
+func (x *SearchHit) GetProduct() *Product {
+	if x != nil {
+		return x.Product
+	}
+	return nil
+}
+
+func (x *SearchHit) GetHighlightedName() string {
+	if x != nil {
+		return x.HighlightedName
+	}
+	return ""
+}
+
+func (x *SearchHit) GetSnippet() string {
+	if x != nil {
+		return x.Snippet
+	}
+	return ""
+}
+
+func (x *SearchHit) GetRank() float64 {
+	if x != nil {
+		return x.Rank
+	}
+	return 0
+}
+
+// CategoryFacet counts the matches directly in a category; category_id is
+// 0 for uncategorized products.
+type CategoryFacet struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Category      string                 `protobuf:"bytes,1,opt,name=category,proto3" json:"category,omitempty"`
+	Count         int64                  `protobuf:"varint,2,opt,name=count,proto3" json:"count,omitempty"`
+	CategoryId    int64                  `protobuf:"varint,3,opt,name=category_id,json=categoryId,proto3" json:"category_id,omitempty"`
+	Slug          string                 `protobuf:"bytes,4,opt,name=slug,proto3" json:"slug,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CategoryFacet) Reset() {
+	*x = CategoryFacet{}
+	mi := &file_proto_inventory_proto_msgTypes[25]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CategoryFacet) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CategoryFacet) ProtoMessage() {}
+
+func (x *CategoryFacet) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_inventory_proto_msgTypes[25]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CategoryFacet.ProtoReflect.Descriptor instead.
+func (*CategoryFacet) Descriptor() ([]byte, []int) {
+	return file_proto_inventory_proto_rawDescGZIP(), []int{25}
+}
+
+func (x *CategoryFacet) GetCategory() string {
+	if x != nil {
+		return x.Category
+	}
+	return ""
+}
+
+func (x *CategoryFacet) GetCount() int64 {
+	if x != nil {
+		return x.Count
+	}
+	return 0
+}
+
+func (x *CategoryFacet) GetCategoryId() int64 {
+	if x != nil {
+		return x.CategoryId
+	}
+	return 0
+}
+
+func (x *CategoryFacet) GetSlug() string {
+	if x != nil {
+		return x.Slug
+	}
+	return ""
+}
+
+type PriceFacet struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	CurrencyCode  string                 `protobuf:"bytes,1,opt,name=currency_code,json=currencyCode,proto3" json:"currency_code,omitempty"`
+	MinMinorUnits int64                  `protobuf:"varint,2,opt,name=min_minor_units,json=minMinorUnits,proto3" json:"min_minor_units,omitempty"` // inclusive
+	MaxMinorUnits int64                  `protobuf:"varint,3,opt,name=max_minor_units,json=maxMinorUnits,proto3" json:"max_minor_units,omitempty"` // exclusive; 0 for no upper bound
+	Count         int64                  `protobuf:"varint,4,opt,name=count,proto3" json:"count,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *PriceFacet) Reset() {
+	*x = PriceFacet{}
+	mi := &file_proto_inventory_proto_msgTypes[26]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *PriceFacet) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PriceFacet) ProtoMessage() {}
+
+func (x *PriceFacet) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_inventory_proto_msgTypes[26]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PriceFacet.ProtoReflect.Descriptor instead.
+func (*PriceFacet) Descriptor() ([]byte, []int) {
+	return file_proto_inventory_proto_rawDescGZIP(), []int{26}
+}
+
+func (x *PriceFacet) GetCurrencyCode() string {
+	if x != nil {
+		return x.CurrencyCode
+	}
+	return ""
+}
+
+func (x *PriceFacet) GetMinMinorUnits() int64 {
+	if x != nil {
+		return x.MinMinorUnits
+	}
+	return 0
+}
+
+func (x *PriceFacet) GetMaxMinorUnits() int64 {
+	if x != nil {
+		return x.MaxMinorUnits
+	}
+	return 0
+}
+
+func (x *PriceFacet) GetCount() int64 {
+	if x != nil {
+		return x.Count
+	}
+	return 0
+}
+
+type SearchProductsResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Hits          []*SearchHit           `protobuf:"bytes,1,rep,name=hits,proto3" json:"hits,omitempty"`
+	NextPageToken string                 `protobuf:"bytes,2,opt,name=next_page_token,json=nextPageToken,proto3" json:"next_page_token,omitempty"`
+	TotalCount    int64                  `protobuf:"varint,3,opt,name=total_count,json=totalCount,proto3" json:"total_count,omitempty"`
+	// True when no product matched the words and the hits are names
+	// similar to the query instead.
+	Fuzzy bool `protobuf:"varint,4,opt,name=fuzzy,proto3" json:"fuzzy,omitempty"`
+	// Counts over all matches; category counts ignore the category filter and
+	// price counts ignore the price filter.
+	Categories    []*CategoryFacet `protobuf:"bytes,5,rep,name=categories,proto3" json:"categories,omitempty"`
+	Prices        []*PriceFacet    `protobuf:"bytes,6,rep,name=prices,proto3" json:"prices,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SearchProductsResponse) Reset() {
+	*x = SearchProductsResponse{}
+	mi := &file_proto_inventory_proto_msgTypes[27]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SearchProductsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SearchProductsResponse) ProtoMessage() {}
+
+func (x *SearchProductsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_inventory_proto_msgTypes[27]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SearchProductsResponse.ProtoReflect.Descriptor instead.
+func (*SearchProductsResponse) Descriptor() ([]byte, []int) {
+	return file_proto_inventory_proto_rawDescGZIP(), []int{27}
+}
+
+func (x *SearchProductsResponse) GetHits() []*SearchHit {
+	if x != nil {
+		return x.Hits
+	}
+	return nil
+}
+
+func (x *SearchProductsResponse) GetNextPageToken() string {
+	if x != nil {
+		return x.NextPageToken
+	}
+	return ""
+}
+
+func (x *SearchProductsResponse) GetTotalCount() int64 {
+	if x != nil {
+		return x.TotalCount
+	}
+	return 0
+}
+
+func (x *SearchProductsResponse) GetFuzzy() bool {
+	if x != nil {
+		return x.Fuzzy
+	}
+	return false
+}
+
+func (x *SearchProductsResponse) GetCategories() []*CategoryFacet {
+	if x != nil {
+		return x.Categories
+	}
+	return nil
+}
+
+func (x *SearchProductsResponse) GetPrices() []*PriceFacet {
+	if x != nil {
+		return x.Prices
+	}
+	return nil
+}
+
+var File_proto_inventory_proto protoreflect.FileDescriptor
+
+const file_proto_inventory_proto_rawDesc = "" +
+	"\n" +
+	"\x15proto/inventory.proto\x12\tinventory\x1a\x11proto/money.proto\"\xfc\x01\n" +
+	"\aProduct\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\x03R\x02id\x12\x12\n" +
+	"\x04name\x18\x02 \x01(\tR\x04name\x12\x1a\n" +
+	"\bcategory\x18\x03 \x01(\tR\bcategory\x12\x14\n" +
+	"\x05stock\x18\x04 \x01(\x05R\x05stock\x12\"\n" +
+	"\x05price\x18\x06 \x01(\v2\f.money.MoneyR\x05price\x12 \n" +
+	"\vdescription\x18\a \x01(\tR\vdescription\x12\x1f\n" +
+	"\vcategory_id\x18\b \x01(\x03R\n" +
+	"categoryId\x12.\n" +
+	"\bvariants\x18\t \x03(\v2\x12.inventory.VariantR\bvariantsJ\x04\b\x05\x10\x06\"\x95\x02\n" +
+	"\aVariant\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\x03R\x02id\x12\x1d\n" +
+	"\n" +
+	"product_id\x18\x02 \x01(\x03R\tproductId\x12\x10\n" +
+	"\x03sku\x18\x03 \x01(\tR\x03sku\x129\n" +
+	"\aoptions\x18\x04 \x03(\v2\x1f.inventory.Variant.OptionsEntryR\aoptions\x12\"\n" +
+	"\x05price\x18\x05 \x01(\v2\f.money.MoneyR\x05price\x12\x14\n" +
+	"\x05stock\x18\x06 \x01(\x05R\x05stock\x12\x18\n" +
+	"\abarcode\x18\a \x01(\tR\abarcode\x1a:\n" +
+	"\fOptionsEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01\"\x1b\n" +
+	"\tVariantID\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\x03R\x02id\"=\n" +
+	"\vVariantList\x12.\n" +
+	"\bvariants\x18\x01 \x03(\v2\x12.inventory.VariantR\bvariants\"\x89\x01\n" +
+	"\bCategory\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\x03R\x02id\x12\x1b\n" +
+	"\tparent_id\x18\x02 \x01(\x03R\bparentId\x12\x12\n" +
+	"\x04name\x18\x03 \x01(\tR\x04name\x12\x12\n" +
+	"\x04slug\x18\x04 \x01(\tR\x04slug\x12\x14\n" +
+	"\x05depth\x18\x05 \x01(\x05R\x05depth\x12\x12\n" +
+	"\x04path\x18\x06 \x01(\tR\x04path\"\\\n" +
+	"\x15CreateCategoryRequest\x12\x12\n" +
+	"\x04name\x18\x01 \x01(\tR\x04name\x12\x12\n" +
+	"\x04slug\x18\x02 \x01(\tR\x04slug\x12\x1b\n" +
+	"\tparent_id\x18\x03 \x01(\x03R\bparentId\"0\n" +
+	"\x15ListCategoriesRequest\x12\x17\n" +
+	"\aroot_id\x18\x01 \x01(\x03R\x06rootId\"C\n" +
+	"\fCategoryList\x123\n" +
+	"\n" +
+	"categories\x18\x01 \x03(\v2\x13.inventory.CategoryR\n" +
+	"categories\"B\n" +
+	"\x13MoveCategoryRequest\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\x03R\x02id\x12\x1b\n" +
+	"\tparent_id\x18\x02 \x01(\x03R\bparentId\"\x1b\n" +
+	"\tProductID\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\x03R\x02id\"u\n" +
+	"\vProductList\x12.\n" +
+	"\bproducts\x18\x01 \x03(\v2\x12.inventory.ProductR\bproducts\x126\n" +
+	"\vallocations\x18\x02 \x03(\v2\x14.inventory.StockItemR\vallocations\"\a\n" +
+	"\x05Empty\"\xe1\x02\n" +
+	"\x13ListProductsRequest\x12\x1a\n" +
+	"\bcategory\x18\x01 \x01(\tR\bcategory\x12\x1f\n" +
+	"\vcategory_id\x18\n" +
+	" \x01(\x03R\n" +
+	"categoryId\x12)\n" +
+	"\tmin_price\x18\x02 \x01(\v2\f.money.MoneyR\bminPrice\x12)\n" +
+	"\tmax_price\x18\x03 \x01(\v2\f.money.MoneyR\bmaxPrice\x12\x19\n" +
+	"\bin_stock\x18\x04 \x01(\bR\ainStock\x12\x14\n" +
+	"\x05query\x18\x05 \x01(\tR\x05query\x12*\n" +
+	"\x04sort\x18\x06 \x01(\x0e2\x16.inventory.ProductSortR\x04sort\x12\x1e\n" +
+	"\n" +
+	"descending\x18\a \x01(\bR\n" +
+	"descending\x12\x1b\n" +
+	"\tpage_size\x18\b \x01(\x05R\bpageSize\x12\x1d\n" +
+	"\n" +
+	"page_token\x18\t \x01(\tR\tpageToken\"\x8f\x01\n" +
+	"\x14ListProductsResponse\x12.\n" +
+	"\bproducts\x18\x01 \x03(\v2\x12.inventory.ProductR\bproducts\x12&\n" +
+	"\x0fnext_page_token\x18\x02 \x01(\tR\rnextPageToken\x12\x1f\n" +
+	"\vtotal_count\x18\x03 \x01(\x03R\n" +
+	"totalCount\"\x9a\x01\n" +
+	"\tStockItem\x12\x1d\n" +
+	"\n" +
+	"product_id\x18\x01 \x01(\x03R\tproductId\x12\x1a\n" +
+	"\bquantity\x18\x02 \x01(\x05R\bquantity\x12\x1d\n" +
+	"\n" +
+	"variant_id\x18\x03 \x01(\x03R\tvariantId\x12\x10\n" +
+	"\x03sku\x18\x04 \x01(\tR\x03sku\x12!\n" +
+	"\fwarehouse_id\x18\x05 \x01(\x03R\vwarehouseId\"\x1f\n" +
+	"\rReservationID\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\"a\n" +
+	"\vReservation\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12\x16\n" +
+	"\x06status\x18\x02 \x01(\tR\x06status\x12*\n" +
+	"\x05items\x18\x03 \x03(\v2\x14.inventory.StockItemR\x05items\"\xaf\x01\n" +
+	"\fStockRequest\x12*\n" +
+	"\x05items\x18\x01 \x03(\v2\x14.inventory.StockItemR\x05items\x12%\n" +
+	"\x0ereservation_id\x18\x02 \x01(\tR\rreservationId\x12&\n" +
+	"\x0fship_to_country\x18\x03 \x01(\tR\rshipToCountry\x12$\n" +
+	"\x0eship_to_region\x18\x04 \x01(\tR\fshipToRegion\"\x91\x01\n" +
+	"\tWarehouse\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\x03R\x02id\x12\x12\n" +
+	"\x04code\x18\x02 \x01(\tR\x04code\x12\x12\n" +
+	"\x04name\x18\x03 \x01(\tR\x04name\x12\x18\n" +
+	"\acountry\x18\x04 \x01(\tR\acountry\x12\x16\n" +
+	"\x06region\x18\x05 \x01(\tR\x06region\x12\x1a\n" +
+	"\bpriority\x18\x06 \x01(\x05R\bpriority\"E\n" +
+	"\rWarehouseList\x124\n" +
+	"\n" +
+	"warehouses\x18\x01 \x03(\v2\x14.inventory.WarehouseR\n" +
+	"warehouses\"x\n" +
+	"\x15GetStockLevelsRequest\x12\x1d\n" +
+	"\n" +
+	"product_id\x18\x01 \x01(\x03R\tproductId\x12\x1d\n" +
+	"\n" +
+	"variant_id\x18\x02 \x01(\x03R\tvariantId\x12!\n" +
+	"\fwarehouse_id\x18\x03 \x01(\x03R\vwarehouseId\"\xbc\x01\n" +
+	"\n" +
+	"StockLevel\x12!\n" +
+	"\fwarehouse_id\x18\x01 \x01(\x03R\vwarehouseId\x12%\n" +
+	"\x0ewarehouse_code\x18\x02 \x01(\tR\rwarehouseCode\x12\x1d\n" +
+	"\n" +
+	"product_id\x18\x03 \x01(\x03R\tproductId\x12\x1d\n" +
+	"\n" +
+	"variant_id\x18\x04 \x01(\x03R\tvariantId\x12\x10\n" +
+	"\x03sku\x18\x05 \x01(\tR\x03sku\x12\x14\n" +
+	"\x05stock\x18\x06 \x01(\x05R\x05stock\"<\n" +
+	"\vStockLevels\x12-\n" +
+	"\x06levels\x18\x01 \x03(\v2\x15.inventory.StockLevelR\x06levels\"\xfc\x01\n" +
+	"\x15SearchProductsRequest\x12\x14\n" +
+	"\x05query\x18\x01 \x01(\tR\x05query\x12\x1a\n" +
+	"\bcategory\x18\x02 \x01(\tR\bcategory\x12\x1f\n" +
+	"\vcategory_id\x18\a \x01(\x03R\n" +
+	"categoryId\x12)\n" +
+	"\tmin_price\x18\x03 \x01(\v2\f.money.MoneyR\bminPrice\x12)\n" +
+	"\tmax_price\x18\x04 \x01(\v2\f.money.MoneyR\bmaxPrice\x12\x1b\n" +
+	"\tpage_size\x18\x05 \x01(\x05R\bpageSize\x12\x1d\n" +
+	"\n" +
+	"page_token\x18\x06 \x01(\tR\tpageToken\"\x92\x01\n" +
+	"\tSearchHit\x12,\n" +
+	"\aproduct\x18\x01 \x01(\v2\x12.inventory.ProductR\aproduct\x12)\n" +
+	"\x10highlighted_name\x18\x02 \x01(\tR\x0fhighlightedName\x12\x18\n" +
+	"\asnippet\x18\x03 \x01(\tR\asnippet\x12\x12\n" +
+	"\x04rank\x18\x04 \x01(\x01R\x04rank\"v\n" +
+	"\rCategoryFacet\x12\x1a\n" +
+	"\bcategory\x18\x01 \x01(\tR\bcategory\x12\x14\n" +
+	"\x05count\x18\x02 \x01(\x03R\x05count\x12\x1f\n" +
+	"\vcategory_id\x18\x03 \x01(\x03R\n" +
+	"categoryId\x12\x12\n" +
+	"\x04slug\x18\x04 \x01(\tR\x04slug\"\x97\x01\n" +
+	"\n" +
+	"PriceFacet\x12#\n" +
+	"\rcurrency_code\x18\x01 \x01(\tR\fcurrencyCode\x12&\n" +
+	"\x0fmin_minor_units\x18\x02 \x01(\x03R\rminMinorUnits\x12&\n" +
+	"\x0fmax_minor_units\x18\x03 \x01(\x03R\rmaxMinorUnits\x12\x14\n" +
+	"\x05count\x18\x04 \x01(\x03R\x05count\"\x8a\x02\n" +
+	"\x16SearchProductsResponse\x12(\n" +
+	"\x04hits\x18\x01 \x03(\v2\x14.inventory.SearchHitR\x04hits\x12&\n" +
+	"\x0fnext_page_token\x18\x02 \x01(\tR\rnextPageToken\x12\x1f\n" +
+	"\vtotal_count\x18\x03 \x01(\x03R\n" +
+	"totalCount\x12\x14\n" +
+	"\x05fuzzy\x18\x04 \x01(\bR\x05fuzzy\x128\n" +
+	"\n" +
+	"categories\x18\x05 \x03(\v2\x18.inventory.CategoryFacetR\n" +
+	"categories\x12-\n" +
+	"\x06prices\x18\x06 \x03(\v2\x15.inventory.PriceFacetR\x06prices*r\n" +
+	"\vProductSort\x12\x1c\n" +
+	"\x18PRODUCT_SORT_UNSPECIFIED\x10\x00\x12\x15\n" +
+	"\x11PRODUCT_SORT_NAME\x10\x01\x12\x16\n" +
+	"\x12PRODUCT_SORT_PRICE\x10\x02\x12\x16\n" +
+	"\x12PRODUCT_SORT_STOCK\x10\x032\xa9\n" +
+	"\n" +
 	"\x10InventoryService\x127\n" +
 	"\rCreateProduct\x12\x12.inventory.Product\x1a\x12.inventory.Product\x126\n" +
 	"\n" +
 	"GetProduct\x12\x14.inventory.ProductID\x1a\x12.inventory.Product\x127\n" +
 	"\rUpdateProduct\x12\x12.inventory.Product\x1a\x12.inventory.Product\x127\n" +
-	"\rDeleteProduct\x12\x14.inventory.ProductID\x1a\x10.inventory.Empty\x128\n" +
-	"\fListProducts\x12\x10.inventory.Empty\x1a\x16.inventory.ProductListB$Z\"api-gateway/pb/inventory;inventoryb\x06proto3"
+	"\rDeleteProduct\x12\x14.inventory.ProductID\x1a\x10.inventory.Empty\x12O\n" +
+	"\fListProducts\x12\x1e.inventory.ListProductsRequest\x1a\x1f.inventory.ListProductsResponse\x12U\n" +
+	"\x0eSearchProducts\x12 .inventory.SearchProductsRequest\x1a!.inventory.SearchProductsResponse\x12G\n" +
+	"\x0eCreateCategory\x12 .inventory.CreateCategoryRequest\x1a\x13.inventory.Category\x12K\n" +
+	"\x0eListCategories\x12 .inventory.ListCategoriesRequest\x1a\x17.inventory.CategoryList\x12C\n" +
+	"\fMoveCategory\x12\x1e.inventory.MoveCategoryRequest\x1a\x13.inventory.Category\x127\n" +
+	"\rCreateVariant\x12\x12.inventory.Variant\x1a\x12.inventory.Variant\x127\n" +
+	"\rUpdateVariant\x12\x12.inventory.Variant\x1a\x12.inventory.Variant\x127\n" +
+	"\rDeleteVariant\x12\x14.inventory.VariantID\x1a\x10.inventory.Empty\x12<\n" +
+	"\fListVariants\x12\x14.inventory.ProductID\x1a\x16.inventory.VariantList\x12=\n" +
+	"\x0fCreateWarehouse\x12\x14.inventory.Warehouse\x1a\x14.inventory.Warehouse\x12<\n" +
+	"\x0eListWarehouses\x12\x10.inventory.Empty\x1a\x18.inventory.WarehouseList\x12J\n" +
+	"\x0eGetStockLevels\x12 .inventory.GetStockLevelsRequest\x1a\x16.inventory.StockLevels\x12=\n" +
+	"\rSetStockLevel\x12\x15.inventory.StockLevel\x1a\x15.inventory.StockLevel\x12?\n" +
+	"\fReserveStock\x12\x17.inventory.StockRequest\x1a\x16.inventory.ProductList\x129\n" +
+	"\fReleaseStock\x12\x17.inventory.StockRequest\x1a\x10.inventory.Empty\x12B\n" +
+	"\x0eGetReservation\x12\x18.inventory.ReservationID\x1a\x16.inventory.ReservationB$Z\"api-gateway/pb/inventory;inventoryb\x06proto3"
 
 var (
 	file_proto_inventory_proto_rawDescOnce sync.Once
@@ -257,30 +2138,109 @@ func file_proto_inventory_proto_rawDescGZIP() []byte {
 	return file_proto_inventory_proto_rawDescData
 }
 
-var file_proto_inventory_proto_msgTypes = make([]protoimpl.MessageInfo, 4)
+var file_proto_inventory_proto_enumTypes = make([]protoimpl.EnumInfo, 1)
+var file_proto_inventory_proto_msgTypes = make([]protoimpl.MessageInfo, 29)
 var file_proto_inventory_proto_goTypes = []any{
-	(*Product)(nil),     // 0: inventory.Product
-	(*ProductID)(nil),   // 1: inventory.ProductID
-	(*ProductList)(nil), // 2: inventory.ProductList
-	(*Empty)(nil),       // 3: inventory.Empty
+	(ProductSort)(0),               // 0: inventory.ProductSort
+	(*Product)(nil),                // 1: inventory.Product
+	(*Variant)(nil),                // 2: inventory.Variant
+	(*VariantID)(nil),              // 3: inventory.VariantID
+	(*VariantList)(nil),            // 4: inventory.VariantList
+	(*Category)(nil),               // 5: inventory.Category
+	(*CreateCategoryRequest)(nil),  // 6: inventory.CreateCategoryRequest
+	(*ListCategoriesRequest)(nil),  // 7: inventory.ListCategoriesRequest
+	(*CategoryList)(nil),           // 8: inventory.CategoryList
+	(*MoveCategoryRequest)(nil),    // 9: inventory.MoveCategoryRequest
+	(*ProductID)(nil),              // 10: inventory.ProductID
+	(*ProductList)(nil),            // 11: inventory.ProductList
+	(*Empty)(nil),                  // 12: inventory.Empty
+	(*ListProductsRequest)(nil),    // 13: inventory.ListProductsRequest
+	(*ListProductsResponse)(nil),   // 14: inventory.ListProductsResponse
+	(*StockItem)(nil),              // 15: inventory.StockItem
+	(*ReservationID)(nil),          // 16: inventory.ReservationID
+	(*Reservation)(nil),            // 17: inventory.Reservation
+	(*StockRequest)(nil),           // 18: inventory.StockRequest
+	(*Warehouse)(nil),              // 19: inventory.Warehouse
+	(*WarehouseList)(nil),          // 20: inventory.WarehouseList
+	(*GetStockLevelsRequest)(nil),  // 21: inventory.GetStockLevelsRequest
+	(*StockLevel)(nil),             // 22: inventory.StockLevel
+	(*StockLevels)(nil),            // 23: inventory.StockLevels
+	(*SearchProductsRequest)(nil),  // 24: inventory.SearchProductsRequest
+	(*SearchHit)(nil),              // 25: inventory.SearchHit
+	(*CategoryFacet)(nil),          // 26: inventory.CategoryFacet
+	(*PriceFacet)(nil),             // 27: inventory.PriceFacet
+	(*SearchProductsResponse)(nil), // 28: inventory.SearchProductsResponse
+	nil,                            // 29: inventory.Variant.OptionsEntry
+	(*money.Money)(nil),            // 30: money.Money
 }
 var file_proto_inventory_proto_depIdxs = []int32{
-	0, // 0: inventory.ProductList.products:type_name -> inventory.Product
-	0, // 1: inventory.InventoryService.CreateProduct:input_type -> inventory.Product
-	1, // 2: inventory.InventoryService.GetProduct:input_type -> inventory.ProductID
-	0, // 3: inventory.InventoryService.UpdateProduct:input_type -> inventory.Product
-	1, // 4: inventory.InventoryService.DeleteProduct:input_type -> inventory.ProductID
-	3, // 5: inventory.InventoryService.ListProducts:input_type -> inventory.Empty
-	0, // 6: inventory.InventoryService.CreateProduct:output_type -> inventory.Product
-	0, // 7: inventory.InventoryService.GetProduct:output_type -> inventory.Product
-	0, // 8: inventory.InventoryService.UpdateProduct:output_type -> inventory.Product
-	3, // 9: inventory.InventoryService.DeleteProduct:output_type -> inventory.Empty
-	2, // 10: inventory.InventoryService.ListProducts:output_type -> inventory.ProductList
-	6, // [6:11] is the sub-list for method output_type
-	1, // [1:6] is the sub-list for method input_type
-	1, // [1:1] is the sub-list for extension type_name
-	1, // [1:1] is the sub-list for extension extendee
-	0, // [0:1] is the sub-list for field type_name
+	30, // 0: inventory.Product.price:type_name -> money.Money
+	2,  // 1: inventory.Product.variants:type_name -> inventory.Variant
+	29, // 2: inventory.Variant.options:type_name -> inventory.Variant.OptionsEntry
+	30, // 3: inventory.Variant.price:type_name -> money.Money
+	2,  // 4: inventory.VariantList.variants:type_name -> inventory.Variant
+	5,  // 5: inventory.CategoryList.categories:type_name -> inventory.Category
+	1,  // 6: inventory.ProductList.products:type_name -> inventory.Product
+	15, // 7: inventory.ProductList.allocations:type_name -> inventory.StockItem
+	30, // 8: inventory.ListProductsRequest.min_price:type_name -> money.Money
+	30, // 9: inventory.ListProductsRequest.max_price:type_name -> money.Money
+	0,  // 10: inventory.ListProductsRequest.sort:type_name -> inventory.ProductSort
+	1,  // 11: inventory.ListProductsResponse.products:type_name -> inventory.Product
+	15, // 12: inventory.Reservation.items:type_name -> inventory.StockItem
+	15, // 13: inventory.StockRequest.items:type_name -> inventory.StockItem
+	19, // 14: inventory.WarehouseList.warehouses:type_name -> inventory.Warehouse
+	22, // 15: inventory.StockLevels.levels:type_name -> inventory.StockLevel
+	30, // 16: inventory.SearchProductsRequest.min_price:type_name -> money.Money
+	30, // 17: inventory.SearchProductsRequest.max_price:type_name -> money.Money
+	1,  // 18: inventory.SearchHit.product:type_name -> inventory.Product
+	25, // 19: inventory.SearchProductsResponse.hits:type_name -> inventory.SearchHit
+	26, // 20: inventory.SearchProductsResponse.categories:type_name -> inventory.CategoryFacet
+	27, // 21: inventory.SearchProductsResponse.prices:type_name -> inventory.PriceFacet
+	1,  // 22: inventory.InventoryService.CreateProduct:input_type -> inventory.Product
+	10, // 23: inventory.InventoryService.GetProduct:input_type -> inventory.ProductID
+	1,  // 24: inventory.InventoryService.UpdateProduct:input_type -> inventory.Product
+	10, // 25: inventory.InventoryService.DeleteProduct:input_type -> inventory.ProductID
+	13, // 26: inventory.InventoryService.ListProducts:input_type -> inventory.ListProductsRequest
+	24, // 27: inventory.InventoryService.SearchProducts:input_type -> inventory.SearchProductsRequest
+	6,  // 28: inventory.InventoryService.CreateCategory:input_type -> inventory.CreateCategoryRequest
+	7,  // 29: inventory.InventoryService.ListCategories:input_type -> inventory.ListCategoriesRequest
+	9,  // 30: inventory.InventoryService.MoveCategory:input_type -> inventory.MoveCategoryRequest
+	2,  // 31: inventory.InventoryService.CreateVariant:input_type -> inventory.Variant
+	2,  // 32: inventory.InventoryService.UpdateVariant:input_type -> inventory.Variant
+	3,  // 33: inventory.InventoryService.DeleteVariant:input_type -> inventory.VariantID
+	10, // 34: inventory.InventoryService.ListVariants:input_type -> inventory.ProductID
+	19, // 35: inventory.InventoryService.CreateWarehouse:input_type -> inventory.Warehouse
+	12, // 36: inventory.InventoryService.ListWarehouses:input_type -> inventory.Empty
+	21, // 37: inventory.InventoryService.GetStockLevels:input_type -> inventory.GetStockLevelsRequest
+	22, // 38: inventory.InventoryService.SetStockLevel:input_type -> inventory.StockLevel
+	18, // 39: inventory.InventoryService.ReserveStock:input_type -> inventory.StockRequest
+	18, // 40: inventory.InventoryService.ReleaseStock:input_type -> inventory.StockRequest
+	16, // 41: inventory.InventoryService.GetReservation:input_type -> inventory.ReservationID
+	1,  // 42: inventory.InventoryService.CreateProduct:output_type -> inventory.Product
+	1,  // 43: inventory.InventoryService.GetProduct:output_type -> inventory.Product
+	1,  // 44: inventory.InventoryService.UpdateProduct:output_type -> inventory.Product
+	12, // 45: inventory.InventoryService.DeleteProduct:output_type -> inventory.Empty
+	14, // 46: inventory.InventoryService.ListProducts:output_type -> inventory.ListProductsResponse
+	28, // 47: inventory.InventoryService.SearchProducts:output_type -> inventory.SearchProductsResponse
+	5,  // 48: inventory.InventoryService.CreateCategory:output_type -> inventory.Category
+	8,  // 49: inventory.InventoryService.ListCategories:output_type -> inventory.CategoryList
+	5,  // 50: inventory.InventoryService.MoveCategory:output_type -> inventory.Category
+	2,  // 51: inventory.InventoryService.CreateVariant:output_type -> inventory.Variant
+	2,  // 52: inventory.InventoryService.UpdateVariant:output_type -> inventory.Variant
+	12, // 53: inventory.InventoryService.DeleteVariant:output_type -> inventory.Empty
+	4,  // 54: inventory.InventoryService.ListVariants:output_type -> inventory.VariantList
+	19, // 55: inventory.InventoryService.CreateWarehouse:output_type -> inventory.Warehouse
+	20, // 56: inventory.InventoryService.ListWarehouses:output_type -> inventory.WarehouseList
+	23, // 57: inventory.InventoryService.GetStockLevels:output_type -> inventory.StockLevels
+	22, // 58: inventory.InventoryService.SetStockLevel:output_type -> inventory.StockLevel
+	11, // 59: inventory.InventoryService.ReserveStock:output_type -> inventory.ProductList
+	12, // 60: inventory.InventoryService.ReleaseStock:output_type -> inventory.Empty
+	17, // 61: inventory.InventoryService.GetReservation:output_type -> inventory.Reservation
+	42, // [42:62] is the sub-list for method output_type
+	22, // [22:42] is the sub-list for method input_type
+	22, // [22:22] is the sub-list for extension type_name
+	22, // [22:22] is the sub-list for extension extendee
+	0,  // [0:22] is the sub-list for field type_name
 }
 
 func init() { file_proto_inventory_proto_init() }
@@ -293,13 +2253,14 @@ func file_proto_inventory_proto_init() {
 		File: protoimpl.DescBuilder{
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: unsafe.Slice(unsafe.StringData(file_proto_inventory_proto_rawDesc), len(file_proto_inventory_proto_rawDesc)),
-			NumEnums:      0,
-			NumMessages:   4,
+			NumEnums:      1,
+			NumMessages:   29,
 			NumExtensions: 0,
 			NumServices:   1,
 		},
 		GoTypes:           file_proto_inventory_proto_goTypes,
 		DependencyIndexes: file_proto_inventory_proto_depIdxs,
+		EnumInfos:         file_proto_inventory_proto_enumTypes,
 		MessageInfos:      file_proto_inventory_proto_msgTypes,
 	}.Build()
 	File_proto_inventory_proto = out.File
@@ -0,0 +1,137 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.6
+// 	protoc        v6.30.2
+// source: proto/money.proto
+
+package money
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+// Money is an exact amount of money, shaped after google.type.Money but
+// counted in the currency's minor unit (cents for USD) instead of units and
+// nanos.
+type Money struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// ISO 4217 currency code, e.g. "USD".
+	CurrencyCode string `protobuf:"bytes,1,opt,name=currency_code,json=currencyCode,proto3" json:"currency_code,omitempty"`
+	// Amount in minor units: 199999 is 1999.99 USD.
+	MinorUnits    int64 `protobuf:"varint,2,opt,name=minor_units,json=minorUnits,proto3" json:"minor_units,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Money) Reset() {
+	*x = Money{}
+	mi := &file_proto_money_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Money) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Money) ProtoMessage() {}
+
+func (x *Money) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_money_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Money.ProtoReflect.Descriptor instead.
+func (*Money) Descriptor() ([]byte, []int) {
+	return file_proto_money_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *Money) GetCurrencyCode() string {
+	if x != nil {
+		return x.CurrencyCode
+	}
+	return ""
+}
+
+func (x *Money) GetMinorUnits() int64 {
+	if x != nil {
+		return x.MinorUnits
+	}
+	return 0
+}
+
+var File_proto_money_proto protoreflect.FileDescriptor
+
+const file_proto_money_proto_rawDesc = "" +
+	"\n" +
+	"\x11proto/money.proto\x12\x05money\"M\n" +
+	"\x05Money\x12#\n" +
+	"\rcurrency_code\x18\x01 \x01(\tR\fcurrencyCode\x12\x1f\n" +
+	"\vminor_units\x18\x02 \x01(\x03R\n" +
+	"minorUnitsB\x1cZ\x1aapi-gateway/pb/money;moneyb\x06proto3"
+
+var (
+	file_proto_money_proto_rawDescOnce sync.Once
+	file_proto_money_proto_rawDescData []byte
+)
+
+func file_proto_money_proto_rawDescGZIP() []byte {
+	file_proto_money_proto_rawDescOnce.Do(func() {
+		file_proto_money_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_proto_money_proto_rawDesc), len(file_proto_money_proto_rawDesc)))
+	})
+	return file_proto_money_proto_rawDescData
+}
+
+var file_proto_money_proto_msgTypes = make([]protoimpl.MessageInfo, 1)
+var file_proto_money_proto_goTypes = []any{
+	(*Money)(nil), // 0: money.Money
+}
+var file_proto_money_proto_depIdxs = []int32{
+	0, // [0:0] is the sub-list for method output_type
+	0, // [0:0] is the sub-list for method input_type
+	0, // [0:0] is the sub-list for extension type_name
+	0, // [0:0] is the sub-list for extension extendee
+	0, // [0:0] is the sub-list for field type_name
+}
+
+func init() { file_proto_money_proto_init() }
+func file_proto_money_proto_init() {
+	if File_proto_money_proto != nil {
+		return
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_proto_money_proto_rawDesc), len(file_proto_money_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   1,
+			NumExtensions: 0,
+			NumServices:   0,
+		},
+		GoTypes:           file_proto_money_proto_goTypes,
+		DependencyIndexes: file_proto_money_proto_depIdxs,
+		MessageInfos:      file_proto_money_proto_msgTypes,
+	}.Build()
+	File_proto_money_proto = out.File
+	file_proto_money_proto_goTypes = nil
+	file_proto_money_proto_depIdxs = nil
+}
@@ -7,6 +7,7 @@
 package order
 
 import (
+	money "api-gateway/pb/money"
 	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
 	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
 	reflect "reflect"
@@ -21,10 +22,88 @@ const (
 	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
 )
 
+type OrderStatus int32
+
+const (
+	OrderStatus_ORDER_STATUS_UNSPECIFIED OrderStatus = 0
+	OrderStatus_ORDER_STATUS_PENDING     OrderStatus = 1
+	OrderStatus_ORDER_STATUS_PAID        OrderStatus = 2
+	OrderStatus_ORDER_STATUS_FULFILLED   OrderStatus = 3
+	OrderStatus_ORDER_STATUS_SHIPPED     OrderStatus = 4
+	OrderStatus_ORDER_STATUS_DELIVERED   OrderStatus = 5
+	OrderStatus_ORDER_STATUS_CANCELLED   OrderStatus = 6
+	OrderStatus_ORDER_STATUS_REFUNDED    OrderStatus = 7
+)
+
+// Enum value maps for OrderStatus.
+var (
+	OrderStatus_name = map[int32]string{
+		0: "ORDER_STATUS_UNSPECIFIED",
+		1: "ORDER_STATUS_PENDING",
+		2: "ORDER_STATUS_PAID",
+		3: "ORDER_STATUS_FULFILLED",
+		4: "ORDER_STATUS_SHIPPED",
+		5: "ORDER_STATUS_DELIVERED",
+		6: "ORDER_STATUS_CANCELLED",
+		7: "ORDER_STATUS_REFUNDED",
+	}
+	OrderStatus_value = map[string]int32{
+		"ORDER_STATUS_UNSPECIFIED": 0,
+		"ORDER_STATUS_PENDING":     1,
+		"ORDER_STATUS_PAID":        2,
+		"ORDER_STATUS_FULFILLED":   3,
+		"ORDER_STATUS_SHIPPED":     4,
+		"ORDER_STATUS_DELIVERED":   5,
+		"ORDER_STATUS_CANCELLED":   6,
+		"ORDER_STATUS_REFUNDED":    7,
+	}
+)
+
+func (x OrderStatus) Enum() *OrderStatus {
+	p := new(OrderStatus)
+	*p = x
+	return p
+}
+
+func (x OrderStatus) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (OrderStatus) Descriptor() protoreflect.EnumDescriptor {
+	return file_proto_order_proto_enumTypes[0].Descriptor()
+}
+
+func (OrderStatus) Type() protoreflect.EnumType {
+	return &file_proto_order_proto_enumTypes[0]
+}
+
+func (x OrderStatus) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use OrderStatus.Descriptor instead.
+func (OrderStatus) EnumDescriptor() ([]byte, []int) {
+	return file_proto_order_proto_rawDescGZIP(), []int{0}
+}
+
+// An item names the inventory variant by variant_id or sku, or by
+// product_id alone for a product with a single variant. product_name,
+// variant_options, unit_price and line_total are set by the order service
+// from the prices at the time the order was placed, and product_id,
+// variant_id and sku are filled in; variant_options is ignored in requests.
 type OrderItem struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
-	ProductId     int64                  `protobuf:"varint,1,opt,name=product_id,json=productId,proto3" json:"product_id,omitempty"`
-	Quantity      int32                  `protobuf:"varint,2,opt,name=quantity,proto3" json:"quantity,omitempty"`
+	state          protoimpl.MessageState `protogen:"open.v1"`
+	ProductId      int64                  `protobuf:"varint,1,opt,name=product_id,json=productId,proto3" json:"product_id,omitempty"`
+	Quantity       int32                  `protobuf:"varint,2,opt,name=quantity,proto3" json:"quantity,omitempty"`
+	ProductName    string                 `protobuf:"bytes,3,opt,name=product_name,json=productName,proto3" json:"product_name,omitempty"`
+	UnitPrice      *money.Money           `protobuf:"bytes,6,opt,name=unit_price,json=unitPrice,proto3" json:"unit_price,omitempty"`
+	LineTotal      *money.Money           `protobuf:"bytes,7,opt,name=line_total,json=lineTotal,proto3" json:"line_total,omitempty"`
+	VariantId      int64                  `protobuf:"varint,8,opt,name=variant_id,json=variantId,proto3" json:"variant_id,omitempty"` // 0 for orders placed before variants
+	Sku            string                 `protobuf:"bytes,9,opt,name=sku,proto3" json:"sku,omitempty"`
+	VariantOptions string                 `protobuf:"bytes,10,opt,name=variant_options,json=variantOptions,proto3" json:"variant_options,omitempty"` // e.g. "colour=red, size=M"
+	// Inventory warehouse the item ships from; 0 for orders placed before
+	// warehouses. Ignored in requests.
+	WarehouseId   int64 `protobuf:"varint,11,opt,name=warehouse_id,json=warehouseId,proto3" json:"warehouse_id,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
@@ -73,12 +152,63 @@ func (x *OrderItem) GetQuantity() int32 {
 	return 0
 }
 
+func (x *OrderItem) GetProductName() string {
+	if x != nil {
+		return x.ProductName
+	}
+	return ""
+}
+
+func (x *OrderItem) GetUnitPrice() *money.Money {
+	if x != nil {
+		return x.UnitPrice
+	}
+	return nil
+}
+
+func (x *OrderItem) GetLineTotal() *money.Money {
+	if x != nil {
+		return x.LineTotal
+	}
+	return nil
+}
+
+func (x *OrderItem) GetVariantId() int64 {
+	if x != nil {
+		return x.VariantId
+	}
+	return 0
+}
+
+func (x *OrderItem) GetSku() string {
+	if x != nil {
+		return x.Sku
+	}
+	return ""
+}
+
+func (x *OrderItem) GetVariantOptions() string {
+	if x != nil {
+		return x.VariantOptions
+	}
+	return ""
+}
+
+func (x *OrderItem) GetWarehouseId() int64 {
+	if x != nil {
+		return x.WarehouseId
+	}
+	return 0
+}
+
 type OrderRequest struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
-	UserId        int64                  `protobuf:"varint,1,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
-	Items         []*OrderItem           `protobuf:"bytes,2,rep,name=items,proto3" json:"items,omitempty"`
-	unknownFields protoimpl.UnknownFields
-	sizeCache     protoimpl.SizeCache
+	state             protoimpl.MessageState `protogen:"open.v1"`
+	UserId            int64                  `protobuf:"varint,1,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
+	Items             []*OrderItem           `protobuf:"bytes,2,rep,name=items,proto3" json:"items,omitempty"`
+	ShippingAddressId int64                  `protobuf:"varint,3,opt,name=shipping_address_id,json=shippingAddressId,proto3" json:"shipping_address_id,omitempty"` // address book entry; 0 for none
+	BillingAddressId  int64                  `protobuf:"varint,4,opt,name=billing_address_id,json=billingAddressId,proto3" json:"billing_address_id,omitempty"`    // 0 bills the shipping address
+	unknownFields     protoimpl.UnknownFields
+	sizeCache         protoimpl.SizeCache
 }
 
 func (x *OrderRequest) Reset() {
@@ -125,20 +255,149 @@ func (x *OrderRequest) GetItems() []*OrderItem {
 	return nil
 }
 
-type OrderResponse struct {
+func (x *OrderRequest) GetShippingAddressId() int64 {
+	if x != nil {
+		return x.ShippingAddressId
+	}
+	return 0
+}
+
+func (x *OrderRequest) GetBillingAddressId() int64 {
+	if x != nil {
+		return x.BillingAddressId
+	}
+	return 0
+}
+
+// OrderAddress is the copy of an address book entry taken when the order
+// was placed; later edits to the address book do not change it.
+type OrderAddress struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
-	Id            int64                  `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
-	UserId        int64                  `protobuf:"varint,2,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
-	Status        string                 `protobuf:"bytes,3,opt,name=status,proto3" json:"status,omitempty"`
-	CreatedAt     string                 `protobuf:"bytes,4,opt,name=created_at,json=createdAt,proto3" json:"created_at,omitempty"`
-	Items         []*OrderItem           `protobuf:"bytes,5,rep,name=items,proto3" json:"items,omitempty"`
+	AddressId     int64                  `protobuf:"varint,1,opt,name=address_id,json=addressId,proto3" json:"address_id,omitempty"`
+	RecipientName string                 `protobuf:"bytes,2,opt,name=recipient_name,json=recipientName,proto3" json:"recipient_name,omitempty"`
+	Line1         string                 `protobuf:"bytes,3,opt,name=line1,proto3" json:"line1,omitempty"`
+	Line2         string                 `protobuf:"bytes,4,opt,name=line2,proto3" json:"line2,omitempty"`
+	City          string                 `protobuf:"bytes,5,opt,name=city,proto3" json:"city,omitempty"`
+	Region        string                 `protobuf:"bytes,6,opt,name=region,proto3" json:"region,omitempty"`
+	PostalCode    string                 `protobuf:"bytes,7,opt,name=postal_code,json=postalCode,proto3" json:"postal_code,omitempty"`
+	Country       string                 `protobuf:"bytes,8,opt,name=country,proto3" json:"country,omitempty"`
+	Phone         string                 `protobuf:"bytes,9,opt,name=phone,proto3" json:"phone,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
+func (x *OrderAddress) Reset() {
+	*x = OrderAddress{}
+	mi := &file_proto_order_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *OrderAddress) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*OrderAddress) ProtoMessage() {}
+
+func (x *OrderAddress) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_order_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use OrderAddress.ProtoReflect.Descriptor instead.
+func (*OrderAddress) Descriptor() ([]byte, []int) {
+	return file_proto_order_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *OrderAddress) GetAddressId() int64 {
+	if x != nil {
+		return x.AddressId
+	}
+	return 0
+}
+
+func (x *OrderAddress) GetRecipientName() string {
+	if x != nil {
+		return x.RecipientName
+	}
+	return ""
+}
+
+func (x *OrderAddress) GetLine1() string {
+	if x != nil {
+		return x.Line1
+	}
+	return ""
+}
+
+func (x *OrderAddress) GetLine2() string {
+	if x != nil {
+		return x.Line2
+	}
+	return ""
+}
+
+func (x *OrderAddress) GetCity() string {
+	if x != nil {
+		return x.City
+	}
+	return ""
+}
+
+func (x *OrderAddress) GetRegion() string {
+	if x != nil {
+		return x.Region
+	}
+	return ""
+}
+
+func (x *OrderAddress) GetPostalCode() string {
+	if x != nil {
+		return x.PostalCode
+	}
+	return ""
+}
+
+func (x *OrderAddress) GetCountry() string {
+	if x != nil {
+		return x.Country
+	}
+	return ""
+}
+
+func (x *OrderAddress) GetPhone() string {
+	if x != nil {
+		return x.Phone
+	}
+	return ""
+}
+
+type OrderResponse struct {
+	state           protoimpl.MessageState `protogen:"open.v1"`
+	Id              int64                  `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	UserId          int64                  `protobuf:"varint,2,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
+	CreatedAt       string                 `protobuf:"bytes,4,opt,name=created_at,json=createdAt,proto3" json:"created_at,omitempty"`
+	Items           []*OrderItem           `protobuf:"bytes,5,rep,name=items,proto3" json:"items,omitempty"`
+	Status          OrderStatus            `protobuf:"varint,6,opt,name=status,proto3,enum=order.OrderStatus" json:"status,omitempty"`
+	Subtotal        *money.Money           `protobuf:"bytes,10,opt,name=subtotal,proto3" json:"subtotal,omitempty"`
+	Tax             *money.Money           `protobuf:"bytes,11,opt,name=tax,proto3" json:"tax,omitempty"`
+	TotalAmount     *money.Money           `protobuf:"bytes,12,opt,name=total_amount,json=totalAmount,proto3" json:"total_amount,omitempty"`
+	ShippingAddress *OrderAddress          `protobuf:"bytes,13,opt,name=shipping_address,json=shippingAddress,proto3" json:"shipping_address,omitempty"`
+	BillingAddress  *OrderAddress          `protobuf:"bytes,14,opt,name=billing_address,json=billingAddress,proto3" json:"billing_address,omitempty"`
+	unknownFields   protoimpl.UnknownFields
+	sizeCache       protoimpl.SizeCache
+}
+
 func (x *OrderResponse) Reset() {
 	*x = OrderResponse{}
-	mi := &file_proto_order_proto_msgTypes[2]
+	mi := &file_proto_order_proto_msgTypes[3]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -150,7 +409,7 @@ func (x *OrderResponse) String() string {
 func (*OrderResponse) ProtoMessage() {}
 
 func (x *OrderResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_proto_order_proto_msgTypes[2]
+	mi := &file_proto_order_proto_msgTypes[3]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -163,7 +422,7 @@ func (x *OrderResponse) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use OrderResponse.ProtoReflect.Descriptor instead.
 func (*OrderResponse) Descriptor() ([]byte, []int) {
-	return file_proto_order_proto_rawDescGZIP(), []int{2}
+	return file_proto_order_proto_rawDescGZIP(), []int{3}
 }
 
 func (x *OrderResponse) GetId() int64 {
@@ -180,13 +439,6 @@ func (x *OrderResponse) GetUserId() int64 {
 	return 0
 }
 
-func (x *OrderResponse) GetStatus() string {
-	if x != nil {
-		return x.Status
-	}
-	return ""
-}
-
 func (x *OrderResponse) GetCreatedAt() string {
 	if x != nil {
 		return x.CreatedAt
@@ -201,6 +453,48 @@ func (x *OrderResponse) GetItems() []*OrderItem {
 	return nil
 }
 
+func (x *OrderResponse) GetStatus() OrderStatus {
+	if x != nil {
+		return x.Status
+	}
+	return OrderStatus_ORDER_STATUS_UNSPECIFIED
+}
+
+func (x *OrderResponse) GetSubtotal() *money.Money {
+	if x != nil {
+		return x.Subtotal
+	}
+	return nil
+}
+
+func (x *OrderResponse) GetTax() *money.Money {
+	if x != nil {
+		return x.Tax
+	}
+	return nil
+}
+
+func (x *OrderResponse) GetTotalAmount() *money.Money {
+	if x != nil {
+		return x.TotalAmount
+	}
+	return nil
+}
+
+func (x *OrderResponse) GetShippingAddress() *OrderAddress {
+	if x != nil {
+		return x.ShippingAddress
+	}
+	return nil
+}
+
+func (x *OrderResponse) GetBillingAddress() *OrderAddress {
+	if x != nil {
+		return x.BillingAddress
+	}
+	return nil
+}
+
 type OrderID struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	Id            int64                  `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
@@ -210,7 +504,7 @@ type OrderID struct {
 
 func (x *OrderID) Reset() {
 	*x = OrderID{}
-	mi := &file_proto_order_proto_msgTypes[3]
+	mi := &file_proto_order_proto_msgTypes[4]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -222,7 +516,7 @@ func (x *OrderID) String() string {
 func (*OrderID) ProtoMessage() {}
 
 func (x *OrderID) ProtoReflect() protoreflect.Message {
-	mi := &file_proto_order_proto_msgTypes[3]
+	mi := &file_proto_order_proto_msgTypes[4]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -235,7 +529,7 @@ func (x *OrderID) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use OrderID.ProtoReflect.Descriptor instead.
 func (*OrderID) Descriptor() ([]byte, []int) {
-	return file_proto_order_proto_rawDescGZIP(), []int{3}
+	return file_proto_order_proto_rawDescGZIP(), []int{4}
 }
 
 func (x *OrderID) GetId() int64 {
@@ -254,7 +548,7 @@ type OrderList struct {
 
 func (x *OrderList) Reset() {
 	*x = OrderList{}
-	mi := &file_proto_order_proto_msgTypes[4]
+	mi := &file_proto_order_proto_msgTypes[5]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -266,7 +560,7 @@ func (x *OrderList) String() string {
 func (*OrderList) ProtoMessage() {}
 
 func (x *OrderList) ProtoReflect() protoreflect.Message {
-	mi := &file_proto_order_proto_msgTypes[4]
+	mi := &file_proto_order_proto_msgTypes[5]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -279,7 +573,7 @@ func (x *OrderList) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use OrderList.ProtoReflect.Descriptor instead.
 func (*OrderList) Descriptor() ([]byte, []int) {
-	return file_proto_order_proto_rawDescGZIP(), []int{4}
+	return file_proto_order_proto_rawDescGZIP(), []int{5}
 }
 
 func (x *OrderList) GetOrders() []*OrderResponse {
@@ -292,14 +586,14 @@ func (x *OrderList) GetOrders() []*OrderResponse {
 type StatusUpdate struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	Id            int64                  `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
-	Status        string                 `protobuf:"bytes,2,opt,name=status,proto3" json:"status,omitempty"`
+	Status        OrderStatus            `protobuf:"varint,3,opt,name=status,proto3,enum=order.OrderStatus" json:"status,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
 func (x *StatusUpdate) Reset() {
 	*x = StatusUpdate{}
-	mi := &file_proto_order_proto_msgTypes[5]
+	mi := &file_proto_order_proto_msgTypes[6]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -311,7 +605,7 @@ func (x *StatusUpdate) String() string {
 func (*StatusUpdate) ProtoMessage() {}
 
 func (x *StatusUpdate) ProtoReflect() protoreflect.Message {
-	mi := &file_proto_order_proto_msgTypes[5]
+	mi := &file_proto_order_proto_msgTypes[6]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -324,7 +618,7 @@ func (x *StatusUpdate) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use StatusUpdate.ProtoReflect.Descriptor instead.
 func (*StatusUpdate) Descriptor() ([]byte, []int) {
-	return file_proto_order_proto_rawDescGZIP(), []int{5}
+	return file_proto_order_proto_rawDescGZIP(), []int{6}
 }
 
 func (x *StatusUpdate) GetId() int64 {
@@ -334,10 +628,182 @@ func (x *StatusUpdate) GetId() int64 {
 	return 0
 }
 
-func (x *StatusUpdate) GetStatus() string {
+func (x *StatusUpdate) GetStatus() OrderStatus {
 	if x != nil {
 		return x.Status
 	}
+	return OrderStatus_ORDER_STATUS_UNSPECIFIED
+}
+
+type StatusChange struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	FromStatus    OrderStatus            `protobuf:"varint,1,opt,name=from_status,json=fromStatus,proto3,enum=order.OrderStatus" json:"from_status,omitempty"`
+	ToStatus      OrderStatus            `protobuf:"varint,2,opt,name=to_status,json=toStatus,proto3,enum=order.OrderStatus" json:"to_status,omitempty"`
+	ChangedAt     string                 `protobuf:"bytes,3,opt,name=changed_at,json=changedAt,proto3" json:"changed_at,omitempty"`
+	Reason        string                 `protobuf:"bytes,4,opt,name=reason,proto3" json:"reason,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *StatusChange) Reset() {
+	*x = StatusChange{}
+	mi := &file_proto_order_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *StatusChange) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*StatusChange) ProtoMessage() {}
+
+func (x *StatusChange) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_order_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use StatusChange.ProtoReflect.Descriptor instead.
+func (*StatusChange) Descriptor() ([]byte, []int) {
+	return file_proto_order_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *StatusChange) GetFromStatus() OrderStatus {
+	if x != nil {
+		return x.FromStatus
+	}
+	return OrderStatus_ORDER_STATUS_UNSPECIFIED
+}
+
+func (x *StatusChange) GetToStatus() OrderStatus {
+	if x != nil {
+		return x.ToStatus
+	}
+	return OrderStatus_ORDER_STATUS_UNSPECIFIED
+}
+
+func (x *StatusChange) GetChangedAt() string {
+	if x != nil {
+		return x.ChangedAt
+	}
+	return ""
+}
+
+func (x *StatusChange) GetReason() string {
+	if x != nil {
+		return x.Reason
+	}
+	return ""
+}
+
+type OrderHistory struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	OrderId       int64                  `protobuf:"varint,1,opt,name=order_id,json=orderId,proto3" json:"order_id,omitempty"`
+	Changes       []*StatusChange        `protobuf:"bytes,2,rep,name=changes,proto3" json:"changes,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *OrderHistory) Reset() {
+	*x = OrderHistory{}
+	mi := &file_proto_order_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *OrderHistory) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*OrderHistory) ProtoMessage() {}
+
+func (x *OrderHistory) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_order_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use OrderHistory.ProtoReflect.Descriptor instead.
+func (*OrderHistory) Descriptor() ([]byte, []int) {
+	return file_proto_order_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *OrderHistory) GetOrderId() int64 {
+	if x != nil {
+		return x.OrderId
+	}
+	return 0
+}
+
+func (x *OrderHistory) GetChanges() []*StatusChange {
+	if x != nil {
+		return x.Changes
+	}
+	return nil
+}
+
+type CancelOrderRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            int64                  `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	Reason        string                 `protobuf:"bytes,2,opt,name=reason,proto3" json:"reason,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CancelOrderRequest) Reset() {
+	*x = CancelOrderRequest{}
+	mi := &file_proto_order_proto_msgTypes[9]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CancelOrderRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CancelOrderRequest) ProtoMessage() {}
+
+func (x *CancelOrderRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_order_proto_msgTypes[9]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CancelOrderRequest.ProtoReflect.Descriptor instead.
+func (*CancelOrderRequest) Descriptor() ([]byte, []int) {
+	return file_proto_order_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *CancelOrderRequest) GetId() int64 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+func (x *CancelOrderRequest) GetReason() string {
+	if x != nil {
+		return x.Reason
+	}
 	return ""
 }
 
@@ -350,7 +816,7 @@ type UserOrdersRequest struct {
 
 func (x *UserOrdersRequest) Reset() {
 	*x = UserOrdersRequest{}
-	mi := &file_proto_order_proto_msgTypes[6]
+	mi := &file_proto_order_proto_msgTypes[10]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -362,7 +828,7 @@ func (x *UserOrdersRequest) String() string {
 func (*UserOrdersRequest) ProtoMessage() {}
 
 func (x *UserOrdersRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_proto_order_proto_msgTypes[6]
+	mi := &file_proto_order_proto_msgTypes[10]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -375,7 +841,7 @@ func (x *UserOrdersRequest) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use UserOrdersRequest.ProtoReflect.Descriptor instead.
 func (*UserOrdersRequest) Descriptor() ([]byte, []int) {
-	return file_proto_order_proto_rawDescGZIP(), []int{6}
+	return file_proto_order_proto_rawDescGZIP(), []int{10}
 }
 
 func (x *UserOrdersRequest) GetUserId() int64 {
@@ -389,36 +855,92 @@ var File_proto_order_proto protoreflect.FileDescriptor
 
 const file_proto_order_proto_rawDesc = "" +
 	"\n" +
-	"\x11proto/order.proto\x12\x05order\"F\n" +
+	"\x11proto/order.proto\x12\x05order\x1a\x11proto/money.proto\"\xcc\x02\n" +
 	"\tOrderItem\x12\x1d\n" +
 	"\n" +
 	"product_id\x18\x01 \x01(\x03R\tproductId\x12\x1a\n" +
-	"\bquantity\x18\x02 \x01(\x05R\bquantity\"O\n" +
+	"\bquantity\x18\x02 \x01(\x05R\bquantity\x12!\n" +
+	"\fproduct_name\x18\x03 \x01(\tR\vproductName\x12+\n" +
+	"\n" +
+	"unit_price\x18\x06 \x01(\v2\f.money.MoneyR\tunitPrice\x12+\n" +
+	"\n" +
+	"line_total\x18\a \x01(\v2\f.money.MoneyR\tlineTotal\x12\x1d\n" +
+	"\n" +
+	"variant_id\x18\b \x01(\x03R\tvariantId\x12\x10\n" +
+	"\x03sku\x18\t \x01(\tR\x03sku\x12'\n" +
+	"\x0fvariant_options\x18\n" +
+	" \x01(\tR\x0evariantOptions\x12!\n" +
+	"\fwarehouse_id\x18\v \x01(\x03R\vwarehouseIdJ\x04\b\x04\x10\x05J\x04\b\x05\x10\x06\"\xad\x01\n" +
 	"\fOrderRequest\x12\x17\n" +
 	"\auser_id\x18\x01 \x01(\x03R\x06userId\x12&\n" +
-	"\x05items\x18\x02 \x03(\v2\x10.order.OrderItemR\x05items\"\x97\x01\n" +
+	"\x05items\x18\x02 \x03(\v2\x10.order.OrderItemR\x05items\x12.\n" +
+	"\x13shipping_address_id\x18\x03 \x01(\x03R\x11shippingAddressId\x12,\n" +
+	"\x12billing_address_id\x18\x04 \x01(\x03R\x10billingAddressId\"\xfd\x01\n" +
+	"\fOrderAddress\x12\x1d\n" +
+	"\n" +
+	"address_id\x18\x01 \x01(\x03R\taddressId\x12%\n" +
+	"\x0erecipient_name\x18\x02 \x01(\tR\rrecipientName\x12\x14\n" +
+	"\x05line1\x18\x03 \x01(\tR\x05line1\x12\x14\n" +
+	"\x05line2\x18\x04 \x01(\tR\x05line2\x12\x12\n" +
+	"\x04city\x18\x05 \x01(\tR\x04city\x12\x16\n" +
+	"\x06region\x18\x06 \x01(\tR\x06region\x12\x1f\n" +
+	"\vpostal_code\x18\a \x01(\tR\n" +
+	"postalCode\x12\x18\n" +
+	"\acountry\x18\b \x01(\tR\acountry\x12\x14\n" +
+	"\x05phone\x18\t \x01(\tR\x05phone\"\xbc\x03\n" +
 	"\rOrderResponse\x12\x0e\n" +
 	"\x02id\x18\x01 \x01(\x03R\x02id\x12\x17\n" +
-	"\auser_id\x18\x02 \x01(\x03R\x06userId\x12\x16\n" +
-	"\x06status\x18\x03 \x01(\tR\x06status\x12\x1d\n" +
+	"\auser_id\x18\x02 \x01(\x03R\x06userId\x12\x1d\n" +
 	"\n" +
 	"created_at\x18\x04 \x01(\tR\tcreatedAt\x12&\n" +
-	"\x05items\x18\x05 \x03(\v2\x10.order.OrderItemR\x05items\"\x19\n" +
+	"\x05items\x18\x05 \x03(\v2\x10.order.OrderItemR\x05items\x12*\n" +
+	"\x06status\x18\x06 \x01(\x0e2\x12.order.OrderStatusR\x06status\x12(\n" +
+	"\bsubtotal\x18\n" +
+	" \x01(\v2\f.money.MoneyR\bsubtotal\x12\x1e\n" +
+	"\x03tax\x18\v \x01(\v2\f.money.MoneyR\x03tax\x12/\n" +
+	"\ftotal_amount\x18\f \x01(\v2\f.money.MoneyR\vtotalAmount\x12>\n" +
+	"\x10shipping_address\x18\r \x01(\v2\x13.order.OrderAddressR\x0fshippingAddress\x12<\n" +
+	"\x0fbilling_address\x18\x0e \x01(\v2\x13.order.OrderAddressR\x0ebillingAddressJ\x04\b\x03\x10\x04J\x04\b\a\x10\bJ\x04\b\b\x10\tJ\x04\b\t\x10\n" +
+	"\"\x19\n" +
 	"\aOrderID\x12\x0e\n" +
 	"\x02id\x18\x01 \x01(\x03R\x02id\"9\n" +
 	"\tOrderList\x12,\n" +
-	"\x06orders\x18\x01 \x03(\v2\x14.order.OrderResponseR\x06orders\"6\n" +
+	"\x06orders\x18\x01 \x03(\v2\x14.order.OrderResponseR\x06orders\"P\n" +
 	"\fStatusUpdate\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\x03R\x02id\x12*\n" +
+	"\x06status\x18\x03 \x01(\x0e2\x12.order.OrderStatusR\x06statusJ\x04\b\x02\x10\x03\"\xab\x01\n" +
+	"\fStatusChange\x123\n" +
+	"\vfrom_status\x18\x01 \x01(\x0e2\x12.order.OrderStatusR\n" +
+	"fromStatus\x12/\n" +
+	"\tto_status\x18\x02 \x01(\x0e2\x12.order.OrderStatusR\btoStatus\x12\x1d\n" +
+	"\n" +
+	"changed_at\x18\x03 \x01(\tR\tchangedAt\x12\x16\n" +
+	"\x06reason\x18\x04 \x01(\tR\x06reason\"X\n" +
+	"\fOrderHistory\x12\x19\n" +
+	"\border_id\x18\x01 \x01(\x03R\aorderId\x12-\n" +
+	"\achanges\x18\x02 \x03(\v2\x13.order.StatusChangeR\achanges\"<\n" +
+	"\x12CancelOrderRequest\x12\x0e\n" +
 	"\x02id\x18\x01 \x01(\x03R\x02id\x12\x16\n" +
-	"\x06status\x18\x02 \x01(\tR\x06status\",\n" +
+	"\x06reason\x18\x02 \x01(\tR\x06reason\",\n" +
 	"\x11UserOrdersRequest\x12\x17\n" +
-	"\auser_id\x18\x01 \x01(\x03R\x06userId2\xf4\x01\n" +
+	"\auser_id\x18\x01 \x01(\x03R\x06userId*\xe5\x01\n" +
+	"\vOrderStatus\x12\x1c\n" +
+	"\x18ORDER_STATUS_UNSPECIFIED\x10\x00\x12\x18\n" +
+	"\x14ORDER_STATUS_PENDING\x10\x01\x12\x15\n" +
+	"\x11ORDER_STATUS_PAID\x10\x02\x12\x1a\n" +
+	"\x16ORDER_STATUS_FULFILLED\x10\x03\x12\x18\n" +
+	"\x14ORDER_STATUS_SHIPPED\x10\x04\x12\x1a\n" +
+	"\x16ORDER_STATUS_DELIVERED\x10\x05\x12\x1a\n" +
+	"\x16ORDER_STATUS_CANCELLED\x10\x06\x12\x19\n" +
+	"\x15ORDER_STATUS_REFUNDED\x10\a2\xec\x02\n" +
 	"\fOrderService\x128\n" +
 	"\vCreateOrder\x12\x13.order.OrderRequest\x1a\x14.order.OrderResponse\x120\n" +
 	"\bGetOrder\x12\x0e.order.OrderID\x1a\x14.order.OrderResponse\x12>\n" +
 	"\x11UpdateOrderStatus\x12\x13.order.StatusUpdate\x1a\x14.order.OrderResponse\x128\n" +
 	"\n" +
-	"ListOrders\x12\x18.order.UserOrdersRequest\x1a\x10.order.OrderListB\x1cZ\x1aapi-gateway/pb/order;orderb\x06proto3"
+	"ListOrders\x12\x18.order.UserOrdersRequest\x1a\x10.order.OrderList\x126\n" +
+	"\x0fGetOrderHistory\x12\x0e.order.OrderID\x1a\x13.order.OrderHistory\x12>\n" +
+	"\vCancelOrder\x12\x19.order.CancelOrderRequest\x1a\x14.order.OrderResponseB\x1cZ\x1aapi-gateway/pb/order;orderb\x06proto3"
 
 var (
 	file_proto_order_proto_rawDescOnce sync.Once
@@ -432,33 +954,56 @@ func file_proto_order_proto_rawDescGZIP() []byte {
 	return file_proto_order_proto_rawDescData
 }
 
-var file_proto_order_proto_msgTypes = make([]protoimpl.MessageInfo, 7)
+var file_proto_order_proto_enumTypes = make([]protoimpl.EnumInfo, 1)
+var file_proto_order_proto_msgTypes = make([]protoimpl.MessageInfo, 11)
 var file_proto_order_proto_goTypes = []any{
-	(*OrderItem)(nil),         // 0: order.OrderItem
-	(*OrderRequest)(nil),      // 1: order.OrderRequest
-	(*OrderResponse)(nil),     // 2: order.OrderResponse
-	(*OrderID)(nil),           // 3: order.OrderID
-	(*OrderList)(nil),         // 4: order.OrderList
-	(*StatusUpdate)(nil),      // 5: order.StatusUpdate
-	(*UserOrdersRequest)(nil), // 6: order.UserOrdersRequest
+	(OrderStatus)(0),           // 0: order.OrderStatus
+	(*OrderItem)(nil),          // 1: order.OrderItem
+	(*OrderRequest)(nil),       // 2: order.OrderRequest
+	(*OrderAddress)(nil),       // 3: order.OrderAddress
+	(*OrderResponse)(nil),      // 4: order.OrderResponse
+	(*OrderID)(nil),            // 5: order.OrderID
+	(*OrderList)(nil),          // 6: order.OrderList
+	(*StatusUpdate)(nil),       // 7: order.StatusUpdate
+	(*StatusChange)(nil),       // 8: order.StatusChange
+	(*OrderHistory)(nil),       // 9: order.OrderHistory
+	(*CancelOrderRequest)(nil), // 10: order.CancelOrderRequest
+	(*UserOrdersRequest)(nil),  // 11: order.UserOrdersRequest
+	(*money.Money)(nil),        // 12: money.Money
 }
 var file_proto_order_proto_depIdxs = []int32{
-	0, // 0: order.OrderRequest.items:type_name -> order.OrderItem
-	0, // 1: order.OrderResponse.items:type_name -> order.OrderItem
-	2, // 2: order.OrderList.orders:type_name -> order.OrderResponse
-	1, // 3: order.OrderService.CreateOrder:input_type -> order.OrderRequest
-	3, // 4: order.OrderService.GetOrder:input_type -> order.OrderID
-	5, // 5: order.OrderService.UpdateOrderStatus:input_type -> order.StatusUpdate
-	6, // 6: order.OrderService.ListOrders:input_type -> order.UserOrdersRequest
-	2, // 7: order.OrderService.CreateOrder:output_type -> order.OrderResponse
-	2, // 8: order.OrderService.GetOrder:output_type -> order.OrderResponse
-	2, // 9: order.OrderService.UpdateOrderStatus:output_type -> order.OrderResponse
-	4, // 10: order.OrderService.ListOrders:output_type -> order.OrderList
-	7, // [7:11] is the sub-list for method output_type
-	3, // [3:7] is the sub-list for method input_type
-	3, // [3:3] is the sub-list for extension type_name
-	3, // [3:3] is the sub-list for extension extendee
-	0, // [0:3] is the sub-list for field type_name
+	12, // 0: order.OrderItem.unit_price:type_name -> money.Money
+	12, // 1: order.OrderItem.line_total:type_name -> money.Money
+	1,  // 2: order.OrderRequest.items:type_name -> order.OrderItem
+	1,  // 3: order.OrderResponse.items:type_name -> order.OrderItem
+	0,  // 4: order.OrderResponse.status:type_name -> order.OrderStatus
+	12, // 5: order.OrderResponse.subtotal:type_name -> money.Money
+	12, // 6: order.OrderResponse.tax:type_name -> money.Money
+	12, // 7: order.OrderResponse.total_amount:type_name -> money.Money
+	3,  // 8: order.OrderResponse.shipping_address:type_name -> order.OrderAddress
+	3,  // 9: order.OrderResponse.billing_address:type_name -> order.OrderAddress
+	4,  // 10: order.OrderList.orders:type_name -> order.OrderResponse
+	0,  // 11: order.StatusUpdate.status:type_name -> order.OrderStatus
+	0,  // 12: order.StatusChange.from_status:type_name -> order.OrderStatus
+	0,  // 13: order.StatusChange.to_status:type_name -> order.OrderStatus
+	8,  // 14: order.OrderHistory.changes:type_name -> order.StatusChange
+	2,  // 15: order.OrderService.CreateOrder:input_type -> order.OrderRequest
+	5,  // 16: order.OrderService.GetOrder:input_type -> order.OrderID
+	7,  // 17: order.OrderService.UpdateOrderStatus:input_type -> order.StatusUpdate
+	11, // 18: order.OrderService.ListOrders:input_type -> order.UserOrdersRequest
+	5,  // 19: order.OrderService.GetOrderHistory:input_type -> order.OrderID
+	10, // 20: order.OrderService.CancelOrder:input_type -> order.CancelOrderRequest
+	4,  // 21: order.OrderService.CreateOrder:output_type -> order.OrderResponse
+	4,  // 22: order.OrderService.GetOrder:output_type -> order.OrderResponse
+	4,  // 23: order.OrderService.UpdateOrderStatus:output_type -> order.OrderResponse
+	6,  // 24: order.OrderService.ListOrders:output_type -> order.OrderList
+	9,  // 25: order.OrderService.GetOrderHistory:output_type -> order.OrderHistory
+	4,  // 26: order.OrderService.CancelOrder:output_type -> order.OrderResponse
+	21, // [21:27] is the sub-list for method output_type
+	15, // [15:21] is the sub-list for method input_type
+	15, // [15:15] is the sub-list for extension type_name
+	15, // [15:15] is the sub-list for extension extendee
+	0,  // [0:15] is the sub-list for field type_name
 }
 
 func init() { file_proto_order_proto_init() }
@@ -471,13 +1016,14 @@ func file_proto_order_proto_init() {
 		File: protoimpl.DescBuilder{
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: unsafe.Slice(unsafe.StringData(file_proto_order_proto_rawDesc), len(file_proto_order_proto_rawDesc)),
-			NumEnums:      0,
-			NumMessages:   7,
+			NumEnums:      1,
+			NumMessages:   11,
 			NumExtensions: 0,
 			NumServices:   1,
 		},
 		GoTypes:           file_proto_order_proto_goTypes,
 		DependencyIndexes: file_proto_order_proto_depIdxs,
+		EnumInfos:         file_proto_order_proto_enumTypes,
 		MessageInfos:      file_proto_order_proto_msgTypes,
 	}.Build()
 	File_proto_order_proto = out.File
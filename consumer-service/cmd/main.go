@@ -1,7 +1,11 @@
 package main
 
 import (
+	"consumer-service/infrastructure/db"
+	"consumer-service/internal/handler"
+	"consumer-service/internal/model"
 	"consumer-service/internal/nats"
+	"consumer-service/internal/repository"
 	"consumer-service/logger"
 	"fmt"
 )
@@ -10,7 +14,11 @@ func main() {
 	logger.InitLogger()
 	logger.Log.Info("🔄 Consumer service started")
 
-	// Connect to NATS
+	// Processed-event ledger makes redelivered messages no-ops
+	database := db.NewPostgres()
+	ledger := &repository.EventLedger{DB: database}
+
+	// Connect to NATS JetStream
 	natsClient, err := nats.NewSubscriber("nats://nats:4222")
 	if err != nil {
 		logger.Log.Error(fmt.Sprintf("Failed to connect to NATS: %v", err))
@@ -20,8 +28,16 @@ func main() {
 
 	logger.Log.Info("✅ Consumer Service connected to NATS")
 
-	// Subscribe to "order.created" topic
-	if err := natsClient.Subscribe("order.created"); err != nil {
+	orderEvents := handler.NewOrderEventHandler(ledger, handler.NewInventoryHandler(), natsClient)
+
+	// Durable consumer for "order.created"
+	if err := natsClient.Subscribe(nats.OrdersStream, model.SubjectOrderCreated, "consumer-service-order-created", orderEvents.HandleOrderCreated); err != nil {
+		logger.Log.Error(fmt.Sprintf("Failed to subscribe: %v", err))
+		return
+	}
+
+	// Durable consumer for "order.cancelled"
+	if err := natsClient.Subscribe(nats.OrdersStream, model.SubjectOrderCancelled, "consumer-service-order-cancelled", orderEvents.HandleOrderCancelled); err != nil {
 		logger.Log.Error(fmt.Sprintf("Failed to subscribe: %v", err))
 		return
 	}
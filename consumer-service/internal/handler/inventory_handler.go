@@ -1,11 +1,16 @@
 package handler
 
 import (
+	"consumer-service/internal/model"
 	pbInventory "consumer-service/pb/inventory"
 	"context"
+	"fmt"
 	"log"
+	"shared/authz"
 
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 )
 
 type InventoryHandler struct {
@@ -13,7 +18,8 @@ type InventoryHandler struct {
 }
 
 func NewInventoryHandler() *InventoryHandler {
-	conn, err := grpc.Dial("inventory-service:50053", grpc.WithInsecure())
+	conn, err := grpc.Dial("inventory-service:50053", grpc.WithInsecure(),
+		grpc.WithPerRPCCredentials(authz.ServiceCredentials(authz.ServiceToken())))
 	if err != nil {
 		log.Fatalf("Failed to connect to inventory service: %v", err)
 	}
@@ -25,26 +31,42 @@ func NewInventoryHandler() *InventoryHandler {
 	}
 }
 
-func (h *InventoryHandler) DecreaseStock(ctx context.Context, productID int64, quantity int32) error {
-	// Get current product
-	product, err := h.client.GetProduct(ctx, &pbInventory.ProductID{Id: productID})
+// ConfirmReservation checks that the order's stock reservation is in place.
+// It only reads the reservation: stock is taken by order-service when it
+// creates the order, and taking it here for a reservation inventory-service
+// does not know would hold stock that no saga ever releases.
+func (h *InventoryHandler) ConfirmReservation(ctx context.Context, reservationID string) error {
+	res, err := h.client.GetReservation(ctx, &pbInventory.ReservationID{Id: reservationID})
+	if status.Code(err) == codes.NotFound {
+		return fmt.Errorf("reservation %s: %w", reservationID, model.ErrReservationUnknown)
+	}
 	if err != nil {
 		return err
 	}
-
-	// Update stock
-	newStock := product.Stock - quantity
-	if newStock < 0 {
-		newStock = 0
+	if res.Status == reservationReleased {
+		return fmt.Errorf("reservation %s: %w", reservationID, model.ErrReservationReleased)
 	}
+	return nil
+}
 
-	_, err = h.client.UpdateProduct(ctx, &pbInventory.Product{
-		Id:       product.Id,
-		Name:     product.Name,
-		Category: product.Category,
-		Stock:    newStock,
-		Price:    product.Price,
-	})
-
+// ReleaseReservation returns the stock of a reservation to inventory. The
+// items only matter for a legacy reservation, which inventory-service does
+// not know and restores from the request.
+func (h *InventoryHandler) ReleaseReservation(ctx context.Context, reservationID string, items []model.OrderItem) error {
+	req := &pbInventory.StockRequest{ReservationId: reservationID}
+	for _, item := range items {
+		req.Items = append(req.Items, &pbInventory.StockItem{
+			ProductId:   int64(item.ProductID),
+			VariantId:   int64(item.VariantID),
+			Sku:         item.SKU,
+			Quantity:    int32(item.Quantity),
+			WarehouseId: int64(item.WarehouseID),
+		})
+	}
+	_, err := h.client.ReleaseStock(ctx, req)
 	return err
 }
+
+// reservationReleased is the status inventory-service reports for a
+// reservation whose stock was returned.
+const reservationReleased = "released"
@@ -0,0 +1,196 @@
+package handler
+
+import (
+	"consumer-service/internal/model"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+type EventLedger interface {
+	IsProcessed(eventID, subject string, orderID int) (bool, error)
+	Record(e model.ProcessedEvent) error
+}
+
+type EventPublisher interface {
+	Publish(subject, eventID string, data []byte) error
+}
+
+// Reservations is the inventory-service side of an order; see
+// InventoryHandler.
+type Reservations interface {
+	// ConfirmReservation checks that a stock reservation is in place, failing
+	// with model.ErrReservationReleased or model.ErrReservationUnknown when
+	// it is not.
+	ConfirmReservation(ctx context.Context, reservationID string) error
+	// ReleaseReservation returns the stock of a reservation. Releasing it
+	// again is a no-op.
+	ReleaseReservation(ctx context.Context, reservationID string, items []model.OrderItem) error
+}
+
+// OrderEventHandler processes order events exactly once per event.
+//
+// Stock is owned by inventory-service and changed only through reservations:
+// order-service reserves it synchronously while creating the order, and this
+// handler merely confirms that reservation, without taking stock itself. A
+// reservation inventory-service does not know, or that cannot be confirmed
+// for another permanent reason, is reported as stock.adjustment_failed; one
+// that was already released, because the order was cancelled first, is
+// skipped. When an order is cancelled its reservation is released again, so
+// stock that order-service failed to return while cancelling is returned
+// here, with the retries of the event.
+type OrderEventHandler struct {
+	ledger    EventLedger
+	inventory Reservations
+	publisher EventPublisher
+}
+
+func NewOrderEventHandler(ledger EventLedger, inventory Reservations, publisher EventPublisher) *OrderEventHandler {
+	return &OrderEventHandler{
+		ledger:    ledger,
+		inventory: inventory,
+		publisher: publisher,
+	}
+}
+
+// HandleOrderCreated returns an error only when processing should be retried;
+// the event is then not recorded as processed.
+func (h *OrderEventHandler) HandleOrderCreated(ctx context.Context, data []byte) error {
+	var event model.OrderCreatedEvent
+	if err := json.Unmarshal(data, &event); err != nil {
+		return fmt.Errorf("unmarshal order event: %w: %v", model.ErrPoison, err)
+	}
+	if event.EventID == "" {
+		event.EventID = fmt.Sprintf("%s:%d", model.SubjectOrderCreated, event.ID)
+	}
+
+	processed, err := h.ledger.IsProcessed(event.EventID, model.SubjectOrderCreated, event.ID)
+	if err != nil {
+		return fmt.Errorf("check ledger: %w", err)
+	}
+	if processed {
+		log.Printf("🔁 Event %s for order %d already processed, skipping", event.EventID, event.ID)
+		return nil
+	}
+
+	log.Printf("📦 Processing Order ID: %d (event %s)", event.ID, event.EventID)
+
+	outcome := model.OutcomeConfirmed
+	if event.ReservationID == "" {
+		// Published before reservations existed: order-service already
+		// changed the stock synchronously, so there is nothing to do.
+		outcome = model.OutcomeSkipped
+	} else if err := h.inventory.ConfirmReservation(ctx, event.ReservationID); errors.Is(err, model.ErrReservationReleased) {
+		// The order was cancelled before this event arrived and its stock
+		// is back; there is nothing left to confirm.
+		log.Printf("🔁 Reservation %s of order %d already released, skipping", event.ReservationID, event.ID)
+		outcome = model.OutcomeSkipped
+	} else if err != nil {
+		if !isPermanent(err) {
+			return fmt.Errorf("confirm reservation %s: %w", event.ReservationID, err)
+		}
+		log.Printf("⚠️ Stock adjustment failed for order %d: %v", event.ID, err)
+		failed := model.StockAdjustmentFailedEvent{OrderID: event.ID, ReservationID: event.ReservationID, Items: event.Items}
+		if err := h.publishAdjustmentFailed(event.EventID, failed, err); err != nil {
+			return err
+		}
+		outcome = model.OutcomeFailed
+	}
+
+	if err := h.ledger.Record(model.ProcessedEvent{
+		EventID: event.EventID,
+		Subject: model.SubjectOrderCreated,
+		OrderID: event.ID,
+		Outcome: outcome,
+	}); err != nil {
+		return fmt.Errorf("record event: %w", err)
+	}
+
+	log.Printf("✅ Order %d processed: %s", event.ID, outcome)
+	return nil
+}
+
+// HandleOrderCancelled releases the stock of a cancelled order. Like
+// HandleOrderCreated it returns an error only when processing should be
+// retried.
+func (h *OrderEventHandler) HandleOrderCancelled(ctx context.Context, data []byte) error {
+	var event model.OrderCancelledEvent
+	if err := json.Unmarshal(data, &event); err != nil {
+		return fmt.Errorf("unmarshal order event: %w: %v", model.ErrPoison, err)
+	}
+	if event.EventID == "" {
+		event.EventID = fmt.Sprintf("%s:%d", model.SubjectOrderCancelled, event.ID)
+	}
+
+	processed, err := h.ledger.IsProcessed(event.EventID, model.SubjectOrderCancelled, event.ID)
+	if err != nil {
+		return fmt.Errorf("check ledger: %w", err)
+	}
+	if processed {
+		log.Printf("🔁 Event %s for order %d already processed, skipping", event.EventID, event.ID)
+		return nil
+	}
+
+	log.Printf("↩️ Releasing stock of cancelled order %d (event %s)", event.ID, event.EventID)
+
+	outcome := model.OutcomeReleased
+	reservationID := event.StockReservationID()
+	if err := h.inventory.ReleaseReservation(ctx, reservationID, event.Items); err != nil {
+		if !isPermanent(err) {
+			return fmt.Errorf("release reservation %s: %w", reservationID, err)
+		}
+		log.Printf("⚠️ Stock release failed for order %d: %v", event.ID, err)
+		failed := model.StockAdjustmentFailedEvent{OrderID: event.ID, ReservationID: reservationID, Items: event.Items}
+		if err := h.publishAdjustmentFailed(event.EventID, failed, err); err != nil {
+			return err
+		}
+		outcome = model.OutcomeFailed
+	}
+
+	if err := h.ledger.Record(model.ProcessedEvent{
+		EventID: event.EventID,
+		Subject: model.SubjectOrderCancelled,
+		OrderID: event.ID,
+		Outcome: outcome,
+	}); err != nil {
+		return fmt.Errorf("record event: %w", err)
+	}
+
+	log.Printf("✅ Cancelled order %d processed: %s", event.ID, outcome)
+	return nil
+}
+
+// publishAdjustmentFailed reports failed, caused by processing the event
+// sourceEventID.
+func (h *OrderEventHandler) publishAdjustmentFailed(sourceEventID string, failed model.StockAdjustmentFailedEvent, cause error) error {
+	// Derived from the source event, so a retry publishes the same ID.
+	failed.EventID = model.SubjectStockAdjustmentFailed + ":" + sourceEventID
+	failed.SourceEventID = sourceEventID
+	failed.Reason = status.Convert(cause).Message()
+
+	data, err := json.Marshal(failed)
+	if err != nil {
+		return err
+	}
+	if err := h.publisher.Publish(model.SubjectStockAdjustmentFailed, failed.EventID, data); err != nil {
+		return fmt.Errorf("publish %s: %w", model.SubjectStockAdjustmentFailed, err)
+	}
+	return nil
+}
+
+// isPermanent tells errors that retrying cannot fix from transient ones.
+func isPermanent(err error) bool {
+	if errors.Is(err, model.ErrReservationUnknown) {
+		return true
+	}
+	switch status.Code(err) {
+	case codes.FailedPrecondition, codes.NotFound, codes.InvalidArgument:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,165 @@
+package handler
+
+import (
+	"consumer-service/internal/model"
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"testing"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestIsPermanent(t *testing.T) {
+	tests := []struct {
+		name      string
+		err       error
+		permanent bool
+	}{
+		{"unknown reservation", fmt.Errorf("reservation order-saga-1: %w", model.ErrReservationUnknown), true},
+		{"insufficient stock", status.Error(codes.FailedPrecondition, "insufficient stock"), true},
+		{"unknown product", status.Error(codes.NotFound, "product not found"), true},
+		{"unavailable", status.Error(codes.Unavailable, "connection refused"), false},
+	}
+	for _, tt := range tests {
+		if got := isPermanent(tt.err); got != tt.permanent {
+			t.Errorf("%s: isPermanent = %v, want %v", tt.name, got, tt.permanent)
+		}
+	}
+}
+
+type fakeLedger struct {
+	recorded []model.ProcessedEvent
+}
+
+func (l *fakeLedger) IsProcessed(eventID, subject string, orderID int) (bool, error) {
+	for _, e := range l.recorded {
+		if e.EventID == eventID {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (l *fakeLedger) Record(e model.ProcessedEvent) error {
+	l.recorded = append(l.recorded, e)
+	return nil
+}
+
+type fakePublisher struct {
+	subjects []string
+}
+
+func (p *fakePublisher) Publish(subject, eventID string, data []byte) error {
+	p.subjects = append(p.subjects, subject)
+	return nil
+}
+
+// fakeInventory fails confirming with confirmErr and releasing with
+// releaseErr.
+type fakeInventory struct {
+	confirmErr error
+	releaseErr error
+	released   []string
+}
+
+func (f *fakeInventory) ConfirmReservation(context.Context, string) error { return f.confirmErr }
+
+func (f *fakeInventory) ReleaseReservation(ctx context.Context, reservationID string, items []model.OrderItem) error {
+	if f.releaseErr != nil {
+		return f.releaseErr
+	}
+	f.released = append(f.released, reservationID)
+	return nil
+}
+
+func TestHandleOrderCreatedOutcomes(t *testing.T) {
+
+	tests := []struct {
+		name      string
+		confirm   error
+		outcome   string
+		published []string
+	}{
+		{"confirmed", nil, model.OutcomeConfirmed, nil},
+		{"released reservation", fmt.Errorf("reservation order-saga-1: %w", model.ErrReservationReleased),
+			model.OutcomeSkipped, nil},
+		{"unknown reservation", fmt.Errorf("reservation order-saga-1: %w", model.ErrReservationUnknown),
+			model.OutcomeFailed, []string{model.SubjectStockAdjustmentFailed}},
+		{"insufficient stock", status.Error(codes.FailedPrecondition, "insufficient stock"),
+			model.OutcomeFailed, []string{model.SubjectStockAdjustmentFailed}},
+	}
+	for _, tt := range tests {
+		ledger, publisher := &fakeLedger{}, &fakePublisher{}
+		h := NewOrderEventHandler(ledger, &fakeInventory{confirmErr: tt.confirm}, publisher)
+
+		data, _ := json.Marshal(model.OrderCreatedEvent{EventID: "e1", ID: 1, ReservationID: "order-saga-1"})
+		if err := h.HandleOrderCreated(context.Background(), data); err != nil {
+			t.Errorf("%s: %v", tt.name, err)
+			continue
+		}
+		if len(ledger.recorded) != 1 || ledger.recorded[0].Outcome != tt.outcome {
+			t.Errorf("%s: recorded %+v, want outcome %s", tt.name, ledger.recorded, tt.outcome)
+		}
+		if !reflect.DeepEqual(publisher.subjects, tt.published) {
+			t.Errorf("%s: published %v, want %v", tt.name, publisher.subjects, tt.published)
+		}
+	}
+
+	// A transient error is retried, so nothing is recorded.
+	ledger := &fakeLedger{}
+	h := NewOrderEventHandler(ledger, &fakeInventory{confirmErr: status.Error(codes.Unavailable, "down")}, &fakePublisher{})
+	data, _ := json.Marshal(model.OrderCreatedEvent{EventID: "e2", ID: 2, ReservationID: "order-saga-2"})
+	if err := h.HandleOrderCreated(context.Background(), data); err == nil || len(ledger.recorded) != 0 {
+		t.Errorf("transient error: got %v with %+v recorded", err, ledger.recorded)
+	}
+}
+
+func TestHandleOrderCancelledReleasesStock(t *testing.T) {
+	tests := []struct {
+		name      string
+		event     model.OrderCancelledEvent
+		release   error
+		released  []string
+		outcome   string
+		published []string
+	}{
+		{"reservation", model.OrderCancelledEvent{EventID: "e1", ID: 1, ReservationID: "order-saga-1"},
+			nil, []string{"order-saga-1"}, model.OutcomeReleased, nil},
+		{"legacy order", model.OrderCancelledEvent{EventID: "e2", ID: 2},
+			nil, []string{"order-2-legacy"}, model.OutcomeReleased, nil},
+		{"unknown product", model.OrderCancelledEvent{EventID: "e3", ID: 3},
+			status.Error(codes.NotFound, "product not found"), nil, model.OutcomeFailed,
+			[]string{model.SubjectStockAdjustmentFailed}},
+	}
+	for _, tt := range tests {
+		ledger, publisher := &fakeLedger{}, &fakePublisher{}
+		inventory := &fakeInventory{releaseErr: tt.release}
+		h := NewOrderEventHandler(ledger, inventory, publisher)
+
+		data, _ := json.Marshal(tt.event)
+		if err := h.HandleOrderCancelled(context.Background(), data); err != nil {
+			t.Errorf("%s: %v", tt.name, err)
+			continue
+		}
+		if !reflect.DeepEqual(inventory.released, tt.released) {
+			t.Errorf("%s: released %v, want %v", tt.name, inventory.released, tt.released)
+		}
+		if len(ledger.recorded) != 1 || ledger.recorded[0].Outcome != tt.outcome {
+			t.Errorf("%s: recorded %+v, want outcome %s", tt.name, ledger.recorded, tt.outcome)
+		}
+		if !reflect.DeepEqual(publisher.subjects, tt.published) {
+			t.Errorf("%s: published %v, want %v", tt.name, publisher.subjects, tt.published)
+		}
+	}
+
+	// A transient error is retried, so nothing is recorded.
+	ledger := &fakeLedger{}
+	h := NewOrderEventHandler(ledger, &fakeInventory{releaseErr: status.Error(codes.Unavailable, "down")}, &fakePublisher{})
+	data, _ := json.Marshal(model.OrderCancelledEvent{EventID: "e4", ID: 4, ReservationID: "order-saga-4"})
+	if err := h.HandleOrderCancelled(context.Background(), data); err == nil || len(ledger.recorded) != 0 {
+		t.Errorf("transient error: got %v with %+v recorded", err, ledger.recorded)
+	}
+}
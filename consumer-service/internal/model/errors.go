@@ -0,0 +1,15 @@
+package model
+
+import "errors"
+
+// ErrPoison marks a message that can never be processed, e.g. malformed
+// JSON. Such messages go to the dead-letter subject without retrying.
+var ErrPoison = errors.New("poison message")
+
+// Why a stock reservation cannot be confirmed. A released reservation belongs
+// to an order that was cancelled in the meantime; an unknown one was never
+// made, or was rolled back, and must not be made now.
+var (
+	ErrReservationReleased = errors.New("reservation already released")
+	ErrReservationUnknown  = errors.New("unknown reservation")
+)
@@ -0,0 +1,77 @@
+package model
+
+import "fmt"
+
+const (
+	SubjectOrderCreated          = "order.created"
+	SubjectOrderCancelled        = "order.cancelled"
+	SubjectStockAdjustmentFailed = "stock.adjustment_failed"
+)
+
+// Outcomes recorded in the processed-event ledger.
+const (
+	OutcomeConfirmed = "confirmed"
+	OutcomeReleased  = "released"
+	OutcomeSkipped   = "skipped"
+	OutcomeFailed    = "failed"
+)
+
+// OrderItem names the inventory variant; orders placed before variants
+// have only ProductID. WarehouseID is where order-service reserved the item;
+// it is passed on in events but inventory-service picks its own warehouse
+// when reserving.
+type OrderItem struct {
+	ProductID   int    `json:"product_id"`
+	VariantID   int    `json:"variant_id,omitempty"`
+	SKU         string `json:"sku,omitempty"`
+	Quantity    int    `json:"quantity"`
+	WarehouseID int    `json:"warehouse_id,omitempty"`
+}
+
+// OrderCreatedEvent is published by order-service through its outbox.
+type OrderCreatedEvent struct {
+	EventID       string      `json:"event_id"`
+	ID            int         `json:"id"`
+	UserID        int         `json:"user_id"`
+	Status        string      `json:"status"`
+	ReservationID string      `json:"reservation_id"`
+	Items         []OrderItem `json:"items"`
+}
+
+// OrderCancelledEvent is published by order-service through its outbox, in
+// the same transaction that cancels the order.
+type OrderCancelledEvent struct {
+	EventID       string      `json:"event_id"`
+	ID            int         `json:"id"`
+	ReservationID string      `json:"reservation_id"`
+	Reason        string      `json:"reason,omitempty"`
+	Items         []OrderItem `json:"items"`
+}
+
+// StockReservationID is the reservation holding the order's stock. Orders
+// placed before reservations existed have none, and order-service releases
+// their stock under a legacy ID instead.
+func (e OrderCancelledEvent) StockReservationID() string {
+	if e.ReservationID != "" {
+		return e.ReservationID
+	}
+	return fmt.Sprintf("order-%d-legacy", e.ID)
+}
+
+// StockAdjustmentFailedEvent reports an order whose stock change could not be
+// applied in inventory-service.
+type StockAdjustmentFailedEvent struct {
+	EventID       string      `json:"event_id"`
+	SourceEventID string      `json:"source_event_id"`
+	OrderID       int         `json:"order_id"`
+	ReservationID string      `json:"reservation_id"`
+	Reason        string      `json:"reason"`
+	Items         []OrderItem `json:"items"`
+}
+
+type ProcessedEvent struct {
+	EventID string `db:"event_id"`
+	Subject string `db:"subject"`
+	OrderID int    `db:"order_id"`
+	Outcome string `db:"outcome"`
+}
@@ -0,0 +1,45 @@
+package nats
+
+import (
+	"context"
+	"time"
+
+	"github.com/nats-io/nats.go/jetstream"
+)
+
+// Stream layout shared with order-service. Both services create the streams
+// they use on startup, with identical configuration.
+const (
+	OrdersStream = "ORDERS"
+	StockStream  = "STOCK"
+
+	// DeadLetterSuffix is appended to a subject to form its dead-letter
+	// subject, e.g. order.created.dlq. Those subjects live in the same stream.
+	DeadLetterSuffix = ".dlq"
+)
+
+var streamConfigs = []jetstream.StreamConfig{
+	{
+		Name:       OrdersStream,
+		Subjects:   []string{"order.>"},
+		Storage:    jetstream.FileStorage,
+		MaxAge:     7 * 24 * time.Hour,
+		Duplicates: 2 * time.Minute,
+	},
+	{
+		Name:       StockStream,
+		Subjects:   []string{"stock.>"},
+		Storage:    jetstream.FileStorage,
+		MaxAge:     7 * 24 * time.Hour,
+		Duplicates: 2 * time.Minute,
+	},
+}
+
+func ensureStreams(ctx context.Context, js jetstream.JetStream) error {
+	for _, cfg := range streamConfigs {
+		if _, err := js.CreateOrUpdateStream(ctx, cfg); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -1,17 +1,42 @@
 package nats
 
 import (
-	"consumer-service/internal/handler"
+	"consumer-service/internal/model"
 	"context"
-	"encoding/json"
+	"errors"
+	"fmt"
 	"log"
+	"strconv"
+	"time"
 
 	"github.com/nats-io/nats.go"
+	"github.com/nats-io/nats.go/jetstream"
 )
 
+// Headers added to dead-lettered messages.
+const (
+	HeaderDeadLetterSubject    = "Dlq-Original-Subject"
+	HeaderDeadLetterError      = "Dlq-Error"
+	HeaderDeadLetterDeliveries = "Dlq-Deliveries"
+)
+
+// Handler processes one message. A returned error triggers a redelivery
+// unless it wraps model.ErrPoison.
+type Handler func(ctx context.Context, data []byte) error
+
 type Subscriber struct {
-	nc               *nats.Conn
-	inventoryHandler *handler.InventoryHandler
+	nc        *nats.Conn
+	js        jetstream.JetStream
+	consuming []jetstream.ConsumeContext
+
+	// MaxAttempts is how many times a message is tried before it is moved
+	// to the dead-letter subject.
+	MaxAttempts int
+	// Backoff holds the redelivery delay after each failed attempt; the last
+	// value is reused for later attempts.
+	Backoff []time.Duration
+	// AckWait is how long the server waits for an ack before redelivering.
+	AckWait time.Duration
 }
 
 func NewSubscriber(natsURL string) (*Subscriber, error) {
@@ -20,53 +45,143 @@ func NewSubscriber(natsURL string) (*Subscriber, error) {
 		return nil, err
 	}
 
-	invHandler := handler.NewInventoryHandler()
+	s, err := newSubscriber(nc)
+	if err != nil {
+		nc.Close()
+		return nil, err
+	}
+	return s, nil
+}
+
+func newSubscriber(nc *nats.Conn) (*Subscriber, error) {
+	js, err := jetstream.New(nc)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if err := ensureStreams(ctx, js); err != nil {
+		return nil, fmt.Errorf("create streams: %w", err)
+	}
 
 	return &Subscriber{
-		nc:               nc,
-		inventoryHandler: invHandler,
+		nc:          nc,
+		js:          js,
+		MaxAttempts: 5,
+		Backoff:     []time.Duration{time.Second, 5 * time.Second, 30 * time.Second, time.Minute},
+		AckWait:     30 * time.Second,
 	}, nil
 }
 
-func (s *Subscriber) Subscribe(subject string) error {
-	_, err := s.nc.Subscribe(subject, func(msg *nats.Msg) {
-		log.Printf("📩 Received message on [%s]: %s", subject, string(msg.Data))
-		s.HandleOrderCreated(msg.Data)
+// Subscribe consumes subject from its stream through the durable pull
+// consumer durable. Messages published while the service is down are
+// delivered once it is back.
+func (s *Subscriber) Subscribe(stream, subject, durable string, handle Handler) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	consumer, err := s.js.CreateOrUpdateConsumer(ctx, stream, jetstream.ConsumerConfig{
+		Durable:       durable,
+		FilterSubject: subject,
+		AckPolicy:     jetstream.AckExplicitPolicy,
+		AckWait:       s.AckWait,
+		// Safety net only: dead-lettering after MaxAttempts happens in
+		// handleMsg, the margin covers crashes during the last attempts.
+		MaxDeliver: s.MaxAttempts + 2,
 	})
-	return err
+	if err != nil {
+		return err
+	}
+
+	cc, err := consumer.Consume(func(msg jetstream.Msg) {
+		s.handleMsg(msg, handle)
+	})
+	if err != nil {
+		return err
+	}
+	s.consuming = append(s.consuming, cc)
+	return nil
 }
 
-func (s *Subscriber) HandleOrderCreated(data []byte) {
-	var order struct {
-		ID     int    `json:"id"`
-		UserID int    `json:"user_id"`
-		Status string `json:"status"`
-		Items  []struct {
-			ProductID int `json:"product_id"`
-			Quantity  int `json:"quantity"`
-		} `json:"items"`
+func (s *Subscriber) handleMsg(msg jetstream.Msg, handle Handler) {
+	attempt := 1
+	if meta, err := msg.Metadata(); err == nil {
+		attempt = int(meta.NumDelivered)
 	}
 
-	if err := json.Unmarshal(data, &order); err != nil {
-		log.Printf("❌ Failed to unmarshal order: %v", err)
+	log.Printf("📩 Received message on [%s] (attempt %d): %s", msg.Subject(), attempt, string(msg.Data()))
+
+	err := handle(context.Background(), msg.Data())
+	if err == nil {
+		if err := msg.Ack(); err != nil {
+			log.Printf("⚠️ Failed to ack message on [%s]: %v", msg.Subject(), err)
+		}
 		return
 	}
 
-	log.Printf("📦 Processing Order ID: %d", order.ID)
+	if errors.Is(err, model.ErrPoison) || attempt >= s.MaxAttempts {
+		log.Printf("☠️ Giving up on message on [%s] after %d attempts: %v", msg.Subject(), attempt, err)
+		s.deadLetter(msg, attempt, err)
+		return
+	}
 
-	for _, item := range order.Items {
-		log.Printf("🔎 Checking stock for Product ID: %d", item.ProductID)
+	delay := s.backoff(attempt)
+	log.Printf("❌ Failed to process message on [%s], retrying in %s: %v", msg.Subject(), delay, err)
+	if err := msg.NakWithDelay(delay); err != nil {
+		log.Printf("⚠️ Failed to nak message on [%s]: %v", msg.Subject(), err)
+	}
+}
 
-		err := s.inventoryHandler.DecreaseStock(context.Background(), int64(item.ProductID), int32(item.Quantity))
-		if err != nil {
-			log.Printf("⚠️ Failed to decrease stock for product %d: %v", item.ProductID, err)
-			continue
+// deadLetter copies msg to its .dlq subject and terminates it. If the copy
+// cannot be stored the message is left unacknowledged for redelivery.
+func (s *Subscriber) deadLetter(msg jetstream.Msg, attempts int, cause error) {
+	dlq := nats.NewMsg(msg.Subject() + DeadLetterSuffix)
+	for key, values := range msg.Headers() {
+		for _, v := range values {
+			dlq.Header.Add(key, v)
 		}
+	}
+	dlq.Header.Set(HeaderDeadLetterSubject, msg.Subject())
+	dlq.Header.Set(HeaderDeadLetterError, cause.Error())
+	dlq.Header.Set(HeaderDeadLetterDeliveries, strconv.Itoa(attempts))
+	dlq.Data = msg.Data()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if _, err := s.js.PublishMsg(ctx, dlq); err != nil {
+		log.Printf("⚠️ Failed to dead-letter message on [%s]: %v", msg.Subject(), err)
+		return
+	}
+	if err := msg.Term(); err != nil {
+		log.Printf("⚠️ Failed to terminate message on [%s]: %v", msg.Subject(), err)
+	}
+}
 
-		log.Printf("✅ Successfully decreased stock for product %d by %d units", item.ProductID, item.Quantity)
+func (s *Subscriber) backoff(attempt int) time.Duration {
+	if len(s.Backoff) == 0 {
+		return 0
+	}
+	if attempt > len(s.Backoff) {
+		attempt = len(s.Backoff)
 	}
+	return s.Backoff[attempt-1]
+}
+
+// Publish stores data in JetStream with eventID as the message ID, so the
+// server drops duplicates published within the stream's duplicate window.
+func (s *Subscriber) Publish(subject, eventID string, data []byte) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	_, err := s.js.Publish(ctx, subject, data, jetstream.WithMsgID(eventID))
+	return err
 }
 
 func (s *Subscriber) Close() {
+	for _, cc := range s.consuming {
+		cc.Stop()
+	}
 	s.nc.Close()
 }
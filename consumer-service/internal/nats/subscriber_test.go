@@ -0,0 +1,185 @@
+package nats
+
+import (
+	"consumer-service/internal/model"
+	"context"
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/nats-io/nats-server/v2/server"
+	"github.com/nats-io/nats.go"
+)
+
+func runJetStream(t *testing.T) *nats.Conn {
+	t.Helper()
+
+	srv, err := server.NewServer(&server.Options{
+		Host:      "127.0.0.1",
+		Port:      -1,
+		JetStream: true,
+		StoreDir:  t.TempDir(),
+	})
+	if err != nil {
+		t.Fatalf("start nats-server: %v", err)
+	}
+	go srv.Start()
+	if !srv.ReadyForConnections(5 * time.Second) {
+		t.Fatal("nats-server not ready")
+	}
+	t.Cleanup(srv.Shutdown)
+
+	nc, err := nats.Connect(srv.ClientURL())
+	if err != nil {
+		t.Fatalf("connect: %v", err)
+	}
+	t.Cleanup(nc.Close)
+	return nc
+}
+
+func newTestSubscriber(t *testing.T, nc *nats.Conn) *Subscriber {
+	t.Helper()
+
+	s, err := newSubscriber(nc)
+	if err != nil {
+		t.Fatalf("new subscriber: %v", err)
+	}
+	s.MaxAttempts = 3
+	s.Backoff = []time.Duration{10 * time.Millisecond}
+	s.AckWait = time.Second
+	t.Cleanup(func() {
+		for _, cc := range s.consuming {
+			cc.Stop()
+		}
+	})
+	return s
+}
+
+func TestSubscribeDeliversMessagesPublishedWhileAway(t *testing.T) {
+	nc := runJetStream(t)
+	s := newTestSubscriber(t, nc)
+
+	// Published before anyone consumes: core NATS would drop it.
+	if err := s.Publish(model.SubjectOrderCreated, "event-1", []byte(`{"id":1}`)); err != nil {
+		t.Fatalf("publish: %v", err)
+	}
+
+	received := make(chan string, 1)
+	err := s.Subscribe(OrdersStream, model.SubjectOrderCreated, "test", func(_ context.Context, data []byte) error {
+		received <- string(data)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("subscribe: %v", err)
+	}
+
+	select {
+	case data := <-received:
+		if data != `{"id":1}` {
+			t.Fatalf("unexpected payload %s", data)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("message not delivered")
+	}
+}
+
+func TestSubscribeRetriesThenDeadLetters(t *testing.T) {
+	nc := runJetStream(t)
+	s := newTestSubscriber(t, nc)
+
+	dlq, err := nc.SubscribeSync(model.SubjectOrderCreated + DeadLetterSuffix)
+	if err != nil {
+		t.Fatalf("subscribe dlq: %v", err)
+	}
+
+	var attempts atomic.Int32
+	err = s.Subscribe(OrdersStream, model.SubjectOrderCreated, "test", func(context.Context, []byte) error {
+		attempts.Add(1)
+		return errors.New("inventory unavailable")
+	})
+	if err != nil {
+		t.Fatalf("subscribe: %v", err)
+	}
+
+	if err := s.Publish(model.SubjectOrderCreated, "event-2", []byte(`{"id":2}`)); err != nil {
+		t.Fatalf("publish: %v", err)
+	}
+
+	msg, err := dlq.NextMsg(5 * time.Second)
+	if err != nil {
+		t.Fatalf("no dead-lettered message: %v", err)
+	}
+	if got := attempts.Load(); got != 3 {
+		t.Fatalf("expected 3 attempts, got %d", got)
+	}
+	if got := msg.Header.Get(HeaderDeadLetterDeliveries); got != "3" {
+		t.Fatalf("expected deliveries header 3, got %q", got)
+	}
+	if got := msg.Header.Get(HeaderDeadLetterSubject); got != model.SubjectOrderCreated {
+		t.Fatalf("unexpected original subject %q", got)
+	}
+	if got := msg.Header.Get(nats.MsgIdHdr); got != "event-2" {
+		t.Fatalf("event ID not kept, got %q", got)
+	}
+
+	// Terminated: no further deliveries.
+	time.Sleep(200 * time.Millisecond)
+	if got := attempts.Load(); got != 3 {
+		t.Fatalf("message redelivered after dead-lettering: %d attempts", got)
+	}
+}
+
+func TestSubscribeDeadLettersPoisonImmediately(t *testing.T) {
+	nc := runJetStream(t)
+	s := newTestSubscriber(t, nc)
+
+	dlq, err := nc.SubscribeSync(model.SubjectOrderCreated + DeadLetterSuffix)
+	if err != nil {
+		t.Fatalf("subscribe dlq: %v", err)
+	}
+
+	var attempts atomic.Int32
+	err = s.Subscribe(OrdersStream, model.SubjectOrderCreated, "test", func(context.Context, []byte) error {
+		attempts.Add(1)
+		return fmt.Errorf("decode: %w", model.ErrPoison)
+	})
+	if err != nil {
+		t.Fatalf("subscribe: %v", err)
+	}
+
+	if err := s.Publish(model.SubjectOrderCreated, "event-3", []byte(`not json`)); err != nil {
+		t.Fatalf("publish: %v", err)
+	}
+
+	if _, err := dlq.NextMsg(5 * time.Second); err != nil {
+		t.Fatalf("no dead-lettered message: %v", err)
+	}
+	if got := attempts.Load(); got != 1 {
+		t.Fatalf("expected 1 attempt, got %d", got)
+	}
+}
+
+func TestPublishDeduplicatesByEventID(t *testing.T) {
+	nc := runJetStream(t)
+	s := newTestSubscriber(t, nc)
+
+	for i := 0; i < 3; i++ {
+		if err := s.Publish(model.SubjectOrderCreated, "event-4", []byte(`{"id":4}`)); err != nil {
+			t.Fatalf("publish: %v", err)
+		}
+	}
+
+	stream, err := s.js.Stream(context.Background(), OrdersStream)
+	if err != nil {
+		t.Fatalf("stream: %v", err)
+	}
+	info, err := stream.Info(context.Background())
+	if err != nil {
+		t.Fatalf("stream info: %v", err)
+	}
+	if info.State.Msgs != 1 {
+		t.Fatalf("expected 1 stored message, got %d", info.State.Msgs)
+	}
+}
@@ -0,0 +1,34 @@
+package repository
+
+import (
+	"consumer-service/internal/model"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// EventLedger remembers which events were already processed, so redelivered
+// messages can be acknowledged without repeating their side effects.
+type EventLedger struct {
+	DB *sqlx.DB
+}
+
+// IsProcessed reports whether the event, or another event about the same
+// order on the same subject, was already handled.
+func (l *EventLedger) IsProcessed(eventID, subject string, orderID int) (bool, error) {
+	var exists bool
+	err := l.DB.Get(&exists,
+		`SELECT EXISTS (
+		   SELECT 1 FROM processed_events WHERE event_id = $1 OR (subject = $2 AND order_id = $3)
+		 )`,
+		eventID, subject, orderID)
+	return exists, err
+}
+
+func (l *EventLedger) Record(e model.ProcessedEvent) error {
+	_, err := l.DB.Exec(
+		`INSERT INTO processed_events (event_id, subject, order_id, outcome)
+		 VALUES ($1, $2, $3, $4)
+		 ON CONFLICT DO NOTHING`,
+		e.EventID, e.Subject, e.OrderID, e.Outcome)
+	return err
+}
@@ -0,0 +1,8 @@
+CREATE TABLE IF NOT EXISTS processed_events (
+  event_id VARCHAR(100) PRIMARY KEY,
+  subject VARCHAR(100) NOT NULL,
+  order_id BIGINT NOT NULL,
+  outcome VARCHAR(50) NOT NULL,
+  processed_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+  UNIQUE (subject, order_id)
+);
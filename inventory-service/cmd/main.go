@@ -4,15 +4,20 @@ import (
 	"fmt"
 	"inventory-service/infrastructure/db"
 	"inventory-service/internal/handler"
+	"inventory-service/internal/model"
 	"inventory-service/internal/repository"
 	"inventory-service/internal/usecase"
 	"inventory-service/logger"
 	pb "inventory-service/pb/inventory"
 	"net"
+	"shared/authz"
+	"shared/idempotency"
 
 	"github.com/prometheus/client_golang/prometheus/promhttp"
 
 	"net/http"
+	"os"
+	"time"
 
 	"google.golang.org/grpc"
 )
@@ -33,10 +38,56 @@ func main() {
 
 	database := db.NewPostgres()
 	productRepo := &repository.ProductRepository{DB: database}
-	productUsecase := &usecase.ProductUsecase{Repo: productRepo}
-	productHandler := &handler.ProductHandler{Usecase: productUsecase}
+	categoryRepo := &repository.CategoryRepository{DB: database}
+	variantRepo := &repository.VariantRepository{DB: database}
+	warehouseRepo := &repository.WarehouseRepository{DB: database}
+	productUsecase := &usecase.ProductUsecase{
+		Repo:       productRepo,
+		Categories: categoryRepo,
+		Variants:   variantRepo,
+		Allocation: allocationStrategy(),
+	}
+	categoryUsecase := &usecase.CategoryUsecase{Repo: categoryRepo}
+	variantUsecase := &usecase.VariantUsecase{Repo: variantRepo, Products: productRepo}
+	warehouseUsecase := &usecase.WarehouseUsecase{Repo: warehouseRepo}
+	productHandler := &handler.ProductHandler{
+		Usecase:    productUsecase,
+		Categories: categoryUsecase,
+		Variants:   variantUsecase,
+		Warehouses: warehouseUsecase,
+	}
+
+	idempotencyStore := &idempotency.PostgresStore{DB: database}
+	idempotencyInterceptor := &idempotency.Interceptor{
+		Store:     idempotencyStore,
+		Methods:   map[string]bool{pb.InventoryService_CreateProduct_FullMethodName: true},
+		Retention: idempotencyRetention,
+		Lease:     time.Minute,
+		Log:       logger.Log,
+	}
+	go idempotency.PurgeExpired(idempotencyStore, idempotencyRetention, time.Hour, logger.Log)
 
-	grpcServer := grpc.NewServer()
+	authzInterceptor := &authz.Interceptor{
+		Verifier: authz.NewVerifier(jwksURL()),
+		Permissions: map[string]string{
+			pb.InventoryService_CreateProduct_FullMethodName:   authz.PermInventoryWrite,
+			pb.InventoryService_UpdateProduct_FullMethodName:   authz.PermInventoryWrite,
+			pb.InventoryService_DeleteProduct_FullMethodName:   authz.PermInventoryWrite,
+			pb.InventoryService_CreateCategory_FullMethodName:  authz.PermInventoryWrite,
+			pb.InventoryService_MoveCategory_FullMethodName:    authz.PermInventoryWrite,
+			pb.InventoryService_CreateVariant_FullMethodName:   authz.PermInventoryWrite,
+			pb.InventoryService_UpdateVariant_FullMethodName:   authz.PermInventoryWrite,
+			pb.InventoryService_DeleteVariant_FullMethodName:   authz.PermInventoryWrite,
+			pb.InventoryService_CreateWarehouse_FullMethodName: authz.PermInventoryWrite,
+			pb.InventoryService_SetStockLevel_FullMethodName:   authz.PermInventoryWrite,
+			pb.InventoryService_ReserveStock_FullMethodName:    authz.PermStockReserve,
+			pb.InventoryService_ReleaseStock_FullMethodName:    authz.PermStockReserve,
+			pb.InventoryService_GetReservation_FullMethodName:  authz.PermStockReserve,
+		},
+		ServiceToken: authz.ServiceToken(),
+	}
+
+	grpcServer := grpc.NewServer(grpc.ChainUnaryInterceptor(authzInterceptor.Unary(), idempotencyInterceptor.Unary()))
 	pb.RegisterInventoryServiceServer(grpcServer, productHandler)
 
 	logger.Log.Info("Inventory gRPC service running on :50053")
@@ -44,3 +95,29 @@ func main() {
 		logger.Log.Error(fmt.Sprintf("failed to serve: %v", err))
 	}
 }
+
+// jwksURL is where user-service publishes the keys that verify access tokens.
+func jwksURL() string {
+	if url := os.Getenv("JWKS_URL"); url != "" {
+		return url
+	}
+	return "http://user-service:8083/.well-known/jwks.json"
+}
+
+// allocationStrategy is how reservations pick the warehouse of each item:
+// priority (the default), most_stock or nearest.
+func allocationStrategy() model.AllocationStrategy {
+	switch s := model.AllocationStrategy(os.Getenv("ALLOCATION_STRATEGY")); s {
+	case "":
+		return model.AllocateByPriority
+	case model.AllocateByPriority, model.AllocateMostStock, model.AllocateNearest:
+		return s
+	default:
+		logger.Log.Error(fmt.Sprintf("unknown ALLOCATION_STRATEGY %q, allocating by priority", s))
+		return model.AllocateByPriority
+	}
+}
+
+// idempotencyRetention is how long a CreateProduct response is replayed for
+// retries with the same Idempotency-Key.
+const idempotencyRetention = 24 * time.Hour
@@ -0,0 +1,70 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"inventory-service/internal/model"
+	pb "inventory-service/pb/inventory"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func (h *ProductHandler) CreateCategory(ctx context.Context, req *pb.CreateCategoryRequest) (*pb.Category, error) {
+	category := model.Category{
+		ParentID: int(req.ParentId),
+		Name:     req.Name,
+		Slug:     req.Slug,
+	}
+	if err := h.Categories.Create(&category); err != nil {
+		return nil, categoryStatus(err, "failed to create category")
+	}
+	return toProtoCategory(&category), nil
+}
+
+func (h *ProductHandler) ListCategories(ctx context.Context, req *pb.ListCategoriesRequest) (*pb.CategoryList, error) {
+	categories, err := h.Categories.List(int(req.RootId))
+	if err != nil {
+		return nil, categoryStatus(err, "failed to list categories")
+	}
+
+	var protoCategories []*pb.Category
+	for i := range categories {
+		protoCategories = append(protoCategories, toProtoCategory(&categories[i]))
+	}
+	return &pb.CategoryList{Categories: protoCategories}, nil
+}
+
+func (h *ProductHandler) MoveCategory(ctx context.Context, req *pb.MoveCategoryRequest) (*pb.Category, error) {
+	category, err := h.Categories.Move(int(req.Id), int(req.ParentId))
+	if err != nil {
+		return nil, categoryStatus(err, "failed to move category")
+	}
+	return toProtoCategory(category), nil
+}
+
+func categoryStatus(err error, msg string) error {
+	switch {
+	case errors.Is(err, model.ErrInvalidCategory):
+		return status.Errorf(codes.InvalidArgument, "%v", err)
+	case errors.Is(err, model.ErrCategoryNotFound):
+		return status.Errorf(codes.NotFound, "%s: %v", msg, err)
+	case errors.Is(err, model.ErrCategoryExists):
+		return status.Errorf(codes.AlreadyExists, "%s: %v", msg, err)
+	case errors.Is(err, model.ErrCategoryCycle):
+		return status.Errorf(codes.FailedPrecondition, "%s: %v", msg, err)
+	default:
+		return status.Errorf(codes.Internal, "%s: %v", msg, err)
+	}
+}
+
+func toProtoCategory(c *model.Category) *pb.Category {
+	return &pb.Category{
+		Id:       int64(c.ID),
+		ParentId: int64(c.ParentID),
+		Name:     c.Name,
+		Slug:     c.Slug,
+		Depth:    int32(c.Depth),
+		Path:     c.Path,
+	}
+}
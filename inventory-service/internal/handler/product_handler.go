@@ -2,39 +2,49 @@ package handler
 
 import (
 	"context"
+	"errors"
 	"inventory-service/internal/model"
 	"inventory-service/internal/usecase"
 	pb "inventory-service/pb/inventory"
+	"inventory-service/pb/money"
+	"strings"
 
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
 )
 
 type ProductHandler struct {
 	pb.UnimplementedInventoryServiceServer
-	Usecase *usecase.ProductUsecase
+	Usecase    *usecase.ProductUsecase
+	Categories *usecase.CategoryUsecase
+	Variants   *usecase.VariantUsecase
+	Warehouses *usecase.WarehouseUsecase
 }
 
 func (h *ProductHandler) CreateProduct(ctx context.Context, req *pb.Product) (*pb.Product, error) {
 	product := model.Product{
-		Name:     req.Name,
-		Category: req.Category,
-		Stock:    int(req.Stock),
-		Price:    req.Price,
+		Name:       req.Name,
+		CategoryID: int(req.CategoryId),
+		Category:   req.Category,
+		Stock:      int(req.Stock),
+		Price:      fromProtoMoney(req.Price),
+
+		Description: req.Description,
 	}
 
 	err := h.Usecase.Create(&product)
+	if errors.Is(err, model.ErrInvalidPrice) || errors.Is(err, model.ErrCategoryNotFound) || errors.Is(err, model.ErrInvalidVariant) {
+		return nil, status.Errorf(codes.InvalidArgument, "%v", err)
+	}
+	if errors.Is(err, model.ErrVariantExists) {
+		return nil, status.Errorf(codes.AlreadyExists, "%v", err)
+	}
 	if err != nil {
 		return nil, status.Errorf(codes.Internal, "failed to create product: %v", err)
 	}
 
-	return &pb.Product{
-		Id:       int64(product.ID),
-		Name:     product.Name,
-		Category: product.Category,
-		Stock:    int32(product.Stock),
-		Price:    product.Price,
-	}, nil
+	return toProtoProduct(&product), nil
 }
 
 func (h *ProductHandler) GetProduct(ctx context.Context, req *pb.ProductID) (*pb.Product, error) {
@@ -43,36 +53,33 @@ func (h *ProductHandler) GetProduct(ctx context.Context, req *pb.ProductID) (*pb
 		return nil, status.Errorf(codes.NotFound, "product not found")
 	}
 
-	return &pb.Product{
-		Id:       int64(product.ID),
-		Name:     product.Name,
-		Category: product.Category,
-		Stock:    int32(product.Stock),
-		Price:    product.Price,
-	}, nil
+	return toProtoProduct(product), nil
 }
 
 func (h *ProductHandler) UpdateProduct(ctx context.Context, req *pb.Product) (*pb.Product, error) {
 	product := model.Product{
-		ID:       int(req.Id),
-		Name:     req.Name,
-		Category: req.Category,
-		Stock:    int(req.Stock),
-		Price:    req.Price,
+		ID:         int(req.Id),
+		Name:       req.Name,
+		CategoryID: int(req.CategoryId),
+		Category:   req.Category,
+		Stock:      int(req.Stock),
+		Price:      fromProtoMoney(req.Price),
+
+		Description: req.Description,
 	}
 
 	err := h.Usecase.Update(product.ID, &product)
+	if errors.Is(err, model.ErrInvalidPrice) || errors.Is(err, model.ErrCategoryNotFound) || errors.Is(err, model.ErrInvalidVariant) {
+		return nil, status.Errorf(codes.InvalidArgument, "%v", err)
+	}
+	if errors.Is(err, model.ErrProductNotFound) {
+		return nil, status.Errorf(codes.NotFound, "%v", err)
+	}
 	if err != nil {
 		return nil, status.Errorf(codes.Internal, "failed to update product: %v", err)
 	}
 
-	return &pb.Product{
-		Id:       int64(product.ID),
-		Name:     product.Name,
-		Category: product.Category,
-		Stock:    int32(product.Stock),
-		Price:    product.Price,
-	}, nil
+	return toProtoProduct(&product), nil
 }
 
 func (h *ProductHandler) DeleteProduct(ctx context.Context, req *pb.ProductID) (*pb.Empty, error) {
@@ -83,22 +90,223 @@ func (h *ProductHandler) DeleteProduct(ctx context.Context, req *pb.ProductID) (
 	return &pb.Empty{}, nil
 }
 
-func (h *ProductHandler) ListProducts(ctx context.Context, _ *pb.Empty) (*pb.ProductList, error) {
-	products, err := h.Usecase.List("", 100, 0) // or implement filtering later
+func (h *ProductHandler) ListProducts(ctx context.Context, req *pb.ListProductsRequest) (*pb.ListProductsResponse, error) {
+	sort, ok := productSorts[req.Sort]
+	if !ok {
+		return nil, status.Errorf(codes.InvalidArgument, "unknown sort %v", req.Sort)
+	}
+	query := model.ProductQuery{
+		CategoryID: int(req.CategoryId),
+		Category:   req.Category,
+		InStock:    req.InStock,
+		Search:     strings.TrimSpace(req.Query),
+		Sort:       sort,
+		Descending: req.Descending,
+		Limit:      int(req.PageSize),
+	}
+	if req.MinPrice != nil {
+		m := fromProtoMoney(req.MinPrice)
+		query.MinPrice = &m
+	}
+	if req.MaxPrice != nil {
+		m := fromProtoMoney(req.MaxPrice)
+		query.MaxPrice = &m
+	}
+
+	page, err := h.Usecase.List(query, req.PageToken)
+	if errors.Is(err, model.ErrInvalidProductQuery) || errors.Is(err, model.ErrInvalidPageToken) {
+		return nil, status.Errorf(codes.InvalidArgument, "%v", err)
+	}
+	if errors.Is(err, model.ErrCategoryNotFound) {
+		return nil, status.Errorf(codes.NotFound, "%v", err)
+	}
 	if err != nil {
 		return nil, status.Errorf(codes.Internal, "failed to list products: %v", err)
 	}
 
 	var protoProducts []*pb.Product
-	for _, p := range products {
-		protoProducts = append(protoProducts, &pb.Product{
-			Id:       int64(p.ID),
-			Name:     p.Name,
-			Category: p.Category,
-			Stock:    int32(p.Stock),
-			Price:    p.Price,
+	for i := range page.Products {
+		protoProducts = append(protoProducts, toProtoProduct(&page.Products[i]))
+	}
+
+	return &pb.ListProductsResponse{
+		Products:      protoProducts,
+		NextPageToken: page.NextPageToken,
+		TotalCount:    int64(page.Total),
+	}, nil
+}
+
+func (h *ProductHandler) SearchProducts(ctx context.Context, req *pb.SearchProductsRequest) (*pb.SearchProductsResponse, error) {
+	query := model.SearchQuery{
+		Text:       req.Query,
+		CategoryID: int(req.CategoryId),
+		Category:   req.Category,
+		Limit:      int(req.PageSize),
+	}
+	if req.MinPrice != nil {
+		m := fromProtoMoney(req.MinPrice)
+		query.MinPrice = &m
+	}
+	if req.MaxPrice != nil {
+		m := fromProtoMoney(req.MaxPrice)
+		query.MaxPrice = &m
+	}
+
+	result, err := h.Usecase.Search(query, req.PageToken)
+	if errors.Is(err, model.ErrInvalidProductQuery) || errors.Is(err, model.ErrInvalidPageToken) {
+		return nil, status.Errorf(codes.InvalidArgument, "%v", err)
+	}
+	if errors.Is(err, model.ErrCategoryNotFound) {
+		return nil, status.Errorf(codes.NotFound, "%v", err)
+	}
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to search products: %v", err)
+	}
+
+	res := &pb.SearchProductsResponse{
+		NextPageToken: result.NextPageToken,
+		TotalCount:    int64(result.Total),
+		Fuzzy:         result.Fuzzy,
+	}
+	for i := range result.Hits {
+		hit := &result.Hits[i]
+		res.Hits = append(res.Hits, &pb.SearchHit{
+			Product:         toProtoProduct(&hit.Product),
+			HighlightedName: hit.HighlightedName,
+			Snippet:         hit.Snippet,
+			Rank:            hit.Rank,
+		})
+	}
+	for _, f := range result.Categories {
+		res.Categories = append(res.Categories, &pb.CategoryFacet{
+			CategoryId: int64(f.CategoryID),
+			Category:   f.Category,
+			Slug:       f.Slug,
+			Count:      int64(f.Count),
+		})
+	}
+	for _, f := range result.Prices {
+		res.Prices = append(res.Prices, &pb.PriceFacet{
+			CurrencyCode:  f.CurrencyCode,
+			MinMinorUnits: f.Min,
+			MaxMinorUnits: f.Max,
+			Count:         int64(f.Count),
 		})
 	}
+	return res, nil
+}
+
+var productSorts = map[pb.ProductSort]model.ProductSort{
+	pb.ProductSort_PRODUCT_SORT_UNSPECIFIED: model.SortByID,
+	pb.ProductSort_PRODUCT_SORT_NAME:        model.SortByName,
+	pb.ProductSort_PRODUCT_SORT_PRICE:       model.SortByPrice,
+	pb.ProductSort_PRODUCT_SORT_STOCK:       model.SortByStock,
+}
+
+func (h *ProductHandler) ReserveStock(ctx context.Context, req *pb.StockRequest) (*pb.ProductList, error) {
+	products, allocations, err := h.Usecase.ReserveStock(req.ReservationId, toStockItems(req.Items), model.Allocation{
+		ShipToCountry: req.ShipToCountry,
+		ShipToRegion:  req.ShipToRegion,
+	})
+	if err != nil {
+		return nil, stockStatus(err, "failed to reserve stock")
+	}
+
+	var protoProducts []*pb.Product
+	for i := range products {
+		protoProducts = append(protoProducts, toProtoProduct(&products[i]))
+	}
+
+	return &pb.ProductList{Products: protoProducts, Allocations: toProtoStockItems(allocations)}, nil
+}
+
+func (h *ProductHandler) ReleaseStock(ctx context.Context, req *pb.StockRequest) (*pb.Empty, error) {
+	if err := h.Usecase.ReleaseStock(req.ReservationId, toStockItems(req.Items)); err != nil {
+		return nil, stockStatus(err, "failed to release stock")
+	}
+	return &pb.Empty{}, nil
+}
+
+func (h *ProductHandler) GetReservation(ctx context.Context, req *pb.ReservationID) (*pb.Reservation, error) {
+	reservation, err := h.Usecase.GetReservation(req.Id)
+	if err != nil {
+		return nil, stockStatus(err, "failed to get reservation")
+	}
+	return &pb.Reservation{
+		Id:     reservation.ID,
+		Status: reservation.Status,
+		Items:  toProtoStockItems(reservation.Items),
+	}, nil
+}
+
+func toStockItems(items []*pb.StockItem) []model.StockItem {
+	result := make([]model.StockItem, 0, len(items))
+	for _, item := range items {
+		result = append(result, model.StockItem{
+			ProductID:   int(item.ProductId),
+			VariantID:   int(item.VariantId),
+			SKU:         strings.TrimSpace(item.Sku),
+			Quantity:    int(item.Quantity),
+			WarehouseID: int(item.WarehouseId),
+		})
+	}
+	return result
+}
+
+func toProtoStockItems(items []model.StockItem) []*pb.StockItem {
+	var result []*pb.StockItem
+	for _, item := range items {
+		result = append(result, &pb.StockItem{
+			ProductId:   int64(item.ProductID),
+			VariantId:   int64(item.VariantID),
+			Sku:         item.SKU,
+			Quantity:    int32(item.Quantity),
+			WarehouseId: int64(item.WarehouseID),
+		})
+	}
+	return result
+}
+
+// ReasonReservationClosed is the ErrorInfo reason of a ReserveStock call for
+// a reservation that was already released.
+const ReasonReservationClosed = "RESERVATION_CLOSED"
+
+func stockStatus(err error, msg string) error {
+	switch {
+	case errors.Is(err, model.ErrInvalidQuantity), errors.Is(err, model.ErrVariantRequired):
+		return status.Errorf(codes.InvalidArgument, "%s: %v", msg, err)
+	case errors.Is(err, model.ErrProductNotFound), errors.Is(err, model.ErrVariantNotFound),
+		errors.Is(err, model.ErrUnknownReservation):
+		return status.Errorf(codes.NotFound, "%s: %v", msg, err)
+	case errors.Is(err, model.ErrReservationClosed):
+		// Callers tell a released reservation from short stock by the reason.
+		st, detailErr := status.Newf(codes.FailedPrecondition, "%s: %v", msg, err).
+			WithDetails(&errdetails.ErrorInfo{Reason: ReasonReservationClosed, Domain: "inventory-service"})
+		if detailErr != nil {
+			return status.Errorf(codes.FailedPrecondition, "%s: %v", msg, err)
+		}
+		return st.Err()
+	case errors.Is(err, model.ErrInsufficientStock):
+		return status.Errorf(codes.FailedPrecondition, "%s: %v", msg, err)
+	default:
+		return status.Errorf(codes.Internal, "%s: %v", msg, err)
+	}
+}
+
+func toProtoProduct(p *model.Product) *pb.Product {
+	return &pb.Product{
+		Id:         int64(p.ID),
+		Name:       p.Name,
+		CategoryId: int64(p.CategoryID),
+		Category:   p.Category,
+		Stock:      int32(p.Stock),
+		Price:      &money.Money{CurrencyCode: p.Price.CurrencyCode, MinorUnits: p.Price.MinorUnits},
+
+		Description: p.Description,
+		Variants:    toProtoVariants(p.Variants),
+	}
+}
 
-	return &pb.ProductList{Products: protoProducts}, nil
+func fromProtoMoney(m *money.Money) model.Money {
+	return model.Money{CurrencyCode: m.GetCurrencyCode(), MinorUnits: m.GetMinorUnits()}
 }
@@ -0,0 +1,95 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"inventory-service/internal/model"
+	pb "inventory-service/pb/inventory"
+	"inventory-service/pb/money"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func (h *ProductHandler) CreateVariant(ctx context.Context, req *pb.Variant) (*pb.Variant, error) {
+	variant := fromProtoVariant(req)
+	if err := h.Variants.Create(&variant); err != nil {
+		return nil, variantStatus(err, "failed to create variant")
+	}
+	return toProtoVariant(&variant), nil
+}
+
+func (h *ProductHandler) UpdateVariant(ctx context.Context, req *pb.Variant) (*pb.Variant, error) {
+	variant := fromProtoVariant(req)
+	if err := h.Variants.Update(&variant); err != nil {
+		return nil, variantStatus(err, "failed to update variant")
+	}
+	return toProtoVariant(&variant), nil
+}
+
+func (h *ProductHandler) DeleteVariant(ctx context.Context, req *pb.VariantID) (*pb.Empty, error) {
+	if err := h.Variants.Delete(int(req.Id)); err != nil {
+		return nil, variantStatus(err, "failed to delete variant")
+	}
+	return &pb.Empty{}, nil
+}
+
+func (h *ProductHandler) ListVariants(ctx context.Context, req *pb.ProductID) (*pb.VariantList, error) {
+	variants, err := h.Variants.List(int(req.Id))
+	if err != nil {
+		return nil, variantStatus(err, "failed to list variants")
+	}
+	return &pb.VariantList{Variants: toProtoVariants(variants)}, nil
+}
+
+func variantStatus(err error, msg string) error {
+	switch {
+	case errors.Is(err, model.ErrInvalidVariant), errors.Is(err, model.ErrInvalidPrice):
+		return status.Errorf(codes.InvalidArgument, "%v", err)
+	case errors.Is(err, model.ErrVariantNotFound), errors.Is(err, model.ErrProductNotFound):
+		return status.Errorf(codes.NotFound, "%s: %v", msg, err)
+	case errors.Is(err, model.ErrVariantExists):
+		return status.Errorf(codes.AlreadyExists, "%s: %v", msg, err)
+	default:
+		return status.Errorf(codes.Internal, "%s: %v", msg, err)
+	}
+}
+
+func fromProtoVariant(v *pb.Variant) model.Variant {
+	variant := model.Variant{
+		ID:        int(v.Id),
+		ProductID: int(v.ProductId),
+		SKU:       v.Sku,
+		Options:   model.VariantOptions(v.Options),
+		Stock:     int(v.Stock),
+		Barcode:   v.Barcode,
+	}
+	if v.Price != nil {
+		price := fromProtoMoney(v.Price)
+		variant.Price = &price
+	}
+	return variant
+}
+
+func toProtoVariant(v *model.Variant) *pb.Variant {
+	variant := &pb.Variant{
+		Id:        int64(v.ID),
+		ProductId: int64(v.ProductID),
+		Sku:       v.SKU,
+		Options:   v.Options,
+		Stock:     int32(v.Stock),
+		Barcode:   v.Barcode,
+	}
+	if v.Price != nil {
+		variant.Price = &money.Money{CurrencyCode: v.Price.CurrencyCode, MinorUnits: v.Price.MinorUnits}
+	}
+	return variant
+}
+
+func toProtoVariants(variants []model.Variant) []*pb.Variant {
+	var result []*pb.Variant
+	for i := range variants {
+		result = append(result, toProtoVariant(&variants[i]))
+	}
+	return result
+}
@@ -0,0 +1,103 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"inventory-service/internal/model"
+	pb "inventory-service/pb/inventory"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func (h *ProductHandler) CreateWarehouse(ctx context.Context, req *pb.Warehouse) (*pb.Warehouse, error) {
+	warehouse := model.Warehouse{
+		Code:     req.Code,
+		Name:     req.Name,
+		Country:  req.Country,
+		Region:   req.Region,
+		Priority: int(req.Priority),
+	}
+	if err := h.Warehouses.Create(&warehouse); err != nil {
+		return nil, warehouseStatus(err, "failed to create warehouse")
+	}
+	return toProtoWarehouse(&warehouse), nil
+}
+
+func (h *ProductHandler) ListWarehouses(ctx context.Context, req *pb.Empty) (*pb.WarehouseList, error) {
+	warehouses, err := h.Warehouses.List()
+	if err != nil {
+		return nil, warehouseStatus(err, "failed to list warehouses")
+	}
+
+	var protoWarehouses []*pb.Warehouse
+	for i := range warehouses {
+		protoWarehouses = append(protoWarehouses, toProtoWarehouse(&warehouses[i]))
+	}
+	return &pb.WarehouseList{Warehouses: protoWarehouses}, nil
+}
+
+func (h *ProductHandler) GetStockLevels(ctx context.Context, req *pb.GetStockLevelsRequest) (*pb.StockLevels, error) {
+	levels, err := h.Warehouses.StockLevels(model.StockLevelQuery{
+		ProductID:   int(req.ProductId),
+		VariantID:   int(req.VariantId),
+		WarehouseID: int(req.WarehouseId),
+	})
+	if err != nil {
+		return nil, warehouseStatus(err, "failed to get stock levels")
+	}
+
+	var protoLevels []*pb.StockLevel
+	for i := range levels {
+		protoLevels = append(protoLevels, toProtoStockLevel(&levels[i]))
+	}
+	return &pb.StockLevels{Levels: protoLevels}, nil
+}
+
+func (h *ProductHandler) SetStockLevel(ctx context.Context, req *pb.StockLevel) (*pb.StockLevel, error) {
+	level := model.StockLevel{
+		WarehouseID: int(req.WarehouseId),
+		VariantID:   int(req.VariantId),
+		Stock:       int(req.Stock),
+	}
+	if err := h.Warehouses.SetStockLevel(&level); err != nil {
+		return nil, warehouseStatus(err, "failed to set stock level")
+	}
+	return toProtoStockLevel(&level), nil
+}
+
+func warehouseStatus(err error, msg string) error {
+	switch {
+	case errors.Is(err, model.ErrInvalidWarehouse):
+		return status.Errorf(codes.InvalidArgument, "%v", err)
+	case errors.Is(err, model.ErrWarehouseNotFound), errors.Is(err, model.ErrVariantNotFound),
+		errors.Is(err, model.ErrProductNotFound):
+		return status.Errorf(codes.NotFound, "%s: %v", msg, err)
+	case errors.Is(err, model.ErrWarehouseExists):
+		return status.Errorf(codes.AlreadyExists, "%s: %v", msg, err)
+	default:
+		return status.Errorf(codes.Internal, "%s: %v", msg, err)
+	}
+}
+
+func toProtoWarehouse(w *model.Warehouse) *pb.Warehouse {
+	return &pb.Warehouse{
+		Id:       int64(w.ID),
+		Code:     w.Code,
+		Name:     w.Name,
+		Country:  w.Country,
+		Region:   w.Region,
+		Priority: int32(w.Priority),
+	}
+}
+
+func toProtoStockLevel(l *model.StockLevel) *pb.StockLevel {
+	return &pb.StockLevel{
+		WarehouseId:   int64(l.WarehouseID),
+		WarehouseCode: l.WarehouseCode,
+		ProductId:     int64(l.ProductID),
+		VariantId:     int64(l.VariantID),
+		Sku:           l.SKU,
+		Stock:         int32(l.Stock),
+	}
+}
@@ -0,0 +1,26 @@
+package model
+
+import "errors"
+
+var (
+	ErrCategoryNotFound = errors.New("category not found")
+	ErrCategoryExists   = errors.New("category slug already taken")
+	ErrInvalidCategory  = errors.New("invalid category")
+	// ErrCategoryCycle is returned when a category would be moved under
+	// itself or one of its descendants.
+	ErrCategoryCycle = errors.New("category cannot be moved under itself")
+)
+
+// Category is a node of the category tree. ParentID is 0 for a root.
+// Categories are never renamed: products.category keeps a copy of the name.
+type Category struct {
+	ID       int    `db:"id" json:"id"`
+	ParentID int    `db:"parent_id" json:"parent_id"`
+	Name     string `db:"name" json:"name"`
+	Slug     string `db:"slug" json:"slug"`
+
+	// Depth and Path (slugs from the root, joined by "/") are filled in by
+	// listings.
+	Depth int    `db:"depth" json:"depth"`
+	Path  string `db:"path" json:"path"`
+}
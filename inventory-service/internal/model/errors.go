@@ -0,0 +1,16 @@
+package model
+
+import "errors"
+
+var (
+	ErrProductNotFound    = errors.New("product not found")
+	ErrInsufficientStock  = errors.New("insufficient stock")
+	ErrInvalidQuantity    = errors.New("quantity must be positive")
+	ErrReservationClosed  = errors.New("reservation already released")
+	ErrUnknownReservation = errors.New("unknown reservation")
+)
+
+var (
+	ErrInvalidProductQuery = errors.New("invalid product query")
+	ErrInvalidPageToken    = errors.New("invalid page token")
+)
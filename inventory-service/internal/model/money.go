@@ -0,0 +1,65 @@
+package model
+
+import (
+	"errors"
+	"fmt"
+)
+
+// DefaultCurrency is used for prices given without a currency.
+const DefaultCurrency = "USD"
+
+var ErrInvalidPrice = errors.New("invalid price")
+
+// Money is an exact amount in the currency's minor unit, e.g. 199999 for
+// 1999.99 USD. It mirrors money.Money in the protos.
+type Money struct {
+	MinorUnits   int64  `db:"minor_units" json:"minor_units"`
+	CurrencyCode string `db:"currency_code" json:"currency_code"`
+}
+
+// ValidatePrice checks that m is a non-negative amount in an ISO 4217 style
+// currency code.
+func (m Money) ValidatePrice() error {
+	if m.MinorUnits < 0 {
+		return fmt.Errorf("%w: negative amount %d", ErrInvalidPrice, m.MinorUnits)
+	}
+	if !isCurrencyCode(m.CurrencyCode) {
+		return fmt.Errorf("%w: currency code %q", ErrInvalidPrice, m.CurrencyCode)
+	}
+	return nil
+}
+
+// CurrencyExponents lists the ISO 4217 currencies whose minor unit is not a
+// hundredth of the major unit, with the number of decimal places they have.
+var CurrencyExponents = map[string]int{
+	"BIF": 0, "CLP": 0, "DJF": 0, "GNF": 0, "ISK": 0, "JPY": 0, "KMF": 0, "KRW": 0,
+	"PYG": 0, "RWF": 0, "UGX": 0, "UYI": 0, "VND": 0, "VUV": 0, "XAF": 0, "XOF": 0, "XPF": 0,
+	"BHD": 3, "IQD": 3, "JOD": 3, "KWD": 3, "LYD": 3, "OMR": 3, "TND": 3,
+	"CLF": 4, "UYW": 4,
+}
+
+// MinorUnitsPerMajor is 10 to the number of decimal places of currency, e.g.
+// 100 for USD and 1 for JPY; unknown currencies are assumed to have two.
+func MinorUnitsPerMajor(currency string) int64 {
+	exp, ok := CurrencyExponents[currency]
+	if !ok {
+		exp = 2
+	}
+	units := int64(1)
+	for ; exp > 0; exp-- {
+		units *= 10
+	}
+	return units
+}
+
+func isCurrencyCode(code string) bool {
+	if len(code) != 3 {
+		return false
+	}
+	for _, c := range code {
+		if c < 'A' || c > 'Z' {
+			return false
+		}
+	}
+	return true
+}
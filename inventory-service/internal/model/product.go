@@ -1,9 +1,73 @@
 package model
 
+import (
+	"fmt"
+	"regexp"
+)
+
+// Product is stored with its price in products.price (minor units) and
+// products.currency; see productColumns in the repository.
 type Product struct {
-	ID       int     `db:"id" json:"id"`
-	Name     string  `db:"name" json:"name"`
-	Category string  `db:"category" json:"category"`
-	Stock    int     `db:"stock" json:"stock"`
-	Price    float32 `db:"price" json:"price"`
+	ID   int    `db:"id" json:"id"`
+	Name string `db:"name" json:"name"`
+	// CategoryID is 0 for an uncategorized product. Category is the name of
+	// the category, filled in from CategoryID.
+	CategoryID int    `db:"category_id" json:"category_id"`
+	Category   string `db:"category" json:"category"`
+	Stock      int    `db:"stock" json:"stock"`
+	Price      Money  `db:"price" json:"price"`
+
+	Description string `db:"description" json:"description"`
+
+	// Variants is only filled in where noted; Stock is their total.
+	Variants []Variant `db:"-" json:"variants,omitempty"`
+}
+
+// StockItem names the variant by VariantID or SKU, or by ProductID alone for
+// a product with a single variant. The repository resolves it to both
+// ProductID and VariantID; reservations stored before variants existed have
+// only ProductID, and those stored before warehouses no WarehouseID.
+type StockItem struct {
+	ProductID int    `db:"product_id" json:"product_id"`
+	VariantID int    `db:"variant_id" json:"variant_id,omitempty"`
+	SKU       string `db:"sku" json:"sku,omitempty"`
+	Quantity  int    `db:"quantity" json:"quantity"`
+	// WarehouseID is set by ReserveStock; releasing an item without it
+	// returns the stock to the primary warehouse.
+	WarehouseID int `db:"warehouse_id" json:"warehouse_id,omitempty"`
+}
+
+// String names the variant the way the item does, for error messages.
+func (i StockItem) String() string {
+	switch {
+	case i.VariantID != 0:
+		return fmt.Sprintf("variant %d", i.VariantID)
+	case i.SKU != "":
+		return fmt.Sprintf("SKU %q", i.SKU)
+	default:
+		return fmt.Sprintf("product %d", i.ProductID)
+	}
+}
+
+const (
+	ReservationReserved = "reserved"
+	ReservationReleased = "released"
+)
+
+// Reservation is stock taken for an order under an idempotency key.
+type Reservation struct {
+	ID     string
+	Status string // ReservationReserved or ReservationReleased
+	Items  []StockItem
+}
+
+// legacyReservationID matches the IDs order-service gives orders placed
+// before reservations were tracked; see Order.StockReservationID there.
+var legacyReservationID = regexp.MustCompile(`^order-[0-9]+-legacy$`)
+
+// IsLegacyReservation reports whether id belongs to an order placed before
+// reservations were tracked. Only those have no stored reservation to
+// release, so only for them does a release trust the items it is given.
+func IsLegacyReservation(id string) bool {
+	return legacyReservationID.MatchString(id)
 }
@@ -0,0 +1,52 @@
+package model
+
+// ProductSort is the field products are listed by. Ties are broken by ID,
+// so every order is total and pages never overlap.
+type ProductSort string
+
+const (
+	SortByID    ProductSort = "id"
+	SortByName  ProductSort = "name"
+	SortByPrice ProductSort = "price"
+	SortByStock ProductSort = "stock"
+)
+
+// ProductQuery selects one page of products.
+type ProductQuery struct {
+	// CategoryID matches the category and all of its descendants. Category
+	// is a slug or name the usecase resolves to CategoryID.
+	CategoryID int
+	Category   string
+	// MinPrice and MaxPrice are inclusive and restrict the list to their
+	// currency; nil means unbounded.
+	MinPrice *Money
+	MaxPrice *Money
+	InStock  bool
+	Search   string // case-insensitive substring of the name
+
+	Sort       ProductSort
+	Descending bool
+	Limit      int
+	After      *ProductCursor // nil for the first page
+}
+
+// ProductCursor is the position of the last product of a page. Only the
+// field of the query's sort is used besides ID.
+type ProductCursor struct {
+	ID    int    `json:"id"`
+	Name  string `json:"name,omitempty"`
+	Price int64  `json:"price,omitempty"`
+	Stock int    `json:"stock,omitempty"`
+}
+
+// CursorAfter returns the cursor that continues a listing after p.
+func CursorAfter(p Product) *ProductCursor {
+	return &ProductCursor{ID: p.ID, Name: p.Name, Price: p.Price.MinorUnits, Stock: p.Stock}
+}
+
+// ProductPage is one page of a listing.
+type ProductPage struct {
+	Products      []Product
+	NextPageToken string // empty on the last page
+	Total         int    // products matching the filters, on all pages
+}
@@ -0,0 +1,68 @@
+package model
+
+// SearchQuery selects one page of full-text search results.
+type SearchQuery struct {
+	Text       string
+	CategoryID int    // includes descendants; see ProductQuery
+	Category   string // slug or name, resolved to CategoryID
+	MinPrice   *Money // inclusive, in its own currency; see ProductQuery
+	MaxPrice   *Money
+
+	Limit  int
+	Offset int
+}
+
+// Filter returns the filters of q that also apply to ListProducts.
+func (q SearchQuery) Filter() ProductQuery {
+	return ProductQuery{CategoryID: q.CategoryID, MinPrice: q.MinPrice, MaxPrice: q.MaxPrice}
+}
+
+// SearchHit is a matching product. HighlightedName and Snippet are HTML with
+// the product text escaped and the matched words wrapped in HighlightStart
+// and HighlightEnd.
+type SearchHit struct {
+	Product
+	Rank            float64 `db:"rank"`
+	HighlightedName string  `db:"highlighted_name"`
+	Snippet         string  `db:"snippet"`
+}
+
+const (
+	HighlightStart = "<mark>"
+	HighlightEnd   = "</mark>"
+)
+
+// CategoryFacet counts the matches directly in a category; CategoryID is 0
+// for uncategorized products.
+type CategoryFacet struct {
+	CategoryID int    `db:"category_id"`
+	Category   string `db:"category"`
+	Slug       string `db:"slug"`
+	Count      int    `db:"count"`
+}
+
+// PriceFacet counts the matches priced from Min (inclusive) to Max
+// (exclusive) minor units of CurrencyCode. Max is 0 for the top bucket.
+type PriceFacet struct {
+	CurrencyCode string
+	Min          int64
+	Max          int64
+	Count        int
+}
+
+// PriceBuckets are the upper bounds of the price facets, in major units of
+// each currency; see MinorUnitsPerMajor.
+var PriceBuckets = []int64{25, 50, 100, 500, 1000}
+
+type SearchResult struct {
+	Hits  []SearchHit
+	Total int
+	// Fuzzy is set when no product matched the words and Hits are products
+	// with similar names instead.
+	Fuzzy bool
+
+	Categories []CategoryFacet
+	Prices     []PriceFacet
+
+	NextPageToken string
+}
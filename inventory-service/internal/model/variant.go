@@ -0,0 +1,65 @@
+package model
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+var (
+	ErrVariantNotFound = errors.New("variant not found")
+	ErrVariantExists   = errors.New("variant SKU, barcode or options already taken")
+	ErrInvalidVariant  = errors.New("invalid variant")
+	// ErrVariantRequired is returned for stock items that name only a
+	// product, when the product has more than one variant.
+	ErrVariantRequired = errors.New("product has several variants; a variant or SKU is required")
+)
+
+// Variant is the unit that is stocked and ordered: one size and colour of a
+// product. Product.Stock is the total over its variants.
+type Variant struct {
+	ID        int            `json:"id"`
+	ProductID int            `json:"product_id"`
+	SKU       string         `json:"sku"`
+	Options   VariantOptions `json:"options"`
+	// Price overrides the product price when set. It is in the product's
+	// currency.
+	Price   *Money `json:"price,omitempty"`
+	Stock   int    `json:"stock"`
+	Barcode string `json:"barcode,omitempty"`
+}
+
+// PriceOr returns the price of v, or productPrice when v has no price of its
+// own.
+func (v *Variant) PriceOr(productPrice Money) Money {
+	if v.Price == nil {
+		return productPrice
+	}
+	return *v.Price
+}
+
+// VariantOptions maps option names to values, e.g. size=M and colour=red.
+// A product has at most one variant per combination.
+type VariantOptions map[string]string
+
+// String lists the options sorted by name, e.g. "colour=red, size=M".
+func (o VariantOptions) String() string {
+	names := make([]string, 0, len(o))
+	for name := range o {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	parts := make([]string, len(names))
+	for i, name := range names {
+		parts[i] = name + "=" + o[name]
+	}
+	return strings.Join(parts, ", ")
+}
+
+// DefaultSKU is the SKU of the variant created with a product that was
+// added without variants; migration 008 uses the same format.
+func DefaultSKU(productID int) string {
+	return fmt.Sprintf("P%06d", productID)
+}
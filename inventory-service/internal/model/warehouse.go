@@ -0,0 +1,61 @@
+package model
+
+import "errors"
+
+var (
+	ErrWarehouseNotFound = errors.New("warehouse not found")
+	ErrWarehouseExists   = errors.New("warehouse code already taken")
+	ErrInvalidWarehouse  = errors.New("invalid warehouse")
+)
+
+// Warehouse holds stock of variants. The warehouse with the lowest Priority
+// (then ID) is the primary one: stock written on a variant or product, and
+// stock released without a warehouse, goes there.
+type Warehouse struct {
+	ID       int    `db:"id" json:"id"`
+	Code     string `db:"code" json:"code"`
+	Name     string `db:"name" json:"name"`
+	Country  string `db:"country" json:"country"` // ISO 3166-1 alpha-2
+	Region   string `db:"region" json:"region"`
+	Priority int    `db:"priority" json:"priority"`
+}
+
+// StockLevel is the stock of a variant in a warehouse.
+type StockLevel struct {
+	WarehouseID   int    `db:"warehouse_id"`
+	WarehouseCode string `db:"warehouse_code"`
+	ProductID     int    `db:"product_id"`
+	VariantID     int    `db:"variant_id"`
+	SKU           string `db:"sku"`
+	Stock         int    `db:"stock"`
+}
+
+// StockLevelQuery selects the levels of a product's variants, or of one
+// variant, optionally in a single warehouse.
+type StockLevelQuery struct {
+	ProductID   int
+	VariantID   int
+	WarehouseID int
+}
+
+// AllocationStrategy orders the warehouses a reserved item is taken from. The
+// first one gives as much as it holds, and the rest comes from the next ones.
+type AllocationStrategy string
+
+const (
+	// AllocateByPriority takes the warehouse with the lowest priority.
+	AllocateByPriority AllocationStrategy = "priority"
+	// AllocateMostStock takes the warehouse holding the most of the variant.
+	AllocateMostStock AllocationStrategy = "most_stock"
+	// AllocateNearest prefers warehouses in the destination country, then
+	// region, and falls back to priority.
+	AllocateNearest AllocationStrategy = "nearest"
+)
+
+// Allocation tells ReserveStock how to pick warehouses.
+type Allocation struct {
+	Strategy AllocationStrategy
+	// ShipTo is the destination used by AllocateNearest.
+	ShipToCountry string
+	ShipToRegion  string
+}
@@ -0,0 +1,134 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"inventory-service/internal/model"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+)
+
+const categoryColumns = `id, COALESCE(parent_id, 0) AS parent_id, name, slug`
+
+// categoryTree lists every category with its depth, the IDs of its ancestors
+// and itself (ids) and their slugs (slugs), depth first.
+const categoryTree = `WITH RECURSIVE tree AS (
+	SELECT id, parent_id, name, slug, 0 AS depth, ARRAY[id] AS ids, ARRAY[slug::TEXT] AS slugs
+	FROM categories WHERE parent_id IS NULL
+	UNION ALL
+	SELECT c.id, c.parent_id, c.name, c.slug, t.depth + 1, t.ids || c.id, t.slugs || c.slug::TEXT
+	FROM categories c JOIN tree t ON c.parent_id = t.id
+)`
+
+// categoryDescendants selects the category $N and everything below it; see
+// filterConditions.
+const categoryDescendants = `WITH RECURSIVE sub AS (
+	SELECT id FROM categories WHERE id = %s
+	UNION ALL
+	SELECT c.id FROM categories c JOIN sub ON c.parent_id = sub.id
+) SELECT id FROM sub`
+
+type CategoryRepository struct {
+	DB *sqlx.DB
+}
+
+func (r *CategoryRepository) Create(c *model.Category) error {
+	err := r.DB.Get(&c.ID,
+		`INSERT INTO categories (parent_id, name, slug) VALUES (NULLIF($1, 0), $2, $3) RETURNING id`,
+		c.ParentID, c.Name, c.Slug)
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) {
+		switch pqErr.Code {
+		case "23505":
+			return model.ErrCategoryExists
+		case "23503":
+			return model.ErrCategoryNotFound
+		}
+	}
+	return err
+}
+
+func (r *CategoryRepository) Get(id int) (*model.Category, error) {
+	var c model.Category
+	err := r.DB.Get(&c, `SELECT `+categoryColumns+` FROM categories WHERE id = $1`, id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, model.ErrCategoryNotFound
+	}
+	return &c, err
+}
+
+// GetByKey finds a category by slug, or else by name ignoring case. Names
+// are not unique; the oldest category with the name wins.
+func (r *CategoryRepository) GetByKey(key string) (*model.Category, error) {
+	var c model.Category
+	err := r.DB.Get(&c,
+		`SELECT `+categoryColumns+` FROM categories
+		 WHERE slug = lower($1) OR lower(name) = lower($1)
+		 ORDER BY slug = lower($1) DESC, id LIMIT 1`, key)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, model.ErrCategoryNotFound
+	}
+	return &c, err
+}
+
+// List returns the subtree under rootID, including it, depth first; rootID 0
+// lists the whole tree.
+func (r *CategoryRepository) List(rootID int) ([]model.Category, error) {
+	categories := []model.Category{}
+	err := r.DB.Select(&categories,
+		categoryTree+`
+		SELECT id, COALESCE(parent_id, 0) AS parent_id, name, slug, depth, array_to_string(slugs, '/') AS path
+		FROM tree WHERE $1 = 0 OR $1 = ANY(ids)
+		ORDER BY tree.slugs`, rootID)
+	if err != nil {
+		return nil, err
+	}
+	if rootID != 0 && len(categories) == 0 {
+		return nil, model.ErrCategoryNotFound
+	}
+	return categories, nil
+}
+
+// Move makes parentID the parent of category id; parentID 0 makes it a root.
+// Moves are serialized so two concurrent moves cannot form a cycle.
+func (r *CategoryRepository) Move(id, parentID int) (*model.Category, error) {
+	tx, err := r.DB.Beginx()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`LOCK TABLE categories IN SHARE ROW EXCLUSIVE MODE`); err != nil {
+		return nil, err
+	}
+
+	if parentID != 0 {
+		var cycle sql.NullBool
+		err := tx.Get(&cycle,
+			`SELECT $2 IN (`+fmt.Sprintf(categoryDescendants, "$1")+`) FROM categories WHERE id = $2`,
+			id, parentID)
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, model.ErrCategoryNotFound
+		}
+		if err != nil {
+			return nil, err
+		}
+		if cycle.Bool {
+			return nil, model.ErrCategoryCycle
+		}
+	}
+
+	var c model.Category
+	err = tx.Get(&c,
+		`UPDATE categories SET parent_id = NULLIF($2, 0) WHERE id = $1 RETURNING `+categoryColumns,
+		id, parentID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, model.ErrCategoryNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &c, tx.Commit()
+}
@@ -0,0 +1,67 @@
+package repository
+
+import (
+	"errors"
+	"testing"
+
+	"inventory-service/internal/model"
+)
+
+func TestCategoryTree(t *testing.T) {
+	_, db := newTestRepo(t)
+	repo := &CategoryRepository{DB: db}
+
+	electronics := &model.Category{Name: "Electronics", Slug: "electronics"}
+	if err := repo.Create(electronics); err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	computers := &model.Category{Name: "Computers", Slug: "computers", ParentID: electronics.ID}
+	if err := repo.Create(computers); err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	laptops := &model.Category{Name: "Laptops", Slug: "laptops", ParentID: computers.ID}
+	if err := repo.Create(laptops); err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	if err := repo.Create(&model.Category{Name: "Laptops", Slug: "laptops"}); !errors.Is(err, model.ErrCategoryExists) {
+		t.Fatalf("expected ErrCategoryExists, got %v", err)
+	}
+	if err := repo.Create(&model.Category{Name: "x", Slug: "x", ParentID: 999}); !errors.Is(err, model.ErrCategoryNotFound) {
+		t.Fatalf("expected ErrCategoryNotFound for a missing parent, got %v", err)
+	}
+
+	tree, err := repo.List(0)
+	if err != nil {
+		t.Fatalf("list: %v", err)
+	}
+	if len(tree) != 3 || tree[2].Path != "electronics/computers/laptops" || tree[2].Depth != 2 {
+		t.Fatalf("unexpected tree %+v", tree)
+	}
+
+	sub, err := repo.List(computers.ID)
+	if err != nil {
+		t.Fatalf("list subtree: %v", err)
+	}
+	if len(sub) != 2 || sub[0].ID != computers.ID {
+		t.Fatalf("unexpected subtree %+v", sub)
+	}
+
+	if c, err := repo.GetByKey("LAPTOPS"); err != nil || c.ID != laptops.ID {
+		t.Fatalf("expected laptops by key, got %+v, %v", c, err)
+	}
+
+	// A category cannot move below its own descendant.
+	if _, err := repo.Move(electronics.ID, laptops.ID); !errors.Is(err, model.ErrCategoryCycle) {
+		t.Fatalf("expected ErrCategoryCycle, got %v", err)
+	}
+	moved, err := repo.Move(laptops.ID, 0)
+	if err != nil {
+		t.Fatalf("move: %v", err)
+	}
+	if moved.ParentID != 0 {
+		t.Fatalf("expected a root, got parent %d", moved.ParentID)
+	}
+	if _, err := repo.Move(laptops.ID, 999); !errors.Is(err, model.ErrCategoryNotFound) {
+		t.Fatalf("expected ErrCategoryNotFound, got %v", err)
+	}
+}
@@ -0,0 +1,21 @@
+package repository
+
+import (
+	"os"
+	"testing"
+
+	"shared/idempotency"
+)
+
+// The idempotency interceptor's store is shared; this database must have the
+// table it expects.
+func TestIdempotencyMigrationIsSharedSchema(t *testing.T) {
+	const migration = "../../migrations/004_create_idempotency_keys_table.up.sql"
+	ddl, err := os.ReadFile(migration)
+	if err != nil {
+		t.Fatalf("read %s: %v", migration, err)
+	}
+	if string(ddl) != idempotency.Schema {
+		t.Errorf("%s differs from shared/idempotency/schema.sql", migration)
+	}
+}
@@ -1,31 +1,101 @@
 package repository
 
 import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
 	"inventory-service/internal/model"
+	"sort"
+	"strings"
 
 	"github.com/jmoiron/sqlx"
 )
 
+// productColumns selects a product with its price mapped onto model.Money.
+const productColumns = `id, name, COALESCE(category_id, 0) AS category_id, COALESCE(category, '') AS category,
+	stock, price AS "price.minor_units", currency AS "price.currency_code", description`
+
 type ProductRepository struct {
 	DB *sqlx.DB
 }
 
+// Create and Update copy the name of p.CategoryID into products.category.
+//
+// Create also adds the product's first variant, without options and with
+// model.DefaultSKU, holding p.Stock; it is returned in p.Variants.
 func (r *ProductRepository) Create(p *model.Product) error {
-	query := `INSERT INTO products (name, category, stock, price) VALUES ($1, $2, $3, $4)`
-	_, err := r.DB.Exec(query, p.Name, p.Category, p.Stock, p.Price)
-	return err
+	tx, err := r.DB.Beginx()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	query := `INSERT INTO products (name, category_id, category, stock, price, currency, description)
+		VALUES ($1, NULLIF($2, 0), (SELECT name FROM categories WHERE id = $2), $3, $4, $5, $6) RETURNING id`
+	if err := tx.Get(&p.ID, query, p.Name, p.CategoryID, p.Stock, p.Price.MinorUnits, p.Price.CurrencyCode, p.Description); err != nil {
+		return err
+	}
+
+	v := model.Variant{ProductID: p.ID, SKU: model.DefaultSKU(p.ID), Stock: p.Stock}
+	if err := insertVariant(tx, &v); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+	p.Variants = []model.Variant{v}
+	return nil
 }
 
 func (r *ProductRepository) GetByID(id int) (*model.Product, error) {
 	var p model.Product
-	err := r.DB.Get(&p, "SELECT * FROM products WHERE id = $1", id)
+	err := r.DB.Get(&p, "SELECT "+productColumns+" FROM products WHERE id = $1", id)
 	return &p, err
 }
 
+// Update writes p.Stock to the variant of a product that has only one; the
+// stock of a product with several variants is changed through the variants.
+// p.Stock is set to the resulting total. Variant price overrides are stored
+// in the product's currency, so it cannot change while any variant has one.
 func (r *ProductRepository) Update(id int, p *model.Product) error {
-	query := `UPDATE products SET name=$1, category=$2, stock=$3, price=$4 WHERE id=$5`
-	_, err := r.DB.Exec(query, p.Name, p.Category, p.Stock, p.Price, id)
-	return err
+	tx, err := r.DB.Beginx()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := lockProduct(tx, id); err != nil {
+		return err
+	}
+	var repriced bool
+	err = tx.Get(&repriced,
+		`SELECT EXISTS (SELECT 1 FROM products p JOIN product_variants v ON v.product_id = p.id
+		 WHERE p.id = $1 AND p.currency <> $2 AND v.price IS NOT NULL)`, id, p.Price.CurrencyCode)
+	if err != nil {
+		return err
+	}
+	if repriced {
+		return fmt.Errorf("%w: product %d has variant prices in its currency; remove them before changing it to %s",
+			model.ErrInvalidPrice, id, p.Price.CurrencyCode)
+	}
+	var variants []int
+	if err := tx.Select(&variants, `SELECT id FROM product_variants WHERE product_id = $1 LIMIT 2`, id); err != nil {
+		return err
+	}
+	if len(variants) == 1 {
+		if err := setVariantStock(tx, variants[0], p.Stock); err != nil {
+			return err
+		}
+	}
+
+	query := `UPDATE products SET name=$1, category_id=NULLIF($2, 0), category=(SELECT name FROM categories WHERE id = $2),
+		stock=(SELECT COALESCE(SUM(stock), 0) FROM product_variants WHERE product_id = $6),
+		price=$3, currency=$4, description=$5 WHERE id=$6 RETURNING stock`
+	if err := tx.Get(&p.Stock, query, p.Name, p.CategoryID, p.Price.MinorUnits, p.Price.CurrencyCode, p.Description, id); err != nil {
+		return err
+	}
+	return tx.Commit()
 }
 
 func (r *ProductRepository) Delete(id int) error {
@@ -33,9 +103,481 @@ func (r *ProductRepository) Delete(id int) error {
 	return err
 }
 
-func (r *ProductRepository) List(category string, limit, offset int) ([]model.Product, error) {
+// List returns up to q.Limit products ordered by q.Sort and then ID, starting
+// after q.After, and the number of products matching the filters.
+func (r *ProductRepository) List(q model.ProductQuery) ([]model.Product, int, error) {
+	where, args := productFilter(q)
+
+	var total int
+	if err := r.DB.Get(&total, "SELECT COUNT(*) FROM products"+where, args...); err != nil {
+		return nil, 0, err
+	}
+
+	column, ok := sortColumns[q.Sort]
+	if !ok {
+		return nil, 0, fmt.Errorf("%w: unknown sort %q", model.ErrInvalidProductQuery, q.Sort)
+	}
+	dir, cmp := "ASC", ">"
+	if q.Descending {
+		dir, cmp = "DESC", "<"
+	}
+
+	if c := q.After; c != nil {
+		var cond string
+		if column == "id" {
+			args = append(args, c.ID)
+			cond = fmt.Sprintf("id %s $%d", cmp, len(args))
+		} else {
+			args = append(args, cursorValue(q.Sort, c), c.ID)
+			cond = fmt.Sprintf("(%s, id) %s ($%d, $%d)", column, cmp, len(args)-1, len(args))
+		}
+		if where == "" {
+			where = " WHERE " + cond
+		} else {
+			where += " AND " + cond
+		}
+	}
+
+	order := fmt.Sprintf(" ORDER BY %s %s", column, dir)
+	if column != "id" {
+		order += ", id " + dir
+	}
+	args = append(args, q.Limit)
+	query := "SELECT " + productColumns + " FROM products" + where + order + fmt.Sprintf(" LIMIT $%d", len(args))
+
+	var products []model.Product
+	if err := r.DB.Select(&products, query, args...); err != nil {
+		return nil, 0, err
+	}
+	return products, total, nil
+}
+
+var sortColumns = map[model.ProductSort]string{
+	model.SortByID:    "id",
+	model.SortByName:  "name",
+	model.SortByPrice: "price",
+	model.SortByStock: "stock",
+}
+
+func cursorValue(sort model.ProductSort, c *model.ProductCursor) interface{} {
+	switch sort {
+	case model.SortByName:
+		return c.Name
+	case model.SortByPrice:
+		return c.Price
+	default:
+		return c.Stock
+	}
+}
+
+// productFilter builds the WHERE clause for the filters of q.
+func productFilter(q model.ProductQuery) (string, []interface{}) {
+	conds, args := filterConditions(q, nil)
+	return whereClause(conds), args
+}
+
+// filterConditions returns the conditions for the filters of q, numbering
+// their parameters after args.
+func filterConditions(q model.ProductQuery, args []interface{}) ([]string, []interface{}) {
+	var conds []string
+	arg := func(v interface{}) string {
+		args = append(args, v)
+		return fmt.Sprintf("$%d", len(args))
+	}
+
+	if q.CategoryID != 0 {
+		conds = append(conds, "category_id IN ("+fmt.Sprintf(categoryDescendants, arg(q.CategoryID))+")")
+	}
+	if q.MinPrice != nil {
+		conds = append(conds, "currency = "+arg(q.MinPrice.CurrencyCode), "price >= "+arg(q.MinPrice.MinorUnits))
+	}
+	if q.MaxPrice != nil {
+		conds = append(conds, "currency = "+arg(q.MaxPrice.CurrencyCode), "price <= "+arg(q.MaxPrice.MinorUnits))
+	}
+	if q.InStock {
+		conds = append(conds, "stock > 0")
+	}
+	if q.Search != "" {
+		conds = append(conds, `name ILIKE '%' || `+arg(likeEscaper.Replace(q.Search))+` || '%'`)
+	}
+	return conds, args
+}
+
+func whereClause(conds []string) string {
+	if len(conds) == 0 {
+		return ""
+	}
+	return " WHERE " + strings.Join(conds, " AND ")
+}
+
+// likeEscaper makes a search term match literally in LIKE patterns.
+var likeEscaper = strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`)
+
+// ReserveStock decrements the stock of every item's variant in the
+// warehouses picked by alloc, and the totals of the variant and its product,
+// in a single transaction. Each row is updated conditionally, so stock never
+// goes negative: if any item cannot be satisfied the whole reservation is
+// rolled back. It returns the products with the reserved variants, and the
+// items split into one share per warehouse they are taken from; the
+// reservation stores the same shares, so releasing it returns each share to
+// its own warehouse.
+//
+// A non-empty reservationID makes the call idempotent: repeating it returns
+// the current products without touching stock again, and reserving an ID
+// that was already released fails with ErrReservationClosed.
+func (r *ProductRepository) ReserveStock(reservationID string, items []model.StockItem, alloc model.Allocation) ([]model.Product, []model.StockItem, error) {
+	tx, err := r.DB.Beginx()
+	if err != nil {
+		return nil, nil, err
+	}
+	defer tx.Rollback()
+
+	resolved, err := resolveStockItems(tx, items)
+	if err != nil {
+		return nil, nil, err
+	}
+	for i := range resolved {
+		resolved[i].WarehouseID = 0 // picked below
+	}
+	merged := mergeStockItems(resolved)
+
+	if reservationID != "" {
+		data, err := json.Marshal(merged)
+		if err != nil {
+			return nil, nil, err
+		}
+		res, err := tx.Exec(
+			`INSERT INTO stock_reservations (id, items, status) VALUES ($1, $2, $3) ON CONFLICT (id) DO NOTHING`,
+			reservationID, data, model.ReservationReserved)
+		if err != nil {
+			return nil, nil, err
+		}
+		if n, _ := res.RowsAffected(); n == 0 {
+			return replayReservation(tx, reservationID)
+		}
+	}
+
+	// The product row is locked before its variants and their warehouse
+	// stock, as in VariantRepository.
+	allocated := make([]model.StockItem, 0, len(merged))
+	for _, item := range merged {
+		if _, err := tx.Exec(`UPDATE products SET stock = stock - $1 WHERE id = $2`, item.Quantity, item.ProductID); err != nil {
+			return nil, nil, err
+		}
+		res, err := tx.Exec(`UPDATE product_variants SET stock = stock - $1 WHERE id = $2 AND stock >= $1`,
+			item.Quantity, item.VariantID)
+		if err != nil {
+			return nil, nil, err
+		}
+		if n, _ := res.RowsAffected(); n == 0 {
+			return nil, nil, stockError(tx, item)
+		}
+		shares, err := allocate(tx, item, alloc)
+		if err != nil {
+			return nil, nil, err
+		}
+		allocated = append(allocated, shares...)
+	}
+
+	if reservationID != "" {
+		data, err := json.Marshal(allocated)
+		if err != nil {
+			return nil, nil, err
+		}
+		if _, err := tx.Exec(`UPDATE stock_reservations SET items = $1 WHERE id = $2`, data, reservationID); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	reserved, err := reservedProducts(tx, allocated)
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, nil, err
+	}
+	return reserved, allocated, nil
+}
+
+// ReleaseStock returns previously reserved stock in a single transaction.
+//
+// With a non-empty reservationID every call after the first is a no-op:
+//   - a known reservation restores the items stored with it, and the request
+//     items are ignored;
+//   - an unknown ID is recorded as released, so a late ReserveStock with the
+//     same ID cannot take stock afterwards. Only a legacy ID (see
+//     model.IsLegacyReservation) restores the request items; any other
+//     unknown ID changes no stock, as nothing was ever reserved under it.
+func (r *ProductRepository) ReleaseStock(reservationID string, items []model.StockItem) error {
+	tx, err := r.DB.Beginx()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if reservationID != "" {
+		legacy := model.IsLegacyReservation(reservationID)
+		recorded := []model.StockItem{}
+		if legacy {
+			recorded = mergeStockItems(items)
+		}
+		data, err := json.Marshal(recorded)
+		if err != nil {
+			return err
+		}
+
+		var reservation struct {
+			Items    []byte `db:"items"`
+			Status   string `db:"status"`
+			Inserted bool   `db:"inserted"`
+		}
+		err = tx.Get(&reservation,
+			`INSERT INTO stock_reservations (id, items, status) VALUES ($1, $2, $3)
+			 ON CONFLICT (id) DO UPDATE SET updated_at = CURRENT_TIMESTAMP
+			 RETURNING items, status, (xmax = 0) AS inserted`,
+			reservationID, data, model.ReservationReleased)
+		if err != nil {
+			return err
+		}
+		switch {
+		case reservation.Inserted && !legacy:
+			return tx.Commit()
+		case reservation.Inserted:
+			// Order placed before reservations were tracked: restore the
+			// request items.
+			items = defaultVariants(items)
+		case reservation.Status == model.ReservationReleased:
+			return tx.Commit()
+		default:
+			items = nil
+			if err := json.Unmarshal(reservation.Items, &items); err != nil {
+				return err
+			}
+			if _, err := tx.Exec(`UPDATE stock_reservations SET status = $1 WHERE id = $2`,
+				model.ReservationReleased, reservationID); err != nil {
+				return err
+			}
+		}
+	}
+
+	resolved, err := resolveStockItems(tx, items)
+	if err != nil {
+		return err
+	}
+	for _, item := range mergeStockItems(resolved) {
+		res, err := tx.Exec(`UPDATE products SET stock = stock + $1 WHERE id = $2`, item.Quantity, item.ProductID)
+		if err != nil {
+			return err
+		}
+		if n, _ := res.RowsAffected(); n == 0 {
+			return fmt.Errorf("product %d: %w", item.ProductID, model.ErrProductNotFound)
+		}
+		res, err = tx.Exec(`UPDATE product_variants SET stock = stock + $1 WHERE id = $2`, item.Quantity, item.VariantID)
+		if err != nil {
+			return err
+		}
+		if n, _ := res.RowsAffected(); n == 0 {
+			return fmt.Errorf("%v: %w", item, model.ErrVariantNotFound)
+		}
+		if err := restock(tx, item); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// GetReservation returns the reservation stored under id, or
+// ErrUnknownReservation.
+func (r *ProductRepository) GetReservation(id string) (*model.Reservation, error) {
+	var row struct {
+		Items  []byte `db:"items"`
+		Status string `db:"status"`
+	}
+	err := r.DB.Get(&row, `SELECT items, status FROM stock_reservations WHERE id = $1`, id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("reservation %s: %w", id, model.ErrUnknownReservation)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	reservation := &model.Reservation{ID: id, Status: row.Status}
+	if err := json.Unmarshal(row.Items, &reservation.Items); err != nil {
+		return nil, err
+	}
+	return reservation, nil
+}
+
+// replayReservation answers a repeated ReserveStock call for an existing
+// reservation with the stored items, without changing stock.
+func replayReservation(tx *sqlx.Tx, reservationID string) ([]model.Product, []model.StockItem, error) {
+	var reservation struct {
+		Items  []byte `db:"items"`
+		Status string `db:"status"`
+	}
+	if err := tx.Get(&reservation, `SELECT items, status FROM stock_reservations WHERE id = $1`, reservationID); err != nil {
+		return nil, nil, err
+	}
+	if reservation.Status == model.ReservationReleased {
+		return nil, nil, fmt.Errorf("reservation %s: %w", reservationID, model.ErrReservationClosed)
+	}
+
+	var items []model.StockItem
+	if err := json.Unmarshal(reservation.Items, &items); err != nil {
+		return nil, nil, err
+	}
+	resolved, err := resolveStockItems(tx, items)
+	if err != nil {
+		return nil, nil, err
+	}
+	merged := mergeStockItems(resolved)
+	products, err := reservedProducts(tx, merged)
+	if err != nil {
+		return nil, nil, err
+	}
+	return products, merged, tx.Commit()
+}
+
+// resolveStockItems fills in ProductID and VariantID of every item from its
+// VariantID, SKU or, for a product with a single variant, ProductID.
+func resolveStockItems(tx *sqlx.Tx, items []model.StockItem) ([]model.StockItem, error) {
+	resolved := make([]model.StockItem, 0, len(items))
+	for _, item := range items {
+		var (
+			v   model.StockItem
+			err error
+		)
+		switch {
+		case item.VariantID != 0:
+			err = tx.Get(&v, `SELECT product_id, id AS variant_id FROM product_variants WHERE id = $1`, item.VariantID)
+		case item.SKU != "":
+			err = tx.Get(&v, `SELECT product_id, id AS variant_id FROM product_variants WHERE sku = $1`, item.SKU)
+		default:
+			v, err = onlyVariant(tx, item.ProductID)
+		}
+		if errors.Is(err, sql.ErrNoRows) || (err == nil && item.ProductID != 0 && v.ProductID != item.ProductID) {
+			return nil, fmt.Errorf("%v: %w", item, model.ErrVariantNotFound)
+		}
+		if err != nil {
+			return nil, err
+		}
+		v.Quantity, v.WarehouseID = item.Quantity, item.WarehouseID
+		resolved = append(resolved, v)
+	}
+	return resolved, nil
+}
+
+// defaultVariants names the default variant (see model.DefaultSKU) for every
+// item that names only its product. Orders placed before variants have such
+// items, and their stock went to the default variant when migration 008
+// created it, even if the product has more variants now.
+func defaultVariants(items []model.StockItem) []model.StockItem {
+	named := make([]model.StockItem, len(items))
+	for i, item := range items {
+		if item.VariantID == 0 && item.SKU == "" {
+			item.SKU = model.DefaultSKU(item.ProductID)
+		}
+		named[i] = item
+	}
+	return named
+}
+
+// onlyVariant finds the variant of a product that has exactly one.
+func onlyVariant(tx *sqlx.Tx, productID int) (model.StockItem, error) {
+	var ids []int
+	err := tx.Select(&ids, `SELECT id FROM product_variants WHERE product_id = $1 ORDER BY id LIMIT 2`, productID)
+	if err != nil {
+		return model.StockItem{}, err
+	}
+	switch len(ids) {
+	case 1:
+		return model.StockItem{ProductID: productID, VariantID: ids[0]}, nil
+	case 0:
+		var exists bool
+		if err := tx.Get(&exists, `SELECT EXISTS (SELECT 1 FROM products WHERE id = $1)`, productID); err != nil {
+			return model.StockItem{}, err
+		}
+		if !exists {
+			return model.StockItem{}, fmt.Errorf("product %d: %w", productID, model.ErrProductNotFound)
+		}
+		return model.StockItem{}, fmt.Errorf("product %d has no variants: %w", productID, model.ErrVariantNotFound)
+	default:
+		return model.StockItem{}, fmt.Errorf("product %d: %w", productID, model.ErrVariantRequired)
+	}
+}
+
+// reservedProducts returns the product of every item, in item order, with
+// the items' variants. items must be merged, with the warehouse shares of a
+// variant next to each other.
+func reservedProducts(tx *sqlx.Tx, items []model.StockItem) ([]model.Product, error) {
 	var products []model.Product
-	query := `SELECT * FROM products WHERE ($1 = '' OR category = $1) LIMIT $2 OFFSET $3`
-	err := r.DB.Select(&products, query, category, limit, offset)
-	return products, err
+	for _, item := range items {
+		if n := len(products); n == 0 || products[n-1].ID != item.ProductID {
+			var p model.Product
+			if err := tx.Get(&p, `SELECT `+productColumns+` FROM products WHERE id = $1`, item.ProductID); err != nil {
+				return nil, err
+			}
+			products = append(products, p)
+		}
+		p := &products[len(products)-1]
+		if n := len(p.Variants); n > 0 && p.Variants[n-1].ID == item.VariantID {
+			continue // another warehouse's share of the same variant
+		}
+
+		variants, err := selectVariants(tx, `WHERE v.id = $1`, item.VariantID)
+		if err != nil {
+			return nil, err
+		}
+		if len(variants) == 0 {
+			return nil, fmt.Errorf("%v: %w", item, model.ErrVariantNotFound)
+		}
+		p.Variants = append(p.Variants, variants...)
+	}
+	return products, nil
+}
+
+// stockError explains why a conditional stock update matched no row.
+func stockError(tx *sqlx.Tx, item model.StockItem) error {
+	var exists bool
+	if err := tx.Get(&exists, `SELECT EXISTS (SELECT 1 FROM product_variants WHERE id = $1)`, item.VariantID); err != nil {
+		return err
+	}
+	if !exists {
+		return fmt.Errorf("%v: %w", item, model.ErrVariantNotFound)
+	}
+	return fmt.Errorf("%v: %w", item, model.ErrInsufficientStock)
+}
+
+// mergeStockItems sums quantities per variant and warehouse and orders the
+// result by product and variant ID, so concurrent transactions lock rows in
+// the same order. Items that are not resolved yet are merged by how they name
+// the variant.
+func mergeStockItems(items []model.StockItem) []model.StockItem {
+	totals := make(map[model.StockItem]int)
+	for _, item := range items {
+		key := item
+		key.Quantity = 0
+		totals[key] += item.Quantity
+	}
+
+	merged := make([]model.StockItem, 0, len(totals))
+	for item, qty := range totals {
+		item.Quantity = qty
+		merged = append(merged, item)
+	}
+	sort.Slice(merged, func(i, j int) bool {
+		a, b := merged[i], merged[j]
+		if a.ProductID != b.ProductID {
+			return a.ProductID < b.ProductID
+		}
+		if a.VariantID != b.VariantID {
+			return a.VariantID < b.VariantID
+		}
+		if a.SKU != b.SKU {
+			return a.SKU < b.SKU
+		}
+		return a.WarehouseID < b.WarehouseID
+	})
+	return merged
 }
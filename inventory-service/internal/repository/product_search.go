@@ -0,0 +1,204 @@
+package repository
+
+import (
+	"fmt"
+	"inventory-service/internal/model"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+)
+
+// fuzzyThreshold is the word similarity a product name needs to be offered
+// when the query words match nothing.
+const fuzzyThreshold = "0.3"
+
+// textMatch is one way Search matches the query text, passed as $1.
+type textMatch struct {
+	text    string
+	cond    string
+	rank    string
+	name    string
+	snippet string
+}
+
+var headlineOptions = fmt.Sprintf("StartSel=%s, StopSel=%s", model.HighlightStart, model.HighlightEnd)
+
+// fullTextMatch matches every word of the query, each as a prefix, against
+// the search_vector of name, category and description.
+func fullTextMatch(terms string) textMatch {
+	const query = `to_tsquery('english', $1)`
+	return textMatch{
+		text: terms,
+		cond: "search_vector @@ " + query,
+		rank: "ts_rank_cd(search_vector, " + query + ")",
+		name: fmt.Sprintf("ts_headline('english', %s, %s, '%s, HighlightAll=true')",
+			htmlEscaped("name"), query, headlineOptions),
+		snippet: fmt.Sprintf("ts_headline('english', %s, %s, '%s, MaxFragments=2, MinWords=8, MaxWords=20')",
+			htmlEscaped("description"), query, headlineOptions),
+	}
+}
+
+// similarNameMatch finds names with a word similar to the query, for typos.
+func similarNameMatch(text string) textMatch {
+	return textMatch{
+		text:    text,
+		cond:    "$1 <% name",
+		rank:    "word_similarity($1, name)",
+		name:    htmlEscaped("name"),
+		snippet: htmlEscaped("left(description, 160)"),
+	}
+}
+
+// htmlEscapes are the replacements of htmlEscaped, "&" first so that the
+// other entities are not escaped again.
+var htmlEscapes = [][2]string{{"&", "&amp;"}, {"<", "&lt;"}, {">", "&gt;"}, {`"`, "&#34;"}, {"'", "&#39;"}}
+
+// htmlEscaped is the SQL for the text expr escaped for HTML, so that the
+// highlight tags are the only markup in a hit.
+func htmlEscaped(expr string) string {
+	for _, r := range htmlEscapes {
+		expr = fmt.Sprintf("replace(%s, %s, %s)", expr, pq.QuoteLiteral(r[0]), pq.QuoteLiteral(r[1]))
+	}
+	return expr
+}
+
+// minorUnitsPerMajor is the SQL for model.MinorUnitsPerMajor of the currency
+// column.
+func minorUnitsPerMajor() string {
+	byUnits := map[int64][]string{}
+	for code := range model.CurrencyExponents {
+		units := model.MinorUnitsPerMajor(code)
+		byUnits[units] = append(byUnits[units], pq.QuoteLiteral(code))
+	}
+	var units []int64
+	for u, codes := range byUnits {
+		sort.Strings(codes)
+		units = append(units, u)
+	}
+	sort.Slice(units, func(i, j int) bool { return units[i] < units[j] })
+
+	var b strings.Builder
+	b.WriteString("CASE")
+	for _, u := range units {
+		fmt.Fprintf(&b, " WHEN currency IN (%s) THEN %d", strings.Join(byUnits[u], ", "), u)
+	}
+	fmt.Fprintf(&b, " ELSE %d END", model.MinorUnitsPerMajor(model.DefaultCurrency))
+	return b.String()
+}
+
+// Search finds products by the words of q.Text, best matches first. When no
+// product contains the words it falls back to names similar to the text.
+func (r *ProductRepository) Search(q model.SearchQuery) (model.SearchResult, error) {
+	tx, err := r.DB.Beginx()
+	if err != nil {
+		return model.SearchResult{}, err
+	}
+	defer tx.Rollback()
+
+	var result model.SearchResult
+	if terms := tsQueryTerms(q.Text); terms != "" {
+		if result, err = search(tx, fullTextMatch(terms), q); err != nil {
+			return model.SearchResult{}, err
+		}
+	}
+
+	if result.Total == 0 {
+		if _, err := tx.Exec(`SELECT set_config('pg_trgm.word_similarity_threshold', $1, true)`, fuzzyThreshold); err != nil {
+			return model.SearchResult{}, err
+		}
+		if result, err = search(tx, similarNameMatch(q.Text), q); err != nil {
+			return model.SearchResult{}, err
+		}
+		result.Fuzzy = true
+	}
+
+	return result, tx.Commit()
+}
+
+func search(tx *sqlx.Tx, m textMatch, q model.SearchQuery) (model.SearchResult, error) {
+	var result model.SearchResult
+	args := []interface{}{m.text}
+
+	conds, filterArgs := filterConditions(q.Filter(), args)
+	where := whereClause(append([]string{m.cond}, conds...))
+	if err := tx.Get(&result.Total, "SELECT COUNT(*) FROM products"+where, filterArgs...); err != nil {
+		return result, err
+	}
+	if result.Total == 0 {
+		return result, nil
+	}
+
+	filterArgs = append(filterArgs, q.Limit, q.Offset)
+	query := fmt.Sprintf(`SELECT %s, %s AS rank, %s AS highlighted_name, %s AS snippet
+		FROM products%s ORDER BY rank DESC, id LIMIT $%d OFFSET $%d`,
+		productColumns, m.rank, m.name, m.snippet, where, len(filterArgs)-1, len(filterArgs))
+	if err := tx.Select(&result.Hits, query, filterArgs...); err != nil {
+		return result, err
+	}
+
+	// Each facet ignores its own filter, so the other values stay selectable.
+	byCategory := q.Filter()
+	byCategory.CategoryID = 0
+	conds, facetArgs := filterConditions(byCategory, args)
+	err := tx.Select(&result.Categories,
+		`SELECT COALESCE(f.category_id, 0) AS category_id, COALESCE(c.name, '') AS category,
+			COALESCE(c.slug, '') AS slug, f.count
+		 FROM (SELECT category_id, COUNT(*) AS count FROM products`+
+			whereClause(append([]string{m.cond}, conds...))+
+			` GROUP BY 1) f
+		 LEFT JOIN categories c ON c.id = f.category_id
+		 ORDER BY f.count DESC, category`,
+		facetArgs...)
+	if err != nil {
+		return result, err
+	}
+
+	byPrice := q.Filter()
+	byPrice.MinPrice, byPrice.MaxPrice = nil, nil
+	conds, facetArgs = filterConditions(byPrice, args)
+	facetArgs = append(facetArgs, pq.Array(model.PriceBuckets))
+	var buckets []struct {
+		Currency string `db:"currency"`
+		Bucket   int    `db:"bucket"`
+		Count    int    `db:"count"`
+	}
+	err = tx.Select(&buckets,
+		fmt.Sprintf(`SELECT currency, width_bucket(price::NUMERIC / (%s), $%d::NUMERIC[]) AS bucket, COUNT(*) AS count FROM products`,
+			minorUnitsPerMajor(), len(facetArgs))+
+			whereClause(append([]string{m.cond}, conds...))+
+			` GROUP BY 1, 2 ORDER BY 1, 2`,
+		facetArgs...)
+	if err != nil {
+		return result, err
+	}
+	for _, b := range buckets {
+		facet := model.PriceFacet{CurrencyCode: b.Currency, Count: b.Count}
+		units := model.MinorUnitsPerMajor(b.Currency)
+		if b.Bucket > 0 {
+			facet.Min = model.PriceBuckets[b.Bucket-1] * units
+		}
+		if b.Bucket < len(model.PriceBuckets) {
+			facet.Max = model.PriceBuckets[b.Bucket] * units
+		}
+		result.Prices = append(result.Prices, facet)
+	}
+
+	return result, nil
+}
+
+// tsQueryTerms turns free text into a to_tsquery expression that requires
+// every word as a prefix, e.g. "Mac book" becomes "mac:* & book:*". Anything
+// but letters and digits separates words, so the text cannot inject
+// operators.
+func tsQueryTerms(text string) string {
+	words := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	for i, w := range words {
+		words[i] = w + ":*"
+	}
+	return strings.Join(words, " & ")
+}
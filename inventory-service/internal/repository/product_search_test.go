@@ -0,0 +1,100 @@
+package repository
+
+import (
+	"strings"
+	"testing"
+
+	"inventory-service/internal/model"
+)
+
+func TestTSQueryTerms(t *testing.T) {
+	tests := map[string]string{
+		"MacBook pro":        "macbook:* & pro:*",
+		"  usb-c  ":          "usb:* & c:*",
+		"a' | b:* & !c":      "a:* & b:* & c:*",
+		"ноутбук":            "ноутбук:*",
+		"!&|()":              "",
+		"wireless   mouse 2": "wireless:* & mouse:* & 2:*",
+	}
+	for in, want := range tests {
+		if got := tsQueryTerms(in); got != want {
+			t.Errorf("tsQueryTerms(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestSearch(t *testing.T) {
+	repo, db := newTestRepo(t)
+
+	laptops := insertCategory(t, db, "laptops", 0)
+	accessories := insertCategory(t, db, "accessories", 0)
+	db.MustExec(`INSERT INTO products (name, category_id, category, stock, price, description) VALUES
+		('MacBook Pro', $1, 'laptops', 3, 199999, 'Apple laptop with an M3 chip'),
+		('MacBook Air', $1, 'laptops', 0, 99999, 'Thin and light'),
+		('Magic Mouse', $2, 'accessories', 9, 7999, 'Wireless mouse for your MacBook'),
+		('USB-C cable', $2, 'accessories', 50, 1999, 'Braided, two metres')`, laptops, accessories)
+
+	result, err := repo.Search(model.SearchQuery{Text: "macb", Limit: 10})
+	if err != nil {
+		t.Fatalf("search: %v", err)
+	}
+	if result.Fuzzy || result.Total != 3 || len(result.Hits) != 3 {
+		t.Fatalf("expected 3 prefix matches, got %+v", result)
+	}
+	if result.Hits[2].Name != "Magic Mouse" {
+		t.Errorf("expected the description match last, got %q", result.Hits[2].Name)
+	}
+	if !strings.Contains(result.Hits[0].HighlightedName, model.HighlightStart+"MacBook"+model.HighlightEnd) {
+		t.Errorf("expected a highlighted name, got %q", result.Hits[0].HighlightedName)
+	}
+	want := model.CategoryFacet{CategoryID: laptops, Category: "laptops", Slug: "laptops", Count: 2}
+	if len(result.Categories) != 2 || result.Categories[0] != want {
+		t.Errorf("unexpected category facets %+v", result.Categories)
+	}
+
+	// The category facet ignores the category filter.
+	result, err = repo.Search(model.SearchQuery{Text: "macbook", CategoryID: accessories, Limit: 10})
+	if err != nil {
+		t.Fatalf("search: %v", err)
+	}
+	if result.Total != 1 || len(result.Categories) != 2 {
+		t.Errorf("expected 1 hit and 2 categories, got %+v", result)
+	}
+	var buckets int
+	for _, f := range result.Prices {
+		buckets += f.Count
+	}
+	if buckets != 1 {
+		t.Errorf("expected price facets over the filtered hit, got %+v", result.Prices)
+	}
+
+	// Product text is escaped; only the highlight is markup.
+	db.MustExec(`INSERT INTO products (name, stock, price, currency, description) VALUES
+		('Kettle <b>XL</b>', 1, 4500, 'JPY', 'Boils 1.7 l & keeps "warm"')`)
+	result, err = repo.Search(model.SearchQuery{Text: "kettle", Limit: 10})
+	if err != nil {
+		t.Fatalf("search: %v", err)
+	}
+	if len(result.Hits) != 1 {
+		t.Fatalf("expected the kettle, got %+v", result)
+	}
+	if got, want := result.Hits[0].HighlightedName, "<mark>Kettle</mark> &lt;b&gt;XL&lt;/b&gt;"; got != want {
+		t.Errorf("highlighted name = %q, want %q", got, want)
+	}
+	if got := result.Hits[0].Snippet; !strings.Contains(got, "&amp; keeps &#34;warm&#34;") {
+		t.Errorf("expected an escaped snippet, got %q", got)
+	}
+	// 4500 JPY is in the 1000 and up bucket, not 25-50 like 45.00 USD.
+	wantFacet := model.PriceFacet{CurrencyCode: "JPY", Min: 1000, Count: 1}
+	if len(result.Prices) != 1 || result.Prices[0] != wantFacet {
+		t.Errorf("price facets = %+v, want %+v", result.Prices, wantFacet)
+	}
+
+	result, err = repo.Search(model.SearchQuery{Text: "mackbok", Limit: 10})
+	if err != nil {
+		t.Fatalf("search: %v", err)
+	}
+	if !result.Fuzzy || result.Total == 0 {
+		t.Fatalf("expected fuzzy matches for a typo, got %+v", result)
+	}
+}
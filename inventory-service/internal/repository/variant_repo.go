@@ -0,0 +1,237 @@
+package repository
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"inventory-service/internal/model"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+)
+
+// variantColumns selects a variant from product_variants v joined with its
+// product p, for the currency of the price override.
+const variantColumns = `v.id, v.product_id, v.sku, v.options, v.price, p.currency, v.stock, COALESCE(v.barcode, '') AS barcode`
+
+const variantsFrom = ` FROM product_variants v JOIN products p ON p.id = v.product_id`
+
+// variantRow is a variant as scanned from variantColumns.
+type variantRow struct {
+	ID        int           `db:"id"`
+	ProductID int           `db:"product_id"`
+	SKU       string        `db:"sku"`
+	Options   []byte        `db:"options"`
+	Price     sql.NullInt64 `db:"price"`
+	Currency  string        `db:"currency"`
+	Stock     int           `db:"stock"`
+	Barcode   string        `db:"barcode"`
+}
+
+func (r *variantRow) variant() (model.Variant, error) {
+	v := model.Variant{
+		ID:        r.ID,
+		ProductID: r.ProductID,
+		SKU:       r.SKU,
+		Stock:     r.Stock,
+		Barcode:   r.Barcode,
+	}
+	if err := json.Unmarshal(r.Options, &v.Options); err != nil {
+		return model.Variant{}, fmt.Errorf("variant %d options: %w", r.ID, err)
+	}
+	if r.Price.Valid {
+		v.Price = &model.Money{MinorUnits: r.Price.Int64, CurrencyCode: r.Currency}
+	}
+	return v, nil
+}
+
+// Writes to variants lock the product row first and then recompute
+// products.stock, so the total stays exact under concurrent writers; see
+// also ReserveStock. Variant stock is written as a total: the difference
+// goes to the primary warehouse.
+type VariantRepository struct {
+	DB *sqlx.DB
+}
+
+func (r *VariantRepository) Create(v *model.Variant) error {
+	tx, err := r.DB.Beginx()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := lockProduct(tx, v.ProductID); err != nil {
+		return err
+	}
+	if err := insertVariant(tx, v); err != nil {
+		return err
+	}
+	if err := syncProductStock(tx, v.ProductID); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func (r *VariantRepository) Get(id int) (*model.Variant, error) {
+	var row variantRow
+	err := r.DB.Get(&row, `SELECT `+variantColumns+variantsFrom+` WHERE v.id = $1`, id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("variant %d: %w", id, model.ErrVariantNotFound)
+	}
+	if err != nil {
+		return nil, err
+	}
+	v, err := row.variant()
+	return &v, err
+}
+
+// ListByProduct returns the variants of a product in the order they were
+// added.
+func (r *VariantRepository) ListByProduct(productID int) ([]model.Variant, error) {
+	return selectVariants(r.DB, `WHERE v.product_id = $1 ORDER BY v.id`, productID)
+}
+
+// Update replaces everything but the product of variant v.ID.
+func (r *VariantRepository) Update(v *model.Variant) error {
+	tx, err := r.DB.Beginx()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := lockProduct(tx, v.ProductID); err != nil {
+		return err
+	}
+	options, err := marshalOptions(v.Options)
+	if err != nil {
+		return err
+	}
+	res, err := tx.Exec(
+		`UPDATE product_variants SET sku = $1, options = $2, price = $3, barcode = NULLIF($4, '')
+		 WHERE id = $5 AND product_id = $6`,
+		v.SKU, options, variantPrice(v), v.Barcode, v.ID, v.ProductID)
+	if err != nil {
+		return variantError(err)
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return fmt.Errorf("variant %d: %w", v.ID, model.ErrVariantNotFound)
+	}
+	if err := setVariantStock(tx, v.ID, v.Stock); err != nil {
+		return err
+	}
+	if err := syncProductStock(tx, v.ProductID); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// Delete removes a variant; a product without variants has no stock and
+// cannot be ordered.
+func (r *VariantRepository) Delete(id int) error {
+	v, err := r.Get(id)
+	if err != nil {
+		return err
+	}
+
+	tx, err := r.DB.Beginx()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := lockProduct(tx, v.ProductID); err != nil {
+		return err
+	}
+	res, err := tx.Exec(`DELETE FROM product_variants WHERE id = $1 AND product_id = $2`, id, v.ProductID)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return fmt.Errorf("variant %d: %w", id, model.ErrVariantNotFound)
+	}
+	if err := syncProductStock(tx, v.ProductID); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func insertVariant(tx *sqlx.Tx, v *model.Variant) error {
+	options, err := marshalOptions(v.Options)
+	if err != nil {
+		return err
+	}
+	err = tx.Get(&v.ID,
+		`INSERT INTO product_variants (product_id, sku, options, price, stock, barcode)
+		 VALUES ($1, $2, $3, $4, 0, NULLIF($5, '')) RETURNING id`,
+		v.ProductID, v.SKU, options, variantPrice(v), v.Barcode)
+	if err != nil {
+		return variantError(err)
+	}
+	return setVariantStock(tx, v.ID, v.Stock)
+}
+
+func selectVariants(q sqlx.Queryer, where string, args ...interface{}) ([]model.Variant, error) {
+	var rows []variantRow
+	if err := sqlx.Select(q, &rows, `SELECT `+variantColumns+variantsFrom+` `+where, args...); err != nil {
+		return nil, err
+	}
+	variants := make([]model.Variant, 0, len(rows))
+	for i := range rows {
+		v, err := rows[i].variant()
+		if err != nil {
+			return nil, err
+		}
+		variants = append(variants, v)
+	}
+	return variants, nil
+}
+
+// marshalOptions stores no options as {}, so that (product_id, options)
+// stays unique for variants without options.
+func marshalOptions(o model.VariantOptions) ([]byte, error) {
+	if o == nil {
+		o = model.VariantOptions{}
+	}
+	return json.Marshal(o)
+}
+
+func variantPrice(v *model.Variant) sql.NullInt64 {
+	if v.Price == nil {
+		return sql.NullInt64{}
+	}
+	return sql.NullInt64{Int64: v.Price.MinorUnits, Valid: true}
+}
+
+func variantError(err error) error {
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) {
+		switch pqErr.Code {
+		case "23505":
+			return model.ErrVariantExists
+		case "23503":
+			return model.ErrProductNotFound
+		}
+	}
+	return err
+}
+
+// lockProduct takes the row lock that every change to the stock of the
+// product's variants holds.
+func lockProduct(tx *sqlx.Tx, productID int) error {
+	var id int
+	err := tx.Get(&id, `SELECT id FROM products WHERE id = $1 FOR UPDATE`, productID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("product %d: %w", productID, model.ErrProductNotFound)
+	}
+	return err
+}
+
+// syncProductStock sets products.stock to the total over the variants. The
+// caller must hold lockProduct.
+func syncProductStock(tx *sqlx.Tx, productID int) error {
+	_, err := tx.Exec(
+		`UPDATE products SET stock = (SELECT COALESCE(SUM(stock), 0) FROM product_variants WHERE product_id = $1)
+		 WHERE id = $1`, productID)
+	return err
+}
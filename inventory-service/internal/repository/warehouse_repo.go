@@ -0,0 +1,247 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"inventory-service/internal/model"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+)
+
+const warehouseColumns = `id, code, name, country, region, priority`
+
+// primaryWarehouse selects the ID of the primary warehouse.
+const primaryWarehouse = `SELECT id FROM warehouses ORDER BY priority, id LIMIT 1`
+
+// stockLevelColumns selects a level from variants v, warehouses w and their
+// warehouse_stock s, which is missing for empty levels.
+const stockLevelColumns = `w.id AS warehouse_id, w.code AS warehouse_code, v.product_id, v.id AS variant_id, v.sku,
+	COALESCE(s.stock, 0) AS stock`
+
+type WarehouseRepository struct {
+	DB *sqlx.DB
+}
+
+func (r *WarehouseRepository) Create(w *model.Warehouse) error {
+	err := r.DB.Get(&w.ID,
+		`INSERT INTO warehouses (code, name, country, region, priority) VALUES ($1, $2, $3, $4, $5) RETURNING id`,
+		w.Code, w.Name, w.Country, w.Region, w.Priority)
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) && pqErr.Code == "23505" {
+		return model.ErrWarehouseExists
+	}
+	return err
+}
+
+// List returns the warehouses in priority order.
+func (r *WarehouseRepository) List() ([]model.Warehouse, error) {
+	var warehouses []model.Warehouse
+	err := r.DB.Select(&warehouses, `SELECT `+warehouseColumns+` FROM warehouses ORDER BY priority, id`)
+	return warehouses, err
+}
+
+// StockLevels returns the level of every selected variant in every selected
+// warehouse, ordered by variant and then warehouse priority.
+func (r *WarehouseRepository) StockLevels(q model.StockLevelQuery) ([]model.StockLevel, error) {
+	var (
+		conds []string
+		args  []interface{}
+	)
+	arg := func(v interface{}) string {
+		args = append(args, v)
+		return fmt.Sprintf("$%d", len(args))
+	}
+	if q.ProductID != 0 {
+		conds = append(conds, "v.product_id = "+arg(q.ProductID))
+	}
+	if q.VariantID != 0 {
+		conds = append(conds, "v.id = "+arg(q.VariantID))
+	}
+	if q.WarehouseID != 0 {
+		conds = append(conds, "w.id = "+arg(q.WarehouseID))
+	}
+
+	var levels []model.StockLevel
+	err := r.DB.Select(&levels,
+		`SELECT `+stockLevelColumns+`
+		 FROM product_variants v CROSS JOIN warehouses w
+		 LEFT JOIN warehouse_stock s ON s.variant_id = v.id AND s.warehouse_id = w.id`+
+			whereClause(conds)+` ORDER BY v.id, w.priority, w.id`, args...)
+	return levels, err
+}
+
+// SetStockLevel sets the stock of l.VariantID in l.WarehouseID and fills in
+// the rest of l.
+func (r *WarehouseRepository) SetStockLevel(l *model.StockLevel) error {
+	var productID int
+	err := r.DB.Get(&productID, `SELECT product_id FROM product_variants WHERE id = $1`, l.VariantID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("variant %d: %w", l.VariantID, model.ErrVariantNotFound)
+	}
+	if err != nil {
+		return err
+	}
+
+	tx, err := r.DB.Beginx()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := lockProduct(tx, productID); err != nil {
+		return err
+	}
+	_, err = tx.Exec(
+		`INSERT INTO warehouse_stock (warehouse_id, variant_id, stock) VALUES ($1, $2, $3)
+		 ON CONFLICT (warehouse_id, variant_id) DO UPDATE SET stock = EXCLUDED.stock`,
+		l.WarehouseID, l.VariantID, l.Stock)
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) && pqErr.Code == "23503" {
+		// The variant is locked through its product, so the warehouse is missing.
+		return fmt.Errorf("warehouse %d: %w", l.WarehouseID, model.ErrWarehouseNotFound)
+	}
+	if err != nil {
+		return err
+	}
+	if err := syncVariantStock(tx, l.VariantID); err != nil {
+		return err
+	}
+	if err := syncProductStock(tx, productID); err != nil {
+		return err
+	}
+
+	err = tx.Get(l,
+		`SELECT `+stockLevelColumns+`
+		 FROM warehouse_stock s JOIN warehouses w ON w.id = s.warehouse_id JOIN product_variants v ON v.id = s.variant_id
+		 WHERE s.warehouse_id = $1 AND s.variant_id = $2`, l.WarehouseID, l.VariantID)
+	if err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// setVariantStock makes total the stock of a variant by adding the
+// difference to its stock in the primary warehouse. The caller must hold
+// lockProduct.
+func setVariantStock(tx *sqlx.Tx, variantID, total int) error {
+	var current int
+	if err := tx.Get(&current, `SELECT stock FROM product_variants WHERE id = $1`, variantID); err != nil {
+		return err
+	}
+	delta := total - current
+	if delta == 0 {
+		return nil
+	}
+
+	// Not an upsert: the CHECK applies to the proposed row before the
+	// conflict, and a negative delta must update the existing row.
+	res, err := tx.Exec(
+		`UPDATE warehouse_stock SET stock = stock + $2 WHERE variant_id = $1 AND warehouse_id = (`+primaryWarehouse+`)`,
+		variantID, delta)
+	if err == nil {
+		if n, _ := res.RowsAffected(); n == 0 {
+			_, err = tx.Exec(
+				`INSERT INTO warehouse_stock (warehouse_id, variant_id, stock) VALUES ((`+primaryWarehouse+`), $1, $2)`,
+				variantID, delta)
+		}
+	}
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) {
+		switch pqErr.Code {
+		case "23514":
+			return fmt.Errorf("%w: stock %d is less than the other warehouses hold", model.ErrInvalidVariant, total)
+		case "23502":
+			return fmt.Errorf("no warehouse for stock: %w", model.ErrWarehouseNotFound)
+		}
+	}
+	if err != nil {
+		return err
+	}
+	return syncVariantStock(tx, variantID)
+}
+
+// syncVariantStock sets product_variants.stock to the total over the
+// warehouses. The caller must hold lockProduct.
+func syncVariantStock(tx *sqlx.Tx, variantID int) error {
+	_, err := tx.Exec(
+		`UPDATE product_variants SET stock = (SELECT COALESCE(SUM(stock), 0) FROM warehouse_stock WHERE variant_id = $1)
+		 WHERE id = $1`, variantID)
+	return err
+}
+
+// allocationOrder ranks the warehouses w holding stock s for each strategy;
+// $2 and $3 are the destination country and region.
+var allocationOrder = map[model.AllocationStrategy]string{
+	model.AllocateByPriority: `w.priority, w.id`,
+	model.AllocateMostStock:  `s.stock DESC, w.priority, w.id`,
+	model.AllocateNearest:    `upper(w.country) = $2 DESC, lower(w.region) = lower($3) DESC, w.priority, w.id`,
+}
+
+// allocate takes item.Quantity of item.VariantID from the warehouses in
+// strategy order, as much as each holds until the quantity is covered, and
+// returns one share per warehouse it took from, in that order. The caller
+// must hold lockProduct.
+func allocate(tx *sqlx.Tx, item model.StockItem, alloc model.Allocation) ([]model.StockItem, error) {
+	order, ok := allocationOrder[alloc.Strategy]
+	if !ok {
+		return nil, fmt.Errorf("unknown allocation strategy %q", alloc.Strategy)
+	}
+
+	args := []interface{}{item.VariantID}
+	if alloc.Strategy == model.AllocateNearest {
+		args = append(args, alloc.ShipToCountry, alloc.ShipToRegion)
+	}
+	var levels []struct {
+		WarehouseID int `db:"warehouse_id"`
+		Stock       int `db:"stock"`
+	}
+	err := tx.Select(&levels,
+		`SELECT s.warehouse_id, s.stock FROM warehouse_stock s JOIN warehouses w ON w.id = s.warehouse_id
+		 WHERE s.variant_id = $1 AND s.stock > 0
+		 ORDER BY `+order, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	var shares []model.StockItem
+	remaining := item.Quantity
+	for _, level := range levels {
+		if remaining == 0 {
+			break
+		}
+		share := item
+		share.WarehouseID, share.Quantity = level.WarehouseID, min(level.Stock, remaining)
+		if _, err := tx.Exec(`UPDATE warehouse_stock SET stock = stock - $1 WHERE warehouse_id = $2 AND variant_id = $3`,
+			share.Quantity, share.WarehouseID, share.VariantID); err != nil {
+			return nil, err
+		}
+		shares = append(shares, share)
+		remaining -= share.Quantity
+	}
+	if remaining > 0 {
+		return nil, fmt.Errorf("%v: warehouses hold %d less than %d: %w", item, remaining, item.Quantity, model.ErrInsufficientStock)
+	}
+	return shares, nil
+}
+
+// restock returns item.Quantity of item.VariantID to item.WarehouseID, or to
+// the primary warehouse. The caller must hold lockProduct.
+func restock(tx *sqlx.Tx, item model.StockItem) error {
+	_, err := tx.Exec(
+		`INSERT INTO warehouse_stock (warehouse_id, variant_id, stock)
+		 VALUES (COALESCE(NULLIF($1, 0), (`+primaryWarehouse+`)), $2, $3)
+		 ON CONFLICT (warehouse_id, variant_id) DO UPDATE SET stock = warehouse_stock.stock + EXCLUDED.stock`,
+		item.WarehouseID, item.VariantID, item.Quantity)
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) {
+		switch pqErr.Code {
+		case "23503":
+			return fmt.Errorf("%v: %w", item, model.ErrVariantNotFound)
+		case "23502":
+			return fmt.Errorf("no warehouse for stock: %w", model.ErrWarehouseNotFound)
+		}
+	}
+	return err
+}
@@ -0,0 +1,72 @@
+package usecase
+
+import (
+	"fmt"
+	"inventory-service/internal/model"
+	"regexp"
+	"strings"
+)
+
+type CategoryRepo interface {
+	Create(c *model.Category) error
+	Get(id int) (*model.Category, error)
+	GetByKey(key string) (*model.Category, error)
+	List(rootID int) ([]model.Category, error)
+	Move(id, parentID int) (*model.Category, error)
+}
+
+type CategoryUsecase struct {
+	Repo CategoryRepo
+}
+
+const maxCategoryLength = 100
+
+var (
+	slugPattern  = regexp.MustCompile(`^[a-z0-9]+(-[a-z0-9]+)*$`)
+	slugSeparate = regexp.MustCompile(`[^a-z0-9]+`)
+)
+
+// Create adds a category under c.ParentID, or a root for 0. Without a slug
+// one is derived from the name, which only works for Latin letters and
+// digits.
+func (u *CategoryUsecase) Create(c *model.Category) error {
+	c.Name = strings.TrimSpace(c.Name)
+	if c.Name == "" || len(c.Name) > maxCategoryLength {
+		return fmt.Errorf("%w: name must be 1 to %d bytes", model.ErrInvalidCategory, maxCategoryLength)
+	}
+	if c.Slug == "" {
+		c.Slug = slugify(c.Name)
+	}
+	if !slugPattern.MatchString(c.Slug) || len(c.Slug) > maxCategoryLength {
+		return fmt.Errorf("%w: slug %q must be lowercase letters and digits separated by dashes", model.ErrInvalidCategory, c.Slug)
+	}
+	return u.Repo.Create(c)
+}
+
+func (u *CategoryUsecase) List(rootID int) ([]model.Category, error) {
+	return u.Repo.List(rootID)
+}
+
+// Move puts a category, with everything below it, under parentID; parentID
+// 0 makes it a root.
+func (u *CategoryUsecase) Move(id, parentID int) (*model.Category, error) {
+	if id == parentID {
+		return nil, model.ErrCategoryCycle
+	}
+	return u.Repo.Move(id, parentID)
+}
+
+// slugify lowercases name and joins its runs of ASCII letters and digits
+// with dashes, e.g. "Home & Garden" becomes "home-garden".
+func slugify(name string) string {
+	return strings.Trim(slugSeparate.ReplaceAllString(strings.ToLower(name), "-"), "-")
+}
+
+// resolveCategory finds the category named by key, a slug or name.
+func resolveCategory(categories CategoryRepo, key string) (*model.Category, error) {
+	c, err := categories.GetByKey(strings.TrimSpace(key))
+	if err != nil {
+		return nil, fmt.Errorf("category %q: %w", key, err)
+	}
+	return c, nil
+}
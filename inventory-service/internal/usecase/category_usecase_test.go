@@ -0,0 +1,18 @@
+package usecase
+
+import "testing"
+
+func TestSlugify(t *testing.T) {
+	tests := map[string]string{
+		"Laptops":           "laptops",
+		"  Home & Garden  ": "home-garden",
+		"USB-C Cables":      "usb-c-cables",
+		"4K TVs!":           "4k-tvs",
+		"Ноутбуки":          "",
+	}
+	for in, want := range tests {
+		if got := slugify(in); got != want {
+			t.Errorf("slugify(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
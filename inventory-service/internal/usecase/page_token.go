@@ -0,0 +1,80 @@
+package usecase
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"inventory-service/internal/model"
+)
+
+// pageToken is the opaque next_page_token. It records the filters and sort
+// it was issued for, so it cannot silently continue a different listing.
+type pageToken struct {
+	Query  string               `json:"q"`
+	Cursor *model.ProductCursor `json:"c,omitempty"`
+	Offset int                  `json:"o,omitempty"`
+}
+
+func encodePageToken(q model.ProductQuery, after *model.ProductCursor) string {
+	q.Limit, q.After = 0, nil
+	return encodeToken(pageToken{Query: fingerprint(q), Cursor: after})
+}
+
+func decodePageToken(q model.ProductQuery, token string) (*model.ProductCursor, error) {
+	q.Limit, q.After = 0, nil
+	t, err := decodeToken(fingerprint(q), token)
+	if err != nil {
+		return nil, err
+	}
+	if t.Cursor == nil {
+		return nil, model.ErrInvalidPageToken
+	}
+	return t.Cursor, nil
+}
+
+func encodeSearchPageToken(q model.SearchQuery, offset int) string {
+	q.Limit, q.Offset = 0, 0
+	return encodeToken(pageToken{Query: fingerprint(q), Offset: offset})
+}
+
+func decodeSearchPageToken(q model.SearchQuery, token string) (int, error) {
+	q.Limit, q.Offset = 0, 0
+	t, err := decodeToken(fingerprint(q), token)
+	if err != nil {
+		return 0, err
+	}
+	if t.Offset <= 0 {
+		return 0, model.ErrInvalidPageToken
+	}
+	return t.Offset, nil
+}
+
+func encodeToken(t pageToken) string {
+	data, _ := json.Marshal(t)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func decodeToken(query, token string) (pageToken, error) {
+	var t pageToken
+	data, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return t, model.ErrInvalidPageToken
+	}
+	if err := json.Unmarshal(data, &t); err != nil {
+		return t, model.ErrInvalidPageToken
+	}
+	if t.Query != query {
+		return t, fmt.Errorf("%w: filters or sort changed", model.ErrInvalidPageToken)
+	}
+	return t, nil
+}
+
+// fingerprint identifies a query with its position zeroed, i.e. its filters
+// and sort.
+func fingerprint(q interface{}) string {
+	data, _ := json.Marshal(q)
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:8])
+}
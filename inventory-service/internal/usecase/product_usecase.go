@@ -1,7 +1,9 @@
 package usecase
 
 import (
+	"fmt"
 	"inventory-service/internal/model"
+	"strings"
 )
 
 type ProductRepo interface {
@@ -9,29 +11,302 @@ type ProductRepo interface {
 	GetByID(id int) (*model.Product, error)
 	Update(id int, product *model.Product) error
 	Delete(id int) error
-	List(category string, limit, offset int) ([]model.Product, error)
+	// List returns up to q.Limit products after q.After, and how many match
+	// the filters in total.
+	List(q model.ProductQuery) ([]model.Product, int, error)
+	// Search returns up to q.Limit hits after q.Offset with their total and
+	// facets; NextPageToken is left empty.
+	Search(q model.SearchQuery) (model.SearchResult, error)
+	// ReserveStock also returns the items with the warehouses they were
+	// taken from.
+	ReserveStock(reservationID string, items []model.StockItem, alloc model.Allocation) ([]model.Product, []model.StockItem, error)
+	ReleaseStock(reservationID string, items []model.StockItem) error
+	GetReservation(id string) (*model.Reservation, error)
 }
 
 type ProductUsecase struct {
-	Repo ProductRepo
+	Repo       ProductRepo
+	Categories CategoryRepo
+	Variants   VariantRepo
+	// Allocation is the strategy reservations pick warehouses by; empty
+	// means model.AllocateByPriority.
+	Allocation model.AllocationStrategy
 }
 
+// Create adds the product with one variant holding p.Stock; see
+// model.DefaultSKU.
 func (u *ProductUsecase) Create(p *model.Product) error {
+	if err := validatePrice(p); err != nil {
+		return err
+	}
+	if err := validateStock(p); err != nil {
+		return err
+	}
+	if err := u.setCategory(p); err != nil {
+		return err
+	}
 	return u.Repo.Create(p)
 }
 
+// GetByID returns the product with its variants.
 func (u *ProductUsecase) GetByID(id int) (*model.Product, error) {
-	return u.Repo.GetByID(id)
+	p, err := u.Repo.GetByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if p.Variants, err = u.Variants.ListByProduct(id); err != nil {
+		return nil, err
+	}
+	return p, nil
 }
 
+// Update sets p.Stock on a product with a single variant and otherwise
+// leaves stock alone; p.Stock is set to the product's total stock.
 func (u *ProductUsecase) Update(id int, p *model.Product) error {
+	if err := validatePrice(p); err != nil {
+		return err
+	}
+	if err := validateStock(p); err != nil {
+		return err
+	}
+	if err := u.setCategory(p); err != nil {
+		return err
+	}
 	return u.Repo.Update(id, p)
 }
 
+// setCategory checks p.CategoryID, or without one looks up p.Category by
+// slug or name, and fills in both.
+func (u *ProductUsecase) setCategory(p *model.Product) error {
+	var (
+		c   *model.Category
+		err error
+	)
+	switch {
+	case p.CategoryID != 0:
+		c, err = u.Categories.Get(p.CategoryID)
+	case strings.TrimSpace(p.Category) != "":
+		c, err = resolveCategory(u.Categories, p.Category)
+	default:
+		p.Category = ""
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	p.CategoryID, p.Category = c.ID, c.Name
+	return nil
+}
+
 func (u *ProductUsecase) Delete(id int) error {
 	return u.Repo.Delete(id)
 }
 
-func (u *ProductUsecase) List(category string, limit, offset int) ([]model.Product, error) {
-	return u.Repo.List(category, limit, offset)
+const (
+	DefaultPageSize = 20
+	MaxPageSize     = 100
+)
+
+// List returns the page of products after pageToken, which is empty for the
+// first page.
+func (u *ProductUsecase) List(q model.ProductQuery, pageToken string) (model.ProductPage, error) {
+	if err := validateProductQuery(&q); err != nil {
+		return model.ProductPage{}, err
+	}
+	if q.Category != "" {
+		c, err := resolveCategory(u.Categories, q.Category)
+		if err != nil {
+			return model.ProductPage{}, err
+		}
+		q.CategoryID, q.Category = c.ID, ""
+	}
+	if pageToken != "" {
+		after, err := decodePageToken(q, pageToken)
+		if err != nil {
+			return model.ProductPage{}, err
+		}
+		q.After = after
+	}
+
+	// One extra product tells whether another page follows.
+	limit := q.Limit
+	q.Limit++
+	products, total, err := u.Repo.List(q)
+	if err != nil {
+		return model.ProductPage{}, err
+	}
+
+	page := model.ProductPage{Products: products, Total: total}
+	if len(products) > limit {
+		page.Products = products[:limit]
+		page.NextPageToken = encodePageToken(q, model.CursorAfter(products[limit-1]))
+	}
+	return page, nil
+}
+
+// MaxSearchLength bounds the search text, in bytes.
+const MaxSearchLength = 200
+
+// Search returns the page of products matching text after pageToken, which
+// is empty for the first page.
+func (u *ProductUsecase) Search(q model.SearchQuery, pageToken string) (model.SearchResult, error) {
+	q.Text = strings.TrimSpace(q.Text)
+	if q.Text == "" {
+		return model.SearchResult{}, fmt.Errorf("%w: empty search", model.ErrInvalidProductQuery)
+	}
+	if len(q.Text) > MaxSearchLength {
+		return model.SearchResult{}, fmt.Errorf("%w: search longer than %d bytes", model.ErrInvalidProductQuery, MaxSearchLength)
+	}
+	limit, err := pageSize(q.Limit)
+	if err != nil {
+		return model.SearchResult{}, err
+	}
+	if err := validatePriceBounds(q.MinPrice, q.MaxPrice); err != nil {
+		return model.SearchResult{}, err
+	}
+	if q.Category != "" {
+		c, err := resolveCategory(u.Categories, q.Category)
+		if err != nil {
+			return model.SearchResult{}, err
+		}
+		q.CategoryID, q.Category = c.ID, ""
+	}
+
+	q.Limit, q.Offset = 0, 0
+	if pageToken != "" {
+		if q.Offset, err = decodeSearchPageToken(q, pageToken); err != nil {
+			return model.SearchResult{}, err
+		}
+	}
+
+	// Ranking order changes as products change, so search pages by offset.
+	offset := q.Offset
+	q.Limit = limit + 1
+	result, err := u.Repo.Search(q)
+	if err != nil {
+		return model.SearchResult{}, err
+	}
+	if len(result.Hits) > limit {
+		result.Hits = result.Hits[:limit]
+		result.NextPageToken = encodeSearchPageToken(q, offset+limit)
+	}
+	return result, nil
+}
+
+// ReserveStock takes every item from the warehouses in u.Allocation order,
+// splitting it when one warehouse does not hold enough, using the destination
+// in alloc for model.AllocateNearest.
+func (u *ProductUsecase) ReserveStock(reservationID string, items []model.StockItem, alloc model.Allocation) ([]model.Product, []model.StockItem, error) {
+	if err := validateStockItems(items); err != nil {
+		return nil, nil, err
+	}
+	alloc.Strategy = u.Allocation
+	if alloc.Strategy == "" {
+		alloc.Strategy = model.AllocateByPriority
+	}
+	alloc.ShipToCountry = strings.ToUpper(strings.TrimSpace(alloc.ShipToCountry))
+	alloc.ShipToRegion = strings.TrimSpace(alloc.ShipToRegion)
+	return u.Repo.ReserveStock(reservationID, items, alloc)
+}
+
+// ReleaseStock takes the items from the stored reservation when reservationID
+// is set, so items may be empty in that case. Items are only restored without
+// a stored reservation for a legacy reservation ID, once.
+func (u *ProductUsecase) ReleaseStock(reservationID string, items []model.StockItem) error {
+	if reservationID == "" || (model.IsLegacyReservation(reservationID) && len(items) > 0) {
+		if err := validateStockItems(items); err != nil {
+			return err
+		}
+	}
+	return u.Repo.ReleaseStock(reservationID, items)
+}
+
+// GetReservation looks a reservation up without changing stock.
+func (u *ProductUsecase) GetReservation(id string) (*model.Reservation, error) {
+	if strings.TrimSpace(id) == "" {
+		return nil, fmt.Errorf("reservation id is required: %w", model.ErrUnknownReservation)
+	}
+	return u.Repo.GetReservation(id)
+}
+
+// validatePrice defaults a missing currency to model.DefaultCurrency.
+func validatePrice(p *model.Product) error {
+	if p.Price.CurrencyCode == "" {
+		p.Price.CurrencyCode = model.DefaultCurrency
+	}
+	return p.Price.ValidatePrice()
+}
+
+func validateStock(p *model.Product) error {
+	if p.Stock < 0 {
+		return fmt.Errorf("%w: negative stock", model.ErrInvalidVariant)
+	}
+	return nil
+}
+
+// validateProductQuery fills in the defaults of q.
+func validateProductQuery(q *model.ProductQuery) error {
+	switch q.Sort {
+	case "":
+		q.Sort = model.SortByID
+	case model.SortByID, model.SortByName, model.SortByPrice, model.SortByStock:
+	default:
+		return fmt.Errorf("%w: unknown sort %q", model.ErrInvalidProductQuery, q.Sort)
+	}
+
+	limit, err := pageSize(q.Limit)
+	if err != nil {
+		return err
+	}
+	q.Limit = limit
+
+	return validatePriceBounds(q.MinPrice, q.MaxPrice)
+}
+
+func pageSize(limit int) (int, error) {
+	switch {
+	case limit < 0:
+		return 0, fmt.Errorf("%w: negative page size", model.ErrInvalidProductQuery)
+	case limit == 0:
+		return DefaultPageSize, nil
+	case limit > MaxPageSize:
+		return MaxPageSize, nil
+	}
+	return limit, nil
+}
+
+// validatePriceBounds defaults missing currencies to model.DefaultCurrency.
+func validatePriceBounds(min, max *model.Money) error {
+	for _, bound := range []*model.Money{min, max} {
+		if bound == nil {
+			continue
+		}
+		if bound.CurrencyCode == "" {
+			bound.CurrencyCode = model.DefaultCurrency
+		}
+		if err := bound.ValidatePrice(); err != nil {
+			return fmt.Errorf("%w: %v", model.ErrInvalidProductQuery, err)
+		}
+	}
+	if min != nil && max != nil {
+		if min.CurrencyCode != max.CurrencyCode {
+			return fmt.Errorf("%w: price bounds in different currencies", model.ErrInvalidProductQuery)
+		}
+		if min.MinorUnits > max.MinorUnits {
+			return fmt.Errorf("%w: min price above max price", model.ErrInvalidProductQuery)
+		}
+	}
+	return nil
+}
+
+func validateStockItems(items []model.StockItem) error {
+	if len(items) == 0 {
+		return fmt.Errorf("no items: %w", model.ErrInvalidQuantity)
+	}
+	for _, item := range items {
+		if item.Quantity <= 0 {
+			return fmt.Errorf("%v: %w", item, model.ErrInvalidQuantity)
+		}
+	}
+	return nil
 }
@@ -0,0 +1,85 @@
+package usecase
+
+import (
+	"errors"
+	"testing"
+
+	"inventory-service/internal/model"
+)
+
+// listRepo serves List from a slice ordered by ID.
+type listRepo struct {
+	ProductRepo
+	products []model.Product
+}
+
+func (r *listRepo) List(q model.ProductQuery) ([]model.Product, int, error) {
+	var page []model.Product
+	for _, p := range r.products {
+		if q.After != nil && p.ID <= q.After.ID {
+			continue
+		}
+		if len(page) == q.Limit {
+			break
+		}
+		page = append(page, p)
+	}
+	return page, len(r.products), nil
+}
+
+func TestListFollowsPageTokens(t *testing.T) {
+	repo := &listRepo{}
+	for id := 1; id <= 5; id++ {
+		repo.products = append(repo.products, model.Product{ID: id})
+	}
+	uc := &ProductUsecase{Repo: repo}
+
+	query := model.ProductQuery{Limit: 2}
+	var ids []int
+	token := ""
+	for pages := 0; ; pages++ {
+		if pages == 3 {
+			t.Fatal("expected 3 pages")
+		}
+		page, err := uc.List(query, token)
+		if err != nil {
+			t.Fatalf("list: %v", err)
+		}
+		if page.Total != 5 {
+			t.Fatalf("expected total 5, got %d", page.Total)
+		}
+		for _, p := range page.Products {
+			ids = append(ids, p.ID)
+		}
+		if page.NextPageToken == "" {
+			break
+		}
+		token = page.NextPageToken
+	}
+	if len(ids) != 5 || ids[0] != 1 || ids[4] != 5 {
+		t.Fatalf("expected products 1..5, got %v", ids)
+	}
+}
+
+func TestListRejectsForeignPageToken(t *testing.T) {
+	repo := &listRepo{products: []model.Product{{ID: 1}, {ID: 2}}}
+	uc := &ProductUsecase{Repo: repo}
+
+	page, err := uc.List(model.ProductQuery{Limit: 1}, "")
+	if err != nil || page.NextPageToken == "" {
+		t.Fatalf("expected a next page, got %+v, %v", page, err)
+	}
+
+	_, err = uc.List(model.ProductQuery{Limit: 1, CategoryID: 3}, page.NextPageToken)
+	if !errors.Is(err, model.ErrInvalidPageToken) {
+		t.Fatalf("expected ErrInvalidPageToken for other filters, got %v", err)
+	}
+	if _, err := uc.List(model.ProductQuery{}, "not a token"); !errors.Is(err, model.ErrInvalidPageToken) {
+		t.Fatalf("expected ErrInvalidPageToken, got %v", err)
+	}
+
+	// The page size may change between pages.
+	if _, err := uc.List(model.ProductQuery{Limit: 5}, page.NextPageToken); err != nil {
+		t.Fatalf("list with another page size: %v", err)
+	}
+}
@@ -0,0 +1,136 @@
+package usecase
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"inventory-service/internal/model"
+	"strings"
+	"unicode"
+)
+
+type VariantRepo interface {
+	Create(v *model.Variant) error
+	Get(id int) (*model.Variant, error)
+	ListByProduct(productID int) ([]model.Variant, error)
+	// Update replaces everything but the product of variant v.ID.
+	Update(v *model.Variant) error
+	Delete(id int) error
+}
+
+type VariantUsecase struct {
+	Repo     VariantRepo
+	Products ProductRepo
+}
+
+const (
+	maxSKULength    = 64
+	maxOptionLength = 64
+	maxOptions      = 10
+)
+
+func (u *VariantUsecase) Create(v *model.Variant) error {
+	if err := u.validate(v); err != nil {
+		return err
+	}
+	return u.Repo.Create(v)
+}
+
+func (u *VariantUsecase) Get(id int) (*model.Variant, error) {
+	return u.Repo.Get(id)
+}
+
+func (u *VariantUsecase) List(productID int) ([]model.Variant, error) {
+	if _, err := u.product(productID); err != nil {
+		return nil, err
+	}
+	return u.Repo.ListByProduct(productID)
+}
+
+// Update changes variant v.ID; a variant cannot move to another product.
+func (u *VariantUsecase) Update(v *model.Variant) error {
+	existing, err := u.Repo.Get(v.ID)
+	if err != nil {
+		return err
+	}
+	if v.ProductID != 0 && v.ProductID != existing.ProductID {
+		return fmt.Errorf("%w: variant %d belongs to product %d", model.ErrInvalidVariant, v.ID, existing.ProductID)
+	}
+	v.ProductID = existing.ProductID
+	if err := u.validate(v); err != nil {
+		return err
+	}
+	return u.Repo.Update(v)
+}
+
+func (u *VariantUsecase) Delete(id int) error {
+	return u.Repo.Delete(id)
+}
+
+// validate normalizes v: option names are lowercased, and a price override
+// without a currency is in the product's currency, which is the only one it
+// may use.
+func (u *VariantUsecase) validate(v *model.Variant) error {
+	v.SKU = strings.TrimSpace(v.SKU)
+	if v.SKU == "" || len(v.SKU) > maxSKULength || strings.IndexFunc(v.SKU, unicode.IsSpace) >= 0 {
+		return fmt.Errorf("%w: SKU must be 1 to %d bytes without spaces", model.ErrInvalidVariant, maxSKULength)
+	}
+	v.Barcode = strings.TrimSpace(v.Barcode)
+	if len(v.Barcode) > maxSKULength {
+		return fmt.Errorf("%w: barcode longer than %d bytes", model.ErrInvalidVariant, maxSKULength)
+	}
+	if v.Stock < 0 {
+		return fmt.Errorf("%w: negative stock", model.ErrInvalidVariant)
+	}
+
+	options, err := normalizeOptions(v.Options)
+	if err != nil {
+		return err
+	}
+	v.Options = options
+
+	p, err := u.product(v.ProductID)
+	if err != nil {
+		return err
+	}
+	if v.Price != nil {
+		if v.Price.CurrencyCode == "" {
+			v.Price.CurrencyCode = p.Price.CurrencyCode
+		}
+		if err := v.Price.ValidatePrice(); err != nil {
+			return err
+		}
+		if v.Price.CurrencyCode != p.Price.CurrencyCode {
+			return fmt.Errorf("%w: variant priced in %s, product in %s",
+				model.ErrInvalidPrice, v.Price.CurrencyCode, p.Price.CurrencyCode)
+		}
+	}
+	return nil
+}
+
+func (u *VariantUsecase) product(id int) (*model.Product, error) {
+	p, err := u.Products.GetByID(id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("product %d: %w", id, model.ErrProductNotFound)
+	}
+	return p, err
+}
+
+// normalizeOptions trims names and values and lowercases the names.
+func normalizeOptions(options model.VariantOptions) (model.VariantOptions, error) {
+	if len(options) > maxOptions {
+		return nil, fmt.Errorf("%w: more than %d options", model.ErrInvalidVariant, maxOptions)
+	}
+	normalized := make(model.VariantOptions, len(options))
+	for name, value := range options {
+		name, value = strings.ToLower(strings.TrimSpace(name)), strings.TrimSpace(value)
+		if name == "" || value == "" || len(name) > maxOptionLength || len(value) > maxOptionLength {
+			return nil, fmt.Errorf("%w: option names and values must be 1 to %d bytes", model.ErrInvalidVariant, maxOptionLength)
+		}
+		if _, ok := normalized[name]; ok {
+			return nil, fmt.Errorf("%w: option %q given twice", model.ErrInvalidVariant, name)
+		}
+		normalized[name] = value
+	}
+	return normalized, nil
+}
@@ -0,0 +1,28 @@
+package usecase
+
+import (
+	"errors"
+	"testing"
+
+	"inventory-service/internal/model"
+)
+
+func TestNormalizeOptions(t *testing.T) {
+	got, err := normalizeOptions(model.VariantOptions{" Size ": " M ", "colour": "red"})
+	if err != nil {
+		t.Fatalf("normalize: %v", err)
+	}
+	if got.String() != "colour=red, size=M" {
+		t.Fatalf("got %q", got.String())
+	}
+
+	for _, options := range []model.VariantOptions{
+		{"size": " "},
+		{"": "M"},
+		{"Size": "M", "size": "L"},
+	} {
+		if _, err := normalizeOptions(options); !errors.Is(err, model.ErrInvalidVariant) {
+			t.Errorf("%v: expected ErrInvalidVariant, got %v", options, err)
+		}
+	}
+}
@@ -0,0 +1,78 @@
+package usecase
+
+import (
+	"fmt"
+	"inventory-service/internal/model"
+	"regexp"
+	"strings"
+)
+
+type WarehouseRepo interface {
+	Create(w *model.Warehouse) error
+	List() ([]model.Warehouse, error)
+	StockLevels(q model.StockLevelQuery) ([]model.StockLevel, error)
+	// SetStockLevel sets l.Stock and fills in the rest of l.
+	SetStockLevel(l *model.StockLevel) error
+}
+
+type WarehouseUsecase struct {
+	Repo WarehouseRepo
+}
+
+const (
+	maxWarehouseCode = 20
+	maxWarehouseName = 100
+)
+
+var (
+	warehouseCodePattern = regexp.MustCompile(`^[A-Z0-9]+(-[A-Z0-9]+)*$`)
+	countryPattern       = regexp.MustCompile(`^[A-Z]{2}$`)
+)
+
+// Create adds a warehouse. The code is uppercased, as is the country, which
+// is an ISO 3166-1 alpha-2 code or empty.
+func (u *WarehouseUsecase) Create(w *model.Warehouse) error {
+	w.Code = strings.ToUpper(strings.TrimSpace(w.Code))
+	if !warehouseCodePattern.MatchString(w.Code) || len(w.Code) > maxWarehouseCode {
+		return fmt.Errorf("%w: code %q must be 1 to %d letters and digits separated by dashes",
+			model.ErrInvalidWarehouse, w.Code, maxWarehouseCode)
+	}
+	w.Name = strings.TrimSpace(w.Name)
+	if w.Name == "" || len(w.Name) > maxWarehouseName {
+		return fmt.Errorf("%w: name must be 1 to %d bytes", model.ErrInvalidWarehouse, maxWarehouseName)
+	}
+	w.Country = strings.ToUpper(strings.TrimSpace(w.Country))
+	if w.Country != "" && !countryPattern.MatchString(w.Country) {
+		return fmt.Errorf("%w: country %q is not a two-letter code", model.ErrInvalidWarehouse, w.Country)
+	}
+	w.Region = strings.TrimSpace(w.Region)
+	if len(w.Region) > maxWarehouseName {
+		return fmt.Errorf("%w: region longer than %d bytes", model.ErrInvalidWarehouse, maxWarehouseName)
+	}
+	return u.Repo.Create(w)
+}
+
+func (u *WarehouseUsecase) List() ([]model.Warehouse, error) {
+	return u.Repo.List()
+}
+
+// StockLevels needs a product or a variant, so that it never lists every
+// variant in every warehouse.
+func (u *WarehouseUsecase) StockLevels(q model.StockLevelQuery) ([]model.StockLevel, error) {
+	if q.ProductID == 0 && q.VariantID == 0 {
+		return nil, fmt.Errorf("%w: product or variant required", model.ErrInvalidWarehouse)
+	}
+	return u.Repo.StockLevels(q)
+}
+
+// SetStockLevel sets the stock of a variant in a warehouse; the totals of
+// the variant and its product follow.
+func (u *WarehouseUsecase) SetStockLevel(l *model.StockLevel) error {
+	if l.WarehouseID == 0 || l.VariantID == 0 {
+		return fmt.Errorf("%w: warehouse and variant required", model.ErrInvalidWarehouse)
+	}
+	if l.Stock < 0 {
+		return fmt.Errorf("%w: negative stock", model.ErrInvalidWarehouse)
+	}
+	return u.Repo.SetStockLevel(l)
+}
@@ -0,0 +1,38 @@
+package usecase
+
+import (
+	"errors"
+	"testing"
+
+	"inventory-service/internal/model"
+)
+
+// warehouseRepo accepts every warehouse it is given.
+type warehouseRepo struct {
+	WarehouseRepo
+}
+
+func (warehouseRepo) Create(w *model.Warehouse) error { return nil }
+
+func TestCreateWarehouseNormalizes(t *testing.T) {
+	uc := &WarehouseUsecase{Repo: warehouseRepo{}}
+
+	w := model.Warehouse{Code: " ala-1 ", Name: " Almaty ", Country: "kz"}
+	if err := uc.Create(&w); err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	if w.Code != "ALA-1" || w.Name != "Almaty" || w.Country != "KZ" {
+		t.Fatalf("got %+v", w)
+	}
+
+	for _, w := range []model.Warehouse{
+		{Code: "", Name: "Almaty"},
+		{Code: "ALA 1", Name: "Almaty"},
+		{Code: "ALA-1", Name: " "},
+		{Code: "ALA-1", Name: "Almaty", Country: "KAZ"},
+	} {
+		if err := uc.Create(&w); !errors.Is(err, model.ErrInvalidWarehouse) {
+			t.Errorf("%+v: expected ErrInvalidWarehouse, got %v", w, err)
+		}
+	}
+}
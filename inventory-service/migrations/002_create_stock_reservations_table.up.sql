@@ -0,0 +1,7 @@
+CREATE TABLE IF NOT EXISTS stock_reservations (
+  id VARCHAR(100) PRIMARY KEY,
+  items JSONB NOT NULL DEFAULT '[]',
+  status VARCHAR(20) NOT NULL,
+  created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+  updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
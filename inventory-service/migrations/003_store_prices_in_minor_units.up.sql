@@ -0,0 +1,3 @@
+-- Prices are stored exactly, in minor units of the product's currency
+ALTER TABLE products ALTER COLUMN price TYPE BIGINT USING ROUND(price * 100)::BIGINT;
+ALTER TABLE products ADD COLUMN IF NOT EXISTS currency VARCHAR(3) NOT NULL DEFAULT 'USD';
@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS products_stock_id_idx;
+DROP INDEX IF EXISTS products_price_id_idx;
+DROP INDEX IF EXISTS products_name_id_idx;
+DROP INDEX IF EXISTS products_category_idx;
@@ -0,0 +1,5 @@
+-- Keyset pagination orders by the sort column and then id
+CREATE INDEX IF NOT EXISTS products_category_idx ON products (category);
+CREATE INDEX IF NOT EXISTS products_name_id_idx ON products (name, id);
+CREATE INDEX IF NOT EXISTS products_price_id_idx ON products (price, id);
+CREATE INDEX IF NOT EXISTS products_stock_id_idx ON products (stock, id);
@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS products_category_id_idx;
+CREATE INDEX IF NOT EXISTS products_category_idx ON products (category);
+ALTER TABLE products DROP COLUMN IF EXISTS category_id;
+DROP TABLE IF EXISTS categories;
@@ -0,0 +1,44 @@
+CREATE TABLE IF NOT EXISTS categories (
+  id SERIAL PRIMARY KEY,
+  parent_id INT REFERENCES categories(id) ON DELETE RESTRICT,
+  name VARCHAR(100) NOT NULL,
+  slug VARCHAR(100) NOT NULL UNIQUE,
+  created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS categories_parent_id_idx ON categories (parent_id);
+
+-- One root category per existing category string, ignoring case and
+-- surrounding spaces. The most common spelling becomes the name; names
+-- without Latin letters or digits get numbered "category" slugs.
+WITH names AS (
+  SELECT lower(trim(category)) AS key,
+         mode() WITHIN GROUP (ORDER BY trim(category)) AS name
+  FROM products
+  WHERE trim(coalesce(category, '')) <> ''
+  GROUP BY 1
+), slugs AS (
+  SELECT key, name,
+         coalesce(nullif(trim(BOTH '-' FROM regexp_replace(key, '[^a-z0-9]+', '-', 'g')), ''), 'category') AS base
+  FROM names
+), numbered AS (
+  SELECT name, base, row_number() OVER (PARTITION BY base ORDER BY key) AS n
+  FROM slugs
+)
+INSERT INTO categories (name, slug)
+SELECT name, CASE WHEN n = 1 THEN base ELSE base || '-' || n END
+FROM numbered;
+
+-- products.category stays as a copy of the category name for search_vector;
+-- categories cannot be renamed, so the copy never goes stale
+ALTER TABLE products ADD COLUMN IF NOT EXISTS category_id INT REFERENCES categories(id) ON DELETE RESTRICT;
+
+UPDATE products p
+SET category_id = c.id, category = c.name
+FROM categories c
+WHERE lower(trim(p.category)) = lower(c.name);
+
+UPDATE products SET category = NULL WHERE category_id IS NULL;
+
+DROP INDEX IF EXISTS products_category_idx;
+CREATE INDEX IF NOT EXISTS products_category_id_idx ON products (category_id);
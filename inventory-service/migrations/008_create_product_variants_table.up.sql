@@ -0,0 +1,24 @@
+-- A variant is the unit that is stocked and ordered. Its price, when set,
+-- overrides the product price and is in the product's currency.
+CREATE TABLE IF NOT EXISTS product_variants (
+  id SERIAL PRIMARY KEY,
+  product_id INT NOT NULL REFERENCES products(id) ON DELETE CASCADE,
+  sku VARCHAR(64) NOT NULL UNIQUE,
+  options JSONB NOT NULL DEFAULT '{}',
+  price BIGINT CHECK (price >= 0),
+  stock INT NOT NULL DEFAULT 0 CHECK (stock >= 0),
+  barcode VARCHAR(64) UNIQUE,
+  created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  UNIQUE (product_id, options)
+);
+
+-- Every existing product gets a default variant holding its stock.
+INSERT INTO product_variants (product_id, sku, stock)
+SELECT id, 'P' || repeat('0', 6 - length(id::TEXT)) || id, GREATEST(stock, 0)
+FROM products
+ON CONFLICT DO NOTHING;
+
+-- products.stock stays as the total over the variants, for listing, sorting
+-- and the in_stock filter; the repository keeps it up to date.
+UPDATE products p
+SET stock = COALESCE((SELECT SUM(v.stock) FROM product_variants v WHERE v.product_id = p.id), 0);
@@ -0,0 +1,30 @@
+CREATE TABLE IF NOT EXISTS warehouses (
+  id SERIAL PRIMARY KEY,
+  code VARCHAR(20) NOT NULL UNIQUE,
+  name VARCHAR(100) NOT NULL,
+  country VARCHAR(2) NOT NULL DEFAULT '',
+  region VARCHAR(100) NOT NULL DEFAULT '',
+  priority INT NOT NULL DEFAULT 0,
+  created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+-- warehouse_stock is where stock lives; product_variants.stock is the total
+-- over the warehouses, like products.stock over the variants.
+CREATE TABLE IF NOT EXISTS warehouse_stock (
+  warehouse_id INT NOT NULL REFERENCES warehouses(id) ON DELETE RESTRICT,
+  variant_id INT NOT NULL REFERENCES product_variants(id) ON DELETE CASCADE,
+  stock INT NOT NULL CHECK (stock >= 0),
+  PRIMARY KEY (warehouse_id, variant_id)
+);
+
+CREATE INDEX IF NOT EXISTS warehouse_stock_variant_id_idx ON warehouse_stock (variant_id);
+
+-- Existing stock starts out in a single primary warehouse.
+INSERT INTO warehouses (code, name, priority) VALUES ('MAIN', 'Main warehouse', 0)
+ON CONFLICT (code) DO NOTHING;
+
+INSERT INTO warehouse_stock (warehouse_id, variant_id, stock)
+SELECT w.id, v.id, v.stock
+FROM product_variants v CROSS JOIN warehouses w
+WHERE w.code = 'MAIN' AND v.stock > 0
+ON CONFLICT DO NOTHING;
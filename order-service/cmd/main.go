@@ -1,18 +1,27 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"net"
 	"order-service/infrastructure/db"
 	"order-service/internal/handler"
+	"order-service/internal/inventory"
 	"order-service/internal/repository"
 	"order-service/internal/usecase"
+	"order-service/internal/users"
 	"order-service/logger"
 	pbInventory "order-service/pb/inventory"
 	pb "order-service/pb/order"
+	pbUser "order-service/pb/user"
+	"shared/authz"
+	"shared/idempotency"
 
 	"net/http"
 	"order-service/internal/nats"
+	"os"
+	"strconv"
+	"time"
 
 	"github.com/prometheus/client_golang/prometheus/promhttp"
 	"google.golang.org/grpc"
@@ -32,21 +41,66 @@ func main() {
 		logger.Log.Error(fmt.Sprintf("Failed to listen on port 50052: %v", err))
 	}
 
-	conn, err := grpc.Dial("inventory-service:50053", grpc.WithInsecure())
+	conn, err := grpc.Dial("inventory-service:50053", grpc.WithInsecure(),
+		grpc.WithPerRPCCredentials(authz.ServiceCredentials(authz.ServiceToken())))
 	if err != nil {
 		logger.Log.Error(fmt.Sprintf("Could not connect to Inventory Service: %v", err))
 	}
 
+	userConn, err := grpc.Dial("user-service:50051", grpc.WithInsecure())
+	if err != nil {
+		logger.Log.Error(fmt.Sprintf("Could not connect to User Service: %v", err))
+	}
+
 	natsPublisher := nats.NewNatsPublisher("nats://nats:4222")
 
 	inventoryClient := pbInventory.NewInventoryServiceClient(conn)
 
 	database := db.NewPostgres()
 	orderRepo := &repository.OrderRepository{DB: database}
-	orderUsecase := &usecase.OrderUsecase{Repo: orderRepo}
-	orderHandler := handler.NewOrderHandler(orderUsecase, inventoryClient, natsPublisher)
+	stockClient := inventory.NewStockClient(inventoryClient)
+	addressClient := users.NewAddressClient(pbUser.NewUserServiceClient(userConn))
+	orderUsecase := &usecase.OrderUsecase{Repo: orderRepo, Stock: stockClient, Addresses: addressClient}
+	createOrderSaga := &usecase.CreateOrderSaga{
+		Sagas:   &repository.SagaRepository{DB: database},
+		Orders:  orderRepo,
+		Stock:   stockClient,
+		TaxRate: taxRate(),
+	}
+	orderHandler := handler.NewOrderHandler(orderUsecase, createOrderSaga)
+
+	outboxRelay := &usecase.OutboxRelay{
+		Repo:         &repository.OutboxRepository{DB: database},
+		Publisher:    natsPublisher,
+		PollInterval: time.Second,
+	}
 
-	grpcServer := grpc.NewServer()
+	go recoverSagas(createOrderSaga)
+	go outboxRelay.Run(context.Background())
+
+	idempotencyStore := &idempotency.PostgresStore{DB: database}
+	idempotencyInterceptor := &idempotency.Interceptor{
+		Store:     idempotencyStore,
+		Methods:   map[string]bool{pb.OrderService_CreateOrder_FullMethodName: true},
+		Retention: idempotencyRetention,
+		Lease:     time.Minute,
+		Log:       logger.Log,
+	}
+	go idempotency.PurgeExpired(idempotencyStore, idempotencyRetention, time.Hour, logger.Log)
+
+	authzInterceptor := &authz.Interceptor{
+		Verifier: authz.NewVerifier(jwksURL()),
+		Permissions: map[string]string{
+			pb.OrderService_CreateOrder_FullMethodName:       authz.Authenticated,
+			pb.OrderService_GetOrder_FullMethodName:          authz.Authenticated,
+			pb.OrderService_CancelOrder_FullMethodName:       authz.Authenticated,
+			pb.OrderService_GetOrderHistory_FullMethodName:   authz.Authenticated,
+			pb.OrderService_ListOrders_FullMethodName:        authz.Authenticated,
+			pb.OrderService_UpdateOrderStatus_FullMethodName: authz.PermOrdersManage,
+		},
+	}
+
+	grpcServer := grpc.NewServer(grpc.ChainUnaryInterceptor(authzInterceptor.Unary(), idempotencyInterceptor.Unary()))
 	pb.RegisterOrderServiceServer(grpcServer, orderHandler)
 
 	logger.Log.Info("Order gRPC service running on :50052")
@@ -54,3 +108,49 @@ func main() {
 		logger.Log.Error(fmt.Sprintf("Failed to serve gRPC server: %v", err))
 	}
 }
+
+// idempotencyRetention is how long a CreateOrder response is replayed for
+// retries with the same Idempotency-Key.
+const idempotencyRetention = 24 * time.Hour
+
+// taxRate reads the sales tax rate applied to new orders from TAX_RATE, a
+// fraction such as 0.12. It defaults to 0.
+func taxRate() float64 {
+	value := os.Getenv("TAX_RATE")
+	if value == "" {
+		return 0
+	}
+	rate, err := strconv.ParseFloat(value, 64)
+	if err != nil || rate < 0 {
+		logger.Log.Errorf("Invalid TAX_RATE %q, using 0", value)
+		return 0
+	}
+	return rate
+}
+
+// jwksURL is where user-service publishes the keys that verify access tokens.
+func jwksURL() string {
+	if url := os.Getenv("JWKS_URL"); url != "" {
+		return url
+	}
+	return "http://user-service:8083/.well-known/jwks.json"
+}
+
+// recoverSagas resumes or rolls back order sagas that stopped making progress,
+// e.g. because a previous instance of the service crashed mid-saga.
+func recoverSagas(saga *usecase.CreateOrderSaga) {
+	const staleAfter = time.Minute
+
+	ticker := time.NewTicker(30 * time.Second)
+	defer ticker.Stop()
+
+	for {
+		n, err := saga.RecoverStale(context.Background(), staleAfter)
+		if err != nil {
+			logger.Log.Error(fmt.Sprintf("Saga recovery failed: %v", err))
+		} else if n > 0 {
+			logger.Log.Info(fmt.Sprintf("Recovered %d order sagas", n))
+		}
+		<-ticker.C
+	}
+}
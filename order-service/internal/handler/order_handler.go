@@ -2,94 +2,98 @@ package handler
 
 import (
 	"context"
+	"errors"
 	"order-service/internal/model"
-	"order-service/internal/nats"
 	"order-service/internal/usecase"
-
-	pbInventory "order-service/pb/inventory"
+	"shared/authz"
+	"strings"
 	"time"
 
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
 
+	"order-service/pb/money"
 	pb "order-service/pb/order"
 )
 
+// OrderHandler serves OrderService. Every RPC needs an access token; callers
+// only place, see and cancel their own orders unless they hold orders.manage.
+// The user always comes from the token, never from the request.
 type OrderHandler struct {
 	pb.UnimplementedOrderServiceServer
-	usecase         *usecase.OrderUsecase
-	inventoryClient pbInventory.InventoryServiceClient
-	natsPublisher   *nats.NatsPublisher
+	usecase     *usecase.OrderUsecase
+	createOrder *usecase.CreateOrderSaga
 }
 
 // handler/order_handler.go
-func NewOrderHandler(uc *usecase.OrderUsecase, inventoryClient pbInventory.InventoryServiceClient, natsPublisher *nats.NatsPublisher) *OrderHandler {
+func NewOrderHandler(uc *usecase.OrderUsecase, createOrder *usecase.CreateOrderSaga) *OrderHandler {
 	return &OrderHandler{
-		usecase:         uc,
-		inventoryClient: inventoryClient,
-		natsPublisher:   natsPublisher,
+		usecase:     uc,
+		createOrder: createOrder,
 	}
 }
 
 func (h *OrderHandler) CreateOrder(ctx context.Context, req *pb.OrderRequest) (*pb.OrderResponse, error) {
+	p, err := caller(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if req.UserId != 0 && int(req.UserId) != p.UserID {
+		return nil, status.Error(codes.PermissionDenied, "orders can only be placed for the caller")
+	}
+
 	var order model.Order
-	order.UserID = int(req.UserId)
-	order.Status = "pending"
+	order.UserID = p.UserID
+	order.Status = model.StatusPending
 	order.CreatedAt = time.Now()
 
-	// Prepare items
 	for _, item := range req.Items {
-		// Call InventoryService to check product stock
-		productResp, err := h.inventoryClient.GetProduct(ctx, &pbInventory.ProductID{
-			Id: item.ProductId,
-		})
-		if err != nil {
-			return nil, status.Errorf(codes.FailedPrecondition, "Product %d not found", item.ProductId)
-		}
-
-		if item.Quantity > productResp.Stock {
-			return nil, status.Errorf(codes.FailedPrecondition, "Insufficient stock for product %d", item.ProductId)
-		}
-
-		// Update stock in inventory
-		_, err = h.inventoryClient.UpdateProduct(ctx, &pbInventory.Product{
-			Id:       productResp.Id,
-			Name:     productResp.Name,
-			Category: productResp.Category,
-			Stock:    productResp.Stock - item.Quantity, // Decrease
-			Price:    productResp.Price,
-		})
-		if err != nil {
-			return nil, status.Errorf(codes.Internal, "Failed to update stock for product %d", item.ProductId)
-		}
-
 		order.Items = append(order.Items, model.OrderItem{
 			ProductID: int(item.ProductId),
+			VariantID: int(item.VariantId),
+			SKU:       strings.TrimSpace(item.Sku),
 			Quantity:  int(item.Quantity),
 		})
 	}
 
-	// Save order in DB
-	if err := h.usecase.Create(&order); err != nil {
+	if err := h.usecase.AttachAddresses(ctx, &order, int(req.ShippingAddressId), int(req.BillingAddressId)); err != nil {
+		if st, ok := status.FromError(err); ok && st.Code() != codes.Unknown {
+			return nil, status.Errorf(st.Code(), "Failed to resolve address: %s", st.Message())
+		}
+		return nil, status.Errorf(codes.Internal, "Failed to resolve address: %v", err)
+	}
+
+	// Reserve stock, save the order and publish order.created as one saga;
+	// a failed step rolls back the ones before it
+	if err := h.createOrder.Execute(ctx, &order); err != nil {
+		if st, ok := status.FromError(err); ok && st.Code() != codes.Unknown {
+			return nil, status.Errorf(st.Code(), "Failed to create order: %s", st.Message())
+		}
 		return nil, status.Errorf(codes.Internal, "Failed to create order: %v", err)
 	}
-	_ = h.natsPublisher.PublishOrderCreated("order.created", order)
 
-	//Publish event after saving to DB
 	return convertToOrderResponse(&order), nil
 }
 
 func (h *OrderHandler) GetOrder(ctx context.Context, req *pb.OrderID) (*pb.OrderResponse, error) {
-	order, err := h.usecase.GetByID(int(req.Id)) // order is *model.Order
+	order, err := h.authorizedOrder(ctx, int(req.Id))
 	if err != nil {
-		return nil, status.Errorf(codes.NotFound, "order not found")
+		return nil, err
 	}
 	return convertToOrderResponse(order), nil
 }
 
 func (h *OrderHandler) UpdateOrderStatus(ctx context.Context, req *pb.StatusUpdate) (*pb.OrderResponse, error) {
-	if err := h.usecase.UpdateStatus(int(req.Id), req.Status); err != nil {
-		return nil, status.Errorf(codes.Internal, "failed to update order status")
+	if err := h.usecase.UpdateStatus(ctx, int(req.Id), fromProtoStatus(req.Status)); err != nil {
+		switch {
+		case errors.Is(err, model.ErrUnknownStatus):
+			return nil, status.Errorf(codes.InvalidArgument, "invalid order status: %v", req.Status)
+		case errors.Is(err, model.ErrOrderNotFound):
+			return nil, status.Errorf(codes.NotFound, "order not found")
+		case errors.Is(err, model.ErrInvalidTransition):
+			return nil, status.Errorf(codes.FailedPrecondition, "%v", err)
+		}
+		return nil, status.Errorf(codes.Internal, "failed to update order status: %v", err)
 	}
 	order, err := h.usecase.GetByID(int(req.Id))
 	if err != nil {
@@ -98,14 +102,65 @@ func (h *OrderHandler) UpdateOrderStatus(ctx context.Context, req *pb.StatusUpda
 	return convertToOrderResponse(order), nil
 }
 
+func (h *OrderHandler) CancelOrder(ctx context.Context, req *pb.CancelOrderRequest) (*pb.OrderResponse, error) {
+	if _, err := h.authorizedOrder(ctx, int(req.Id)); err != nil {
+		return nil, err
+	}
+	order, err := h.usecase.Cancel(ctx, int(req.Id), req.Reason)
+	if err != nil {
+		switch {
+		case errors.Is(err, model.ErrOrderNotFound):
+			return nil, status.Errorf(codes.NotFound, "order not found")
+		case errors.Is(err, model.ErrInvalidTransition):
+			return nil, status.Errorf(codes.FailedPrecondition, "order cannot be cancelled: %v", err)
+		}
+		return nil, status.Errorf(codes.Internal, "failed to cancel order: %v", err)
+	}
+	return convertToOrderResponse(order), nil
+}
+
+func (h *OrderHandler) GetOrderHistory(ctx context.Context, req *pb.OrderID) (*pb.OrderHistory, error) {
+	if _, err := h.authorizedOrder(ctx, int(req.Id)); err != nil {
+		return nil, err
+	}
+	changes, err := h.usecase.History(int(req.Id))
+	if errors.Is(err, model.ErrOrderNotFound) {
+		return nil, status.Errorf(codes.NotFound, "order not found")
+	}
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to load order history: %v", err)
+	}
+
+	history := &pb.OrderHistory{OrderId: req.Id}
+	for _, change := range changes {
+		history.Changes = append(history.Changes, &pb.StatusChange{
+			FromStatus: toProtoStatus(change.FromStatus),
+			ToStatus:   toProtoStatus(change.ToStatus),
+			ChangedAt:  change.ChangedAt.Format(time.RFC3339),
+			Reason:     change.Reason,
+		})
+	}
+	return history, nil
+}
+
+// ListOrders lists the orders of req.UserId, or of every user when it is 0.
+// Callers without orders.manage only get their own orders either way.
 func (h *OrderHandler) ListOrders(ctx context.Context, req *pb.UserOrdersRequest) (*pb.OrderList, error) {
-	var (
-		orders []model.Order
-		err    error
-	)
+	p, err := caller(ctx)
+	if err != nil {
+		return nil, err
+	}
+	userID := int(req.UserId)
+	if !p.HasPermission(authz.PermOrdersManage) {
+		if userID != 0 && userID != p.UserID {
+			return nil, status.Errorf(codes.PermissionDenied, "permission %q required", authz.PermOrdersManage)
+		}
+		userID = p.UserID
+	}
 
-	if req.UserId != 0 {
-		orders, err = h.usecase.ListByUser(int(req.UserId))
+	var orders []model.Order
+	if userID != 0 {
+		orders, err = h.usecase.ListByUser(userID)
 	} else {
 		orders, err = h.usecase.ListAll()
 	}
@@ -122,20 +177,107 @@ func (h *OrderHandler) ListOrders(ctx context.Context, req *pb.UserOrdersRequest
 
 }
 
+// caller returns the principal the authz interceptor put in ctx.
+func caller(ctx context.Context) (*authz.Principal, error) {
+	p, ok := authz.PrincipalFrom(ctx)
+	if !ok {
+		return nil, status.Error(codes.Unauthenticated, "missing access token")
+	}
+	return p, nil
+}
+
+// authorizedOrder loads the order if the caller owns it or holds
+// orders.manage, and fails with PermissionDenied otherwise.
+func (h *OrderHandler) authorizedOrder(ctx context.Context, id int) (*model.Order, error) {
+	p, err := caller(ctx)
+	if err != nil {
+		return nil, err
+	}
+	order, err := h.usecase.GetByID(id)
+	if errors.Is(err, model.ErrOrderNotFound) {
+		return nil, status.Errorf(codes.NotFound, "order not found")
+	}
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to load order: %v", err)
+	}
+	if order.UserID != p.UserID && !p.HasPermission(authz.PermOrdersManage) {
+		return nil, status.Errorf(codes.PermissionDenied, "permission %q required", authz.PermOrdersManage)
+	}
+	return order, nil
+}
+
 func convertToOrderResponse(order *model.Order) *pb.OrderResponse {
 	var items []*pb.OrderItem
 	for _, item := range order.Items {
 		items = append(items, &pb.OrderItem{
-			ProductId: int64(item.ProductID),
-			Quantity:  int32(item.Quantity),
+			ProductId:      int64(item.ProductID),
+			VariantId:      int64(item.VariantID),
+			Sku:            item.SKU,
+			Quantity:       int32(item.Quantity),
+			ProductName:    item.ProductName,
+			VariantOptions: item.VariantOptions,
+			UnitPrice:      toProtoMoney(item.UnitPrice),
+			LineTotal:      toProtoMoney(item.LineTotal),
+			WarehouseId:    int64(item.WarehouseID),
 		})
 	}
 	return &pb.OrderResponse{
-		Id:        int64(order.ID),
-		UserId:    int64(order.UserID),
-		Status:    order.Status,
-		CreatedAt: order.CreatedAt.Format(time.RFC3339),
-		Items:     items,
+		Id:              int64(order.ID),
+		UserId:          int64(order.UserID),
+		Status:          toProtoStatus(order.Status),
+		CreatedAt:       order.CreatedAt.Format(time.RFC3339),
+		Items:           items,
+		Subtotal:        toProtoMoney(order.Subtotal),
+		Tax:             toProtoMoney(order.Tax),
+		TotalAmount:     toProtoMoney(order.TotalAmount),
+		ShippingAddress: toProtoAddress(order.ShippingAddress),
+		BillingAddress:  toProtoAddress(order.BillingAddress),
+	}
+
+}
+
+func toProtoAddress(a *model.Address) *pb.OrderAddress {
+	if a == nil {
+		return nil
 	}
+	return &pb.OrderAddress{
+		AddressId:     int64(a.AddressID),
+		RecipientName: a.RecipientName,
+		Line1:         a.Line1,
+		Line2:         a.Line2,
+		City:          a.City,
+		Region:        a.Region,
+		PostalCode:    a.PostalCode,
+		Country:       a.Country,
+		Phone:         a.Phone,
+	}
+}
 
+func toProtoMoney(m model.Money) *money.Money {
+	return &money.Money{CurrencyCode: m.CurrencyCode, MinorUnits: m.MinorUnits}
+}
+
+var protoStatuses = map[string]pb.OrderStatus{
+	model.StatusPending:   pb.OrderStatus_ORDER_STATUS_PENDING,
+	model.StatusPaid:      pb.OrderStatus_ORDER_STATUS_PAID,
+	model.StatusFulfilled: pb.OrderStatus_ORDER_STATUS_FULFILLED,
+	model.StatusShipped:   pb.OrderStatus_ORDER_STATUS_SHIPPED,
+	model.StatusDelivered: pb.OrderStatus_ORDER_STATUS_DELIVERED,
+	model.StatusCancelled: pb.OrderStatus_ORDER_STATUS_CANCELLED,
+	model.StatusRefunded:  pb.OrderStatus_ORDER_STATUS_REFUNDED,
+}
+
+// toProtoStatus maps unknown (legacy) statuses to ORDER_STATUS_UNSPECIFIED.
+func toProtoStatus(s string) pb.OrderStatus {
+	return protoStatuses[s]
+}
+
+// fromProtoStatus returns "" for ORDER_STATUS_UNSPECIFIED and unknown values.
+func fromProtoStatus(s pb.OrderStatus) string {
+	for name, value := range protoStatuses {
+		if value == s {
+			return name
+		}
+	}
+	return ""
 }
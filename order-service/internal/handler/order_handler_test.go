@@ -0,0 +1,157 @@
+package handler
+
+import (
+	"context"
+	"order-service/internal/model"
+	"order-service/internal/usecase"
+	"shared/authz"
+	"testing"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	pb "order-service/pb/order"
+)
+
+// fakeOrders holds orders 1 and 2 of user 7 and order 3 of user 8.
+type fakeOrders struct {
+	usecase.OrderRepo
+	orders map[int]*model.Order
+}
+
+func newFakeOrders() *fakeOrders {
+	f := &fakeOrders{orders: map[int]*model.Order{}}
+	for id, userID := range map[int]int{1: 7, 2: 7, 3: 8} {
+		f.orders[id] = &model.Order{ID: id, UserID: userID, Status: model.StatusPending}
+	}
+	return f
+}
+
+func (f *fakeOrders) GetByID(id int) (*model.Order, error) {
+	order, ok := f.orders[id]
+	if !ok {
+		return nil, model.ErrOrderNotFound
+	}
+	copied := *order
+	return &copied, nil
+}
+
+func (f *fakeOrders) Cancel(order *model.Order, reason string) error {
+	f.orders[order.ID].Status = model.StatusCancelled
+	return nil
+}
+
+func (f *fakeOrders) ListStatusHistory(orderID int) ([]model.StatusChange, error) {
+	return nil, nil
+}
+
+func (f *fakeOrders) ListByUser(userID int) ([]model.Order, error) {
+	var orders []model.Order
+	for id := 1; id <= len(f.orders); id++ {
+		if f.orders[id].UserID == userID {
+			orders = append(orders, *f.orders[id])
+		}
+	}
+	return orders, nil
+}
+
+func (f *fakeOrders) ListAll() ([]model.Order, error) {
+	var orders []model.Order
+	for id := 1; id <= len(f.orders); id++ {
+		orders = append(orders, *f.orders[id])
+	}
+	return orders, nil
+}
+
+type fakeStock struct {
+	usecase.StockReserver
+}
+
+func (fakeStock) Release(ctx context.Context, reservationID string, items []model.OrderItem) error {
+	return nil
+}
+
+func newTestHandler() *OrderHandler {
+	uc := &usecase.OrderUsecase{Repo: newFakeOrders(), Stock: fakeStock{}}
+	return NewOrderHandler(uc, nil)
+}
+
+var (
+	customer = &authz.Principal{UserID: 7}
+	manager  = &authz.Principal{UserID: 9, Permissions: []string{authz.PermOrdersManage}}
+)
+
+func TestOrderAccess(t *testing.T) {
+	tests := []struct {
+		name  string
+		as    *authz.Principal
+		order int64
+		want  codes.Code
+	}{
+		{"owner", customer, 1, codes.OK},
+		{"another user's order", customer, 3, codes.PermissionDenied},
+		{"order manager", manager, 3, codes.OK},
+		{"missing order", customer, 42, codes.NotFound},
+		{"no principal", nil, 1, codes.Unauthenticated},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			if tt.as != nil {
+				ctx = authz.WithPrincipal(ctx, tt.as)
+			}
+			h := newTestHandler()
+
+			_, err := h.GetOrder(ctx, &pb.OrderID{Id: tt.order})
+			if got := status.Code(err); got != tt.want {
+				t.Errorf("GetOrder: code = %v, want %v (err: %v)", got, tt.want, err)
+			}
+			_, err = h.GetOrderHistory(ctx, &pb.OrderID{Id: tt.order})
+			if got := status.Code(err); got != tt.want {
+				t.Errorf("GetOrderHistory: code = %v, want %v (err: %v)", got, tt.want, err)
+			}
+			_, err = h.CancelOrder(ctx, &pb.CancelOrderRequest{Id: tt.order})
+			if got := status.Code(err); got != tt.want {
+				t.Errorf("CancelOrder: code = %v, want %v (err: %v)", got, tt.want, err)
+			}
+		})
+	}
+}
+
+func TestListOrdersAccess(t *testing.T) {
+	tests := []struct {
+		name   string
+		as     *authz.Principal
+		userID int64
+		want   codes.Code
+		orders int
+	}{
+		{"own orders", customer, 7, codes.OK, 2},
+		{"all orders as customer", customer, 0, codes.OK, 2},
+		{"another user's orders", customer, 8, codes.PermissionDenied, 0},
+		{"all orders as manager", manager, 0, codes.OK, 3},
+		{"another user's orders as manager", manager, 8, codes.OK, 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := authz.WithPrincipal(context.Background(), tt.as)
+			res, err := newTestHandler().ListOrders(ctx, &pb.UserOrdersRequest{UserId: tt.userID})
+			if got := status.Code(err); got != tt.want {
+				t.Fatalf("code = %v, want %v (err: %v)", got, tt.want, err)
+			}
+			if got := len(res.GetOrders()); got != tt.orders {
+				t.Errorf("listed %d orders, want %d", got, tt.orders)
+			}
+		})
+	}
+}
+
+func TestCreateOrderForAnotherUser(t *testing.T) {
+	ctx := authz.WithPrincipal(context.Background(), customer)
+	_, err := newTestHandler().CreateOrder(ctx, &pb.OrderRequest{UserId: 8})
+	if got := status.Code(err); got != codes.PermissionDenied {
+		t.Fatalf("code = %v, want PermissionDenied (err: %v)", got, err)
+	}
+}
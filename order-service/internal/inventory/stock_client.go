@@ -0,0 +1,106 @@
+package inventory
+
+import (
+	"context"
+	"order-service/internal/model"
+	pbInventory "order-service/pb/inventory"
+	"sort"
+	"strings"
+)
+
+// StockClient reserves and releases stock in InventoryService on behalf of
+// order-service.
+type StockClient struct {
+	client pbInventory.InventoryServiceClient
+}
+
+func NewStockClient(client pbInventory.InventoryServiceClient) *StockClient {
+	return &StockClient{client: client}
+}
+
+// Reserve holds stock for items and returns the current name and price of
+// every reserved variant, with the warehouse it was reserved in. shipTo, if
+// set, lets inventory-service prefer nearby warehouses.
+func (c *StockClient) Reserve(ctx context.Context, reservationID string, items []model.OrderItem, shipTo *model.Address) ([]model.ProductPrice, error) {
+	req := &pbInventory.StockRequest{
+		ReservationId: reservationID,
+		Items:         toStockItems(items),
+	}
+	if shipTo != nil {
+		req.ShipToCountry, req.ShipToRegion = shipTo.Country, shipTo.Region
+	}
+	res, err := c.client.ReserveStock(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	// An item split across warehouses keeps the first one in allocation
+	// order; inventory-service tracks the shares.
+	warehouses := make(map[int64]int64, len(res.Allocations))
+	for _, a := range res.Allocations {
+		if _, ok := warehouses[a.VariantId]; !ok {
+			warehouses[a.VariantId] = a.WarehouseId
+		}
+	}
+
+	var prices []model.ProductPrice
+	for _, p := range res.Products {
+		for _, v := range p.Variants {
+			price := p.Price
+			if v.Price != nil {
+				price = v.Price
+			}
+			prices = append(prices, model.ProductPrice{
+				ProductID:      int(p.Id),
+				VariantID:      int(v.Id),
+				SKU:            v.Sku,
+				Name:           p.Name,
+				VariantOptions: variantOptions(v.Options),
+				UnitPrice:      model.Money{CurrencyCode: price.GetCurrencyCode(), MinorUnits: price.GetMinorUnits()},
+				WarehouseID:    int(warehouses[v.Id]),
+			})
+		}
+	}
+	return prices, nil
+}
+
+// variantOptions formats options sorted by name, e.g. "colour=red, size=M".
+func variantOptions(options map[string]string) string {
+	names := make([]string, 0, len(options))
+	for name := range options {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	parts := make([]string, len(names))
+	for i, name := range names {
+		parts[i] = name + "=" + options[name]
+	}
+	return strings.Join(parts, ", ")
+}
+
+// Release gives back everything held by the reservation. It is safe to call
+// for a reservation that was never made or is already released. items are
+// only used for orders placed before reservations were tracked, whose
+// legacy reservation ID inventory-service does not know; they are restored
+// once.
+func (c *StockClient) Release(ctx context.Context, reservationID string, items []model.OrderItem) error {
+	_, err := c.client.ReleaseStock(ctx, &pbInventory.StockRequest{
+		ReservationId: reservationID,
+		Items:         toStockItems(items),
+	})
+	return err
+}
+
+func toStockItems(items []model.OrderItem) []*pbInventory.StockItem {
+	var stockItems []*pbInventory.StockItem
+	for _, item := range items {
+		stockItems = append(stockItems, &pbInventory.StockItem{
+			ProductId: int64(item.ProductID),
+			VariantId: int64(item.VariantID),
+			Sku:       item.SKU,
+			Quantity:  int32(item.Quantity),
+		})
+	}
+	return stockItems
+}
@@ -0,0 +1,42 @@
+package model
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+var ErrAddressNotFound = errors.New("address not found")
+
+// Address is a copy of a user-service address book entry taken when the
+// order was placed. It is stored as JSONB.
+type Address struct {
+	AddressID     int    `json:"address_id"` // the address book entry it was copied from
+	RecipientName string `json:"recipient_name"`
+	Line1         string `json:"line1"`
+	Line2         string `json:"line2,omitempty"`
+	City          string `json:"city"`
+	Region        string `json:"region,omitempty"`
+	PostalCode    string `json:"postal_code,omitempty"`
+	Country       string `json:"country"`
+	Phone         string `json:"phone,omitempty"`
+}
+
+// Value returns a string: lib/pq would send []byte as bytea, which jsonb
+// rejects.
+func (a Address) Value() (driver.Value, error) {
+	b, err := json.Marshal(a)
+	return string(b), err
+}
+
+func (a *Address) Scan(src interface{}) error {
+	switch v := src.(type) {
+	case []byte:
+		return json.Unmarshal(v, a)
+	case string:
+		return json.Unmarshal([]byte(v), a)
+	default:
+		return fmt.Errorf("cannot scan %T into Address", src)
+	}
+}
@@ -0,0 +1,40 @@
+package model
+
+import (
+	"errors"
+	"fmt"
+	"math"
+)
+
+var ErrCurrencyMismatch = errors.New("currency mismatch")
+
+// Money is an exact amount in the currency's minor unit, e.g. 199999 for
+// 1999.99 USD. It mirrors money.Money in the protos.
+type Money struct {
+	MinorUnits   int64  `db:"minor_units" json:"minor_units"`
+	CurrencyCode string `db:"currency_code" json:"currency_code"`
+}
+
+// Add returns m + other. A zero amount without currency takes the currency
+// of the other operand.
+func (m Money) Add(other Money) (Money, error) {
+	switch {
+	case m.CurrencyCode == "":
+		m.CurrencyCode = other.CurrencyCode
+	case other.CurrencyCode != "" && other.CurrencyCode != m.CurrencyCode:
+		return Money{}, fmt.Errorf("%w: %s and %s", ErrCurrencyMismatch, m.CurrencyCode, other.CurrencyCode)
+	}
+	m.MinorUnits += other.MinorUnits
+	return m, nil
+}
+
+func (m Money) Times(n int) Money {
+	m.MinorUnits *= int64(n)
+	return m
+}
+
+// Percent returns rate * m, rounded half away from zero to the minor unit.
+func (m Money) Percent(rate float64) Money {
+	m.MinorUnits = int64(math.Round(float64(m.MinorUnits) * rate))
+	return m
+}
@@ -1,17 +1,53 @@
 package model
 
-import "time"
+import (
+	"fmt"
+	"time"
+)
 
 type Order struct {
-	ID        int         `db:"id" json:"id"`
-	UserID    int         `db:"user_id" json:"user_id"`
-	Status    string      `db:"status" json:"status"`
-	CreatedAt time.Time   `db:"created_at" json:"created_at"`
-	Items     []OrderItem `json:"items"`
+	ID            int         `db:"id" json:"id"`
+	UserID        int         `db:"user_id" json:"user_id"`
+	Status        string      `db:"status" json:"status"`
+	ReservationID string      `db:"reservation_id" json:"reservation_id"` // stock reservation in inventory-service
+	Subtotal      Money       `db:"subtotal" json:"subtotal"`
+	Tax           Money       `db:"tax" json:"tax"`
+	TotalAmount   Money       `db:"total_amount" json:"total_amount"`
+	CreatedAt     time.Time   `db:"created_at" json:"created_at"`
+	Items         []OrderItem `json:"items"`
+	// Addresses are optional; orders placed before they existed have none.
+	ShippingAddress *Address `db:"shipping_address" json:"shipping_address,omitempty"`
+	BillingAddress  *Address `db:"billing_address" json:"billing_address,omitempty"`
 }
 
+// OrderItem keeps the product name, variant options and unit price at the
+// time the order was placed, so later changes do not rewrite the order.
+//
+// A new item names the inventory variant by VariantID or SKU, or by
+// ProductID alone for a product with a single variant; ApplyPrices fills in
+// the rest, including the WarehouseID the stock was reserved in (the first
+// one, when the item was split across warehouses). Items of
+// orders placed before variants have no VariantID, and before warehouses no
+// WarehouseID.
 type OrderItem struct {
-	OrderID   int `db:"order_id" json:"-"`
-	ProductID int `db:"product_id" json:"product_id"`
-	Quantity  int `db:"quantity" json:"quantity"`
+	OrderID        int    `db:"order_id" json:"-"`
+	ProductID      int    `db:"product_id" json:"product_id"`
+	VariantID      int    `db:"variant_id" json:"variant_id,omitempty"`
+	SKU            string `db:"sku" json:"sku,omitempty"`
+	Quantity       int    `db:"quantity" json:"quantity"`
+	ProductName    string `db:"product_name" json:"product_name"`
+	VariantOptions string `db:"variant_options" json:"variant_options,omitempty"`
+	UnitPrice      Money  `db:"unit_price" json:"unit_price"`
+	LineTotal      Money  `db:"line_total" json:"line_total"`
+	WarehouseID    int    `db:"warehouse_id" json:"warehouse_id,omitempty"`
+}
+
+// StockReservationID identifies the stock held for the order in
+// inventory-service. Orders created before reservations were tracked get a
+// per-order ID, so releasing their stock is idempotent as well.
+func (o *Order) StockReservationID() string {
+	if o.ReservationID != "" {
+		return o.ReservationID
+	}
+	return fmt.Sprintf("order-%d-legacy", o.ID)
 }
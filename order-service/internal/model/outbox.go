@@ -0,0 +1,31 @@
+package model
+
+import "time"
+
+const (
+	SubjectOrderCreated   = "order.created"
+	SubjectOrderCancelled = "order.cancelled"
+)
+
+// OutboxEvent is an event written in the same transaction as the change it
+// describes and delivered to NATS afterwards by the outbox relay.
+type OutboxEvent struct {
+	ID            int64      `db:"id" json:"id"`
+	EventID       string     `db:"event_id" json:"event_id"`
+	Subject       string     `db:"subject" json:"subject"`
+	Payload       []byte     `db:"payload" json:"-"`
+	Attempts      int        `db:"attempts" json:"attempts"`
+	LastError     string     `db:"last_error" json:"last_error"`
+	NextAttemptAt time.Time  `db:"next_attempt_at" json:"next_attempt_at"`
+	CreatedAt     time.Time  `db:"created_at" json:"created_at"`
+	DeliveredAt   *time.Time `db:"delivered_at" json:"delivered_at"`
+}
+
+// OrderEvent is the payload of order events: the order itself plus the
+// event ID, which stays the same across redeliveries, and the reason given
+// for the change, if any.
+type OrderEvent struct {
+	EventID string `json:"event_id"`
+	Reason  string `json:"reason,omitempty"`
+	Order
+}
@@ -0,0 +1,76 @@
+package model
+
+import (
+	"errors"
+	"fmt"
+)
+
+var ErrPriceMissing = errors.New("no price for product")
+
+// ProductPrice is the price inventory-service quoted for a product variant
+// when its stock was reserved, and the warehouse it was reserved in.
+type ProductPrice struct {
+	ProductID      int
+	VariantID      int
+	SKU            string
+	Name           string
+	VariantOptions string
+	UnitPrice      Money
+	WarehouseID    int
+}
+
+// priceFor finds the price of the variant item names. An item with only a
+// product ID matches the product's variant: inventory-service reserves such
+// items only for products with a single variant.
+func priceFor(prices []ProductPrice, item *OrderItem) (ProductPrice, bool) {
+	for _, p := range prices {
+		switch {
+		case item.VariantID != 0:
+			if p.VariantID == item.VariantID {
+				return p, true
+			}
+		case item.SKU != "":
+			if p.SKU == item.SKU {
+				return p, true
+			}
+		case p.ProductID == item.ProductID:
+			return p, true
+		}
+	}
+	return ProductPrice{}, false
+}
+
+// ApplyPrices snapshots the product, variant and warehouse of every item
+// from prices, with name, options and unit price, and computes the line
+// totals, subtotal, tax and total. taxRate is a fraction of the subtotal,
+// e.g. 0.12 for 12%. All items must be priced in the same currency.
+func (o *Order) ApplyPrices(prices []ProductPrice, taxRate float64) error {
+	subtotal := Money{}
+	for i := range o.Items {
+		item := &o.Items[i]
+		p, ok := priceFor(prices, item)
+		if !ok {
+			return fmt.Errorf("product %d: %w", item.ProductID, ErrPriceMissing)
+		}
+		item.ProductID, item.VariantID, item.SKU = p.ProductID, p.VariantID, p.SKU
+		item.ProductName = p.Name
+		item.VariantOptions = p.VariantOptions
+		item.WarehouseID = p.WarehouseID
+		item.UnitPrice = p.UnitPrice
+		item.LineTotal = p.UnitPrice.Times(item.Quantity)
+
+		var err error
+		if subtotal, err = subtotal.Add(item.LineTotal); err != nil {
+			return fmt.Errorf("product %d: %w", item.ProductID, err)
+		}
+	}
+
+	o.Subtotal = subtotal
+	o.Tax = subtotal.Percent(taxRate)
+	total, err := subtotal.Add(o.Tax)
+	if err != nil {
+		return err
+	}
+	o.TotalAmount = total
+	return nil
+}
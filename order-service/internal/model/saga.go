@@ -0,0 +1,35 @@
+package model
+
+import (
+	"fmt"
+	"time"
+)
+
+// Saga states. A saga moves forward through the step states and ends in
+// SagaCompleted, or switches to SagaCompensating and ends in SagaCompensated.
+const (
+	SagaStarted       = "started"
+	SagaStockReserved = "stock_reserved"
+	SagaCompleted     = "completed"
+	SagaCompensating  = "compensating"
+	SagaCompensated   = "compensated"
+)
+
+type OrderSaga struct {
+	ID        int       `db:"id" json:"id"`
+	OrderID   *int      `db:"order_id" json:"order_id"`
+	State     string    `db:"state" json:"state"`
+	Payload   []byte    `db:"payload" json:"-"` // the order being created, as JSON
+	Error     string    `db:"error" json:"error"`
+	CreatedAt time.Time `db:"created_at" json:"created_at"`
+	UpdatedAt time.Time `db:"updated_at" json:"updated_at"`
+}
+
+// ReservationID is the idempotency key used for the saga's stock reservation.
+func (s *OrderSaga) ReservationID() string {
+	return fmt.Sprintf("order-saga-%d", s.ID)
+}
+
+func (s *OrderSaga) Finished() bool {
+	return s.State == SagaCompleted || s.State == SagaCompensated
+}
@@ -0,0 +1,57 @@
+package model
+
+import (
+	"errors"
+	"time"
+)
+
+// Order lifecycle: pending → paid → fulfilled → shipped → delivered, with
+// cancelled and refunded as side exits.
+const (
+	StatusPending   = "pending"
+	StatusPaid      = "paid"
+	StatusFulfilled = "fulfilled"
+	StatusShipped   = "shipped"
+	StatusDelivered = "delivered"
+	StatusCancelled = "cancelled"
+	StatusRefunded  = "refunded"
+)
+
+var (
+	ErrOrderNotFound     = errors.New("order not found")
+	ErrUnknownStatus     = errors.New("unknown order status")
+	ErrInvalidTransition = errors.New("invalid order status transition")
+)
+
+// statusTransitions lists the statuses each status may move to.
+var statusTransitions = map[string][]string{
+	StatusPending:   {StatusPaid, StatusCancelled},
+	StatusPaid:      {StatusFulfilled, StatusCancelled, StatusRefunded},
+	StatusFulfilled: {StatusShipped, StatusRefunded},
+	StatusShipped:   {StatusDelivered},
+	StatusDelivered: {StatusRefunded},
+	StatusCancelled: {},
+	StatusRefunded:  {},
+}
+
+func IsValidStatus(status string) bool {
+	_, ok := statusTransitions[status]
+	return ok
+}
+
+func CanTransition(from, to string) bool {
+	for _, next := range statusTransitions[from] {
+		if next == to {
+			return true
+		}
+	}
+	return false
+}
+
+type StatusChange struct {
+	OrderID    int       `db:"order_id" json:"order_id"`
+	FromStatus string    `db:"from_status" json:"from_status"`
+	ToStatus   string    `db:"to_status" json:"to_status"`
+	Reason     string    `db:"reason" json:"reason,omitempty"`
+	ChangedAt  time.Time `db:"changed_at" json:"changed_at"`
+}
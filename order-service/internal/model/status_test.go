@@ -0,0 +1,58 @@
+package model
+
+import "testing"
+
+func TestCanTransition(t *testing.T) {
+	allowed := map[[2]string]bool{
+		{StatusPending, StatusPaid}:       true,
+		{StatusPending, StatusCancelled}:  true,
+		{StatusPaid, StatusFulfilled}:     true,
+		{StatusPaid, StatusCancelled}:     true,
+		{StatusPaid, StatusRefunded}:      true,
+		{StatusFulfilled, StatusShipped}:  true,
+		{StatusFulfilled, StatusRefunded}: true,
+		{StatusShipped, StatusDelivered}:  true,
+		{StatusDelivered, StatusRefunded}: true,
+	}
+	statuses := []string{
+		StatusPending, StatusPaid, StatusFulfilled, StatusShipped,
+		StatusDelivered, StatusCancelled, StatusRefunded,
+	}
+
+	// Every pair not listed above, including staying in the same status and
+	// leaving the terminal cancelled and refunded statuses, is forbidden.
+	for _, from := range statuses {
+		for _, to := range statuses {
+			want := allowed[[2]string{from, to}]
+			if got := CanTransition(from, to); got != want {
+				t.Errorf("CanTransition(%s, %s) = %v, want %v", from, to, got, want)
+			}
+		}
+	}
+}
+
+func TestCanTransitionUnknownStatus(t *testing.T) {
+	tests := []struct{ from, to string }{
+		{"", StatusPaid},
+		{StatusPending, ""},
+		{"archived", StatusPending},
+		{StatusPaid, "archived"},
+		{"PENDING", StatusPaid},
+	}
+	for _, tt := range tests {
+		if CanTransition(tt.from, tt.to) {
+			t.Errorf("CanTransition(%q, %q) = true, want false", tt.from, tt.to)
+		}
+	}
+}
+
+func TestTerminalStatuses(t *testing.T) {
+	for _, status := range []string{StatusCancelled, StatusRefunded} {
+		if !IsValidStatus(status) {
+			t.Errorf("IsValidStatus(%s) = false, want true", status)
+		}
+		if next := statusTransitions[status]; len(next) != 0 {
+			t.Errorf("%s is terminal but may move to %v", status, next)
+		}
+	}
+}
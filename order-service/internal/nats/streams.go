@@ -0,0 +1,19 @@
+package nats
+
+import (
+	"time"
+
+	"github.com/nats-io/nats.go/jetstream"
+)
+
+// OrdersStream holds all order.* subjects. consumer-service creates the same
+// stream with identical configuration; keep the two in sync.
+const OrdersStream = "ORDERS"
+
+var ordersStreamConfig = jetstream.StreamConfig{
+	Name:       OrdersStream,
+	Subjects:   []string{"order.>"},
+	Storage:    jetstream.FileStorage,
+	MaxAge:     7 * 24 * time.Hour,
+	Duplicates: 2 * time.Minute,
+}
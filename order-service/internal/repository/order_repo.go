@@ -1,6 +1,8 @@
 package repository
 
 import (
+	"database/sql"
+	"errors"
 	"order-service/internal/model"
 	"time"
 
@@ -9,19 +11,56 @@ import (
 	"github.com/jmoiron/sqlx"
 )
 
+// Amounts are stored in minor units, with the currency once per order;
+// orderColumns and selectOrderItems map them onto model.Money.
+const (
+	orderColumns = `id, user_id, status, reservation_id,
+		subtotal AS "subtotal.minor_units", currency AS "subtotal.currency_code",
+		tax AS "tax.minor_units", currency AS "tax.currency_code",
+		total_amount AS "total_amount.minor_units", currency AS "total_amount.currency_code",
+		created_at, shipping_address, billing_address`
+
+	selectOrderItems = `SELECT i.product_id, COALESCE(i.variant_id, 0) AS variant_id, i.sku, i.quantity,
+		i.product_name, i.variant_options,
+		i.unit_price AS "unit_price.minor_units", o.currency AS "unit_price.currency_code",
+		i.line_total AS "line_total.minor_units", o.currency AS "line_total.currency_code",
+		COALESCE(i.warehouse_id, 0) AS warehouse_id
+		FROM order_items i JOIN orders o ON o.id = i.order_id
+		WHERE i.order_id=$1 ORDER BY i.id`
+)
+
 type OrderRepository struct {
 	DB *sqlx.DB
 }
 
 func (r *OrderRepository) Create(order *model.Order) error {
+	return r.create(order, nil)
+}
+
+// CreateInSaga inserts the order and completes its saga in the same
+// transaction, so a crash can never leave one without the other.
+func (r *OrderRepository) CreateInSaga(order *model.Order, sagaID int) error {
+	return r.create(order, func(tx *sqlx.Tx) error {
+		_, err := tx.Exec(
+			"UPDATE order_sagas SET state=$1, order_id=$2, updated_at=CURRENT_TIMESTAMP WHERE id=$3",
+			model.SagaCompleted, order.ID, sagaID)
+		return err
+	})
+}
+
+// create inserts the order with its items and the order.created outbox event;
+// beforeCommit, if set, runs inside the same transaction.
+func (r *OrderRepository) create(order *model.Order, beforeCommit func(tx *sqlx.Tx) error) error {
 	tx := r.DB.MustBegin()
 	now := time.Now()
 
 	fmt.Println("🚀 Starting transaction to insert order and items...")
 
 	err := tx.QueryRowx(
-		"INSERT INTO orders (user_id, status, created_at) VALUES ($1, $2, $3) RETURNING id",
-		order.UserID, order.Status, now).Scan(&order.ID)
+		"INSERT INTO orders (user_id, status, reservation_id, subtotal, tax, total_amount, currency, created_at, shipping_address, billing_address) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10) RETURNING id",
+		order.UserID, order.Status, order.ReservationID, order.Subtotal.MinorUnits, order.Tax.MinorUnits,
+		order.TotalAmount.MinorUnits, order.TotalAmount.CurrencyCode, now,
+		order.ShippingAddress, order.BillingAddress).Scan(&order.ID)
 	if err != nil {
 		tx.Rollback()
 		fmt.Println("❌ Failed to insert order. Rolling back:", err)
@@ -30,14 +69,35 @@ func (r *OrderRepository) Create(order *model.Order) error {
 	fmt.Println("✅ Order inserted with ID:", order.ID)
 
 	for _, item := range order.Items {
-		_, err := tx.Exec("INSERT INTO order_items (order_id, product_id, quantity) VALUES ($1, $2, $3)",
-			order.ID, item.ProductID, item.Quantity)
+		_, err := tx.Exec("INSERT INTO order_items (order_id, product_id, variant_id, sku, quantity, product_name, variant_options, unit_price, line_total, warehouse_id) VALUES ($1, $2, NULLIF($3, 0), $4, $5, $6, $7, $8, $9, NULLIF($10, 0))",
+			order.ID, item.ProductID, item.VariantID, item.SKU, item.Quantity, item.ProductName, item.VariantOptions,
+			item.UnitPrice.MinorUnits, item.LineTotal.MinorUnits, item.WarehouseID)
 		if err != nil {
 			tx.Rollback()
 			fmt.Println("❌ Failed to insert order item. Rolling back:", err)
 			return err
 		}
-		fmt.Printf("✅ Inserted item: ProductID=%d, Quantity=%d\n", item.ProductID, item.Quantity)
+		fmt.Printf("✅ Inserted item: ProductID=%d, SKU=%s, Quantity=%d, UnitPrice=%d %s\n", item.ProductID, item.SKU, item.Quantity, item.UnitPrice.MinorUnits, item.UnitPrice.CurrencyCode)
+	}
+
+	if err := insertStatusChange(tx, order.ID, "", order.Status, ""); err != nil {
+		tx.Rollback()
+		fmt.Println("❌ Failed to record order status. Rolling back:", err)
+		return err
+	}
+
+	if err := enqueueOrderEvent(tx, model.SubjectOrderCreated, order, ""); err != nil {
+		tx.Rollback()
+		fmt.Println("❌ Failed to write outbox event. Rolling back:", err)
+		return err
+	}
+
+	if beforeCommit != nil {
+		if err := beforeCommit(tx); err != nil {
+			tx.Rollback()
+			fmt.Println("❌ Failed to finish transaction. Rolling back:", err)
+			return err
+		}
 	}
 
 	err = tx.Commit()
@@ -52,13 +112,13 @@ func (r *OrderRepository) Create(order *model.Order) error {
 
 func (r *OrderRepository) ListAll() ([]model.Order, error) {
 	var orders []model.Order
-	err := r.DB.Select(&orders, "SELECT * FROM orders")
+	err := r.DB.Select(&orders, "SELECT "+orderColumns+" FROM orders")
 	if err != nil {
 		return nil, err
 	}
 
 	for i := range orders {
-		r.DB.Select(&orders[i].Items, "SELECT product_id, quantity FROM order_items WHERE order_id=$1", orders[i].ID)
+		r.DB.Select(&orders[i].Items, selectOrderItems, orders[i].ID)
 	}
 
 	return orders, nil
@@ -66,29 +126,100 @@ func (r *OrderRepository) ListAll() ([]model.Order, error) {
 
 func (r *OrderRepository) GetByID(id int) (*model.Order, error) {
 	var order model.Order
-	err := r.DB.Get(&order, "SELECT * FROM orders WHERE id=$1", id)
+	err := r.DB.Get(&order, "SELECT "+orderColumns+" FROM orders WHERE id=$1", id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, model.ErrOrderNotFound
+	}
 	if err != nil {
 		return nil, err
 	}
 
-	err = r.DB.Select(&order.Items, "SELECT product_id, quantity FROM order_items WHERE order_id=$1", id)
+	err = r.DB.Select(&order.Items, selectOrderItems, id)
 	return &order, err
 }
 
-func (r *OrderRepository) UpdateStatus(id int, status string) error {
-	_, err := r.DB.Exec("UPDATE orders SET status=$1 WHERE id=$2", status, id)
+func (r *OrderRepository) Delete(id int) error {
+	_, err := r.DB.Exec("DELETE FROM orders WHERE id=$1", id)
+	return err
+}
+
+// UpdateStatus moves the order from one status to another and records the
+// change in order_status_history. It fails with ErrInvalidTransition if the
+// order is no longer in status from, e.g. after a concurrent update.
+func (r *OrderRepository) UpdateStatus(id int, from, to string) error {
+	tx, err := r.DB.Beginx()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := changeStatus(tx, id, from, to, ""); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// Cancel moves the order to cancelled, records reason in its history and
+// enqueues order.cancelled, all in one transaction. Like UpdateStatus it
+// fails with ErrInvalidTransition if order.Status is stale.
+func (r *OrderRepository) Cancel(order *model.Order, reason string) error {
+	tx, err := r.DB.Beginx()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := changeStatus(tx, order.ID, order.Status, model.StatusCancelled, reason); err != nil {
+		return err
+	}
+
+	cancelled := *order
+	cancelled.Status = model.StatusCancelled
+	if err := enqueueOrderEvent(tx, model.SubjectOrderCancelled, &cancelled, reason); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+	order.Status = model.StatusCancelled
+	return nil
+}
+
+func changeStatus(tx *sqlx.Tx, id int, from, to, reason string) error {
+	res, err := tx.Exec("UPDATE orders SET status=$1 WHERE id=$2 AND status=$3", to, id, from)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return fmt.Errorf("%w: order %d is no longer %s", model.ErrInvalidTransition, id, from)
+	}
+	return insertStatusChange(tx, id, from, to, reason)
+}
+
+func (r *OrderRepository) ListStatusHistory(orderID int) ([]model.StatusChange, error) {
+	var changes []model.StatusChange
+	err := r.DB.Select(&changes,
+		"SELECT order_id, from_status, to_status, reason, changed_at FROM order_status_history WHERE order_id=$1 ORDER BY id",
+		orderID)
+	return changes, err
+}
+
+func insertStatusChange(tx *sqlx.Tx, orderID int, from, to, reason string) error {
+	_, err := tx.Exec("INSERT INTO order_status_history (order_id, from_status, to_status, reason) VALUES ($1, $2, $3, $4)",
+		orderID, from, to, reason)
 	return err
 }
 
 func (r *OrderRepository) ListByUser(userID int) ([]model.Order, error) {
 	var orders []model.Order
-	err := r.DB.Select(&orders, "SELECT * FROM orders WHERE user_id=$1", userID)
+	err := r.DB.Select(&orders, "SELECT "+orderColumns+" FROM orders WHERE user_id=$1", userID)
 	if err != nil {
 		return nil, err
 	}
 
 	for i := range orders {
-		r.DB.Select(&orders[i].Items, "SELECT product_id, quantity FROM order_items WHERE order_id=$1", orders[i].ID)
+		r.DB.Select(&orders[i].Items, selectOrderItems, orders[i].ID)
 	}
 
 	return orders, nil
@@ -0,0 +1,60 @@
+package repository
+
+import (
+	"encoding/json"
+	"order-service/internal/model"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+)
+
+type OutboxRepository struct {
+	DB *sqlx.DB
+}
+
+// ClaimPending returns up to limit undelivered events that are due and hides
+// them from other relays for lease, so each instance works on its own batch.
+func (r *OutboxRepository) ClaimPending(limit int, lease time.Duration) ([]model.OutboxEvent, error) {
+	var events []model.OutboxEvent
+	err := r.DB.Select(&events,
+		`UPDATE outbox SET next_attempt_at = CURRENT_TIMESTAMP + $2 * INTERVAL '1 second'
+		 WHERE id IN (
+		   SELECT id FROM outbox
+		   WHERE delivered_at IS NULL AND next_attempt_at <= CURRENT_TIMESTAMP
+		   ORDER BY id LIMIT $1
+		   FOR UPDATE SKIP LOCKED
+		 )
+		 RETURNING *`,
+		limit, lease.Seconds())
+	return events, err
+}
+
+func (r *OutboxRepository) MarkDelivered(id int64) error {
+	_, err := r.DB.Exec(`UPDATE outbox SET delivered_at = CURRENT_TIMESTAMP, last_error = '' WHERE id = $1`, id)
+	return err
+}
+
+// MarkFailed records a failed delivery and schedules the next attempt.
+func (r *OutboxRepository) MarkFailed(id int64, errMsg string, retryIn time.Duration) error {
+	_, err := r.DB.Exec(
+		`UPDATE outbox
+		 SET attempts = attempts + 1, last_error = $2,
+		     next_attempt_at = CURRENT_TIMESTAMP + $3 * INTERVAL '1 second'
+		 WHERE id = $1`,
+		id, errMsg, retryIn.Seconds())
+	return err
+}
+
+// enqueueOrderEvent writes an order event to the outbox inside tx.
+func enqueueOrderEvent(tx *sqlx.Tx, subject string, order *model.Order, reason string) error {
+	eventID := uuid.NewString()
+	payload, err := json.Marshal(model.OrderEvent{EventID: eventID, Reason: reason, Order: *order})
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(`INSERT INTO outbox (event_id, subject, payload) VALUES ($1, $2, $3)`,
+		eventID, subject, payload)
+	return err
+}
@@ -0,0 +1,93 @@
+package repository
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"order-service/internal/model"
+
+	"github.com/jmoiron/sqlx"
+)
+
+func TestCreateEnqueuesOrderCreated(t *testing.T) {
+	db := newTestDB(t)
+	orders := &OrderRepository{DB: db}
+	outbox := &OutboxRepository{DB: db}
+
+	order := newTestOrder()
+	if err := orders.Create(order); err != nil {
+		t.Fatalf("create: %v", err)
+	}
+
+	events, err := outbox.ClaimPending(10, time.Minute)
+	if err != nil {
+		t.Fatalf("claim: %v", err)
+	}
+	if len(events) != 1 || events[0].Subject != model.SubjectOrderCreated {
+		t.Fatalf("expected one order.created event, got %+v", events)
+	}
+	var payload model.OrderEvent
+	if err := json.Unmarshal(events[0].Payload, &payload); err != nil {
+		t.Fatalf("decode payload: %v", err)
+	}
+	if payload.ID != order.ID || payload.EventID != events[0].EventID {
+		t.Fatalf("payload does not describe order %d as event %s: %+v", order.ID, events[0].EventID, payload)
+	}
+}
+
+func TestCreateRollsBackOutboxEventWithOrder(t *testing.T) {
+	db := newTestDB(t)
+	orders := &OrderRepository{DB: db}
+
+	failed := errors.New("commit refused")
+	err := orders.create(newTestOrder(), func(tx *sqlx.Tx) error { return failed })
+	if !errors.Is(err, failed) {
+		t.Fatalf("expected the beforeCommit error, got %v", err)
+	}
+
+	var count int
+	if err := db.Get(&count, `SELECT (SELECT COUNT(*) FROM orders) + (SELECT COUNT(*) FROM outbox)`); err != nil {
+		t.Fatalf("count: %v", err)
+	}
+	if count != 0 {
+		t.Fatalf("expected neither order nor outbox event after rollback, got %d rows", count)
+	}
+}
+
+func TestOutboxDelivery(t *testing.T) {
+	db := newTestDB(t)
+	outbox := &OutboxRepository{DB: db}
+	if err := (&OrderRepository{DB: db}).Create(newTestOrder()); err != nil {
+		t.Fatalf("create: %v", err)
+	}
+
+	events, err := outbox.ClaimPending(10, time.Minute)
+	if err != nil || len(events) != 1 {
+		t.Fatalf("claim: %v, %+v", err, events)
+	}
+	if again, err := outbox.ClaimPending(10, time.Minute); err != nil || len(again) != 0 {
+		t.Fatalf("expected a claimed event to be leased, got %v, %+v", err, again)
+	}
+
+	id := events[0].ID
+	if err := outbox.MarkFailed(id, "nats: timeout", 0); err != nil {
+		t.Fatalf("mark failed: %v", err)
+	}
+	events, err = outbox.ClaimPending(10, time.Minute)
+	if err != nil || len(events) != 1 {
+		t.Fatalf("expected the failed event to be due again, got %v, %+v", err, events)
+	}
+	if events[0].Attempts != 1 || events[0].LastError != "nats: timeout" {
+		t.Fatalf("failure not recorded: %+v", events[0])
+	}
+
+	if err := outbox.MarkDelivered(id); err != nil {
+		t.Fatalf("mark delivered: %v", err)
+	}
+	db.MustExec(`UPDATE outbox SET next_attempt_at = CURRENT_TIMESTAMP`)
+	if events, err := outbox.ClaimPending(10, time.Minute); err != nil || len(events) != 0 {
+		t.Fatalf("expected a delivered event not to be claimed, got %v, %+v", err, events)
+	}
+}
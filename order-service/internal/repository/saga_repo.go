@@ -0,0 +1,50 @@
+package repository
+
+import (
+	"order-service/internal/model"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+type SagaRepository struct {
+	DB *sqlx.DB
+}
+
+func (r *SagaRepository) Create(s *model.OrderSaga) error {
+	return r.DB.QueryRowx(
+		`INSERT INTO order_sagas (state, payload) VALUES ($1, $2) RETURNING id, created_at, updated_at`,
+		s.State, s.Payload).Scan(&s.ID, &s.CreatedAt, &s.UpdatedAt)
+}
+
+func (r *SagaRepository) UpdateState(id int, state, errMsg string) error {
+	_, err := r.DB.Exec(
+		`UPDATE order_sagas SET state=$1, error=$2, updated_at=CURRENT_TIMESTAMP WHERE id=$3`,
+		state, errMsg, id)
+	return err
+}
+
+// ListStale returns unfinished sagas that have not moved for olderThan.
+func (r *SagaRepository) ListStale(olderThan time.Duration) ([]model.OrderSaga, error) {
+	var sagas []model.OrderSaga
+	err := r.DB.Select(&sagas,
+		`SELECT * FROM order_sagas
+		 WHERE state NOT IN ($1, $2) AND updated_at < CURRENT_TIMESTAMP - $3 * INTERVAL '1 second'
+		 ORDER BY id`,
+		model.SagaCompleted, model.SagaCompensated, olderThan.Seconds())
+	return sagas, err
+}
+
+// Claim bumps updated_at if the saga is still stale and in the same state,
+// so only one order-service instance recovers a given saga.
+func (r *SagaRepository) Claim(s *model.OrderSaga, olderThan time.Duration) (bool, error) {
+	res, err := r.DB.Exec(
+		`UPDATE order_sagas SET updated_at=CURRENT_TIMESTAMP
+		 WHERE id=$1 AND state=$2 AND updated_at < CURRENT_TIMESTAMP - $3 * INTERVAL '1 second'`,
+		s.ID, s.State, olderThan.Seconds())
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	return n == 1, err
+}
@@ -0,0 +1,206 @@
+package usecase
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"order-service/internal/model"
+	"order-service/logger"
+	"time"
+)
+
+type SagaRepo interface {
+	Create(saga *model.OrderSaga) error
+	UpdateState(id int, state, errMsg string) error
+	ListStale(olderThan time.Duration) ([]model.OrderSaga, error)
+	Claim(saga *model.OrderSaga, olderThan time.Duration) (bool, error)
+}
+
+// StockReserver reserves stock under an idempotency key, from warehouses
+// picked for shipping to shipTo if set, and quotes the reserved products'
+// prices. Release must be a no-op for reservations that are already
+// released; for the legacy reservation IDs of orders placed before
+// reservations were tracked it restores items, once.
+type StockReserver interface {
+	Reserve(ctx context.Context, reservationID string, items []model.OrderItem, shipTo *model.Address) ([]model.ProductPrice, error)
+	Release(ctx context.Context, reservationID string, items []model.OrderItem) error
+}
+
+// compensationTimeout bounds the rollback of a saga; compensation runs even
+// when the caller's context is already cancelled.
+const compensationTimeout = 30 * time.Second
+
+// sagaStep is one forward action of the order saga together with the action
+// that undoes it. Compensations must be idempotent: they are also run for the
+// step that failed, whose outcome may be unknown, and retried on recovery.
+type sagaStep struct {
+	name       string
+	done       string // saga state once the step succeeded
+	execute    func(ctx context.Context, saga *model.OrderSaga, order *model.Order) error
+	compensate func(ctx context.Context, saga *model.OrderSaga, order *model.Order) error
+}
+
+// CreateOrderSaga creates an order as a saga: reserve inventory and price the
+// order with the prices quoted for the reservation, then persist the order
+// together with its order.created outbox event, which the outbox relay
+// publishes. The progress is stored in order_sagas so a restarted service can
+// roll back sagas that were left in flight.
+type CreateOrderSaga struct {
+	Sagas   SagaRepo
+	Orders  OrderRepo
+	Stock   StockReserver
+	TaxRate float64 // fraction of the subtotal, e.g. 0.12
+}
+
+func (u *CreateOrderSaga) steps() []sagaStep {
+	return []sagaStep{
+		{
+			name: "reserve_stock",
+			done: model.SagaStockReserved,
+			execute: func(ctx context.Context, saga *model.OrderSaga, order *model.Order) error {
+				prices, err := u.Stock.Reserve(ctx, saga.ReservationID(), order.Items, order.ShippingAddress)
+				if err != nil {
+					return err
+				}
+				return order.ApplyPrices(prices, u.TaxRate)
+			},
+			compensate: func(ctx context.Context, saga *model.OrderSaga, _ *model.Order) error {
+				return u.Stock.Release(ctx, saga.ReservationID(), nil)
+			},
+		},
+		{
+			// Also enqueues order.created in the outbox, in the same transaction.
+			name: "persist_order",
+			done: model.SagaCompleted,
+			execute: func(ctx context.Context, saga *model.OrderSaga, order *model.Order) error {
+				order.ReservationID = saga.ReservationID()
+				if err := u.Orders.CreateInSaga(order, saga.ID); err != nil {
+					return err
+				}
+				saga.OrderID = &order.ID
+				return nil
+			},
+			compensate: func(ctx context.Context, saga *model.OrderSaga, order *model.Order) error {
+				switch {
+				case saga.OrderID != nil:
+					return u.Orders.Delete(*saga.OrderID)
+				case order.ID != 0:
+					return u.Orders.Delete(order.ID)
+				}
+				return nil
+			},
+		},
+	}
+}
+
+// Execute runs the saga for a new order. On failure every step is
+// compensated and the error of the failed step is returned.
+func (u *CreateOrderSaga) Execute(ctx context.Context, order *model.Order) error {
+	payload, err := json.Marshal(order)
+	if err != nil {
+		return err
+	}
+
+	saga := &model.OrderSaga{State: model.SagaStarted, Payload: payload}
+	if err := u.Sagas.Create(saga); err != nil {
+		return fmt.Errorf("start saga: %w", err)
+	}
+
+	return u.run(ctx, saga, order)
+}
+
+// RecoverStale finishes or rolls back sagas that have not progressed for
+// olderThan, typically because the service that ran them was restarted.
+// Sagas whose order was persisted are marked completed; all others are
+// compensated.
+func (u *CreateOrderSaga) RecoverStale(ctx context.Context, olderThan time.Duration) (int, error) {
+	sagas, err := u.Sagas.ListStale(olderThan)
+	if err != nil {
+		return 0, err
+	}
+
+	recovered := 0
+	for i := range sagas {
+		saga := &sagas[i]
+		claimed, err := u.Sagas.Claim(saga, olderThan)
+		if err != nil {
+			return recovered, err
+		}
+		if !claimed {
+			continue
+		}
+
+		if err := u.recover(ctx, saga); err != nil {
+			logger.Log.Errorf("saga %d: recovery failed: %v", saga.ID, err)
+			continue
+		}
+		recovered++
+	}
+	return recovered, nil
+}
+
+func (u *CreateOrderSaga) recover(ctx context.Context, saga *model.OrderSaga) error {
+	var order model.Order
+	if err := json.Unmarshal(saga.Payload, &order); err != nil {
+		return fmt.Errorf("decode payload: %w", err)
+	}
+
+	if saga.OrderID != nil && saga.State != model.SagaCompensating {
+		return u.Sagas.UpdateState(saga.ID, model.SagaCompleted, "")
+	}
+
+	return u.compensate(ctx, saga, &order, len(u.steps())-1, errors.New("recovered in-flight saga"))
+}
+
+// run executes the steps in order, compensating on the first failure.
+func (u *CreateOrderSaga) run(ctx context.Context, saga *model.OrderSaga, order *model.Order) error {
+	steps := u.steps()
+	for i := 0; i < len(steps); i++ {
+		step := steps[i]
+		if err := step.execute(ctx, saga, order); err != nil {
+			stepErr := fmt.Errorf("%s: %w", step.name, err)
+			if cerr := u.compensate(ctx, saga, order, i, stepErr); cerr != nil {
+				logger.Log.Errorf("saga %d: %v", saga.ID, cerr)
+			}
+			return stepErr
+		}
+
+		// Failing to record progress is not fatal: persist_order completes the
+		// saga in its own transaction, and recovery from an older state is
+		// safe because compensations are idempotent.
+		if err := u.Sagas.UpdateState(saga.ID, step.done, ""); err != nil {
+			logger.Log.Errorf("saga %d: record state %s: %v", saga.ID, step.done, err)
+		}
+		saga.State = step.done
+	}
+	return nil
+}
+
+// compensate undoes steps last..0 in reverse order. If a compensation fails
+// the saga stays in the compensating state and is retried by RecoverStale.
+func (u *CreateOrderSaga) compensate(ctx context.Context, saga *model.OrderSaga, order *model.Order, last int, cause error) error {
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), compensationTimeout)
+	defer cancel()
+
+	if err := u.Sagas.UpdateState(saga.ID, model.SagaCompensating, cause.Error()); err != nil {
+		return fmt.Errorf("record compensating state: %w", err)
+	}
+	saga.State = model.SagaCompensating
+
+	steps := u.steps()
+	for i := last; i >= 0; i-- {
+		if steps[i].compensate == nil {
+			continue
+		}
+		if err := steps[i].compensate(ctx, saga, order); err != nil {
+			return fmt.Errorf("compensate %s: %w", steps[i].name, err)
+		}
+	}
+
+	if err := u.Sagas.UpdateState(saga.ID, model.SagaCompensated, cause.Error()); err != nil {
+		return fmt.Errorf("record compensated state: %w", err)
+	}
+	saga.State = model.SagaCompensated
+	return nil
+}
@@ -0,0 +1,298 @@
+package usecase
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"order-service/internal/model"
+)
+
+// fakeSagas keeps sagas in memory and records every state they pass through.
+type fakeSagas struct {
+	sagas  map[int]*model.OrderSaga
+	states map[int][]string
+	nextID int
+	lost   map[int]bool // sagas another instance claims first
+}
+
+func newFakeSagas() *fakeSagas {
+	return &fakeSagas{sagas: map[int]*model.OrderSaga{}, states: map[int][]string{}, lost: map[int]bool{}}
+}
+
+func (f *fakeSagas) Create(saga *model.OrderSaga) error {
+	f.nextID++
+	saga.ID = f.nextID
+	stored := *saga
+	f.sagas[saga.ID] = &stored
+	f.states[saga.ID] = []string{saga.State}
+	return nil
+}
+
+func (f *fakeSagas) UpdateState(id int, state, errMsg string) error {
+	f.sagas[id].State = state
+	f.sagas[id].Error = errMsg
+	f.states[id] = append(f.states[id], state)
+	return nil
+}
+
+func (f *fakeSagas) ListStale(olderThan time.Duration) ([]model.OrderSaga, error) {
+	var stale []model.OrderSaga
+	for id := 1; id <= f.nextID; id++ {
+		if s, ok := f.sagas[id]; ok && !s.Finished() {
+			stale = append(stale, *s)
+		}
+	}
+	return stale, nil
+}
+
+func (f *fakeSagas) Claim(saga *model.OrderSaga, olderThan time.Duration) (bool, error) {
+	return !f.lost[saga.ID], nil
+}
+
+// stale stores a saga that was left in state by a previous run.
+func (f *fakeSagas) stale(t *testing.T, state string, orderID *int, order *model.Order) int {
+	t.Helper()
+	payload, err := json.Marshal(order)
+	if err != nil {
+		t.Fatalf("marshal order: %v", err)
+	}
+	saga := &model.OrderSaga{State: state, OrderID: orderID, Payload: payload}
+	f.Create(saga)
+	return saga.ID
+}
+
+func (f *fakeSagas) state(id int) string {
+	return f.sagas[id].State
+}
+
+// fakeStock quotes every item at 10.00 USD from warehouse 1.
+type fakeStock struct {
+	reserveErr error
+	releaseErr error
+	reserved   []string
+	released   []string
+}
+
+func (f *fakeStock) Reserve(ctx context.Context, reservationID string, items []model.OrderItem, shipTo *model.Address) ([]model.ProductPrice, error) {
+	f.reserved = append(f.reserved, reservationID)
+	if f.reserveErr != nil {
+		return nil, f.reserveErr
+	}
+	var prices []model.ProductPrice
+	for _, item := range items {
+		prices = append(prices, model.ProductPrice{
+			ProductID:   item.ProductID,
+			Name:        "Product",
+			UnitPrice:   model.Money{MinorUnits: 1000, CurrencyCode: "USD"},
+			WarehouseID: 1,
+		})
+	}
+	return prices, nil
+}
+
+func (f *fakeStock) Release(ctx context.Context, reservationID string, items []model.OrderItem) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if f.releaseErr != nil {
+		return f.releaseErr
+	}
+	f.released = append(f.released, reservationID)
+	return nil
+}
+
+type fakeOrders struct {
+	OrderRepo
+	createErr error
+	created   []model.Order
+	deleted   []int
+}
+
+func (f *fakeOrders) CreateInSaga(order *model.Order, sagaID int) error {
+	if f.createErr != nil {
+		return f.createErr
+	}
+	order.ID = 100 + len(f.created)
+	f.created = append(f.created, *order)
+	return nil
+}
+
+func (f *fakeOrders) Delete(id int) error {
+	f.deleted = append(f.deleted, id)
+	return nil
+}
+
+func newTestSaga() (*CreateOrderSaga, *fakeSagas, *fakeStock, *fakeOrders) {
+	sagas, stock, orders := newFakeSagas(), &fakeStock{}, &fakeOrders{}
+	return &CreateOrderSaga{Sagas: sagas, Orders: orders, Stock: stock, TaxRate: 0.1}, sagas, stock, orders
+}
+
+func newSagaOrder() *model.Order {
+	return &model.Order{UserID: 7, Items: []model.OrderItem{{ProductID: 1, Quantity: 2}}}
+}
+
+func equalStates(got, want []string) bool {
+	if len(got) != len(want) {
+		return false
+	}
+	for i := range got {
+		if got[i] != want[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func TestSagaCreatesPricedOrder(t *testing.T) {
+	saga, sagas, stock, orders := newTestSaga()
+	order := newSagaOrder()
+
+	if err := saga.Execute(context.Background(), order); err != nil {
+		t.Fatalf("Execute: %v", err)
+	}
+
+	want := []string{model.SagaStarted, model.SagaStockReserved, model.SagaCompleted}
+	if got := sagas.states[1]; !equalStates(got, want) {
+		t.Errorf("saga states = %v, want %v", got, want)
+	}
+	if len(stock.reserved) != 1 || stock.reserved[0] != "order-saga-1" {
+		t.Errorf("reservations = %v, want [order-saga-1]", stock.reserved)
+	}
+	if len(stock.released) != 0 {
+		t.Errorf("released %v, want nothing", stock.released)
+	}
+	if len(orders.created) != 1 {
+		t.Fatalf("created %d orders, want 1", len(orders.created))
+	}
+	created := orders.created[0]
+	if created.ReservationID != "order-saga-1" {
+		t.Errorf("order reservation = %q, want order-saga-1", created.ReservationID)
+	}
+	if created.TotalAmount.MinorUnits != 2200 {
+		t.Errorf("order total = %d, want 2200", created.TotalAmount.MinorUnits)
+	}
+}
+
+func TestSagaReleasesStockWhenReserveFails(t *testing.T) {
+	saga, sagas, stock, orders := newTestSaga()
+	stock.reserveErr = errors.New("insufficient stock")
+
+	// Compensation must run even when the caller has gone away.
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	err := saga.Execute(ctx, newSagaOrder())
+
+	if !errors.Is(err, stock.reserveErr) {
+		t.Fatalf("Execute = %v, want the reserve error", err)
+	}
+	want := []string{model.SagaStarted, model.SagaCompensating, model.SagaCompensated}
+	if got := sagas.states[1]; !equalStates(got, want) {
+		t.Errorf("saga states = %v, want %v", got, want)
+	}
+	// The reservation may have been made even though the call failed.
+	if len(stock.released) != 1 || stock.released[0] != "order-saga-1" {
+		t.Errorf("released %v, want [order-saga-1]", stock.released)
+	}
+	if len(orders.created) != 0 {
+		t.Errorf("created %d orders, want none", len(orders.created))
+	}
+}
+
+func TestSagaReleasesStockWhenOrderIsNotStored(t *testing.T) {
+	saga, sagas, stock, orders := newTestSaga()
+	orders.createErr = errors.New("connection reset")
+
+	err := saga.Execute(context.Background(), newSagaOrder())
+
+	if !errors.Is(err, orders.createErr) {
+		t.Fatalf("Execute = %v, want the create error", err)
+	}
+	if got := sagas.state(1); got != model.SagaCompensated {
+		t.Errorf("saga state = %s, want %s", got, model.SagaCompensated)
+	}
+	if len(stock.released) != 1 {
+		t.Errorf("released %v, want the saga's reservation", stock.released)
+	}
+	if len(orders.deleted) != 0 {
+		t.Errorf("deleted orders %v, want none", orders.deleted)
+	}
+}
+
+func TestSagaFailedReleaseIsRetriedOnRecovery(t *testing.T) {
+	saga, sagas, stock, orders := newTestSaga()
+	orders.createErr = errors.New("connection reset")
+	stock.releaseErr = errors.New("inventory unavailable")
+
+	if err := saga.Execute(context.Background(), newSagaOrder()); !errors.Is(err, orders.createErr) {
+		t.Fatalf("Execute = %v, want the create error, not the release error", err)
+	}
+	if got := sagas.state(1); got != model.SagaCompensating {
+		t.Fatalf("saga state after failed release = %s, want %s", got, model.SagaCompensating)
+	}
+
+	stock.releaseErr = nil
+	n, err := saga.RecoverStale(context.Background(), time.Minute)
+	if err != nil {
+		t.Fatalf("RecoverStale: %v", err)
+	}
+	if n != 1 {
+		t.Errorf("recovered %d sagas, want 1", n)
+	}
+	if got := sagas.state(1); got != model.SagaCompensated {
+		t.Errorf("saga state after recovery = %s, want %s", got, model.SagaCompensated)
+	}
+	if len(stock.released) != 1 || stock.released[0] != "order-saga-1" {
+		t.Errorf("released %v, want [order-saga-1]", stock.released)
+	}
+}
+
+func TestRecoverStaleSagas(t *testing.T) {
+	saga, sagas, stock, orders := newTestSaga()
+	orderID := 42
+	started := sagas.stale(t, model.SagaStarted, nil, newSagaOrder())
+	reserved := sagas.stale(t, model.SagaStockReserved, nil, newSagaOrder())
+	persisted := sagas.stale(t, model.SagaStockReserved, &orderID, newSagaOrder())
+	compensating := sagas.stale(t, model.SagaCompensating, &orderID, newSagaOrder())
+	claimedElsewhere := sagas.stale(t, model.SagaStarted, nil, newSagaOrder())
+	sagas.lost[claimedElsewhere] = true
+
+	n, err := saga.RecoverStale(context.Background(), time.Minute)
+	if err != nil {
+		t.Fatalf("RecoverStale: %v", err)
+	}
+	if n != 4 {
+		t.Errorf("recovered %d sagas, want 4", n)
+	}
+
+	for _, tt := range []struct {
+		name string
+		id   int
+		want string
+	}{
+		{"started", started, model.SagaCompensated},
+		{"stock reserved", reserved, model.SagaCompensated},
+		{"order persisted", persisted, model.SagaCompleted},
+		{"compensating with order", compensating, model.SagaCompensated},
+		{"claimed by another instance", claimedElsewhere, model.SagaStarted},
+	} {
+		if got := sagas.state(tt.id); got != tt.want {
+			t.Errorf("%s: state = %s, want %s", tt.name, got, tt.want)
+		}
+	}
+
+	wantReleased := map[string]bool{"order-saga-1": true, "order-saga-2": true, "order-saga-4": true}
+	if len(stock.released) != len(wantReleased) {
+		t.Errorf("released %v, want the reservations of sagas 1, 2 and 4", stock.released)
+	}
+	for _, id := range stock.released {
+		if !wantReleased[id] {
+			t.Errorf("released %s, which belongs to a saga that is not rolled back", id)
+		}
+	}
+	if len(orders.deleted) != 1 || orders.deleted[0] != orderID {
+		t.Errorf("deleted orders %v, want [%d]", orders.deleted, orderID)
+	}
+}
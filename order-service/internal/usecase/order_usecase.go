@@ -1,17 +1,59 @@
 package usecase
 
-import "order-service/internal/model"
+import (
+	"context"
+	"fmt"
+	"order-service/internal/model"
+	"order-service/logger"
+)
 
 type OrderRepo interface {
 	Create(order *model.Order) error
+	CreateInSaga(order *model.Order, sagaID int) error
+	Delete(id int) error
 	GetByID(id int) (*model.Order, error)
-	UpdateStatus(id int, status string) error
+	UpdateStatus(id int, from, to string) error
+	Cancel(order *model.Order, reason string) error
+	ListStatusHistory(orderID int) ([]model.StatusChange, error)
 	ListByUser(userID int) ([]model.Order, error)
 	ListAll() ([]model.Order, error)
 }
 
+// AddressBook looks up the address book entries of the user placing an
+// order.
+type AddressBook interface {
+	Get(ctx context.Context, id int) (*model.Address, error)
+}
+
 type OrderUsecase struct {
-	Repo OrderRepo
+	Repo      OrderRepo
+	Stock     StockReserver
+	Addresses AddressBook
+}
+
+// AttachAddresses snapshots the shipping and billing addresses onto a new
+// order. A zero ID means none; without a billing address the order is billed
+// to its shipping address.
+func (u *OrderUsecase) AttachAddresses(ctx context.Context, order *model.Order, shippingID, billingID int) error {
+	if shippingID != 0 {
+		a, err := u.Addresses.Get(ctx, shippingID)
+		if err != nil {
+			return err
+		}
+		order.ShippingAddress = a
+	}
+
+	switch {
+	case billingID == 0 || billingID == shippingID:
+		order.BillingAddress = order.ShippingAddress
+	case billingID != 0:
+		a, err := u.Addresses.Get(ctx, billingID)
+		if err != nil {
+			return err
+		}
+		order.BillingAddress = a
+	}
+	return nil
 }
 
 func (u *OrderUsecase) ListAll() ([]model.Order, error) {
@@ -26,8 +68,61 @@ func (u *OrderUsecase) GetByID(id int) (*model.Order, error) {
 	return u.Repo.GetByID(id)
 }
 
-func (u *OrderUsecase) UpdateStatus(id int, status string) error {
-	return u.Repo.UpdateStatus(id, status)
+// UpdateStatus moves the order to status if the lifecycle allows it.
+// Cancelling goes through Cancel so the order's stock is released.
+func (u *OrderUsecase) UpdateStatus(ctx context.Context, id int, status string) error {
+	if !model.IsValidStatus(status) {
+		return fmt.Errorf("%w: %q", model.ErrUnknownStatus, status)
+	}
+	if status == model.StatusCancelled {
+		_, err := u.Cancel(ctx, id, "")
+		return err
+	}
+
+	order, err := u.Repo.GetByID(id)
+	if err != nil {
+		return err
+	}
+	if !model.CanTransition(order.Status, status) {
+		return fmt.Errorf("%w: %s → %s", model.ErrInvalidTransition, order.Status, status)
+	}
+
+	return u.Repo.UpdateStatus(id, order.Status, status)
+}
+
+// Cancel cancels the order, records reason and returns its items to
+// inventory. The status changes first: cancelled is final, so no concurrent
+// update can revive the order once its stock is back. The same transaction
+// enqueues order.cancelled, and consumer-service releases the stock again on
+// that event, so a release that fails here is retried from the outbox
+// instead of failing the cancellation. Releasing is idempotent.
+func (u *OrderUsecase) Cancel(ctx context.Context, id int, reason string) (*model.Order, error) {
+	order, err := u.Repo.GetByID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	if order.Status != model.StatusCancelled {
+		if !model.CanTransition(order.Status, model.StatusCancelled) {
+			return nil, fmt.Errorf("%w: %s → %s", model.ErrInvalidTransition, order.Status, model.StatusCancelled)
+		}
+		if err := u.Repo.Cancel(order, reason); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := u.Stock.Release(ctx, order.StockReservationID(), order.Items); err != nil {
+		logger.Log.Warnf("cancel order %d: release stock %s, left to order.cancelled: %v",
+			order.ID, order.StockReservationID(), err)
+	}
+	return order, nil
+}
+
+func (u *OrderUsecase) History(id int) ([]model.StatusChange, error) {
+	if _, err := u.Repo.GetByID(id); err != nil {
+		return nil, err
+	}
+	return u.Repo.ListStatusHistory(id)
 }
 
 func (u *OrderUsecase) ListByUser(userID int) ([]model.Order, error) {
@@ -0,0 +1,55 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"order-service/internal/model"
+)
+
+// pendingOrders holds pending order 1.
+type pendingOrders struct {
+	OrderRepo
+	order model.Order
+}
+
+func (r *pendingOrders) GetByID(id int) (*model.Order, error) {
+	if id != r.order.ID {
+		return nil, model.ErrOrderNotFound
+	}
+	order := r.order
+	return &order, nil
+}
+
+func (r *pendingOrders) Cancel(order *model.Order, reason string) error {
+	if r.order.Status != order.Status {
+		return model.ErrInvalidTransition
+	}
+	r.order.Status = model.StatusCancelled
+	order.Status = model.StatusCancelled
+	return nil
+}
+
+func TestCancelLeavesFailedReleaseToTheEvent(t *testing.T) {
+	orders := &pendingOrders{order: model.Order{ID: 1, Status: model.StatusPending, ReservationID: "order-saga-1"}}
+	stock := &fakeStock{releaseErr: errors.New("inventory unavailable")}
+	u := &OrderUsecase{Repo: orders, Stock: stock}
+
+	order, err := u.Cancel(context.Background(), 1, "changed my mind")
+	if err != nil {
+		t.Fatalf("Cancel: %v", err)
+	}
+	if order.Status != model.StatusCancelled || orders.order.Status != model.StatusCancelled {
+		t.Errorf("status = %s, stored %s; want both cancelled", order.Status, orders.order.Status)
+	}
+
+	// Cancelling again releases the stock once inventory is back.
+	stock.releaseErr = nil
+	if _, err := u.Cancel(context.Background(), 1, ""); err != nil {
+		t.Fatalf("Cancel again: %v", err)
+	}
+	if len(stock.released) != 1 || stock.released[0] != "order-saga-1" {
+		t.Errorf("released %v, want [order-saga-1]", stock.released)
+	}
+}
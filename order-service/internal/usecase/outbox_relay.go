@@ -0,0 +1,99 @@
+package usecase
+
+import (
+	"context"
+	"order-service/internal/model"
+	"order-service/logger"
+	"time"
+)
+
+type OutboxRepo interface {
+	ClaimPending(limit int, lease time.Duration) ([]model.OutboxEvent, error)
+	MarkDelivered(id int64) error
+	MarkFailed(id int64, errMsg string, retryIn time.Duration) error
+}
+
+// MessagePublisher delivers one event; eventID travels with the message so
+// consumers can drop redeliveries.
+type MessagePublisher interface {
+	Publish(subject, eventID string, data []byte) error
+}
+
+const (
+	outboxBatchSize  = 100
+	outboxLease      = 30 * time.Second
+	outboxRetryBase  = time.Second
+	outboxRetryLimit = 5 * time.Minute
+)
+
+// OutboxRelay publishes events from the outbox table to NATS. An event is
+// marked delivered only after a successful publish, so delivery is
+// at-least-once: a crash between the two publishes the event again.
+type OutboxRelay struct {
+	Repo         OutboxRepo
+	Publisher    MessagePublisher
+	PollInterval time.Duration
+}
+
+// Run relays pending events until ctx is cancelled.
+func (r *OutboxRelay) Run(ctx context.Context) {
+	ticker := time.NewTicker(r.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		for {
+			n, err := r.RelayPending()
+			if err != nil {
+				logger.Log.Errorf("outbox relay: %v", err)
+			}
+			// A full batch means more events are probably waiting.
+			if err != nil || n < outboxBatchSize {
+				break
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// RelayPending publishes one batch of due events and returns its size.
+func (r *OutboxRelay) RelayPending() (int, error) {
+	events, err := r.Repo.ClaimPending(outboxBatchSize, outboxLease)
+	if err != nil {
+		return 0, err
+	}
+
+	for _, event := range events {
+		if err := r.Publisher.Publish(event.Subject, event.EventID, event.Payload); err != nil {
+			retryIn := outboxRetryDelay(event.Attempts + 1)
+			logger.Log.Warnf("outbox relay: publish %s (%s) failed, attempt %d, retry in %s: %v",
+				event.EventID, event.Subject, event.Attempts+1, retryIn, err)
+			if err := r.Repo.MarkFailed(event.ID, err.Error(), retryIn); err != nil {
+				return len(events), err
+			}
+			continue
+		}
+
+		if err := r.Repo.MarkDelivered(event.ID); err != nil {
+			return len(events), err
+		}
+	}
+	return len(events), nil
+}
+
+// outboxRetryDelay doubles the delay with every failed attempt, up to
+// outboxRetryLimit.
+func outboxRetryDelay(attempts int) time.Duration {
+	delay := outboxRetryBase
+	for i := 1; i < attempts && delay < outboxRetryLimit; i++ {
+		delay *= 2
+	}
+	if delay > outboxRetryLimit {
+		delay = outboxRetryLimit
+	}
+	return delay
+}
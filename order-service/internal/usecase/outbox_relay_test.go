@@ -0,0 +1,121 @@
+package usecase
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"order-service/internal/model"
+)
+
+type failedDelivery struct {
+	id      int64
+	retryIn time.Duration
+}
+
+// fakeOutbox hands out its pending events once and records their outcome.
+type fakeOutbox struct {
+	pending   []model.OutboxEvent
+	delivered []int64
+	failed    []failedDelivery
+}
+
+func (f *fakeOutbox) ClaimPending(limit int, lease time.Duration) ([]model.OutboxEvent, error) {
+	n := min(limit, len(f.pending))
+	events := f.pending[:n]
+	f.pending = f.pending[n:]
+	return events, nil
+}
+
+func (f *fakeOutbox) MarkDelivered(id int64) error {
+	f.delivered = append(f.delivered, id)
+	return nil
+}
+
+func (f *fakeOutbox) MarkFailed(id int64, errMsg string, retryIn time.Duration) error {
+	f.failed = append(f.failed, failedDelivery{id: id, retryIn: retryIn})
+	return nil
+}
+
+// fakePublisher fails the events listed in failing.
+type fakePublisher struct {
+	failing   map[string]bool
+	published []string
+}
+
+func (f *fakePublisher) Publish(subject, eventID string, data []byte) error {
+	if f.failing[eventID] {
+		return errors.New("nats: timeout")
+	}
+	f.published = append(f.published, eventID)
+	return nil
+}
+
+func TestRelayPendingMarksPublishedEventsDelivered(t *testing.T) {
+	outbox := &fakeOutbox{pending: []model.OutboxEvent{
+		{ID: 1, EventID: "e1", Subject: model.SubjectOrderCreated},
+		{ID: 2, EventID: "e2", Subject: model.SubjectOrderCancelled},
+	}}
+	publisher := &fakePublisher{}
+	relay := &OutboxRelay{Repo: outbox, Publisher: publisher}
+
+	n, err := relay.RelayPending()
+	if err != nil {
+		t.Fatalf("RelayPending: %v", err)
+	}
+	if n != 2 {
+		t.Errorf("relayed %d events, want 2", n)
+	}
+	if len(publisher.published) != 2 || publisher.published[0] != "e1" || publisher.published[1] != "e2" {
+		t.Errorf("published %v, want [e1 e2] in order", publisher.published)
+	}
+	if len(outbox.delivered) != 2 || len(outbox.failed) != 0 {
+		t.Errorf("delivered %v, failed %v; want both delivered", outbox.delivered, outbox.failed)
+	}
+}
+
+func TestRelayPendingBacksOffFailedEvents(t *testing.T) {
+	outbox := &fakeOutbox{pending: []model.OutboxEvent{
+		{ID: 1, EventID: "first-failure"},
+		{ID: 2, EventID: "ok"},
+		{ID: 3, EventID: "fourth-failure", Attempts: 3},
+	}}
+	publisher := &fakePublisher{failing: map[string]bool{"first-failure": true, "fourth-failure": true}}
+	relay := &OutboxRelay{Repo: outbox, Publisher: publisher}
+
+	if _, err := relay.RelayPending(); err != nil {
+		t.Fatalf("RelayPending: %v", err)
+	}
+
+	want := []failedDelivery{{id: 1, retryIn: time.Second}, {id: 3, retryIn: 8 * time.Second}}
+	if len(outbox.failed) != len(want) {
+		t.Fatalf("failed %v, want %v", outbox.failed, want)
+	}
+	for i := range want {
+		if outbox.failed[i] != want[i] {
+			t.Errorf("failed[%d] = %+v, want %+v", i, outbox.failed[i], want[i])
+		}
+	}
+	if len(outbox.delivered) != 1 || outbox.delivered[0] != 2 {
+		t.Errorf("delivered %v, want [2]: a failure must not hold up the batch", outbox.delivered)
+	}
+}
+
+func TestOutboxRetryDelay(t *testing.T) {
+	tests := []struct {
+		attempts int
+		want     time.Duration
+	}{
+		{1, time.Second},
+		{2, 2 * time.Second},
+		{5, 16 * time.Second},
+		{9, 256 * time.Second},
+		{10, outboxRetryLimit},
+		{100, outboxRetryLimit},
+	}
+	for _, tt := range tests {
+		if got := outboxRetryDelay(tt.attempts); got != tt.want {
+			t.Errorf("outboxRetryDelay(%d) = %s, want %s", tt.attempts, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,45 @@
+package users
+
+import (
+	"context"
+	"order-service/internal/model"
+	pbUser "order-service/pb/user"
+	"shared/authz"
+
+	"google.golang.org/grpc/metadata"
+)
+
+// AddressClient reads address book entries from UserService.
+type AddressClient struct {
+	client pbUser.UserServiceClient
+}
+
+func NewAddressClient(client pbUser.UserServiceClient) *AddressClient {
+	return &AddressClient{client: client}
+}
+
+// Get returns a snapshot of address id, forwarding the access token of the
+// incoming request in ctx. UserService only returns the caller's own
+// addresses, so another user's ID fails with NotFound.
+func (c *AddressClient) Get(ctx context.Context, id int) (*model.Address, error) {
+	md, _ := metadata.FromIncomingContext(ctx)
+	ctx = metadata.NewOutgoingContext(ctx, metadata.MD{
+		authz.MetadataAuthorization: md.Get(authz.MetadataAuthorization),
+	})
+
+	a, err := c.client.GetAddress(ctx, &pbUser.AddressID{Id: int64(id)})
+	if err != nil {
+		return nil, err
+	}
+	return &model.Address{
+		AddressID:     int(a.Id),
+		RecipientName: a.RecipientName,
+		Line1:         a.Line1,
+		Line2:         a.Line2,
+		City:          a.City,
+		Region:        a.Region,
+		PostalCode:    a.PostalCode,
+		Country:       a.Country,
+		Phone:         a.Phone,
+	}, nil
+}
@@ -0,0 +1,11 @@
+CREATE TABLE IF NOT EXISTS order_sagas (
+  id SERIAL PRIMARY KEY,
+  order_id BIGINT,
+  state VARCHAR(50) NOT NULL,
+  payload JSONB NOT NULL,
+  error TEXT NOT NULL DEFAULT '',
+  created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+  updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_order_sagas_state ON order_sagas (state, updated_at);
@@ -0,0 +1,13 @@
+CREATE TABLE IF NOT EXISTS order_status_history (
+  id SERIAL PRIMARY KEY,
+  order_id BIGINT NOT NULL REFERENCES orders(id) ON DELETE CASCADE,
+  from_status VARCHAR(50) NOT NULL DEFAULT '',
+  to_status VARCHAR(50) NOT NULL,
+  changed_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_order_status_history_order ON order_status_history (order_id, id);
+
+-- Seed the history with the status every existing order currently has
+INSERT INTO order_status_history (order_id, from_status, to_status, changed_at)
+SELECT id, '', COALESCE(status, 'pending'), created_at FROM orders;
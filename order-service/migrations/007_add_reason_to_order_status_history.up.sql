@@ -0,0 +1 @@
+ALTER TABLE order_status_history ADD COLUMN IF NOT EXISTS reason TEXT NOT NULL DEFAULT '';
@@ -0,0 +1,9 @@
+ALTER TABLE orders
+  DROP COLUMN IF EXISTS total_amount,
+  DROP COLUMN IF EXISTS tax,
+  DROP COLUMN IF EXISTS subtotal;
+
+ALTER TABLE order_items
+  DROP COLUMN IF EXISTS line_total,
+  DROP COLUMN IF EXISTS unit_price,
+  DROP COLUMN IF EXISTS product_name;
@@ -0,0 +1,10 @@
+ALTER TABLE order_items
+  ADD COLUMN IF NOT EXISTS product_name VARCHAR(100) NOT NULL DEFAULT '',
+  ADD COLUMN IF NOT EXISTS unit_price NUMERIC(12, 2) NOT NULL DEFAULT 0,
+  ADD COLUMN IF NOT EXISTS line_total NUMERIC(12, 2) NOT NULL DEFAULT 0;
+
+-- Orders placed before this migration have no recorded prices and keep zero totals
+ALTER TABLE orders
+  ADD COLUMN IF NOT EXISTS subtotal NUMERIC(12, 2) NOT NULL DEFAULT 0,
+  ADD COLUMN IF NOT EXISTS tax NUMERIC(12, 2) NOT NULL DEFAULT 0,
+  ADD COLUMN IF NOT EXISTS total_amount NUMERIC(12, 2) NOT NULL DEFAULT 0;
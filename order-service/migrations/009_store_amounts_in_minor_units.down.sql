@@ -0,0 +1,9 @@
+ALTER TABLE orders
+  DROP COLUMN IF EXISTS currency,
+  ALTER COLUMN total_amount TYPE NUMERIC(12, 2) USING total_amount / 100.0,
+  ALTER COLUMN tax TYPE NUMERIC(12, 2) USING tax / 100.0,
+  ALTER COLUMN subtotal TYPE NUMERIC(12, 2) USING subtotal / 100.0;
+
+ALTER TABLE order_items
+  ALTER COLUMN line_total TYPE NUMERIC(12, 2) USING line_total / 100.0,
+  ALTER COLUMN unit_price TYPE NUMERIC(12, 2) USING unit_price / 100.0;
@@ -0,0 +1,10 @@
+-- Amounts are stored exactly, in minor units of the order's currency
+ALTER TABLE order_items
+  ALTER COLUMN unit_price TYPE BIGINT USING ROUND(unit_price * 100)::BIGINT,
+  ALTER COLUMN line_total TYPE BIGINT USING ROUND(line_total * 100)::BIGINT;
+
+ALTER TABLE orders
+  ALTER COLUMN subtotal TYPE BIGINT USING ROUND(subtotal * 100)::BIGINT,
+  ALTER COLUMN tax TYPE BIGINT USING ROUND(tax * 100)::BIGINT,
+  ALTER COLUMN total_amount TYPE BIGINT USING ROUND(total_amount * 100)::BIGINT,
+  ADD COLUMN IF NOT EXISTS currency VARCHAR(3) NOT NULL DEFAULT 'USD';
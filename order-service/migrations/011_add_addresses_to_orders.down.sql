@@ -0,0 +1,3 @@
+ALTER TABLE orders
+  DROP COLUMN IF EXISTS shipping_address,
+  DROP COLUMN IF EXISTS billing_address;
@@ -0,0 +1,5 @@
+-- Snapshots of the user-service addresses the order ships and bills to, so
+-- editing or deleting an address book entry does not rewrite past orders.
+ALTER TABLE orders
+  ADD COLUMN IF NOT EXISTS shipping_address JSONB,
+  ADD COLUMN IF NOT EXISTS billing_address JSONB;
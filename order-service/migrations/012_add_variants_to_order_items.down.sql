@@ -0,0 +1,4 @@
+ALTER TABLE order_items
+  DROP COLUMN IF EXISTS variant_options,
+  DROP COLUMN IF EXISTS sku,
+  DROP COLUMN IF EXISTS variant_id;
@@ -0,0 +1,6 @@
+-- The inventory variant (SKU) each item was ordered in, with its options as
+-- they read when the order was placed. Items of older orders have none.
+ALTER TABLE order_items
+  ADD COLUMN IF NOT EXISTS variant_id BIGINT,
+  ADD COLUMN IF NOT EXISTS sku VARCHAR(64) NOT NULL DEFAULT '',
+  ADD COLUMN IF NOT EXISTS variant_options TEXT NOT NULL DEFAULT '';
@@ -0,0 +1,4 @@
+-- The inventory warehouse each item was reserved from. Items of older
+-- orders have none.
+ALTER TABLE order_items
+  ADD COLUMN IF NOT EXISTS warehouse_id BIGINT;
@@ -19,11 +19,26 @@ import (
 const _ = grpc.SupportPackageIsVersion9
 
 const (
-	InventoryService_CreateProduct_FullMethodName = "/inventory.InventoryService/CreateProduct"
-	InventoryService_GetProduct_FullMethodName    = "/inventory.InventoryService/GetProduct"
-	InventoryService_UpdateProduct_FullMethodName = "/inventory.InventoryService/UpdateProduct"
-	InventoryService_DeleteProduct_FullMethodName = "/inventory.InventoryService/DeleteProduct"
-	InventoryService_ListProducts_FullMethodName  = "/inventory.InventoryService/ListProducts"
+	InventoryService_CreateProduct_FullMethodName   = "/inventory.InventoryService/CreateProduct"
+	InventoryService_GetProduct_FullMethodName      = "/inventory.InventoryService/GetProduct"
+	InventoryService_UpdateProduct_FullMethodName   = "/inventory.InventoryService/UpdateProduct"
+	InventoryService_DeleteProduct_FullMethodName   = "/inventory.InventoryService/DeleteProduct"
+	InventoryService_ListProducts_FullMethodName    = "/inventory.InventoryService/ListProducts"
+	InventoryService_SearchProducts_FullMethodName  = "/inventory.InventoryService/SearchProducts"
+	InventoryService_CreateCategory_FullMethodName  = "/inventory.InventoryService/CreateCategory"
+	InventoryService_ListCategories_FullMethodName  = "/inventory.InventoryService/ListCategories"
+	InventoryService_MoveCategory_FullMethodName    = "/inventory.InventoryService/MoveCategory"
+	InventoryService_CreateVariant_FullMethodName   = "/inventory.InventoryService/CreateVariant"
+	InventoryService_UpdateVariant_FullMethodName   = "/inventory.InventoryService/UpdateVariant"
+	InventoryService_DeleteVariant_FullMethodName   = "/inventory.InventoryService/DeleteVariant"
+	InventoryService_ListVariants_FullMethodName    = "/inventory.InventoryService/ListVariants"
+	InventoryService_CreateWarehouse_FullMethodName = "/inventory.InventoryService/CreateWarehouse"
+	InventoryService_ListWarehouses_FullMethodName  = "/inventory.InventoryService/ListWarehouses"
+	InventoryService_GetStockLevels_FullMethodName  = "/inventory.InventoryService/GetStockLevels"
+	InventoryService_SetStockLevel_FullMethodName   = "/inventory.InventoryService/SetStockLevel"
+	InventoryService_ReserveStock_FullMethodName    = "/inventory.InventoryService/ReserveStock"
+	InventoryService_ReleaseStock_FullMethodName    = "/inventory.InventoryService/ReleaseStock"
+	InventoryService_GetReservation_FullMethodName  = "/inventory.InventoryService/GetReservation"
 )
 
 // InventoryServiceClient is the client API for InventoryService service.
@@ -34,7 +49,28 @@ type InventoryServiceClient interface {
 	GetProduct(ctx context.Context, in *ProductID, opts ...grpc.CallOption) (*Product, error)
 	UpdateProduct(ctx context.Context, in *Product, opts ...grpc.CallOption) (*Product, error)
 	DeleteProduct(ctx context.Context, in *ProductID, opts ...grpc.CallOption) (*Empty, error)
-	ListProducts(ctx context.Context, in *Empty, opts ...grpc.CallOption) (*ProductList, error)
+	ListProducts(ctx context.Context, in *ListProductsRequest, opts ...grpc.CallOption) (*ListProductsResponse, error)
+	SearchProducts(ctx context.Context, in *SearchProductsRequest, opts ...grpc.CallOption) (*SearchProductsResponse, error)
+	CreateCategory(ctx context.Context, in *CreateCategoryRequest, opts ...grpc.CallOption) (*Category, error)
+	ListCategories(ctx context.Context, in *ListCategoriesRequest, opts ...grpc.CallOption) (*CategoryList, error)
+	MoveCategory(ctx context.Context, in *MoveCategoryRequest, opts ...grpc.CallOption) (*Category, error)
+	CreateVariant(ctx context.Context, in *Variant, opts ...grpc.CallOption) (*Variant, error)
+	UpdateVariant(ctx context.Context, in *Variant, opts ...grpc.CallOption) (*Variant, error)
+	DeleteVariant(ctx context.Context, in *VariantID, opts ...grpc.CallOption) (*Empty, error)
+	ListVariants(ctx context.Context, in *ProductID, opts ...grpc.CallOption) (*VariantList, error)
+	CreateWarehouse(ctx context.Context, in *Warehouse, opts ...grpc.CallOption) (*Warehouse, error)
+	ListWarehouses(ctx context.Context, in *Empty, opts ...grpc.CallOption) (*WarehouseList, error)
+	GetStockLevels(ctx context.Context, in *GetStockLevelsRequest, opts ...grpc.CallOption) (*StockLevels, error)
+	// Sets warehouse_id, variant_id and stock; the other fields are output.
+	SetStockLevel(ctx context.Context, in *StockLevel, opts ...grpc.CallOption) (*StockLevel, error)
+	// Fails with FailedPrecondition when stock is short, or when the
+	// reservation was already released; the latter carries a
+	// google.rpc.ErrorInfo with reason "RESERVATION_CLOSED".
+	ReserveStock(ctx context.Context, in *StockRequest, opts ...grpc.CallOption) (*ProductList, error)
+	ReleaseStock(ctx context.Context, in *StockRequest, opts ...grpc.CallOption) (*Empty, error)
+	// Looks a reservation up without changing it; NotFound when the ID was
+	// never reserved or released.
+	GetReservation(ctx context.Context, in *ReservationID, opts ...grpc.CallOption) (*Reservation, error)
 }
 
 type inventoryServiceClient struct {
@@ -85,9 +121,9 @@ func (c *inventoryServiceClient) DeleteProduct(ctx context.Context, in *ProductI
 	return out, nil
 }
 
-func (c *inventoryServiceClient) ListProducts(ctx context.Context, in *Empty, opts ...grpc.CallOption) (*ProductList, error) {
+func (c *inventoryServiceClient) ListProducts(ctx context.Context, in *ListProductsRequest, opts ...grpc.CallOption) (*ListProductsResponse, error) {
 	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
-	out := new(ProductList)
+	out := new(ListProductsResponse)
 	err := c.cc.Invoke(ctx, InventoryService_ListProducts_FullMethodName, in, out, cOpts...)
 	if err != nil {
 		return nil, err
@@ -95,6 +131,156 @@ func (c *inventoryServiceClient) ListProducts(ctx context.Context, in *Empty, op
 	return out, nil
 }
 
+func (c *inventoryServiceClient) SearchProducts(ctx context.Context, in *SearchProductsRequest, opts ...grpc.CallOption) (*SearchProductsResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(SearchProductsResponse)
+	err := c.cc.Invoke(ctx, InventoryService_SearchProducts_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *inventoryServiceClient) CreateCategory(ctx context.Context, in *CreateCategoryRequest, opts ...grpc.CallOption) (*Category, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Category)
+	err := c.cc.Invoke(ctx, InventoryService_CreateCategory_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *inventoryServiceClient) ListCategories(ctx context.Context, in *ListCategoriesRequest, opts ...grpc.CallOption) (*CategoryList, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(CategoryList)
+	err := c.cc.Invoke(ctx, InventoryService_ListCategories_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *inventoryServiceClient) MoveCategory(ctx context.Context, in *MoveCategoryRequest, opts ...grpc.CallOption) (*Category, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Category)
+	err := c.cc.Invoke(ctx, InventoryService_MoveCategory_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *inventoryServiceClient) CreateVariant(ctx context.Context, in *Variant, opts ...grpc.CallOption) (*Variant, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Variant)
+	err := c.cc.Invoke(ctx, InventoryService_CreateVariant_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *inventoryServiceClient) UpdateVariant(ctx context.Context, in *Variant, opts ...grpc.CallOption) (*Variant, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Variant)
+	err := c.cc.Invoke(ctx, InventoryService_UpdateVariant_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *inventoryServiceClient) DeleteVariant(ctx context.Context, in *VariantID, opts ...grpc.CallOption) (*Empty, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Empty)
+	err := c.cc.Invoke(ctx, InventoryService_DeleteVariant_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *inventoryServiceClient) ListVariants(ctx context.Context, in *ProductID, opts ...grpc.CallOption) (*VariantList, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(VariantList)
+	err := c.cc.Invoke(ctx, InventoryService_ListVariants_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *inventoryServiceClient) CreateWarehouse(ctx context.Context, in *Warehouse, opts ...grpc.CallOption) (*Warehouse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Warehouse)
+	err := c.cc.Invoke(ctx, InventoryService_CreateWarehouse_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *inventoryServiceClient) ListWarehouses(ctx context.Context, in *Empty, opts ...grpc.CallOption) (*WarehouseList, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(WarehouseList)
+	err := c.cc.Invoke(ctx, InventoryService_ListWarehouses_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *inventoryServiceClient) GetStockLevels(ctx context.Context, in *GetStockLevelsRequest, opts ...grpc.CallOption) (*StockLevels, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(StockLevels)
+	err := c.cc.Invoke(ctx, InventoryService_GetStockLevels_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *inventoryServiceClient) SetStockLevel(ctx context.Context, in *StockLevel, opts ...grpc.CallOption) (*StockLevel, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(StockLevel)
+	err := c.cc.Invoke(ctx, InventoryService_SetStockLevel_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *inventoryServiceClient) ReserveStock(ctx context.Context, in *StockRequest, opts ...grpc.CallOption) (*ProductList, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ProductList)
+	err := c.cc.Invoke(ctx, InventoryService_ReserveStock_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *inventoryServiceClient) ReleaseStock(ctx context.Context, in *StockRequest, opts ...grpc.CallOption) (*Empty, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Empty)
+	err := c.cc.Invoke(ctx, InventoryService_ReleaseStock_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *inventoryServiceClient) GetReservation(ctx context.Context, in *ReservationID, opts ...grpc.CallOption) (*Reservation, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Reservation)
+	err := c.cc.Invoke(ctx, InventoryService_GetReservation_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // InventoryServiceServer is the server API for InventoryService service.
 // All implementations must embed UnimplementedInventoryServiceServer
 // for forward compatibility.
@@ -103,7 +289,28 @@ type InventoryServiceServer interface {
 	GetProduct(context.Context, *ProductID) (*Product, error)
 	UpdateProduct(context.Context, *Product) (*Product, error)
 	DeleteProduct(context.Context, *ProductID) (*Empty, error)
-	ListProducts(context.Context, *Empty) (*ProductList, error)
+	ListProducts(context.Context, *ListProductsRequest) (*ListProductsResponse, error)
+	SearchProducts(context.Context, *SearchProductsRequest) (*SearchProductsResponse, error)
+	CreateCategory(context.Context, *CreateCategoryRequest) (*Category, error)
+	ListCategories(context.Context, *ListCategoriesRequest) (*CategoryList, error)
+	MoveCategory(context.Context, *MoveCategoryRequest) (*Category, error)
+	CreateVariant(context.Context, *Variant) (*Variant, error)
+	UpdateVariant(context.Context, *Variant) (*Variant, error)
+	DeleteVariant(context.Context, *VariantID) (*Empty, error)
+	ListVariants(context.Context, *ProductID) (*VariantList, error)
+	CreateWarehouse(context.Context, *Warehouse) (*Warehouse, error)
+	ListWarehouses(context.Context, *Empty) (*WarehouseList, error)
+	GetStockLevels(context.Context, *GetStockLevelsRequest) (*StockLevels, error)
+	// Sets warehouse_id, variant_id and stock; the other fields are output.
+	SetStockLevel(context.Context, *StockLevel) (*StockLevel, error)
+	// Fails with FailedPrecondition when stock is short, or when the
+	// reservation was already released; the latter carries a
+	// google.rpc.ErrorInfo with reason "RESERVATION_CLOSED".
+	ReserveStock(context.Context, *StockRequest) (*ProductList, error)
+	ReleaseStock(context.Context, *StockRequest) (*Empty, error)
+	// Looks a reservation up without changing it; NotFound when the ID was
+	// never reserved or released.
+	GetReservation(context.Context, *ReservationID) (*Reservation, error)
 	mustEmbedUnimplementedInventoryServiceServer()
 }
 
@@ -126,9 +333,54 @@ func (UnimplementedInventoryServiceServer) UpdateProduct(context.Context, *Produ
 func (UnimplementedInventoryServiceServer) DeleteProduct(context.Context, *ProductID) (*Empty, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method DeleteProduct not implemented")
 }
-func (UnimplementedInventoryServiceServer) ListProducts(context.Context, *Empty) (*ProductList, error) {
+func (UnimplementedInventoryServiceServer) ListProducts(context.Context, *ListProductsRequest) (*ListProductsResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method ListProducts not implemented")
 }
+func (UnimplementedInventoryServiceServer) SearchProducts(context.Context, *SearchProductsRequest) (*SearchProductsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method SearchProducts not implemented")
+}
+func (UnimplementedInventoryServiceServer) CreateCategory(context.Context, *CreateCategoryRequest) (*Category, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CreateCategory not implemented")
+}
+func (UnimplementedInventoryServiceServer) ListCategories(context.Context, *ListCategoriesRequest) (*CategoryList, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListCategories not implemented")
+}
+func (UnimplementedInventoryServiceServer) MoveCategory(context.Context, *MoveCategoryRequest) (*Category, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method MoveCategory not implemented")
+}
+func (UnimplementedInventoryServiceServer) CreateVariant(context.Context, *Variant) (*Variant, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CreateVariant not implemented")
+}
+func (UnimplementedInventoryServiceServer) UpdateVariant(context.Context, *Variant) (*Variant, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method UpdateVariant not implemented")
+}
+func (UnimplementedInventoryServiceServer) DeleteVariant(context.Context, *VariantID) (*Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method DeleteVariant not implemented")
+}
+func (UnimplementedInventoryServiceServer) ListVariants(context.Context, *ProductID) (*VariantList, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListVariants not implemented")
+}
+func (UnimplementedInventoryServiceServer) CreateWarehouse(context.Context, *Warehouse) (*Warehouse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CreateWarehouse not implemented")
+}
+func (UnimplementedInventoryServiceServer) ListWarehouses(context.Context, *Empty) (*WarehouseList, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListWarehouses not implemented")
+}
+func (UnimplementedInventoryServiceServer) GetStockLevels(context.Context, *GetStockLevelsRequest) (*StockLevels, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetStockLevels not implemented")
+}
+func (UnimplementedInventoryServiceServer) SetStockLevel(context.Context, *StockLevel) (*StockLevel, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method SetStockLevel not implemented")
+}
+func (UnimplementedInventoryServiceServer) ReserveStock(context.Context, *StockRequest) (*ProductList, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ReserveStock not implemented")
+}
+func (UnimplementedInventoryServiceServer) ReleaseStock(context.Context, *StockRequest) (*Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ReleaseStock not implemented")
+}
+func (UnimplementedInventoryServiceServer) GetReservation(context.Context, *ReservationID) (*Reservation, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetReservation not implemented")
+}
 func (UnimplementedInventoryServiceServer) mustEmbedUnimplementedInventoryServiceServer() {}
 func (UnimplementedInventoryServiceServer) testEmbeddedByValue()                          {}
 
@@ -223,7 +475,7 @@ func _InventoryService_DeleteProduct_Handler(srv interface{}, ctx context.Contex
 }
 
 func _InventoryService_ListProducts_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
-	in := new(Empty)
+	in := new(ListProductsRequest)
 	if err := dec(in); err != nil {
 		return nil, err
 	}
@@ -235,7 +487,277 @@ func _InventoryService_ListProducts_Handler(srv interface{}, ctx context.Context
 		FullMethod: InventoryService_ListProducts_FullMethodName,
 	}
 	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
-		return srv.(InventoryServiceServer).ListProducts(ctx, req.(*Empty))
+		return srv.(InventoryServiceServer).ListProducts(ctx, req.(*ListProductsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _InventoryService_SearchProducts_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SearchProductsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(InventoryServiceServer).SearchProducts(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: InventoryService_SearchProducts_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(InventoryServiceServer).SearchProducts(ctx, req.(*SearchProductsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _InventoryService_CreateCategory_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CreateCategoryRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(InventoryServiceServer).CreateCategory(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: InventoryService_CreateCategory_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(InventoryServiceServer).CreateCategory(ctx, req.(*CreateCategoryRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _InventoryService_ListCategories_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListCategoriesRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(InventoryServiceServer).ListCategories(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: InventoryService_ListCategories_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(InventoryServiceServer).ListCategories(ctx, req.(*ListCategoriesRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _InventoryService_MoveCategory_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(MoveCategoryRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(InventoryServiceServer).MoveCategory(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: InventoryService_MoveCategory_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(InventoryServiceServer).MoveCategory(ctx, req.(*MoveCategoryRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _InventoryService_CreateVariant_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(Variant)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(InventoryServiceServer).CreateVariant(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: InventoryService_CreateVariant_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(InventoryServiceServer).CreateVariant(ctx, req.(*Variant))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _InventoryService_UpdateVariant_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(Variant)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(InventoryServiceServer).UpdateVariant(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: InventoryService_UpdateVariant_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(InventoryServiceServer).UpdateVariant(ctx, req.(*Variant))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _InventoryService_DeleteVariant_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(VariantID)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(InventoryServiceServer).DeleteVariant(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: InventoryService_DeleteVariant_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(InventoryServiceServer).DeleteVariant(ctx, req.(*VariantID))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _InventoryService_ListVariants_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ProductID)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(InventoryServiceServer).ListVariants(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: InventoryService_ListVariants_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(InventoryServiceServer).ListVariants(ctx, req.(*ProductID))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _InventoryService_CreateWarehouse_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(Warehouse)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(InventoryServiceServer).CreateWarehouse(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: InventoryService_CreateWarehouse_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(InventoryServiceServer).CreateWarehouse(ctx, req.(*Warehouse))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _InventoryService_ListWarehouses_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(Empty)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(InventoryServiceServer).ListWarehouses(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: InventoryService_ListWarehouses_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(InventoryServiceServer).ListWarehouses(ctx, req.(*Empty))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _InventoryService_GetStockLevels_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetStockLevelsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(InventoryServiceServer).GetStockLevels(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: InventoryService_GetStockLevels_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(InventoryServiceServer).GetStockLevels(ctx, req.(*GetStockLevelsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _InventoryService_SetStockLevel_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(StockLevel)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(InventoryServiceServer).SetStockLevel(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: InventoryService_SetStockLevel_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(InventoryServiceServer).SetStockLevel(ctx, req.(*StockLevel))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _InventoryService_ReserveStock_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(StockRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(InventoryServiceServer).ReserveStock(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: InventoryService_ReserveStock_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(InventoryServiceServer).ReserveStock(ctx, req.(*StockRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _InventoryService_ReleaseStock_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(StockRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(InventoryServiceServer).ReleaseStock(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: InventoryService_ReleaseStock_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(InventoryServiceServer).ReleaseStock(ctx, req.(*StockRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _InventoryService_GetReservation_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ReservationID)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(InventoryServiceServer).GetReservation(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: InventoryService_GetReservation_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(InventoryServiceServer).GetReservation(ctx, req.(*ReservationID))
 	}
 	return interceptor(ctx, in, info, handler)
 }
@@ -267,6 +789,66 @@ var InventoryService_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "ListProducts",
 			Handler:    _InventoryService_ListProducts_Handler,
 		},
+		{
+			MethodName: "SearchProducts",
+			Handler:    _InventoryService_SearchProducts_Handler,
+		},
+		{
+			MethodName: "CreateCategory",
+			Handler:    _InventoryService_CreateCategory_Handler,
+		},
+		{
+			MethodName: "ListCategories",
+			Handler:    _InventoryService_ListCategories_Handler,
+		},
+		{
+			MethodName: "MoveCategory",
+			Handler:    _InventoryService_MoveCategory_Handler,
+		},
+		{
+			MethodName: "CreateVariant",
+			Handler:    _InventoryService_CreateVariant_Handler,
+		},
+		{
+			MethodName: "UpdateVariant",
+			Handler:    _InventoryService_UpdateVariant_Handler,
+		},
+		{
+			MethodName: "DeleteVariant",
+			Handler:    _InventoryService_DeleteVariant_Handler,
+		},
+		{
+			MethodName: "ListVariants",
+			Handler:    _InventoryService_ListVariants_Handler,
+		},
+		{
+			MethodName: "CreateWarehouse",
+			Handler:    _InventoryService_CreateWarehouse_Handler,
+		},
+		{
+			MethodName: "ListWarehouses",
+			Handler:    _InventoryService_ListWarehouses_Handler,
+		},
+		{
+			MethodName: "GetStockLevels",
+			Handler:    _InventoryService_GetStockLevels_Handler,
+		},
+		{
+			MethodName: "SetStockLevel",
+			Handler:    _InventoryService_SetStockLevel_Handler,
+		},
+		{
+			MethodName: "ReserveStock",
+			Handler:    _InventoryService_ReserveStock_Handler,
+		},
+		{
+			MethodName: "ReleaseStock",
+			Handler:    _InventoryService_ReleaseStock_Handler,
+		},
+		{
+			MethodName: "GetReservation",
+			Handler:    _InventoryService_GetReservation_Handler,
+		},
 	},
 	Streams:  []grpc.StreamDesc{},
 	Metadata: "proto/inventory.proto",
@@ -0,0 +1,254 @@
+package authz
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	testKeyID               = "test-key"
+	testMethod              = "/inventory.InventoryService/CreateProduct"
+	testOpenMethod          = "/inventory.InventoryService/GetProduct"
+	testServiceMethod       = "/inventory.InventoryService/ReserveStock"
+	testAuthenticatedMethod = "/order.OrderService/GetOrder"
+	testTokenLifetime       = time.Minute
+)
+
+// issuer signs access tokens the way user-service does and serves the key
+// that verifies them as a JWKS.
+type issuer struct {
+	key ed25519.PrivateKey
+	srv *httptest.Server
+}
+
+func newIssuer(t *testing.T) *issuer {
+	t.Helper()
+	pub, key, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("generate key: %v", err)
+	}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"keys": []map[string]string{{
+				"kty": "OKP",
+				"crv": "Ed25519",
+				"kid": testKeyID,
+				"x":   base64.RawURLEncoding.EncodeToString(pub),
+			}},
+		})
+	}))
+	t.Cleanup(srv.Close)
+	return &issuer{key: key, srv: srv}
+}
+
+func (i *issuer) token(t *testing.T, expiresAt time.Time, permissions ...string) string {
+	t.Helper()
+	token := jwt.NewWithClaims(jwt.SigningMethodEdDSA, accessTokenClaims{
+		RegisteredClaims: jwt.RegisteredClaims{
+			Issuer:    tokenIssuer,
+			Audience:  jwt.ClaimStrings{tokenAudience},
+			Subject:   "42",
+			IssuedAt:  jwt.NewNumericDate(expiresAt.Add(-testTokenLifetime)),
+			ExpiresAt: jwt.NewNumericDate(expiresAt),
+		},
+		Email:       "ada@example.com",
+		Roles:       []string{"staff"},
+		Permissions: permissions,
+	})
+	token.Header["kid"] = testKeyID
+	signed, err := token.SignedString(i.key)
+	if err != nil {
+		t.Fatalf("sign token: %v", err)
+	}
+	return signed
+}
+
+func withToken(token string) context.Context {
+	return metadata.NewIncomingContext(context.Background(),
+		metadata.Pairs(MetadataAuthorization, "Bearer "+token))
+}
+
+func TestInterceptor(t *testing.T) {
+	iss := newIssuer(t)
+	other := newIssuer(t)
+	valid := time.Now().Add(testTokenLifetime)
+
+	tests := []struct {
+		name   string
+		ctx    context.Context
+		method string
+		want   codes.Code
+	}{
+		{
+			name:   "unchecked method without token",
+			ctx:    context.Background(),
+			method: testOpenMethod,
+			want:   codes.OK,
+		},
+		{
+			name:   "missing token",
+			ctx:    context.Background(),
+			method: testMethod,
+			want:   codes.Unauthenticated,
+		},
+		{
+			name: "authorization without bearer scheme",
+			ctx: metadata.NewIncomingContext(context.Background(),
+				metadata.Pairs(MetadataAuthorization, iss.token(t, valid, PermInventoryWrite))),
+			method: testMethod,
+			want:   codes.Unauthenticated,
+		},
+		{
+			name:   "malformed token",
+			ctx:    withToken("not-a-jwt"),
+			method: testMethod,
+			want:   codes.Unauthenticated,
+		},
+		{
+			name:   "expired token",
+			ctx:    withToken(iss.token(t, time.Now().Add(-time.Second), PermInventoryWrite)),
+			method: testMethod,
+			want:   codes.Unauthenticated,
+		},
+		{
+			name:   "token signed with another key",
+			ctx:    withToken(other.token(t, valid, PermInventoryWrite)),
+			method: testMethod,
+			want:   codes.Unauthenticated,
+		},
+		{
+			name:   "missing permission",
+			ctx:    withToken(iss.token(t, valid, PermOrdersManage)),
+			method: testMethod,
+			want:   codes.PermissionDenied,
+		},
+		{
+			name:   "authenticated method without permissions",
+			ctx:    withToken(iss.token(t, valid)),
+			method: testAuthenticatedMethod,
+			want:   codes.OK,
+		},
+		{
+			name:   "authenticated method without token",
+			ctx:    context.Background(),
+			method: testAuthenticatedMethod,
+			want:   codes.Unauthenticated,
+		},
+		{
+			name:   "granted permission",
+			ctx:    withToken(iss.token(t, valid, PermOrdersManage, PermInventoryWrite)),
+			method: testMethod,
+			want:   codes.OK,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			interceptor := &Interceptor{
+				Verifier: NewVerifier(iss.srv.URL),
+				Permissions: map[string]string{
+					testMethod:              PermInventoryWrite,
+					testAuthenticatedMethod: Authenticated,
+				},
+			}
+			called := false
+			handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+				called = true
+				return "ok", nil
+			}
+
+			_, err := interceptor.Unary()(tt.ctx, nil, &grpc.UnaryServerInfo{FullMethod: tt.method}, handler)
+			if got := status.Code(err); got != tt.want {
+				t.Fatalf("code = %v, want %v (err: %v)", got, tt.want, err)
+			}
+			if called != (tt.want == codes.OK) {
+				t.Errorf("handler called = %v, want %v", called, tt.want == codes.OK)
+			}
+		})
+	}
+}
+
+func TestInterceptorPassesPrincipal(t *testing.T) {
+	iss := newIssuer(t)
+	interceptor := &Interceptor{
+		Verifier:    NewVerifier(iss.srv.URL),
+		Permissions: map[string]string{testMethod: PermInventoryWrite},
+	}
+	ctx := withToken(iss.token(t, time.Now().Add(testTokenLifetime), PermInventoryWrite))
+
+	var got *Principal
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		got, _ = PrincipalFrom(ctx)
+		return nil, nil
+	}
+	if _, err := interceptor.Unary()(ctx, nil, &grpc.UnaryServerInfo{FullMethod: testMethod}, handler); err != nil {
+		t.Fatalf("Unary: %v", err)
+	}
+	if got == nil {
+		t.Fatal("handler got no principal")
+	}
+	if got.UserID != 42 || got.Email != "ada@example.com" || !got.HasRole("staff") {
+		t.Errorf("principal = %+v, want user 42 ada@example.com with role staff", got)
+	}
+}
+
+func TestInterceptorServiceToken(t *testing.T) {
+	const serviceToken = "service-secret"
+	iss := newIssuer(t)
+	interceptor := &Interceptor{
+		Verifier: NewVerifier(iss.srv.URL),
+		Permissions: map[string]string{
+			testMethod:        PermInventoryWrite,
+			testServiceMethod: PermStockReserve,
+		},
+		ServiceToken: serviceToken,
+	}
+
+	tests := []struct {
+		name   string
+		ctx    context.Context
+		method string
+		want   codes.Code
+	}{
+		{"service token", withToken(serviceToken), testServiceMethod, codes.OK},
+		{"wrong service token", withToken("other-secret"), testServiceMethod, codes.Unauthenticated},
+		{"service token on a user method", withToken(serviceToken), testMethod, codes.PermissionDenied},
+		{"user token", withToken(iss.token(t, time.Now().Add(testTokenLifetime), PermInventoryWrite)), testServiceMethod, codes.PermissionDenied},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+				if p, _ := PrincipalFrom(ctx); p == nil || p.UserID != 0 {
+					t.Errorf("principal = %+v, want the service principal", p)
+				}
+				return nil, nil
+			}
+			_, err := interceptor.Unary()(tt.ctx, nil, &grpc.UnaryServerInfo{FullMethod: tt.method}, handler)
+			if got := status.Code(err); got != tt.want {
+				t.Fatalf("code = %v, want %v (err: %v)", got, tt.want, err)
+			}
+		})
+	}
+
+	unset := &Interceptor{
+		Verifier:    NewVerifier(iss.srv.URL),
+		Permissions: map[string]string{testServiceMethod: PermStockReserve},
+	}
+	_, err := unset.Unary()(withToken(""), nil, &grpc.UnaryServerInfo{FullMethod: testServiceMethod}, nil)
+	if got := status.Code(err); got != codes.Unauthenticated {
+		t.Errorf("empty token without a service token configured: code = %v, want Unauthenticated", got)
+	}
+}
@@ -0,0 +1,57 @@
+// Package authz checks the access tokens user-service issues and the
+// permissions its roles grant. It is shared by the gateway and every backend
+// service.
+package authz
+
+import "context"
+
+// Permissions granted by user-service roles.
+const (
+	PermInventoryWrite = "inventory.write"
+	PermOrdersManage   = "orders.manage"
+	PermUsersManage    = "users.manage"
+)
+
+// PermStockReserve lets a backend service reserve, release and look up
+// stock. No role grants it; services hold it through the service token.
+const PermStockReserve = "inventory.reserve"
+
+// Principal is the authenticated caller of a request.
+type Principal struct {
+	UserID      int
+	Email       string
+	Roles       []string
+	Permissions []string
+}
+
+func (p *Principal) HasRole(role string) bool {
+	for _, r := range p.Roles {
+		if r == role {
+			return true
+		}
+	}
+	return false
+}
+
+func (p *Principal) HasPermission(permission string) bool {
+	for _, perm := range p.Permissions {
+		if perm == permission {
+			return true
+		}
+	}
+	return false
+}
+
+type principalKey struct{}
+
+// WithPrincipal returns a copy of ctx carrying p, as the interceptor passes
+// it to handlers.
+func WithPrincipal(ctx context.Context, p *Principal) context.Context {
+	return context.WithValue(ctx, principalKey{}, p)
+}
+
+// PrincipalFrom returns the caller the interceptor authenticated, if any.
+func PrincipalFrom(ctx context.Context) (*Principal, bool) {
+	p, ok := ctx.Value(principalKey{}).(*Principal)
+	return p, ok
+}
@@ -0,0 +1,137 @@
+package authz
+
+import (
+	"crypto/ed25519"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const (
+	tokenIssuer   = "user-service"
+	tokenAudience = "ecommerce-platform"
+
+	// keyRefreshInterval limits how often an unknown kid triggers a JWKS fetch.
+	keyRefreshInterval = 30 * time.Second
+)
+
+var ErrInvalidToken = errors.New("invalid access token")
+
+// Verifier checks access tokens issued by user-service against the public
+// keys it publishes as a JWKS. Keys are fetched lazily and refetched when a
+// token names a key that is not known yet, e.g. after a rotation.
+type Verifier struct {
+	jwksURL string
+	client  *http.Client
+
+	mu        sync.Mutex
+	keys      map[string]ed25519.PublicKey
+	fetchedAt time.Time
+}
+
+func NewVerifier(jwksURL string) *Verifier {
+	return &Verifier{
+		jwksURL: jwksURL,
+		client:  &http.Client{Timeout: 5 * time.Second},
+		keys:    map[string]ed25519.PublicKey{},
+	}
+}
+
+type accessTokenClaims struct {
+	jwt.RegisteredClaims
+	Email       string   `json:"email"`
+	Roles       []string `json:"roles"`
+	Permissions []string `json:"permissions"`
+}
+
+func (v *Verifier) Verify(token string) (*Principal, error) {
+	var claims accessTokenClaims
+	_, err := jwt.ParseWithClaims(token, &claims, v.keyFunc,
+		jwt.WithValidMethods([]string{jwt.SigningMethodEdDSA.Alg()}),
+		jwt.WithIssuer(tokenIssuer),
+		jwt.WithAudience(tokenAudience),
+		jwt.WithExpirationRequired(),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidToken, err)
+	}
+
+	userID, err := strconv.Atoi(claims.Subject)
+	if err != nil {
+		return nil, fmt.Errorf("%w: subject %q", ErrInvalidToken, claims.Subject)
+	}
+	return &Principal{
+		UserID:      userID,
+		Email:       claims.Email,
+		Roles:       claims.Roles,
+		Permissions: claims.Permissions,
+	}, nil
+}
+
+func (v *Verifier) keyFunc(token *jwt.Token) (interface{}, error) {
+	kid, _ := token.Header["kid"].(string)
+
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	if key, ok := v.keys[kid]; ok {
+		return key, nil
+	}
+	if time.Since(v.fetchedAt) < keyRefreshInterval {
+		return nil, fmt.Errorf("unknown key %q", kid)
+	}
+	if err := v.fetchKeys(); err != nil {
+		return nil, err
+	}
+	if key, ok := v.keys[kid]; ok {
+		return key, nil
+	}
+	return nil, fmt.Errorf("unknown key %q", kid)
+}
+
+// fetchKeys replaces the key cache with the current JWKS; v.mu must be held.
+func (v *Verifier) fetchKeys() error {
+	v.fetchedAt = time.Now()
+
+	resp, err := v.client.Get(v.jwksURL)
+	if err != nil {
+		return fmt.Errorf("fetch JWKS: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("fetch JWKS: status %d", resp.StatusCode)
+	}
+
+	var set struct {
+		Keys []struct {
+			Kty string `json:"kty"`
+			Crv string `json:"crv"`
+			X   string `json:"x"`
+			Kid string `json:"kid"`
+		} `json:"keys"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&set); err != nil {
+		return fmt.Errorf("decode JWKS: %w", err)
+	}
+
+	keys := map[string]ed25519.PublicKey{}
+	for _, k := range set.Keys {
+		if k.Kty != "OKP" || k.Crv != "Ed25519" {
+			continue
+		}
+		x, err := base64.RawURLEncoding.DecodeString(k.X)
+		if err != nil || len(x) != ed25519.PublicKeySize {
+			continue
+		}
+		keys[k.Kid] = ed25519.PublicKey(x)
+	}
+	v.keys = keys
+	return nil
+}
@@ -0,0 +1,157 @@
+// Package idempotency replays the stored response of a gRPC call when a
+// client retries it with the same idempotency key. It is shared by the
+// services whose create calls accept an Idempotency-Key.
+package idempotency
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/anypb"
+)
+
+// MetadataKey is the gRPC metadata key the gateway copies the HTTP
+// Idempotency-Key header into.
+const MetadataKey = "idempotency-key"
+
+const maxKeyLength = 255
+
+// Record remembers the response to a request sent with an idempotency key.
+// Response is nil while the first request is in progress.
+type Record struct {
+	Key         string    `db:"key"`
+	Method      string    `db:"method"`
+	RequestHash []byte    `db:"request_hash"`
+	Response    []byte    `db:"response"`
+	CreatedAt   time.Time `db:"created_at"`
+	UpdatedAt   time.Time `db:"updated_at"`
+}
+
+type Store interface {
+	Claim(key, method string, requestHash []byte, retention, lease time.Duration) (*Record, bool, error)
+	Complete(key, method string, response []byte) error
+	Release(key, method string) error
+}
+
+// Interceptor makes the listed methods idempotent for callers that send an
+// idempotency key: the first successful response is stored and replayed for
+// every later request with the same key and payload within Retention. A key
+// reused with a different payload is rejected with InvalidArgument, and a
+// duplicate that arrives while the first request is still running gets
+// Aborted. Failed requests release their key so they can be retried.
+type Interceptor struct {
+	Store     Store
+	Methods   map[string]bool // full gRPC method names, e.g. "/order.OrderService/CreateOrder"
+	Retention time.Duration
+	Lease     time.Duration // after this an unfinished request is presumed dead
+	Log       logrus.FieldLogger
+}
+
+func (i *Interceptor) Unary() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		key := keyFromContext(ctx)
+		if key == "" || !i.Methods[info.FullMethod] {
+			return handler(ctx, req)
+		}
+		if len(key) > maxKeyLength {
+			return nil, status.Errorf(codes.InvalidArgument, "idempotency key longer than %d characters", maxKeyLength)
+		}
+
+		msg, ok := req.(proto.Message)
+		if !ok {
+			return handler(ctx, req)
+		}
+		hash, err := requestHash(msg)
+		if err != nil {
+			return nil, status.Errorf(codes.Internal, "hash request: %v", err)
+		}
+
+		record, claimed, err := i.Store.Claim(key, info.FullMethod, hash, i.Retention, i.Lease)
+		if err != nil {
+			return nil, status.Errorf(codes.Unavailable, "check idempotency key: %v", err)
+		}
+		if !claimed {
+			return replay(record, hash)
+		}
+
+		resp, err := handler(ctx, req)
+		if err != nil {
+			if rerr := i.Store.Release(key, info.FullMethod); rerr != nil {
+				i.Log.Errorf("release idempotency key %q: %v", key, rerr)
+			}
+			return nil, err
+		}
+
+		if err := i.complete(key, info.FullMethod, resp); err != nil {
+			// The request succeeded; a retry is only replayed once the lease
+			// runs out and it is executed again.
+			i.Log.Errorf("store response for idempotency key %q: %v", key, err)
+		}
+		return resp, nil
+	}
+}
+
+func (i *Interceptor) complete(key, method string, resp interface{}) error {
+	msg, ok := resp.(proto.Message)
+	if !ok {
+		return nil
+	}
+	stored, err := anypb.New(msg)
+	if err != nil {
+		return err
+	}
+	data, err := proto.Marshal(stored)
+	if err != nil {
+		return err
+	}
+	return i.Store.Complete(key, method, data)
+}
+
+func replay(record *Record, hash []byte) (interface{}, error) {
+	if !bytes.Equal(record.RequestHash, hash) {
+		return nil, status.Error(codes.InvalidArgument, "idempotency key was already used with a different request")
+	}
+	if record.Response == nil {
+		return nil, status.Error(codes.Aborted, "a request with this idempotency key is still in progress")
+	}
+
+	var stored anypb.Any
+	if err := proto.Unmarshal(record.Response, &stored); err != nil {
+		return nil, status.Errorf(codes.Internal, "decode stored response: %v", err)
+	}
+	resp, err := stored.UnmarshalNew()
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "decode stored response: %v", err)
+	}
+	return resp, nil
+}
+
+func keyFromContext(ctx context.Context) string {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return ""
+	}
+	if values := md.Get(MetadataKey); len(values) > 0 {
+		return values[0]
+	}
+	return ""
+}
+
+// requestHash fingerprints the request; deterministic marshalling keeps it
+// stable for equal messages.
+func requestHash(msg proto.Message) ([]byte, error) {
+	data, err := proto.MarshalOptions{Deterministic: true}.Marshal(msg)
+	if err != nil {
+		return nil, err
+	}
+	sum := sha256.Sum256(data)
+	return sum[:], nil
+}
@@ -0,0 +1,183 @@
+package idempotency
+
+import (
+	"context"
+	"errors"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+)
+
+const testMethod = "/order.OrderService/CreateOrder"
+
+// memoryStore claims a key for the first request and hands every later one
+// the stored record, without expiry.
+type memoryStore struct {
+	records map[string]*Record
+}
+
+func newMemoryStore() *memoryStore {
+	return &memoryStore{records: map[string]*Record{}}
+}
+
+func (s *memoryStore) Claim(key, method string, requestHash []byte, retention, lease time.Duration) (*Record, bool, error) {
+	if r, ok := s.records[method+" "+key]; ok {
+		copied := *r
+		return &copied, false, nil
+	}
+	s.records[method+" "+key] = &Record{Key: key, Method: method, RequestHash: requestHash}
+	return nil, true, nil
+}
+
+func (s *memoryStore) Complete(key, method string, response []byte) error {
+	s.records[method+" "+key].Response = response
+	return nil
+}
+
+func (s *memoryStore) Release(key, method string) error {
+	delete(s.records, method+" "+key)
+	return nil
+}
+
+// countingHandler answers every call with a new response.
+type countingHandler struct {
+	calls int
+	err   error
+}
+
+func (h *countingHandler) handle(ctx context.Context, req interface{}) (interface{}, error) {
+	h.calls++
+	if h.err != nil {
+		return nil, h.err
+	}
+	return wrapperspb.Int64(int64(h.calls)), nil
+}
+
+func newInterceptor(store Store) grpc.UnaryServerInterceptor {
+	log := logrus.New()
+	log.Out = io.Discard
+	return (&Interceptor{
+		Store:     store,
+		Methods:   map[string]bool{testMethod: true},
+		Retention: time.Hour,
+		Lease:     time.Minute,
+		Log:       log,
+	}).Unary()
+}
+
+func call(interceptor grpc.UnaryServerInterceptor, key string, req proto.Message, h *countingHandler) (interface{}, error) {
+	ctx := context.Background()
+	if key != "" {
+		ctx = metadata.NewIncomingContext(ctx, metadata.Pairs(MetadataKey, key))
+	}
+	return interceptor(ctx, req, &grpc.UnaryServerInfo{FullMethod: testMethod}, h.handle)
+}
+
+func TestInterceptorReplaysResponse(t *testing.T) {
+	interceptor := newInterceptor(newMemoryStore())
+	h := &countingHandler{}
+
+	first, err := call(interceptor, "key-1", wrapperspb.String("order"), h)
+	if err != nil {
+		t.Fatalf("first call: %v", err)
+	}
+	second, err := call(interceptor, "key-1", wrapperspb.String("order"), h)
+	if err != nil {
+		t.Fatalf("retry: %v", err)
+	}
+
+	if h.calls != 1 {
+		t.Errorf("handler ran %d times, want 1", h.calls)
+	}
+	if !proto.Equal(first.(proto.Message), second.(proto.Message)) {
+		t.Errorf("retry got %v, want the stored %v", second, first)
+	}
+}
+
+func TestInterceptorRejectsKeyReusedForAnotherRequest(t *testing.T) {
+	interceptor := newInterceptor(newMemoryStore())
+	h := &countingHandler{}
+
+	if _, err := call(interceptor, "key-1", wrapperspb.String("order"), h); err != nil {
+		t.Fatalf("first call: %v", err)
+	}
+	_, err := call(interceptor, "key-1", wrapperspb.String("another order"), h)
+
+	if status.Code(err) != codes.InvalidArgument {
+		t.Errorf("code = %v, want InvalidArgument (err: %v)", status.Code(err), err)
+	}
+	if h.calls != 1 {
+		t.Errorf("handler ran %d times, want 1", h.calls)
+	}
+}
+
+func TestInterceptorRejectsDuplicateWhileInProgress(t *testing.T) {
+	store := newMemoryStore()
+	interceptor := newInterceptor(store)
+	h := &countingHandler{}
+
+	var duplicateErr error
+	slow := &countingHandler{}
+	outer := func(ctx context.Context, req interface{}) (interface{}, error) {
+		_, duplicateErr = call(interceptor, "key-1", wrapperspb.String("order"), h)
+		return slow.handle(ctx, req)
+	}
+	ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs(MetadataKey, "key-1"))
+	if _, err := interceptor(ctx, wrapperspb.String("order"), &grpc.UnaryServerInfo{FullMethod: testMethod}, outer); err != nil {
+		t.Fatalf("first call: %v", err)
+	}
+
+	if status.Code(duplicateErr) != codes.Aborted {
+		t.Errorf("duplicate code = %v, want Aborted (err: %v)", status.Code(duplicateErr), duplicateErr)
+	}
+	if h.calls != 0 {
+		t.Errorf("duplicate ran the handler %d times, want 0", h.calls)
+	}
+}
+
+func TestInterceptorReleasesKeyOfFailedRequest(t *testing.T) {
+	interceptor := newInterceptor(newMemoryStore())
+	failing := &countingHandler{err: status.Error(codes.Unavailable, "inventory down")}
+
+	if _, err := call(interceptor, "key-1", wrapperspb.String("order"), failing); status.Code(err) != codes.Unavailable {
+		t.Fatalf("first call: got %v, want the handler's error", err)
+	}
+	h := &countingHandler{}
+	if _, err := call(interceptor, "key-1", wrapperspb.String("order"), h); err != nil {
+		t.Fatalf("retry: %v", err)
+	}
+	if h.calls != 1 {
+		t.Errorf("retry ran the handler %d times, want 1", h.calls)
+	}
+}
+
+func TestInterceptorIgnoresCallsWithoutKey(t *testing.T) {
+	interceptor := newInterceptor(failingStore{})
+	h := &countingHandler{}
+
+	for i := 0; i < 2; i++ {
+		if _, err := call(interceptor, "", wrapperspb.String("order"), h); err != nil {
+			t.Fatalf("call %d: %v", i, err)
+		}
+	}
+	if h.calls != 2 {
+		t.Errorf("handler ran %d times, want 2", h.calls)
+	}
+}
+
+// failingStore fails every call, to show a request never reached it.
+type failingStore struct{}
+
+func (failingStore) Claim(string, string, []byte, time.Duration, time.Duration) (*Record, bool, error) {
+	return nil, false, errors.New("store used")
+}
+func (failingStore) Complete(string, string, []byte) error { return errors.New("store used") }
+func (failingStore) Release(string, string) error          { return errors.New("store used") }
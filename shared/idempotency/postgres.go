@@ -0,0 +1,93 @@
+package idempotency
+
+import (
+	"database/sql"
+	_ "embed"
+	"errors"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/sirupsen/logrus"
+)
+
+// Schema creates the idempotency_keys table. Services that use PostgresStore
+// ship it verbatim as one of their migrations.
+//
+//go:embed schema.sql
+var Schema string
+
+// PostgresStore keeps idempotency keys in the idempotency_keys table.
+type PostgresStore struct {
+	DB *sqlx.DB
+}
+
+// Claim records key for a new request. If the key is already taken it
+// returns false together with the stored record. Keys older than retention,
+// and keys whose request has been in progress for longer than lease, are
+// taken over as if they were new.
+func (s *PostgresStore) Claim(key, method string, requestHash []byte, retention, lease time.Duration) (*Record, bool, error) {
+	var claimed string
+	err := s.DB.Get(&claimed,
+		`INSERT INTO idempotency_keys (key, method, request_hash) VALUES ($1, $2, $3)
+		 ON CONFLICT (key, method) DO UPDATE
+		 SET request_hash = EXCLUDED.request_hash, response = NULL,
+		     created_at = CURRENT_TIMESTAMP, updated_at = CURRENT_TIMESTAMP
+		 WHERE idempotency_keys.created_at < CURRENT_TIMESTAMP - $4 * INTERVAL '1 second'
+		    OR (idempotency_keys.response IS NULL
+		        AND idempotency_keys.updated_at < CURRENT_TIMESTAMP - $5 * INTERVAL '1 second')
+		 RETURNING key`,
+		key, method, requestHash, retention.Seconds(), lease.Seconds())
+	if err == nil {
+		return nil, true, nil
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return nil, false, err
+	}
+
+	var record Record
+	err = s.DB.Get(&record, `SELECT * FROM idempotency_keys WHERE key = $1 AND method = $2`, key, method)
+	if err != nil {
+		return nil, false, err
+	}
+	return &record, false, nil
+}
+
+func (s *PostgresStore) Complete(key, method string, response []byte) error {
+	_, err := s.DB.Exec(
+		`UPDATE idempotency_keys SET response = $3, updated_at = CURRENT_TIMESTAMP WHERE key = $1 AND method = $2`,
+		key, method, response)
+	return err
+}
+
+// Release forgets a key whose request failed, so the client can retry it.
+func (s *PostgresStore) Release(key, method string) error {
+	_, err := s.DB.Exec(`DELETE FROM idempotency_keys WHERE key = $1 AND method = $2`, key, method)
+	return err
+}
+
+func (s *PostgresStore) PurgeExpired(retention time.Duration) (int64, error) {
+	res, err := s.DB.Exec(
+		`DELETE FROM idempotency_keys WHERE created_at < CURRENT_TIMESTAMP - $1 * INTERVAL '1 second'`,
+		retention.Seconds())
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
+
+// PurgeExpired deletes the keys that are older than retention every interval.
+// It does not return.
+func PurgeExpired(store *PostgresStore, retention, interval time.Duration, log logrus.FieldLogger) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		n, err := store.PurgeExpired(retention)
+		if err != nil {
+			log.Errorf("purging idempotency keys failed: %v", err)
+		} else if n > 0 {
+			log.Infof("purged %d expired idempotency keys", n)
+		}
+		<-ticker.C
+	}
+}
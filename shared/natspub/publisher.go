@@ -0,0 +1,84 @@
+// Package natspub publishes events to a JetStream stream. It is shared by
+// the services that emit events; each one names the stream it owns.
+package natspub
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/nats-io/nats.go"
+	"github.com/nats-io/nats.go/jetstream"
+)
+
+// publishTimeout bounds how long Publish waits for JetStream to acknowledge
+// that the message is stored.
+const publishTimeout = 5 * time.Second
+
+type Publisher struct {
+	conn   *nats.Conn
+	js     jetstream.JetStream
+	stream jetstream.StreamConfig
+
+	mu          sync.Mutex
+	streamReady bool
+}
+
+// NewPublisher connects to url and publishes into stream, which it creates
+// or updates on first use. It keeps trying to (re)connect, so the service
+// starts while NATS is down; Publish fails until it is back.
+func NewPublisher(url string, stream jetstream.StreamConfig) *Publisher {
+	nc, err := nats.Connect(url,
+		nats.RetryOnFailedConnect(true),
+		nats.MaxReconnects(-1),
+	)
+	if err != nil {
+		log.Fatalf("Failed to connect to NATS: %v", err)
+	}
+
+	p, err := newPublisher(nc, stream)
+	if err != nil {
+		log.Fatalf("Failed to create JetStream context: %v", err)
+	}
+	return p
+}
+
+func newPublisher(nc *nats.Conn, stream jetstream.StreamConfig) (*Publisher, error) {
+	js, err := jetstream.New(nc)
+	if err != nil {
+		return nil, err
+	}
+	return &Publisher{conn: nc, js: js, stream: stream}, nil
+}
+
+// Publish stores data in JetStream with eventID as the message ID, so a
+// republished event within the stream's duplicate window is stored once.
+// A nil error means the server has persisted the message.
+func (p *Publisher) Publish(subject, eventID string, data []byte) error {
+	ctx, cancel := context.WithTimeout(context.Background(), publishTimeout)
+	defer cancel()
+
+	if err := p.ensureStream(ctx); err != nil {
+		return err
+	}
+
+	_, err := p.js.Publish(ctx, subject, data, jetstream.WithMsgID(eventID))
+	return err
+}
+
+// ensureStream creates the stream on first use rather than at startup, so the
+// service can start while NATS is unreachable.
+func (p *Publisher) ensureStream(ctx context.Context) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.streamReady {
+		return nil
+	}
+	if _, err := p.js.CreateOrUpdateStream(ctx, p.stream); err != nil {
+		return err
+	}
+	p.streamReady = true
+	return nil
+}
@@ -0,0 +1,74 @@
+package natspub
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/nats-io/nats-server/v2/server"
+	"github.com/nats-io/nats.go"
+	"github.com/nats-io/nats.go/jetstream"
+)
+
+var testStream = jetstream.StreamConfig{
+	Name:       "TEST",
+	Subjects:   []string{"test.>"},
+	Storage:    jetstream.MemoryStorage,
+	Duplicates: time.Minute,
+}
+
+func runJetStream(t *testing.T) *nats.Conn {
+	t.Helper()
+
+	srv, err := server.NewServer(&server.Options{
+		Host:      "127.0.0.1",
+		Port:      -1,
+		JetStream: true,
+		StoreDir:  t.TempDir(),
+	})
+	if err != nil {
+		t.Fatalf("start nats-server: %v", err)
+	}
+	go srv.Start()
+	if !srv.ReadyForConnections(5 * time.Second) {
+		t.Fatal("nats-server not ready")
+	}
+	t.Cleanup(srv.Shutdown)
+
+	nc, err := nats.Connect(srv.ClientURL())
+	if err != nil {
+		t.Fatalf("connect: %v", err)
+	}
+	t.Cleanup(nc.Close)
+	return nc
+}
+
+func TestPublishCreatesStreamAndDeduplicates(t *testing.T) {
+	nc := runJetStream(t)
+	p, err := newPublisher(nc, testStream)
+	if err != nil {
+		t.Fatalf("new publisher: %v", err)
+	}
+
+	for _, e := range []struct{ subject, id string }{
+		{"test.created", "event-1"},
+		{"test.created", "event-1"}, // republished by a retry
+		{"test.deleted", "event-2"},
+	} {
+		if err := p.Publish(e.subject, e.id, []byte(`{}`)); err != nil {
+			t.Fatalf("publish %s: %v", e.id, err)
+		}
+	}
+
+	stream, err := p.js.Stream(context.Background(), testStream.Name)
+	if err != nil {
+		t.Fatalf("stream not created: %v", err)
+	}
+	info, err := stream.Info(context.Background())
+	if err != nil {
+		t.Fatalf("stream info: %v", err)
+	}
+	if info.State.Msgs != 2 {
+		t.Errorf("stream holds %d messages, want 2", info.State.Msgs)
+	}
+}
@@ -5,6 +5,9 @@ import (
 	"log"
 	"net"
 	"net/http"
+	"os"
+	"strings"
+	"time"
 
 	"github.com/prometheus/client_golang/prometheus/promhttp"
 	"google.golang.org/grpc"
@@ -12,10 +15,14 @@ import (
 	"user-service/infrastructure"
 
 	"user-service/internal/handler"
+	"user-service/internal/mailer"
+	"user-service/internal/nats"
 	"user-service/internal/repository"
 	"user-service/internal/usecase"
 	"user-service/logger"
 	userpb "user-service/pb/user"
+
+	"shared/authz"
 )
 
 func main() {
@@ -37,20 +44,100 @@ func main() {
 	// 4) Конструируем слои приложения:
 	// 4.1) Репозиторий
 	repo := repository.NewUserRepository(db)
-	// 4.2) Mailer для отправки писем
-	mailer := usecase.NewSMTPMailer()
-	// 4.3) Usecase (repo + mailer)
-	uc := usecase.NewUserUsecase(repo, mailer)
-	// 4.4) Handler (gRPC)
-	userHandler := handler.NewUserHandler(uc)
-
-	// 5) Запускаем gRPC-сервер
+	// 4.2) Mailer для отправки писем (SMTP, папка outbox или память, см. MAIL_BACKEND)
+	mailCfg, err := mailer.ConfigFromEnv()
+	if err != nil {
+		logger.Log.Fatalf("Invalid mail configuration: %v", err)
+	}
+	sender, err := mailer.NewSender(mailCfg)
+	if err != nil {
+		logger.Log.Fatalf("Failed to set up %s mail backend: %v", mailCfg.Backend, err)
+	}
+	mail, err := mailer.New(sender, mailCfg.From, mailCfg.BaseURL, mailCfg.Locale)
+	if err != nil {
+		logger.Log.Fatalf("Failed to load mail templates: %v", err)
+	}
+	// 4.3) Защита от перебора паролей: неудачные входы по аккаунту и IP,
+	// события user.login_failed и user.locked уходят в NATS
+	loginGuard := usecase.NewLoginGuard(
+		repository.NewLoginAttemptRepository(db),
+		nats.NewNatsPublisher(natsURL()),
+		usecase.AccountLoginPolicy,
+		usecase.IPLoginPolicy,
+	)
+	go purgeExpired("failed login records", loginGuard.DeleteStale)
+	// 4.4) Двухфакторная аутентификация (TOTP) и вызовы второго шага входа
+	loginChallenges := repository.NewLoginChallengeRepository(db)
+	totpUC := usecase.NewTOTPUsecase(repo, repository.NewTOTPRepository(db), loginChallenges, totpIssuer())
+	go purgeExpired("login challenges", loginChallenges.DeleteExpired)
+	// 4.5) Usecase (repo + токены сброса пароля и смены email + mailer + защита входа + 2FA)
+	passwordResets := repository.NewPasswordResetRepository(db)
+	emailChanges := repository.NewEmailChangeRepository(db)
+	uc := usecase.NewUserUsecase(repo, passwordResets, emailChanges, mail, loginGuard, totpUC)
+	go purgeExpired("email change requests", emailChanges.DeleteExpired)
+	go purgeExpired("password reset tokens", passwordResets.DeleteExpired)
+	go purgeExpired("pending registrations", repo.DeleteExpiredPending)
+	// 4.6) Токены: подпись access-токенов и ротация refresh-токенов
+	signer, err := usecase.NewJWTSignerFromEnv()
+	if err != nil {
+		logger.Log.Fatalf("Failed to load JWT signing key: %v", err)
+	}
+	refreshTokens := repository.NewRefreshTokenRepository(db)
+	// ADMIN_EMAILS: через запятую; пока нет ни одного admin, эти пользователи получают роль при входе
+	adminEmails := strings.Split(os.Getenv("ADMIN_EMAILS"), ",")
+	roleRepo := repository.NewRoleRepository(db)
+	tokens := usecase.NewTokenUsecase(repo, refreshTokens, roleRepo, signer, adminEmails)
+	go purgeExpired("refresh tokens", refreshTokens.DeleteExpired)
+	// 4.7) Handler (gRPC)
+	// TRUSTED_PROXIES: через запятую адреса, подсети или имена хостов (по умолчанию
+	// api-gateway), которым разрешено передавать адрес клиента в x-client-ip
+	proxies := os.Getenv("TRUSTED_PROXIES")
+	if proxies == "" {
+		proxies = "api-gateway"
+	}
+	userHandler := handler.NewUserHandler(uc, tokens, usecase.NewRoleUsecase(roleRepo), totpUC,
+		usecase.NewAddressUsecase(repository.NewAddressRepository(db)), handler.ParseTrustedProxies(proxies))
+
+	// 4.8) JWKS для проверки токенов в других сервисах
+	go func() {
+		mux := http.NewServeMux()
+		mux.Handle("/.well-known/jwks.json", handler.NewJWKSHandler(tokens))
+		if err := http.ListenAndServe(":8083", mux); err != nil {
+			logger.Log.Error(fmt.Sprintf("JWKS server error: %v", err))
+		}
+	}()
+
+	// 5) Запускаем gRPC-сервер; токены проверяет общий authz-перехватчик
 	lis, err := net.Listen("tcp", ":50051")
 	if err != nil {
 		logger.Log.Error(fmt.Sprintf("Failed to listen: %v", err))
 		return
 	}
-	grpcServer := grpc.NewServer()
+	authzInterceptor := &authz.Interceptor{
+		Verifier: handler.TokenVerifier{Tokens: tokens},
+		Permissions: map[string]string{
+			userpb.UserService_UpdateUser_FullMethodName:        authz.Authenticated,
+			userpb.UserService_DeleteUser_FullMethodName:        authz.Authenticated,
+			userpb.UserService_ChangeEmail_FullMethodName:       authz.Authenticated,
+			userpb.UserService_ChangePassword_FullMethodName:    authz.Authenticated,
+			userpb.UserService_GetTOTPStatus_FullMethodName:     authz.Authenticated,
+			userpb.UserService_EnrollTOTP_FullMethodName:        authz.Authenticated,
+			userpb.UserService_ConfirmTOTP_FullMethodName:       authz.Authenticated,
+			userpb.UserService_DisableTOTP_FullMethodName:       authz.Authenticated,
+			userpb.UserService_ListUserRoles_FullMethodName:     authz.Authenticated,
+			userpb.UserService_AddAddress_FullMethodName:        authz.Authenticated,
+			userpb.UserService_ListAddresses_FullMethodName:     authz.Authenticated,
+			userpb.UserService_GetAddress_FullMethodName:        authz.Authenticated,
+			userpb.UserService_UpdateAddress_FullMethodName:     authz.Authenticated,
+			userpb.UserService_DeleteAddress_FullMethodName:     authz.Authenticated,
+			userpb.UserService_SetDefaultAddress_FullMethodName: authz.Authenticated,
+			userpb.UserService_UnlockUser_FullMethodName:        authz.PermUsersManage,
+			userpb.UserService_AssignRole_FullMethodName:        authz.PermUsersManage,
+			userpb.UserService_RevokeRole_FullMethodName:        authz.PermUsersManage,
+		},
+	}
+
+	grpcServer := grpc.NewServer(grpc.UnaryInterceptor(authzInterceptor.Unary()))
 	userpb.RegisterUserServiceServer(grpcServer, userHandler)
 
 	logger.Log.Info("🚀 User gRPC server running on port 50051")
@@ -58,3 +145,34 @@ func main() {
 		logger.Log.Error(fmt.Sprintf("Failed to serve gRPC server: %v", err))
 	}
 }
+
+// purgeExpired periodically deletes expired tokens of one kind.
+func purgeExpired(kind string, deleteExpired func() (int64, error)) {
+	ticker := time.NewTicker(time.Hour)
+	defer ticker.Stop()
+
+	for {
+		n, err := deleteExpired()
+		if err != nil {
+			logger.Log.Error(fmt.Sprintf("Purging %s failed: %v", kind, err))
+		} else if n > 0 {
+			logger.Log.Info(fmt.Sprintf("Purged %d expired %s", n, kind))
+		}
+		<-ticker.C
+	}
+}
+
+func natsURL() string {
+	if url := os.Getenv("NATS_URL"); url != "" {
+		return url
+	}
+	return "nats://nats:4222"
+}
+
+// totpIssuer names the service in authenticator apps.
+func totpIssuer() string {
+	if issuer := os.Getenv("TOTP_ISSUER"); issuer != "" {
+		return issuer
+	}
+	return "E-Commerce Platform"
+}
@@ -0,0 +1,135 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"user-service/internal/model"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	emptypb "google.golang.org/protobuf/types/known/emptypb"
+
+	pb "user-service/pb/user"
+)
+
+// Address book RPCs act on the caller identified by the access token.
+
+func (h *UserHandler) AddAddress(ctx context.Context, req *pb.Address) (*pb.Address, error) {
+	caller, err := authenticated(ctx)
+	if err != nil {
+		return nil, err
+	}
+	a, err := h.addresses.Add(caller.UserID, fromProtoAddress(req))
+	if err != nil {
+		return nil, addressError("add address", err)
+	}
+	return toProtoAddress(a), nil
+}
+
+func (h *UserHandler) ListAddresses(ctx context.Context, _ *emptypb.Empty) (*pb.AddressList, error) {
+	caller, err := authenticated(ctx)
+	if err != nil {
+		return nil, err
+	}
+	addresses, err := h.addresses.List(caller.UserID)
+	if err != nil {
+		return nil, addressError("list addresses", err)
+	}
+	res := &pb.AddressList{}
+	for _, a := range addresses {
+		res.Addresses = append(res.Addresses, toProtoAddress(a))
+	}
+	return res, nil
+}
+
+// GetAddress is also how order-service snapshots an order's addresses, with
+// the access token of the user placing the order.
+func (h *UserHandler) GetAddress(ctx context.Context, req *pb.AddressID) (*pb.Address, error) {
+	caller, err := authenticated(ctx)
+	if err != nil {
+		return nil, err
+	}
+	a, err := h.addresses.Get(caller.UserID, int(req.Id))
+	if err != nil {
+		return nil, addressError("get address", err)
+	}
+	return toProtoAddress(a), nil
+}
+
+func (h *UserHandler) UpdateAddress(ctx context.Context, req *pb.Address) (*pb.Address, error) {
+	caller, err := authenticated(ctx)
+	if err != nil {
+		return nil, err
+	}
+	a, err := h.addresses.Update(caller.UserID, fromProtoAddress(req))
+	if err != nil {
+		return nil, addressError("update address", err)
+	}
+	return toProtoAddress(a), nil
+}
+
+func (h *UserHandler) DeleteAddress(ctx context.Context, req *pb.AddressID) (*emptypb.Empty, error) {
+	caller, err := authenticated(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if err := h.addresses.Delete(caller.UserID, int(req.Id)); err != nil {
+		return nil, addressError("delete address", err)
+	}
+	return &emptypb.Empty{}, nil
+}
+
+func (h *UserHandler) SetDefaultAddress(ctx context.Context, req *pb.AddressID) (*pb.Address, error) {
+	caller, err := authenticated(ctx)
+	if err != nil {
+		return nil, err
+	}
+	a, err := h.addresses.SetDefault(caller.UserID, int(req.Id))
+	if err != nil {
+		return nil, addressError("set default address", err)
+	}
+	return toProtoAddress(a), nil
+}
+
+func addressError(action string, err error) error {
+	var invalid *model.AddressError
+	switch {
+	case errors.As(err, &invalid):
+		return status.Errorf(codes.InvalidArgument, "%v", err)
+	case errors.Is(err, model.ErrAddressNotFound):
+		return status.Errorf(codes.NotFound, "%s: %v", action, err)
+	default:
+		return status.Errorf(codes.Internal, "%s: %v", action, err)
+	}
+}
+
+func fromProtoAddress(a *pb.Address) model.Address {
+	return model.Address{
+		ID:            int(a.Id),
+		Label:         a.Label,
+		RecipientName: a.RecipientName,
+		Line1:         a.Line1,
+		Line2:         a.Line2,
+		City:          a.City,
+		Region:        a.Region,
+		PostalCode:    a.PostalCode,
+		Country:       a.Country,
+		Phone:         a.Phone,
+	}
+}
+
+func toProtoAddress(a model.Address) *pb.Address {
+	return &pb.Address{
+		Id:            int64(a.ID),
+		Label:         a.Label,
+		RecipientName: a.RecipientName,
+		Line1:         a.Line1,
+		Line2:         a.Line2,
+		City:          a.City,
+		Region:        a.Region,
+		PostalCode:    a.PostalCode,
+		Country:       a.Country,
+		Phone:         a.Phone,
+		IsDefault:     a.IsDefault,
+	}
+}
@@ -0,0 +1,80 @@
+package handler
+
+import (
+	"context"
+	"net"
+	"strings"
+
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+)
+
+// TrustedProxies are the callers, such as the gateway, whose "x-client-ip"
+// metadata is believed. Entries are IP addresses, CIDR ranges or host names;
+// host names are resolved on every check, so a gateway container that comes
+// back with a new address is still recognised.
+type TrustedProxies []string
+
+// ParseTrustedProxies reads a comma-separated list, e.g. "api-gateway,10.0.0.0/8".
+func ParseTrustedProxies(list string) TrustedProxies {
+	var proxies TrustedProxies
+	for _, entry := range strings.Split(list, ",") {
+		if entry = strings.TrimSpace(entry); entry != "" {
+			proxies = append(proxies, entry)
+		}
+	}
+	return proxies
+}
+
+// Trusts tells whether ip belongs to a trusted proxy.
+func (t TrustedProxies) Trusts(ip net.IP) bool {
+	if ip == nil {
+		return false
+	}
+	for _, entry := range t {
+		if _, network, err := net.ParseCIDR(entry); err == nil {
+			if network.Contains(ip) {
+				return true
+			}
+			continue
+		}
+		if proxy := net.ParseIP(entry); proxy != nil {
+			if proxy.Equal(ip) {
+				return true
+			}
+			continue
+		}
+		addrs, err := net.LookupHost(entry)
+		if err != nil {
+			continue
+		}
+		for _, addr := range addrs {
+			if net.ParseIP(addr).Equal(ip) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// clientIP returns the address of the gRPC peer, or the address a trusted
+// proxy forwarded as "x-client-ip" metadata. Anyone else could set the
+// metadata to dodge the per-IP login throttle.
+func (t TrustedProxies) clientIP(ctx context.Context) string {
+	p, ok := peer.FromContext(ctx)
+	if !ok || p.Addr == nil {
+		return ""
+	}
+	host := p.Addr.String()
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+
+	if t.Trusts(net.ParseIP(host)) {
+		md, _ := metadata.FromIncomingContext(ctx)
+		if values := md.Get("x-client-ip"); len(values) > 0 && values[0] != "" {
+			return values[0]
+		}
+	}
+	return host
+}
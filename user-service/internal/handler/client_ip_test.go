@@ -0,0 +1,44 @@
+package handler
+
+import (
+	"context"
+	"net"
+	"testing"
+
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+)
+
+func TestClientIPTrustsOnlyProxies(t *testing.T) {
+	call := func(from string, forwarded string) context.Context {
+		ctx := peer.NewContext(context.Background(), &peer.Peer{
+			Addr: &net.TCPAddr{IP: net.ParseIP(from), Port: 40000},
+		})
+		if forwarded != "" {
+			ctx = metadata.NewIncomingContext(ctx, metadata.Pairs("x-client-ip", forwarded))
+		}
+		return ctx
+	}
+	proxies := ParseTrustedProxies(" 172.18.0.5, 10.1.0.0/16 ,")
+
+	tests := []struct {
+		name, from, forwarded, want string
+	}{
+		{"gateway", "172.18.0.5", "203.0.113.7", "203.0.113.7"},
+		{"proxy range", "10.1.2.3", "203.0.113.7", "203.0.113.7"},
+		{"gateway without metadata", "172.18.0.5", "", "172.18.0.5"},
+		{"direct caller", "198.51.100.9", "203.0.113.7", "198.51.100.9"},
+	}
+	for _, tt := range tests {
+		if got := proxies.clientIP(call(tt.from, tt.forwarded)); got != tt.want {
+			t.Errorf("%s: got %q, want %q", tt.name, got, tt.want)
+		}
+	}
+
+	if got := TrustedProxies(nil).clientIP(call("172.18.0.5", "203.0.113.7")); got != "172.18.0.5" {
+		t.Errorf("no proxies: got %q", got)
+	}
+	if got := proxies.clientIP(context.Background()); got != "" {
+		t.Errorf("no peer: got %q", got)
+	}
+}
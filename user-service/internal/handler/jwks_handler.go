@@ -0,0 +1,22 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"user-service/internal/usecase"
+)
+
+// NewJWKSHandler serves the public keys for access tokens as a JWKS
+// document, so other services can verify tokens without calling
+// user-service for every request.
+func NewJWKSHandler(tokens usecase.TokenUsecase) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Cache-Control", "public, max-age=300")
+		json.NewEncoder(w).Encode(tokens.KeySet())
+	})
+}
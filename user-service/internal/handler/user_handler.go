@@ -2,10 +2,16 @@ package handler
 
 import (
 	"context"
+	"errors"
+	"math"
+	"shared/authz"
+	"strconv"
 	"user-service/internal/model"
 	"user-service/internal/usecase"
 
+	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
 	"google.golang.org/grpc/status"
 	emptypb "google.golang.org/protobuf/types/known/emptypb"
 
@@ -14,11 +20,18 @@ import (
 
 type UserHandler struct {
 	pb.UnimplementedUserServiceServer
-	usecase usecase.UserUsecase
+	usecase   usecase.UserUsecase
+	tokens    usecase.TokenUsecase
+	roles     usecase.RoleUsecase
+	totp      usecase.TOTPUsecase
+	addresses usecase.AddressUsecase
+	proxies   TrustedProxies
 }
 
-func NewUserHandler(uc usecase.UserUsecase) *UserHandler {
-	return &UserHandler{usecase: uc}
+// NewUserHandler takes the client address of logins from "x-client-ip"
+// metadata only when the caller is one of proxies.
+func NewUserHandler(uc usecase.UserUsecase, tokens usecase.TokenUsecase, roles usecase.RoleUsecase, totp usecase.TOTPUsecase, addresses usecase.AddressUsecase, proxies TrustedProxies) *UserHandler {
+	return &UserHandler{usecase: uc, tokens: tokens, roles: roles, totp: totp, addresses: addresses, proxies: proxies}
 }
 
 func (h *UserHandler) RegisterUser(ctx context.Context, req *pb.UserRequest) (*pb.RegisterUserResponse, error) {
@@ -30,12 +43,43 @@ func (h *UserHandler) RegisterUser(ctx context.Context, req *pb.UserRequest) (*p
 }
 
 func (h *UserHandler) AuthenticateUser(ctx context.Context, req *pb.AuthRequest) (*pb.AuthResponse, error) {
-	user, err := h.usecase.Login(req.Email, req.Password)
+	result, err := h.usecase.Login(req.Email, req.Password, h.proxies.clientIP(ctx))
 	if err != nil {
+		return nil, loginError(ctx, err)
+	}
+	if result.ChallengeToken != "" {
 		return &pb.AuthResponse{
-			Success: false,
-			Message: "Invalid credentials",
-		}, status.Errorf(codes.Unauthenticated, "authentication failed")
+			Success:              false,
+			Message:              "Second factor required",
+			SecondFactorRequired: true,
+			ChallengeToken:       result.ChallengeToken,
+		}, nil
+	}
+	return h.loginResponse(result.User)
+}
+
+// VerifySecondFactor completes a login that AuthenticateUser answered with a
+// challenge.
+func (h *UserHandler) VerifySecondFactor(ctx context.Context, req *pb.SecondFactorRequest) (*pb.AuthResponse, error) {
+	user, err := h.usecase.VerifySecondFactor(req.ChallengeToken, req.Code, h.proxies.clientIP(ctx))
+	var locked *model.LoginLockedError
+	switch {
+	case errors.As(err, &locked):
+		return nil, loginError(ctx, err)
+	case errors.Is(err, model.ErrInvalidLoginChallenge):
+		return nil, status.Errorf(codes.Unauthenticated, "%v", err)
+	case errors.Is(err, model.ErrInvalidTOTPCode):
+		return nil, status.Errorf(codes.Unauthenticated, "%v", err)
+	case err != nil:
+		return nil, status.Errorf(codes.Internal, "second factor failed: %v", err)
+	}
+	return h.loginResponse(user)
+}
+
+func (h *UserHandler) loginResponse(user model.User) (*pb.AuthResponse, error) {
+	tokens, err := h.tokens.Issue(user)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to issue tokens: %v", err)
 	}
 
 	return &pb.AuthResponse{
@@ -46,9 +90,54 @@ func (h *UserHandler) AuthenticateUser(ctx context.Context, req *pb.AuthRequest)
 			Email: user.Email,
 			Name:  user.Name,
 		},
+		Tokens: toTokenPair(tokens),
 	}, nil
 }
 
+// loginError maps a failed password check; a lockout also sets the
+// "retry-after" header in seconds.
+func loginError(ctx context.Context, err error) error {
+	var locked *model.LoginLockedError
+	switch {
+	case errors.As(err, &locked):
+		seconds := int64(math.Ceil(locked.RetryAfter.Seconds()))
+		_ = grpc.SetHeader(ctx, metadata.Pairs("retry-after", strconv.FormatInt(seconds, 10)))
+		return status.Errorf(codes.ResourceExhausted, "%v", err)
+	case errors.Is(err, model.ErrInvalidCredentials):
+		return status.Errorf(codes.Unauthenticated, "authentication failed")
+	default:
+		return status.Errorf(codes.Internal, "authentication failed: %v", err)
+	}
+}
+
+func (h *UserHandler) RefreshToken(ctx context.Context, req *pb.RefreshTokenRequest) (*pb.TokenPair, error) {
+	tokens, err := h.tokens.Refresh(req.RefreshToken)
+	if errors.Is(err, model.ErrInvalidRefreshToken) || errors.Is(err, model.ErrRefreshTokenReused) {
+		return nil, status.Errorf(codes.Unauthenticated, "%v", err)
+	}
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to refresh token: %v", err)
+	}
+	return toTokenPair(tokens), nil
+}
+
+func (h *UserHandler) Logout(ctx context.Context, req *pb.LogoutRequest) (*emptypb.Empty, error) {
+	if err := h.tokens.Logout(req.RefreshToken, req.AllSessions); err != nil {
+		return nil, status.Errorf(codes.Internal, "logout failed: %v", err)
+	}
+	return &emptypb.Empty{}, nil
+}
+
+func toTokenPair(t model.TokenPair) *pb.TokenPair {
+	return &pb.TokenPair{
+		AccessToken:      t.AccessToken,
+		TokenType:        "Bearer",
+		ExpiresIn:        int64(t.ExpiresIn.Seconds()),
+		RefreshToken:     t.RefreshToken,
+		RefreshExpiresIn: int64(t.RefreshExpiresIn.Seconds()),
+	}
+}
+
 func (h *UserHandler) GetUserProfile(ctx context.Context, req *pb.UserID) (*pb.UserProfile, error) {
 	user, err := h.usecase.GetProfile(int(req.Id))
 	if err != nil {
@@ -62,34 +151,242 @@ func (h *UserHandler) GetUserProfile(ctx context.Context, req *pb.UserID) (*pb.U
 	}, nil
 }
 
+// UpdateUser and DeleteUser are open to the user themselves and to callers
+// with users.manage.
 func (h *UserHandler) UpdateUser(ctx context.Context, req *pb.UpdateUserRequest) (*pb.UserResponse, error) {
-	user := model.User{
-		ID:       int(req.Id),
-		Email:    req.Email,
-		Name:     req.Name,
-		Password: req.Password, // optional: ignore if not updating password
+	if err := selfOr(ctx, req.Id, authz.PermUsersManage); err != nil {
+		return nil, err
 	}
-
-	if err := h.usecase.UpdateUser(user); err != nil {
+	user, err := h.usecase.UpdateUser(model.User{ID: int(req.Id), Name: req.Name})
+	if errors.Is(err, model.ErrUserNotFound) {
+		return nil, status.Errorf(codes.NotFound, "%v", err)
+	}
+	if err != nil {
 		return nil, status.Errorf(codes.Internal, "failed to update user: %v", err)
 	}
+	return toUserResponse(user), nil
+}
+
+func (h *UserHandler) ChangeEmail(ctx context.Context, req *pb.ChangeEmailRequest) (*emptypb.Empty, error) {
+	caller, err := authenticated(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if err := h.usecase.ChangeEmail(caller.UserID, req.NewEmail); err != nil {
+		return nil, credentialError("change email", err)
+	}
+	return &emptypb.Empty{}, nil
+}
+
+func (h *UserHandler) ConfirmEmailChange(ctx context.Context, req *pb.ConfirmEmailChangeRequest) (*pb.UserResponse, error) {
+	user, err := h.usecase.ConfirmEmailChange(req.Token)
+	if err != nil {
+		return nil, credentialError("confirm email change", err)
+	}
+	return toUserResponse(user), nil
+}
 
+func (h *UserHandler) ChangePassword(ctx context.Context, req *pb.ChangePasswordRequest) (*emptypb.Empty, error) {
+	caller, err := authenticated(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if err := h.usecase.ChangePassword(caller.UserID, req.CurrentPassword, req.NewPassword); err != nil {
+		return nil, credentialError("change password", err)
+	}
+	return &emptypb.Empty{}, nil
+}
+
+func toUserResponse(user model.User) *pb.UserResponse {
 	return &pb.UserResponse{
 		Id:    int64(user.ID),
 		Email: user.Email,
 		Name:  user.Name,
-	}, nil
+	}
 }
 
 func (h *UserHandler) DeleteUser(ctx context.Context, req *pb.UserID) (*emptypb.Empty, error) {
+	if err := selfOr(ctx, req.Id, authz.PermUsersManage); err != nil {
+		return nil, err
+	}
 	if err := h.usecase.DeleteUser(int(req.Id)); err != nil {
 		return nil, status.Errorf(codes.Internal, "failed to delete user: %v", err)
 	}
 	return &emptypb.Empty{}, nil
 }
 func (h *UserHandler) VerifyUser(ctx context.Context, req *pb.VerifyRequest) (*pb.VerifyResponse, error) {
-	if err := h.usecase.Verify(req.Token); err != nil {
+	err := h.usecase.Verify(req.Token)
+	switch {
+	case errors.Is(err, model.ErrInvalidVerificationToken):
 		return nil, status.Errorf(codes.InvalidArgument, "verify failed: %v", err)
+	case errors.Is(err, model.ErrEmailTaken):
+		return nil, status.Errorf(codes.AlreadyExists, "verify failed: %v", err)
+	case err != nil:
+		return nil, status.Errorf(codes.Internal, "verify failed: %v", err)
 	}
 	return &pb.VerifyResponse{Success: true}, nil
 }
+
+func (h *UserHandler) ResendVerification(ctx context.Context, req *pb.ResendVerificationRequest) (*emptypb.Empty, error) {
+	if err := h.usecase.ResendVerification(req.Email); err != nil {
+		return nil, status.Errorf(codes.Internal, "resend verification failed: %v", err)
+	}
+	return &emptypb.Empty{}, nil
+}
+
+func (h *UserHandler) RequestPasswordReset(ctx context.Context, req *pb.PasswordResetRequest) (*emptypb.Empty, error) {
+	if err := h.usecase.RequestPasswordReset(req.Email); err != nil {
+		return nil, status.Errorf(codes.Internal, "password reset failed: %v", err)
+	}
+	return &emptypb.Empty{}, nil
+}
+
+func (h *UserHandler) ResetPassword(ctx context.Context, req *pb.ResetPasswordRequest) (*emptypb.Empty, error) {
+	err := h.usecase.ResetPassword(req.Token, req.NewPassword)
+	if errors.Is(err, model.ErrInvalidResetToken) || errors.Is(err, model.ErrPasswordTooShort) {
+		return nil, status.Errorf(codes.InvalidArgument, "%v", err)
+	}
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "password reset failed: %v", err)
+	}
+	return &emptypb.Empty{}, nil
+}
+
+func (h *UserHandler) GetTOTPStatus(ctx context.Context, _ *emptypb.Empty) (*pb.TOTPStatus, error) {
+	caller, err := authenticated(ctx)
+	if err != nil {
+		return nil, err
+	}
+	st, err := h.totp.Status(caller.UserID)
+	if err != nil {
+		return nil, totpError("get two-factor status", err)
+	}
+	return &pb.TOTPStatus{Enabled: st.Enabled, RecoveryCodesLeft: int32(st.RecoveryCodesLeft)}, nil
+}
+
+func (h *UserHandler) EnrollTOTP(ctx context.Context, _ *emptypb.Empty) (*pb.TOTPEnrollment, error) {
+	caller, err := authenticated(ctx)
+	if err != nil {
+		return nil, err
+	}
+	enrollment, err := h.totp.Enroll(caller.UserID)
+	if err != nil {
+		return nil, totpError("enroll", err)
+	}
+	return &pb.TOTPEnrollment{Secret: enrollment.Secret, OtpauthUri: enrollment.URI}, nil
+}
+
+func (h *UserHandler) ConfirmTOTP(ctx context.Context, req *pb.TOTPCode) (*pb.RecoveryCodes, error) {
+	caller, err := authenticated(ctx)
+	if err != nil {
+		return nil, err
+	}
+	recoveryCodes, err := h.totp.Confirm(caller.UserID, req.Code)
+	if err != nil {
+		return nil, totpError("confirm", err)
+	}
+	return &pb.RecoveryCodes{Codes: recoveryCodes}, nil
+}
+
+func (h *UserHandler) DisableTOTP(ctx context.Context, req *pb.TOTPCode) (*emptypb.Empty, error) {
+	caller, err := authenticated(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if err := h.totp.Disable(caller.UserID, req.Code); err != nil {
+		return nil, totpError("disable", err)
+	}
+	return &emptypb.Empty{}, nil
+}
+
+// UnlockUser needs the users.manage permission.
+func (h *UserHandler) UnlockUser(ctx context.Context, req *pb.UserID) (*emptypb.Empty, error) {
+	err := h.usecase.UnlockUser(int(req.Id))
+	if errors.Is(err, model.ErrUserNotFound) {
+		return nil, status.Errorf(codes.NotFound, "%v", err)
+	}
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "unlock user failed: %v", err)
+	}
+	return &emptypb.Empty{}, nil
+}
+
+// AssignRole and RevokeRole need the users.manage permission.
+func (h *UserHandler) AssignRole(ctx context.Context, req *pb.RoleAssignment) (*pb.UserRoles, error) {
+	roles, err := h.roles.AssignRole(int(req.UserId), req.Role)
+	if err != nil {
+		return nil, roleError("assign role", err)
+	}
+	return toUserRoles(req.UserId, roles), nil
+}
+
+func (h *UserHandler) RevokeRole(ctx context.Context, req *pb.RoleAssignment) (*pb.UserRoles, error) {
+	roles, err := h.roles.RevokeRole(int(req.UserId), req.Role)
+	if err != nil {
+		return nil, roleError("revoke role", err)
+	}
+	return toUserRoles(req.UserId, roles), nil
+}
+
+// ListUserRoles is open to the user themselves and to callers with
+// users.manage.
+func (h *UserHandler) ListUserRoles(ctx context.Context, req *pb.UserID) (*pb.UserRoles, error) {
+	if err := selfOr(ctx, req.Id, authz.PermUsersManage); err != nil {
+		return nil, err
+	}
+	roles, err := h.roles.ListUserRoles(int(req.Id))
+	if err != nil {
+		return nil, roleError("list roles", err)
+	}
+	return toUserRoles(req.Id, roles), nil
+}
+
+func credentialError(action string, err error) error {
+	switch {
+	case errors.Is(err, model.ErrInvalidEmail), errors.Is(err, model.ErrInvalidEmailChangeToken),
+		errors.Is(err, model.ErrPasswordTooShort):
+		return status.Errorf(codes.InvalidArgument, "%s: %v", action, err)
+	case errors.Is(err, model.ErrWrongPassword):
+		return status.Errorf(codes.PermissionDenied, "%s: %v", action, err)
+	case errors.Is(err, model.ErrEmailTaken):
+		return status.Errorf(codes.AlreadyExists, "%s: %v", action, err)
+	case errors.Is(err, model.ErrUserNotFound):
+		return status.Errorf(codes.NotFound, "%s: %v", action, err)
+	default:
+		return status.Errorf(codes.Internal, "%s: %v", action, err)
+	}
+}
+
+func totpError(action string, err error) error {
+	switch {
+	case errors.Is(err, model.ErrInvalidTOTPCode):
+		return status.Errorf(codes.InvalidArgument, "%s: %v", action, err)
+	case errors.Is(err, model.ErrTOTPAlreadyEnabled), errors.Is(err, model.ErrTOTPNotEnabled), errors.Is(err, model.ErrTOTPNotEnrolled):
+		return status.Errorf(codes.FailedPrecondition, "%s: %v", action, err)
+	case errors.Is(err, model.ErrUserNotFound):
+		return status.Errorf(codes.NotFound, "%s: %v", action, err)
+	default:
+		return status.Errorf(codes.Internal, "%s: %v", action, err)
+	}
+}
+
+func roleError(action string, err error) error {
+	switch {
+	case errors.Is(err, model.ErrRoleNotFound), errors.Is(err, model.ErrUserNotFound):
+		return status.Errorf(codes.NotFound, "%s: %v", action, err)
+	default:
+		return status.Errorf(codes.Internal, "%s: %v", action, err)
+	}
+}
+
+func toUserRoles(userID int64, roles []model.Role) *pb.UserRoles {
+	res := &pb.UserRoles{UserId: userID}
+	for _, r := range roles {
+		res.Roles = append(res.Roles, &pb.Role{
+			Name:        r.Name,
+			Description: r.Description,
+			Permissions: r.Permissions,
+		})
+	}
+	return res
+}
@@ -0,0 +1,71 @@
+package handler
+
+import (
+	"context"
+	"shared/authz"
+	"testing"
+	"user-service/internal/model"
+	"user-service/internal/usecase"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	pb "user-service/pb/user"
+)
+
+type fakeUsers struct {
+	usecase.UserUsecase
+	updated, deleted []int
+}
+
+func (f *fakeUsers) UpdateUser(user model.User) (model.User, error) {
+	f.updated = append(f.updated, user.ID)
+	return user, nil
+}
+
+func (f *fakeUsers) DeleteUser(id int) error {
+	f.deleted = append(f.deleted, id)
+	return nil
+}
+
+func TestUpdateAndDeleteUserNeedSelfOrUsersManage(t *testing.T) {
+	tests := []struct {
+		name string
+		as   *authz.Principal
+		user int64
+		want codes.Code
+	}{
+		{"self", &authz.Principal{UserID: 7}, 7, codes.OK},
+		{"another user", &authz.Principal{UserID: 7}, 8, codes.PermissionDenied},
+		{"another user as admin", &authz.Principal{UserID: 1, Permissions: []string{authz.PermUsersManage}}, 8, codes.OK},
+		{"no principal", nil, 7, codes.Unauthenticated},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			if tt.as != nil {
+				ctx = authz.WithPrincipal(ctx, tt.as)
+			}
+			users := &fakeUsers{}
+			h := NewUserHandler(users, nil, nil, nil, nil, nil)
+
+			_, err := h.UpdateUser(ctx, &pb.UpdateUserRequest{Id: tt.user, Name: "Ada"})
+			if got := status.Code(err); got != tt.want {
+				t.Errorf("UpdateUser: code = %v, want %v (err: %v)", got, tt.want, err)
+			}
+			_, err = h.DeleteUser(ctx, &pb.UserID{Id: tt.user})
+			if got := status.Code(err); got != tt.want {
+				t.Errorf("DeleteUser: code = %v, want %v (err: %v)", got, tt.want, err)
+			}
+
+			wantCalls := 0
+			if tt.want == codes.OK {
+				wantCalls = 1
+			}
+			if len(users.updated) != wantCalls || len(users.deleted) != wantCalls {
+				t.Errorf("updated %v and deleted %v, want %d call(s) each", users.updated, users.deleted, wantCalls)
+			}
+		})
+	}
+}
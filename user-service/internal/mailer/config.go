@@ -0,0 +1,93 @@
+package mailer
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+)
+
+// Backends selectable with MAIL_BACKEND.
+const (
+	BackendSMTP   = "smtp"
+	BackendFile   = "file"
+	BackendMemory = "memory"
+)
+
+type Config struct {
+	Backend   string
+	From      string
+	BaseURL   string // of the pages linked from emails
+	Locale    string
+	OutboxDir string // for the file backend
+	SMTP      SMTPConfig
+}
+
+// ConfigFromEnv reads MAIL_BACKEND, MAIL_FROM, MAIL_LOCALE, MAIL_OUTBOX_DIR,
+// FRONTEND_URL and SMTP_HOST, SMTP_PORT, SMTP_USER, SMTP_PASS, SMTP_TLS.
+// Without MAIL_BACKEND, SMTP is used if SMTP_HOST is set and the file
+// backend otherwise.
+func ConfigFromEnv() (Config, error) {
+	cfg := Config{
+		Backend:   os.Getenv("MAIL_BACKEND"),
+		From:      envOr("MAIL_FROM", "no-reply@localhost"),
+		BaseURL:   envOr("FRONTEND_URL", "http://localhost:8080"),
+		Locale:    envOr("MAIL_LOCALE", DefaultLocale),
+		OutboxDir: envOr("MAIL_OUTBOX_DIR", "outbox"),
+		SMTP: SMTPConfig{
+			Host:     os.Getenv("SMTP_HOST"),
+			Port:     587,
+			Username: os.Getenv("SMTP_USER"),
+			Password: os.Getenv("SMTP_PASS"),
+			TLS:      TLSMode(os.Getenv("SMTP_TLS")),
+		},
+	}
+
+	if port := os.Getenv("SMTP_PORT"); port != "" {
+		p, err := strconv.Atoi(port)
+		if err != nil {
+			return Config{}, fmt.Errorf("invalid SMTP_PORT %q", port)
+		}
+		cfg.SMTP.Port = p
+	}
+	if cfg.SMTP.TLS == "" {
+		cfg.SMTP.TLS = TLSStartTLS
+		if cfg.SMTP.Port == 465 {
+			cfg.SMTP.TLS = TLSImplicit
+		}
+	}
+	if cfg.Backend == "" {
+		cfg.Backend = BackendFile
+		if cfg.SMTP.Host != "" {
+			cfg.Backend = BackendSMTP
+		}
+	}
+	return cfg, nil
+}
+
+// NewSender returns the Sender for cfg.Backend.
+func NewSender(cfg Config) (Sender, error) {
+	switch cfg.Backend {
+	case BackendSMTP:
+		if cfg.SMTP.Host == "" {
+			return nil, fmt.Errorf("SMTP backend needs SMTP_HOST")
+		}
+		switch cfg.SMTP.TLS {
+		case TLSStartTLS, TLSImplicit, TLSNone:
+		default:
+			return nil, fmt.Errorf("invalid SMTP TLS mode %q", cfg.SMTP.TLS)
+		}
+		return NewSMTPSender(cfg.SMTP), nil
+	case BackendFile:
+		return NewFileSender(cfg.OutboxDir)
+	case BackendMemory:
+		return NewMemorySender(), nil
+	}
+	return nil, fmt.Errorf("unknown mail backend %q", cfg.Backend)
+}
+
+func envOr(key, fallback string) string {
+	if v := os.Getenv(key); v != "" {
+		return v
+	}
+	return fallback
+}
@@ -0,0 +1,42 @@
+package mailer
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"user-service/logger"
+)
+
+// FileSender writes every message as an .eml file into a directory instead
+// of sending it, for development without an SMTP server.
+type FileSender struct {
+	dir string
+}
+
+func NewFileSender(dir string) (*FileSender, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	return &FileSender{dir: dir}, nil
+}
+
+func (s *FileSender) Send(msg Message) error {
+	raw, err := msg.Bytes()
+	if err != nil {
+		return err
+	}
+
+	suffix := make([]byte, 4)
+	rand.Read(suffix)
+	name := fmt.Sprintf("%s-%s.eml", time.Now().UTC().Format("20060102T150405.000"), hex.EncodeToString(suffix))
+	path := filepath.Join(s.dir, name)
+	if err := os.WriteFile(path, raw, 0o600); err != nil {
+		return err
+	}
+	logger.Log.Info(fmt.Sprintf("📧 Email %q to %s written to %s", msg.Subject, msg.To, path))
+	return nil
+}
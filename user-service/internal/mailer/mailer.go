@@ -0,0 +1,136 @@
+// Package mailer renders the emails user-service sends and delivers them
+// through a pluggable Sender: SMTP in production, an outbox directory in
+// development and an in-memory capture in tests.
+package mailer
+
+import (
+	"bytes"
+	"embed"
+	"fmt"
+	htmltemplate "html/template"
+	"net/url"
+	texttemplate "text/template"
+)
+
+//go:embed templates
+var templatesFS embed.FS
+
+// Locales with a translation of every email.
+var Locales = []string{"en", "ru"}
+
+const DefaultLocale = "en"
+
+// Emails, each with <name>.txt and <name>.html templates per locale. The
+// text template also defines the "subject" template.
+const (
+	emailVerification  = "verification"
+	emailPasswordReset = "password_reset"
+	emailChange        = "email_change"
+)
+
+var emails = []string{emailVerification, emailPasswordReset, emailChange}
+
+// Sender delivers a rendered message.
+type Sender interface {
+	Send(msg Message) error
+}
+
+type emailTemplate struct {
+	text *texttemplate.Template
+	html *htmltemplate.Template
+}
+
+// Mailer sends the user-service emails, rendered in one locale, and
+// implements usecase.Mailer.
+type Mailer struct {
+	sender    Sender
+	from      string
+	baseURL   string
+	templates map[string]emailTemplate
+}
+
+// New returns a Mailer that sends from the address from, links to pages
+// under baseURL and writes in locale.
+func New(sender Sender, from, baseURL, locale string) (*Mailer, error) {
+	if locale == "" {
+		locale = DefaultLocale
+	}
+	if !supported(locale) {
+		return nil, fmt.Errorf("unsupported mail locale %q", locale)
+	}
+
+	templates := map[string]emailTemplate{}
+	for _, name := range emails {
+		text, err := texttemplate.ParseFS(templatesFS, fmt.Sprintf("templates/%s/%s.txt", locale, name))
+		if err != nil {
+			return nil, err
+		}
+		if text.Lookup("subject") == nil {
+			return nil, fmt.Errorf("%s/%s.txt: no subject template", locale, name)
+		}
+		html, err := htmltemplate.ParseFS(templatesFS, fmt.Sprintf("templates/%s/%s.html", locale, name))
+		if err != nil {
+			return nil, err
+		}
+		templates[name] = emailTemplate{text: text, html: html}
+	}
+
+	return &Mailer{sender: sender, from: from, baseURL: baseURL, templates: templates}, nil
+}
+
+func (m *Mailer) SendVerification(email, token string) error {
+	return m.send(email, emailVerification, m.link("/verify", token))
+}
+
+func (m *Mailer) SendPasswordReset(email, token string) error {
+	return m.send(email, emailPasswordReset, m.link("/users/password/reset", token))
+}
+
+// SendEmailChange goes to the new address of a user changing their email.
+func (m *Mailer) SendEmailChange(email, token string) error {
+	return m.send(email, emailChange, m.link("/users/email/confirm", token))
+}
+
+func (m *Mailer) link(path, token string) string {
+	return m.baseURL + path + "?token=" + url.QueryEscape(token)
+}
+
+func (m *Mailer) send(to, name, link string) error {
+	msg, err := m.render(to, name, struct{ Link string }{Link: link})
+	if err != nil {
+		return fmt.Errorf("render %s email: %w", name, err)
+	}
+	return m.sender.Send(msg)
+}
+
+func (m *Mailer) render(to, name string, data interface{}) (Message, error) {
+	t := m.templates[name]
+
+	var subject, text, html bytes.Buffer
+	if err := t.text.ExecuteTemplate(&subject, "subject", data); err != nil {
+		return Message{}, err
+	}
+	if err := t.text.Execute(&text, data); err != nil {
+		return Message{}, err
+	}
+	if err := t.html.Execute(&html, data); err != nil {
+		return Message{}, err
+	}
+
+	return Message{
+		From:    m.from,
+		To:      to,
+		Subject: subject.String(),
+		Text:    text.String(),
+		HTML:    html.String(),
+	}, nil
+}
+
+func supported(locale string) bool {
+	for _, l := range Locales {
+		if l == locale {
+			return true
+		}
+	}
+	return false
+}
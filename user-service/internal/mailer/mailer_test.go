@@ -0,0 +1,166 @@
+package mailer
+
+import (
+	"bytes"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/mail"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func newTestMailer(t *testing.T, locale string) (*Mailer, *MemorySender) {
+	t.Helper()
+
+	sender := NewMemorySender()
+	m, err := New(sender, "Shop <no-reply@shop.test>", "http://shop.test", locale)
+	if err != nil {
+		t.Fatalf("new mailer: %v", err)
+	}
+	return m, sender
+}
+
+func TestSendVerificationRendersEveryLocale(t *testing.T) {
+	subjects := map[string]string{
+		"en": "Please verify your email",
+		"ru": "Подтвердите адрес электронной почты",
+	}
+	for _, locale := range Locales {
+		m, sender := newTestMailer(t, locale)
+
+		if err := m.SendVerification("user@shop.test", "a+b&c"); err != nil {
+			t.Fatalf("%s: send: %v", locale, err)
+		}
+
+		msgs := sender.Messages()
+		if len(msgs) != 1 {
+			t.Fatalf("%s: expected 1 message, got %d", locale, len(msgs))
+		}
+		msg := msgs[0]
+		if msg.Subject != subjects[locale] {
+			t.Errorf("%s: unexpected subject %q", locale, msg.Subject)
+		}
+		link := "http://shop.test/verify?token=a%2Bb%26c"
+		if !strings.Contains(msg.Text, link) {
+			t.Errorf("%s: text part lacks link:\n%s", locale, msg.Text)
+		}
+		if !strings.Contains(msg.HTML, `href="http://shop.test/verify?token=a%2Bb%26c"`) {
+			t.Errorf("%s: html part lacks link:\n%s", locale, msg.HTML)
+		}
+	}
+}
+
+func TestSendPasswordResetLinksToResetPage(t *testing.T) {
+	m, sender := newTestMailer(t, "en")
+
+	if err := m.SendPasswordReset("user@shop.test", "tok"); err != nil {
+		t.Fatalf("send: %v", err)
+	}
+	msg := sender.Messages()[0]
+	if msg.Subject != "Reset your password" {
+		t.Errorf("unexpected subject %q", msg.Subject)
+	}
+	if !strings.Contains(msg.Text, "http://shop.test/users/password/reset?token=tok") {
+		t.Errorf("text part lacks link:\n%s", msg.Text)
+	}
+}
+
+func TestSendEmailChangeLinksToConfirmPage(t *testing.T) {
+	m, sender := newTestMailer(t, "en")
+
+	if err := m.SendEmailChange("new@shop.test", "tok"); err != nil {
+		t.Fatalf("send: %v", err)
+	}
+	msg := sender.Messages()[0]
+	if msg.To != "new@shop.test" || msg.Subject != "Confirm your new email address" {
+		t.Errorf("unexpected message to %q with subject %q", msg.To, msg.Subject)
+	}
+	if !strings.Contains(msg.HTML, `href="http://shop.test/users/email/confirm?token=tok"`) {
+		t.Errorf("html part lacks link:\n%s", msg.HTML)
+	}
+}
+
+func TestMessageBytesIsMultipartAlternative(t *testing.T) {
+	m, sender := newTestMailer(t, "ru")
+	if err := m.SendPasswordReset("user@shop.test", "tok"); err != nil {
+		t.Fatalf("send: %v", err)
+	}
+
+	raw, err := sender.Messages()[0].Bytes()
+	if err != nil {
+		t.Fatalf("bytes: %v", err)
+	}
+	parsed, err := mail.ReadMessage(bytes.NewReader(raw))
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+
+	subject, err := new(mime.WordDecoder).DecodeHeader(parsed.Header.Get("Subject"))
+	if err != nil || subject != "Сброс пароля" {
+		t.Errorf("unexpected subject %q (%v)", subject, err)
+	}
+
+	mediaType, params, err := mime.ParseMediaType(parsed.Header.Get("Content-Type"))
+	if err != nil || mediaType != "multipart/alternative" {
+		t.Fatalf("unexpected content type %q (%v)", parsed.Header.Get("Content-Type"), err)
+	}
+	parts := multipart.NewReader(parsed.Body, params["boundary"])
+	var types []string
+	for {
+		part, err := parts.NextPart()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("next part: %v", err)
+		}
+		body, _ := io.ReadAll(part) // NextPart decodes quoted-printable
+		if !strings.Contains(string(body), "/users/password/reset?token=tok") {
+			t.Errorf("part %s lacks link", part.Header.Get("Content-Type"))
+		}
+		types = append(types, part.Header.Get("Content-Type"))
+	}
+	if len(types) != 2 || !strings.HasPrefix(types[0], "text/plain") || !strings.HasPrefix(types[1], "text/html") {
+		t.Fatalf("unexpected parts %v", types)
+	}
+}
+
+func TestMessageBytesRejectsHeaderInjection(t *testing.T) {
+	msg := Message{From: "no-reply@shop.test", To: "user@shop.test\r\nBcc: victim@shop.test", Subject: "hi"}
+	if _, err := msg.Bytes(); err == nil {
+		t.Fatal("expected an error for a line break in To")
+	}
+}
+
+func TestFileSenderWritesOutbox(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "outbox")
+	sender, err := NewFileSender(dir)
+	if err != nil {
+		t.Fatalf("new file sender: %v", err)
+	}
+	m, err := New(sender, "no-reply@shop.test", "http://shop.test", "en")
+	if err != nil {
+		t.Fatalf("new mailer: %v", err)
+	}
+
+	if err := m.SendVerification("user@shop.test", "tok"); err != nil {
+		t.Fatalf("send: %v", err)
+	}
+	files, err := filepath.Glob(filepath.Join(dir, "*.eml"))
+	if err != nil || len(files) != 1 {
+		t.Fatalf("expected 1 .eml file, got %v (%v)", files, err)
+	}
+	raw, _ := os.ReadFile(files[0])
+	if !strings.Contains(string(raw), "To: user@shop.test") {
+		t.Errorf("unexpected file content:\n%s", raw)
+	}
+}
+
+func TestNewRejectsUnknownLocale(t *testing.T) {
+	if _, err := New(NewMemorySender(), "no-reply@shop.test", "http://shop.test", "de"); err == nil {
+		t.Fatal("expected an error for an unsupported locale")
+	}
+}
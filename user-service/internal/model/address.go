@@ -0,0 +1,120 @@
+package model
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+)
+
+var ErrAddressNotFound = errors.New("address not found")
+
+// Address is an entry of a user's address book, used for shipping and
+// billing.
+type Address struct {
+	ID            int       `db:"id"`
+	UserID        int       `db:"user_id"`
+	Label         string    `db:"label"` // e.g. "Home", "Office"
+	RecipientName string    `db:"recipient_name"`
+	Line1         string    `db:"line1"`
+	Line2         string    `db:"line2"`
+	City          string    `db:"city"`
+	Region        string    `db:"region"` // state, province or oblast
+	PostalCode    string    `db:"postal_code"`
+	Country       string    `db:"country"` // ISO 3166-1 alpha-2
+	Phone         string    `db:"phone"`
+	IsDefault     bool      `db:"is_default"`
+	CreatedAt     time.Time `db:"created_at"`
+	UpdatedAt     time.Time `db:"updated_at"`
+}
+
+// AddressError names the field of an address that failed validation.
+type AddressError struct {
+	Field  string
+	Reason string
+}
+
+func (e *AddressError) Error() string {
+	return fmt.Sprintf("invalid address: %s %s", e.Field, e.Reason)
+}
+
+// countryRule describes the postal codes of a country and whether addresses
+// there need a region.
+type countryRule struct {
+	postalCode    *regexp.Regexp
+	postalExample string
+	needsRegion   bool
+}
+
+var countryRules = map[string]countryRule{
+	"US": {regexp.MustCompile(`^\d{5}(-\d{4})?$`), "12345 or 12345-6789", true},
+	"CA": {regexp.MustCompile(`^[A-Z]\d[A-Z] ?\d[A-Z]\d$`), "K1A 0B1", true},
+	"GB": {regexp.MustCompile(`^[A-Z]{1,2}\d[A-Z\d]? ?\d[A-Z]{2}$`), "SW1A 1AA", false},
+	"NL": {regexp.MustCompile(`^\d{4} ?[A-Z]{2}$`), "1012 AB", false},
+	"DE": {regexp.MustCompile(`^\d{5}$`), "10115", false},
+	"FR": {regexp.MustCompile(`^\d{5}$`), "75001", false},
+	"IT": {regexp.MustCompile(`^\d{5}$`), "00118", false},
+	"ES": {regexp.MustCompile(`^\d{5}$`), "28001", false},
+	"KZ": {regexp.MustCompile(`^\d{6}$`), "050000", false},
+	"RU": {regexp.MustCompile(`^\d{6}$`), "101000", false},
+}
+
+var (
+	countryCode = regexp.MustCompile(`^[A-Z]{2}$`)
+	phoneNumber = regexp.MustCompile(`^\+?[0-9 ()-]{5,20}$`)
+)
+
+// Normalize trims every field and upper-cases the country and postal code.
+func (a *Address) Normalize() {
+	for _, f := range []*string{&a.Label, &a.RecipientName, &a.Line1, &a.Line2, &a.City, &a.Region, &a.PostalCode, &a.Country, &a.Phone} {
+		*f = strings.TrimSpace(*f)
+	}
+	a.Country = strings.ToUpper(a.Country)
+	a.PostalCode = strings.ToUpper(a.PostalCode)
+}
+
+// Validate checks a normalized address. Postal codes and regions are checked
+// for the countries in countryRules; elsewhere only the common fields are.
+func (a *Address) Validate() error {
+	required := []struct{ field, value string }{
+		{"recipient_name", a.RecipientName},
+		{"line1", a.Line1},
+		{"city", a.City},
+		{"country", a.Country},
+	}
+	for _, r := range required {
+		if r.value == "" {
+			return &AddressError{Field: r.field, Reason: "is required"}
+		}
+	}
+	for field, value := range map[string]string{
+		"label": a.Label, "recipient_name": a.RecipientName, "line1": a.Line1,
+		"line2": a.Line2, "city": a.City, "region": a.Region,
+	} {
+		if len(value) > 255 || (field == "label" && len(value) > 64) {
+			return &AddressError{Field: field, Reason: "is too long"}
+		}
+	}
+	if !countryCode.MatchString(a.Country) {
+		return &AddressError{Field: "country", Reason: "must be an ISO 3166-1 alpha-2 code such as US"}
+	}
+	if a.Phone != "" && !phoneNumber.MatchString(a.Phone) {
+		return &AddressError{Field: "phone", Reason: "is not a phone number"}
+	}
+
+	rule, ok := countryRules[a.Country]
+	if !ok {
+		if len(a.PostalCode) > 16 {
+			return &AddressError{Field: "postal_code", Reason: "is too long"}
+		}
+		return nil
+	}
+	if !rule.postalCode.MatchString(a.PostalCode) {
+		return &AddressError{Field: "postal_code", Reason: fmt.Sprintf("must look like %s in %s", rule.postalExample, a.Country)}
+	}
+	if rule.needsRegion && a.Region == "" {
+		return &AddressError{Field: "region", Reason: fmt.Sprintf("is required in %s", a.Country)}
+	}
+	return nil
+}
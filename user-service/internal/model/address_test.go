@@ -0,0 +1,52 @@
+package model
+
+import (
+	"errors"
+	"testing"
+)
+
+func validAddress() Address {
+	return Address{
+		RecipientName: "Jane Doe",
+		Line1:         "1 Main St",
+		City:          "Springfield",
+		Region:        "IL",
+		PostalCode:    "62701",
+		Country:       "us",
+	}
+}
+
+func TestAddressValidate(t *testing.T) {
+	cases := []struct {
+		name   string
+		modify func(a *Address)
+		field  string // "" when valid
+	}{
+		{"valid", func(a *Address) {}, ""},
+		{"zip+4", func(a *Address) { a.PostalCode = "62701-1234" }, ""},
+		{"missing line1", func(a *Address) { a.Line1 = " " }, "line1"},
+		{"bad country", func(a *Address) { a.Country = "USA" }, "country"},
+		{"bad zip", func(a *Address) { a.PostalCode = "6270" }, "postal_code"},
+		{"us needs region", func(a *Address) { a.Region = "" }, "region"},
+		{"uk postcode", func(a *Address) { a.Country, a.Region, a.PostalCode = "GB", "", "sw1a 1aa" }, ""},
+		{"kz postcode", func(a *Address) { a.Country, a.PostalCode = "KZ", "05000" }, "postal_code"},
+		{"other country", func(a *Address) { a.Country, a.Region, a.PostalCode = "JP", "", "" }, ""},
+		{"bad phone", func(a *Address) { a.Phone = "call me" }, "phone"},
+	}
+	for _, tc := range cases {
+		a := validAddress()
+		tc.modify(&a)
+		a.Normalize()
+
+		err := a.Validate()
+		var addrErr *AddressError
+		switch {
+		case tc.field == "" && err != nil:
+			t.Errorf("%s: unexpected error %v", tc.name, err)
+		case tc.field != "" && !errors.As(err, &addrErr):
+			t.Errorf("%s: expected an error for %s, got %v", tc.name, tc.field, err)
+		case tc.field != "" && addrErr.Field != tc.field:
+			t.Errorf("%s: error for %s, want %s", tc.name, addrErr.Field, tc.field)
+		}
+	}
+}
@@ -0,0 +1,24 @@
+package model
+
+import (
+	"errors"
+	"time"
+)
+
+var (
+	ErrInvalidEmail            = errors.New("invalid email address")
+	ErrInvalidEmailChangeToken = errors.New("invalid or expired email change token")
+	ErrWrongPassword           = errors.New("current password is incorrect")
+)
+
+// EmailChangeRequest moves a user to NewEmail once the link sent there is
+// opened. The token is stored by hash only.
+type EmailChangeRequest struct {
+	ID        int64      `db:"id"`
+	UserID    int        `db:"user_id"`
+	NewEmail  string     `db:"new_email"`
+	TokenHash string     `db:"token_hash"`
+	ExpiresAt time.Time  `db:"expires_at"`
+	UsedAt    *time.Time `db:"used_at"`
+	CreatedAt time.Time  `db:"created_at"`
+}
@@ -0,0 +1,35 @@
+package model
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ErrInvalidCredentials covers both an unknown email and a wrong password.
+var ErrInvalidCredentials = errors.New("invalid credentials")
+
+// LoginLockedError rejects a login attempt made while the account or the
+// client IP is backing off after failed attempts.
+type LoginLockedError struct {
+	RetryAfter time.Duration
+}
+
+func (e *LoginLockedError) Error() string {
+	return fmt.Sprintf("too many failed login attempts, retry in %s", e.RetryAfter.Round(time.Second))
+}
+
+const (
+	SubjectLoginFailed = "user.login_failed"
+	SubjectUserLocked  = "user.locked"
+)
+
+// LoginEvent is published on user.login_failed and user.locked.
+type LoginEvent struct {
+	EventID     string     `json:"event_id"`
+	Email       string     `json:"email"`
+	ClientIP    string     `json:"client_ip,omitempty"`
+	Failures    int        `json:"failures"`
+	LockedUntil *time.Time `json:"locked_until,omitempty"`
+	OccurredAt  time.Time  `json:"occurred_at"`
+}
@@ -0,0 +1,24 @@
+package model
+
+import (
+	"errors"
+	"time"
+)
+
+var (
+	ErrInvalidResetToken = errors.New("invalid or expired password reset token")
+	ErrPasswordTooShort  = errors.New("password must be at least 6 characters")
+)
+
+// MinPasswordLength matches the gateway's registration form.
+const MinPasswordLength = 6
+
+// PasswordResetToken is stored by hash only and can be used once.
+type PasswordResetToken struct {
+	ID        int64      `db:"id"`
+	UserID    int        `db:"user_id"`
+	TokenHash string     `db:"token_hash"`
+	ExpiresAt time.Time  `db:"expires_at"`
+	UsedAt    *time.Time `db:"used_at"`
+	CreatedAt time.Time  `db:"created_at"`
+}
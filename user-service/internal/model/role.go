@@ -0,0 +1,48 @@
+package model
+
+import (
+	"errors"
+	"sort"
+)
+
+// Roles seeded by migration 003. Users without a role are customers. The
+// permissions they grant are the authz.Perm* constants of the shared module.
+const (
+	RoleAdmin     = "admin"
+	RoleWarehouse = "warehouse"
+)
+
+var (
+	ErrRoleNotFound = errors.New("role not found")
+	ErrUserNotFound = errors.New("user not found")
+)
+
+type Role struct {
+	Name        string
+	Description string
+	Permissions []string
+}
+
+// PermissionsOf returns the union of the roles' permissions, sorted.
+func PermissionsOf(roles []Role) []string {
+	seen := map[string]bool{}
+	var perms []string
+	for _, r := range roles {
+		for _, p := range r.Permissions {
+			if !seen[p] {
+				seen[p] = true
+				perms = append(perms, p)
+			}
+		}
+	}
+	sort.Strings(perms)
+	return perms
+}
+
+func RoleNames(roles []Role) []string {
+	names := make([]string, 0, len(roles))
+	for _, r := range roles {
+		names = append(names, r.Name)
+	}
+	return names
+}
@@ -0,0 +1,61 @@
+package model
+
+import (
+	"errors"
+	"time"
+)
+
+var (
+	ErrInvalidAccessToken  = errors.New("invalid access token")
+	ErrInvalidRefreshToken = errors.New("invalid or expired refresh token")
+	// ErrRefreshTokenReused means an already rotated refresh token was
+	// presented again, which suggests it was stolen; the whole session is
+	// revoked.
+	ErrRefreshTokenReused = errors.New("refresh token reused")
+)
+
+// AccessClaims is the content of a signed access token.
+type AccessClaims struct {
+	ID          string // jti
+	UserID      int
+	Email       string
+	Roles       []string
+	Permissions []string
+	IssuedAt    time.Time
+	ExpiresAt   time.Time
+}
+
+// RefreshToken is stored by hash only. Tokens rotated from one login share a
+// FamilyID, so a whole session can be revoked at once.
+type RefreshToken struct {
+	ID         int64      `db:"id"`
+	UserID     int        `db:"user_id"`
+	TokenHash  string     `db:"token_hash"`
+	FamilyID   string     `db:"family_id"`
+	ExpiresAt  time.Time  `db:"expires_at"`
+	RevokedAt  *time.Time `db:"revoked_at"`
+	ReplacedBy *int64     `db:"replaced_by"`
+	CreatedAt  time.Time  `db:"created_at"`
+}
+
+type TokenPair struct {
+	AccessToken      string
+	ExpiresIn        time.Duration
+	RefreshToken     string
+	RefreshExpiresIn time.Duration
+}
+
+// JWK is a public key in JSON Web Key format (RFC 7517, OKP keys per RFC 8037).
+type JWK struct {
+	Kty string `json:"kty"`
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+}
+
+// KeySet is the JWKS document other services use to verify access tokens.
+type KeySet struct {
+	Keys []JWK `json:"keys"`
+}
@@ -0,0 +1,59 @@
+package model
+
+import (
+	"errors"
+	"time"
+)
+
+var (
+	ErrTOTPAlreadyEnabled    = errors.New("two-factor authentication is already enabled")
+	ErrTOTPNotEnabled        = errors.New("two-factor authentication is not enabled")
+	ErrTOTPNotEnrolled       = errors.New("no two-factor enrollment to confirm")
+	ErrInvalidTOTPCode       = errors.New("invalid two-factor code")
+	ErrInvalidLoginChallenge = errors.New("invalid or expired login challenge")
+)
+
+// UserTOTP is a user's authenticator secret. Until ConfirmedAt is set the
+// enrollment is pending and logins do not ask for a code.
+type UserTOTP struct {
+	UserID      int        `db:"user_id"`
+	Secret      string     `db:"secret"`
+	ConfirmedAt *time.Time `db:"confirmed_at"`
+	// LastUsedStep is the time step of the last accepted code; codes of
+	// that step or earlier are rejected so they cannot be replayed.
+	LastUsedStep int64     `db:"last_used_step"`
+	CreatedAt    time.Time `db:"created_at"`
+}
+
+func (t *UserTOTP) Enabled() bool {
+	return t != nil && t.ConfirmedAt != nil
+}
+
+// TOTPEnrollment is shown to the user once, to set up an authenticator app.
+type TOTPEnrollment struct {
+	Secret string
+	URI    string
+}
+
+// TOTPStatus describes a user's two-factor setup.
+type TOTPStatus struct {
+	Enabled           bool
+	RecoveryCodesLeft int
+}
+
+// LoginChallenge is issued after the password of a user with two-factor
+// authentication checks out, and is exchanged for tokens together with a
+// code. It is stored by hash only.
+type LoginChallenge struct {
+	TokenHash string    `db:"token_hash"`
+	UserID    int       `db:"user_id"`
+	Attempts  int       `db:"attempts"`
+	ExpiresAt time.Time `db:"expires_at"`
+}
+
+// LoginResult is either a logged-in user or, with two-factor
+// authentication, a challenge to complete with VerifySecondFactor.
+type LoginResult struct {
+	User           User
+	ChallengeToken string
+}
@@ -1,8 +1,28 @@
 package model
 
+import (
+	"errors"
+	"time"
+)
+
+var (
+	ErrInvalidVerificationToken = errors.New("invalid or expired verification token")
+	ErrEmailTaken               = errors.New("email is already registered")
+)
+
 type User struct {
 	ID       int    `db:"id" json:"id"`
 	Email    string `db:"email" json:"email"`
 	Password string `db:"password" json:"-"` // hashed
 	Name     string `db:"name" json:"name"`
 }
+
+// PendingUser is a registration waiting for its email to be verified.
+type PendingUser struct {
+	Email     string    `db:"email"`
+	Name      string    `db:"name"`
+	Password  string    `db:"password"` // hashed
+	Token     string    `db:"token"`
+	CreatedAt time.Time `db:"created_at"`
+	ExpiresAt time.Time `db:"expires_at"`
+}
@@ -0,0 +1,9 @@
+package nats
+
+import "shared/natspub"
+
+// NewNatsPublisher publishes user events into UsersStream. Events published
+// while NATS is down fail and are only logged.
+func NewNatsPublisher(url string) *natspub.Publisher {
+	return natspub.NewPublisher(url, usersStreamConfig)
+}
@@ -0,0 +1,19 @@
+package nats
+
+import (
+	"time"
+
+	"github.com/nats-io/nats.go/jetstream"
+)
+
+// UsersStream holds all user.* subjects, such as user.login_failed and
+// user.locked.
+const UsersStream = "USERS"
+
+var usersStreamConfig = jetstream.StreamConfig{
+	Name:       UsersStream,
+	Subjects:   []string{"user.>"},
+	Storage:    jetstream.FileStorage,
+	MaxAge:     30 * 24 * time.Hour,
+	Duplicates: 2 * time.Minute,
+}
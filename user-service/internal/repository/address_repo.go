@@ -0,0 +1,125 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"user-service/internal/model"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// AddressRepository scopes every lookup to the owning user, so an address ID
+// of another user behaves like an unknown one.
+type AddressRepository interface {
+	Create(a *model.Address) error
+	ListByUser(userID int) ([]model.Address, error)
+	Get(userID, id int) (model.Address, error)
+	Update(a *model.Address) error
+	Delete(userID, id int) error
+	SetDefault(userID, id int) error
+}
+
+type addressRepo struct {
+	db *sqlx.DB
+}
+
+func NewAddressRepository(db *sqlx.DB) AddressRepository {
+	return &addressRepo{db: db}
+}
+
+// Create adds the address; a user's first address becomes the default.
+func (r *addressRepo) Create(a *model.Address) error {
+	return r.db.QueryRowx(`
+		INSERT INTO addresses (user_id, label, recipient_name, line1, line2, city, region, postal_code, country, phone, is_default)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10,
+		        NOT EXISTS (SELECT 1 FROM addresses WHERE user_id=$1))
+		RETURNING id, is_default, created_at, updated_at`,
+		a.UserID, a.Label, a.RecipientName, a.Line1, a.Line2, a.City, a.Region, a.PostalCode, a.Country, a.Phone,
+	).Scan(&a.ID, &a.IsDefault, &a.CreatedAt, &a.UpdatedAt)
+}
+
+// ListByUser returns the default address first, then the newest.
+func (r *addressRepo) ListByUser(userID int) ([]model.Address, error) {
+	addresses := []model.Address{}
+	err := r.db.Select(&addresses,
+		`SELECT * FROM addresses WHERE user_id=$1 ORDER BY is_default DESC, id DESC`, userID)
+	return addresses, err
+}
+
+func (r *addressRepo) Get(userID, id int) (model.Address, error) {
+	var a model.Address
+	err := r.db.Get(&a, `SELECT * FROM addresses WHERE id=$1 AND user_id=$2`, id, userID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return a, model.ErrAddressNotFound
+	}
+	return a, err
+}
+
+// Update saves every field except the default flag, see SetDefault.
+func (r *addressRepo) Update(a *model.Address) error {
+	err := r.db.QueryRowx(`
+		UPDATE addresses SET label=$3, recipient_name=$4, line1=$5, line2=$6, city=$7, region=$8,
+		       postal_code=$9, country=$10, phone=$11, updated_at=CURRENT_TIMESTAMP
+		WHERE id=$1 AND user_id=$2
+		RETURNING is_default, created_at, updated_at`,
+		a.ID, a.UserID, a.Label, a.RecipientName, a.Line1, a.Line2, a.City, a.Region, a.PostalCode, a.Country, a.Phone,
+	).Scan(&a.IsDefault, &a.CreatedAt, &a.UpdatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return model.ErrAddressNotFound
+	}
+	return err
+}
+
+// Delete removes the address. If it was the default, the user's newest
+// remaining address becomes the default.
+func (r *addressRepo) Delete(userID, id int) error {
+	tx, err := r.db.Beginx()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var wasDefault bool
+	err = tx.Get(&wasDefault,
+		`DELETE FROM addresses WHERE id=$1 AND user_id=$2 RETURNING is_default`, id, userID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return model.ErrAddressNotFound
+	}
+	if err != nil {
+		return err
+	}
+
+	if wasDefault {
+		if _, err := tx.Exec(`
+			UPDATE addresses SET is_default=TRUE
+			WHERE id=(SELECT id FROM addresses WHERE user_id=$1 ORDER BY id DESC LIMIT 1)`, userID); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+func (r *addressRepo) SetDefault(userID, id int) error {
+	tx, err := r.db.Beginx()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	// Clear the old default first; the unique index allows one per user.
+	if _, err := tx.Exec(
+		`UPDATE addresses SET is_default=FALSE WHERE user_id=$1 AND is_default AND id<>$2`, userID, id); err != nil {
+		return err
+	}
+	res, err := tx.Exec(
+		`UPDATE addresses SET is_default=TRUE, updated_at=CURRENT_TIMESTAMP WHERE id=$1 AND user_id=$2`, id, userID)
+	if err != nil {
+		return err
+	}
+	if n, err := res.RowsAffected(); err != nil {
+		return err
+	} else if n == 0 {
+		return model.ErrAddressNotFound
+	}
+	return tx.Commit()
+}
@@ -0,0 +1,100 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"user-service/internal/model"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+)
+
+type EmailChangeRepository interface {
+	Create(req *model.EmailChangeRequest) error
+	Confirm(tokenHash string) (int, error)
+	DeleteExpired() (int64, error)
+}
+
+type emailChangeRepo struct {
+	db *sqlx.DB
+}
+
+func NewEmailChangeRepository(db *sqlx.DB) EmailChangeRepository {
+	return &emailChangeRepo{db: db}
+}
+
+// Create stores a new request; the user's earlier unconfirmed requests stop
+// working.
+func (r *emailChangeRepo) Create(req *model.EmailChangeRequest) error {
+	tx, err := r.db.Beginx()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(
+		`UPDATE email_change_requests SET used_at=CURRENT_TIMESTAMP WHERE user_id=$1 AND used_at IS NULL`,
+		req.UserID); err != nil {
+		return err
+	}
+
+	err = tx.QueryRowx(
+		`INSERT INTO email_change_requests (user_id, new_email, token_hash, expires_at)
+		 VALUES ($1, $2, $3, $4) RETURNING id, created_at`,
+		req.UserID, req.NewEmail, req.TokenHash, req.ExpiresAt,
+	).Scan(&req.ID, &req.CreatedAt)
+	if err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// Confirm uses up the token and moves its user to the new email in one
+// transaction, returning the user's ID. It fails with
+// model.ErrInvalidEmailChangeToken for unknown, expired or used tokens and
+// with model.ErrEmailTaken if the address was registered in the meantime.
+func (r *emailChangeRepo) Confirm(tokenHash string) (int, error) {
+	tx, err := r.db.Beginx()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	var req model.EmailChangeRequest
+	err = tx.QueryRowx(
+		`UPDATE email_change_requests SET used_at=CURRENT_TIMESTAMP
+		 WHERE token_hash=$1 AND used_at IS NULL AND expires_at > CURRENT_TIMESTAMP
+		 RETURNING user_id, new_email`, tokenHash,
+	).Scan(&req.UserID, &req.NewEmail)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, model.ErrInvalidEmailChangeToken
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	res, err := tx.Exec(`UPDATE users SET email=$1 WHERE id=$2`, req.NewEmail, req.UserID)
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) && pqErr.Code == "23505" { // unique_violation
+		return 0, model.ErrEmailTaken
+	}
+	if err != nil {
+		return 0, err
+	}
+	if n, err := res.RowsAffected(); err != nil {
+		return 0, err
+	} else if n == 0 {
+		return 0, model.ErrInvalidEmailChangeToken
+	}
+	return req.UserID, tx.Commit()
+}
+
+// DeleteExpired removes requests that can no longer be confirmed.
+func (r *emailChangeRepo) DeleteExpired() (int64, error) {
+	res, err := r.db.Exec(
+		`DELETE FROM email_change_requests WHERE expires_at < CURRENT_TIMESTAMP OR used_at IS NOT NULL`)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
@@ -0,0 +1,83 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// LoginAttemptRepository counts failed logins per key. A Redis
+// implementation would fit as well; the Postgres one needs no extra
+// infrastructure.
+type LoginAttemptRepository interface {
+	// RecordFailure adds a failure and returns the failures counted for key;
+	// the count restarts when the previous failure is older than window.
+	RecordFailure(key string, window time.Duration) (int, error)
+	// LockedUntil returns the zero time if key is not locked.
+	LockedUntil(key string) (time.Time, error)
+	Lock(key string, until time.Time) error
+	Reset(key string) error
+	DeleteStale(olderThan time.Duration) (int64, error)
+}
+
+type loginAttemptRepo struct {
+	db *sqlx.DB
+}
+
+func NewLoginAttemptRepository(db *sqlx.DB) LoginAttemptRepository {
+	return &loginAttemptRepo{db: db}
+}
+
+func (r *loginAttemptRepo) RecordFailure(key string, window time.Duration) (int, error) {
+	var failures int
+	err := r.db.Get(&failures, `
+		INSERT INTO login_attempts (key, failures, last_failed_at)
+		VALUES ($1, 1, CURRENT_TIMESTAMP)
+		ON CONFLICT (key) DO UPDATE SET
+			failures = CASE
+				WHEN login_attempts.last_failed_at < CURRENT_TIMESTAMP - make_interval(secs => $2) THEN 1
+				ELSE login_attempts.failures + 1
+			END,
+			last_failed_at = CURRENT_TIMESTAMP
+		RETURNING failures`, key, window.Seconds())
+	return failures, err
+}
+
+func (r *loginAttemptRepo) LockedUntil(key string) (time.Time, error) {
+	var until sql.NullTime
+	err := r.db.Get(&until, `SELECT locked_until FROM login_attempts WHERE key=$1`, key)
+	if errors.Is(err, sql.ErrNoRows) {
+		return time.Time{}, nil
+	}
+	if err != nil {
+		return time.Time{}, err
+	}
+	return until.Time, nil
+}
+
+// Lock never shortens an existing lock.
+func (r *loginAttemptRepo) Lock(key string, until time.Time) error {
+	_, err := r.db.Exec(
+		`UPDATE login_attempts SET locked_until=GREATEST(COALESCE(locked_until, $2), $2) WHERE key=$1`,
+		key, until)
+	return err
+}
+
+func (r *loginAttemptRepo) Reset(key string) error {
+	_, err := r.db.Exec(`DELETE FROM login_attempts WHERE key=$1`, key)
+	return err
+}
+
+// DeleteStale forgets keys without a recent failure or an active lock.
+func (r *loginAttemptRepo) DeleteStale(olderThan time.Duration) (int64, error) {
+	res, err := r.db.Exec(`
+		DELETE FROM login_attempts
+		WHERE last_failed_at < CURRENT_TIMESTAMP - make_interval(secs => $1)
+		  AND (locked_until IS NULL OR locked_until < CURRENT_TIMESTAMP)`, olderThan.Seconds())
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
@@ -0,0 +1,79 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"user-service/internal/model"
+
+	"github.com/jmoiron/sqlx"
+)
+
+type LoginChallengeRepository interface {
+	Create(c *model.LoginChallenge) error
+	// Get returns sql.ErrNoRows for unknown and expired challenges.
+	Get(tokenHash string) (*model.LoginChallenge, error)
+	// Fail counts a wrong code; the challenge is dropped after maxAttempts.
+	Fail(tokenHash string, maxAttempts int) error
+	// Consume deletes the challenge; false means it was already used.
+	Consume(tokenHash string) (bool, error)
+	DeleteExpired() (int64, error)
+}
+
+type loginChallengeRepo struct {
+	db *sqlx.DB
+}
+
+func NewLoginChallengeRepository(db *sqlx.DB) LoginChallengeRepository {
+	return &loginChallengeRepo{db: db}
+}
+
+func (r *loginChallengeRepo) Create(c *model.LoginChallenge) error {
+	_, err := r.db.Exec(
+		`INSERT INTO login_challenges (token_hash, user_id, expires_at) VALUES ($1, $2, $3)`,
+		c.TokenHash, c.UserID, c.ExpiresAt)
+	return err
+}
+
+func (r *loginChallengeRepo) Get(tokenHash string) (*model.LoginChallenge, error) {
+	var c model.LoginChallenge
+	err := r.db.Get(&c, `
+		SELECT token_hash, user_id, attempts, expires_at FROM login_challenges
+		WHERE token_hash=$1 AND expires_at > CURRENT_TIMESTAMP`, tokenHash)
+	if err != nil {
+		return nil, err
+	}
+	return &c, nil
+}
+
+func (r *loginChallengeRepo) Fail(tokenHash string, maxAttempts int) error {
+	var attempts int
+	err := r.db.Get(&attempts,
+		`UPDATE login_challenges SET attempts=attempts+1 WHERE token_hash=$1 RETURNING attempts`, tokenHash)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if attempts >= maxAttempts {
+		_, err = r.db.Exec(`DELETE FROM login_challenges WHERE token_hash=$1`, tokenHash)
+	}
+	return err
+}
+
+func (r *loginChallengeRepo) Consume(tokenHash string) (bool, error) {
+	res, err := r.db.Exec(`DELETE FROM login_challenges WHERE token_hash=$1`, tokenHash)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	return n == 1, err
+}
+
+func (r *loginChallengeRepo) DeleteExpired() (int64, error) {
+	res, err := r.db.Exec(`DELETE FROM login_challenges WHERE expires_at < CURRENT_TIMESTAMP`)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
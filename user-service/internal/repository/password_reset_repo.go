@@ -0,0 +1,93 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"user-service/internal/model"
+
+	"github.com/jmoiron/sqlx"
+)
+
+type PasswordResetRepository interface {
+	Create(token *model.PasswordResetToken) error
+	Reset(tokenHash, passwordHash string) (bool, error)
+	DeleteExpired() (int64, error)
+}
+
+type passwordResetRepo struct {
+	db *sqlx.DB
+}
+
+func NewPasswordResetRepository(db *sqlx.DB) PasswordResetRepository {
+	return &passwordResetRepo{db: db}
+}
+
+// Create stores a new reset token. Tokens the user requested earlier and has
+// not used are invalidated, so only the latest email works.
+func (r *passwordResetRepo) Create(t *model.PasswordResetToken) error {
+	tx, err := r.db.Beginx()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(
+		`UPDATE password_reset_tokens SET used_at=CURRENT_TIMESTAMP WHERE user_id=$1 AND used_at IS NULL`,
+		t.UserID); err != nil {
+		return err
+	}
+
+	err = tx.QueryRowx(
+		`INSERT INTO password_reset_tokens (user_id, token_hash, expires_at)
+		 VALUES ($1, $2, $3) RETURNING id, created_at`,
+		t.UserID, t.TokenHash, t.ExpiresAt,
+	).Scan(&t.ID, &t.CreatedAt)
+	if err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// Reset uses up the token and sets the new password hash of its user in one
+// transaction, and revokes the user's refresh tokens. It returns false if the
+// token is unknown, expired or already used.
+func (r *passwordResetRepo) Reset(tokenHash, passwordHash string) (bool, error) {
+	tx, err := r.db.Beginx()
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback()
+
+	var userID int
+	err = tx.QueryRowx(
+		`UPDATE password_reset_tokens SET used_at=CURRENT_TIMESTAMP
+		 WHERE token_hash=$1 AND used_at IS NULL AND expires_at > CURRENT_TIMESTAMP
+		 RETURNING user_id`, tokenHash,
+	).Scan(&userID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	if _, err := tx.Exec(`UPDATE users SET password=$1 WHERE id=$2`, passwordHash, userID); err != nil {
+		return false, err
+	}
+	if _, err := tx.Exec(
+		`UPDATE refresh_tokens SET revoked_at=CURRENT_TIMESTAMP WHERE user_id=$1 AND revoked_at IS NULL`,
+		userID); err != nil {
+		return false, err
+	}
+	return true, tx.Commit()
+}
+
+// DeleteExpired removes tokens that can no longer be used.
+func (r *passwordResetRepo) DeleteExpired() (int64, error) {
+	res, err := r.db.Exec(
+		`DELETE FROM password_reset_tokens WHERE expires_at < CURRENT_TIMESTAMP OR used_at IS NOT NULL`)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
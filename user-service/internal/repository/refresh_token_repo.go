@@ -0,0 +1,93 @@
+package repository
+
+import (
+	"user-service/internal/model"
+
+	"github.com/jmoiron/sqlx"
+)
+
+type RefreshTokenRepository interface {
+	Create(token *model.RefreshToken) error
+	GetByHash(hash string) (model.RefreshToken, error)
+	Rotate(oldID int64, next *model.RefreshToken) (bool, error)
+	RevokeFamily(familyID string) error
+	RevokeAllForUser(userID int) error
+	DeleteExpired() (int64, error)
+}
+
+type refreshTokenRepo struct {
+	db *sqlx.DB
+}
+
+func NewRefreshTokenRepository(db *sqlx.DB) RefreshTokenRepository {
+	return &refreshTokenRepo{db: db}
+}
+
+func (r *refreshTokenRepo) Create(t *model.RefreshToken) error {
+	return r.db.QueryRowx(
+		`INSERT INTO refresh_tokens (user_id, token_hash, family_id, expires_at)
+		 VALUES ($1, $2, $3, $4) RETURNING id, created_at`,
+		t.UserID, t.TokenHash, t.FamilyID, t.ExpiresAt,
+	).Scan(&t.ID, &t.CreatedAt)
+}
+
+func (r *refreshTokenRepo) GetByHash(hash string) (model.RefreshToken, error) {
+	var t model.RefreshToken
+	err := r.db.Get(&t, `SELECT * FROM refresh_tokens WHERE token_hash=$1`, hash)
+	return t, err
+}
+
+// Rotate revokes the token oldID and stores next in its place, in one
+// transaction. It returns false if oldID was revoked in the meantime, so a
+// refresh token can only be exchanged once even under concurrent requests.
+func (r *refreshTokenRepo) Rotate(oldID int64, next *model.RefreshToken) (bool, error) {
+	tx, err := r.db.Beginx()
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback()
+
+	res, err := tx.Exec(
+		`UPDATE refresh_tokens SET revoked_at=CURRENT_TIMESTAMP WHERE id=$1 AND revoked_at IS NULL`, oldID)
+	if err != nil {
+		return false, err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return false, nil
+	}
+
+	err = tx.QueryRowx(
+		`INSERT INTO refresh_tokens (user_id, token_hash, family_id, expires_at)
+		 VALUES ($1, $2, $3, $4) RETURNING id, created_at`,
+		next.UserID, next.TokenHash, next.FamilyID, next.ExpiresAt,
+	).Scan(&next.ID, &next.CreatedAt)
+	if err != nil {
+		return false, err
+	}
+
+	if _, err := tx.Exec(`UPDATE refresh_tokens SET replaced_by=$1 WHERE id=$2`, next.ID, oldID); err != nil {
+		return false, err
+	}
+	return true, tx.Commit()
+}
+
+func (r *refreshTokenRepo) RevokeFamily(familyID string) error {
+	_, err := r.db.Exec(
+		`UPDATE refresh_tokens SET revoked_at=CURRENT_TIMESTAMP WHERE family_id=$1 AND revoked_at IS NULL`, familyID)
+	return err
+}
+
+func (r *refreshTokenRepo) RevokeAllForUser(userID int) error {
+	_, err := r.db.Exec(
+		`UPDATE refresh_tokens SET revoked_at=CURRENT_TIMESTAMP WHERE user_id=$1 AND revoked_at IS NULL`, userID)
+	return err
+}
+
+// DeleteExpired removes tokens that can no longer be used or replayed.
+func (r *refreshTokenRepo) DeleteExpired() (int64, error) {
+	res, err := r.db.Exec(`DELETE FROM refresh_tokens WHERE expires_at < CURRENT_TIMESTAMP`)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
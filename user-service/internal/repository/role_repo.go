@@ -0,0 +1,135 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"user-service/internal/model"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+)
+
+type RoleRepository interface {
+	Assign(userID int, role string) error
+	Revoke(userID int, role string) error
+	// Bootstrap gives the user a role only while nobody has it, and tells
+	// whether it did.
+	Bootstrap(userID int, role string) (bool, error)
+	ListByUser(userID int) ([]model.Role, error)
+}
+
+type roleRepo struct {
+	db *sqlx.DB
+}
+
+func NewRoleRepository(db *sqlx.DB) RoleRepository {
+	return &roleRepo{db: db}
+}
+
+// Assign gives the user a role; assigning a role the user already has is a
+// no-op.
+func (r *roleRepo) Assign(userID int, role string) error {
+	roleID, err := r.roleID(role)
+	if err != nil {
+		return err
+	}
+
+	_, err = r.db.Exec(
+		`INSERT INTO user_roles (user_id, role_id) VALUES ($1, $2) ON CONFLICT DO NOTHING`,
+		userID, roleID)
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) && pqErr.Code == "23503" { // foreign_key_violation
+		return model.ErrUserNotFound
+	}
+	return err
+}
+
+// Bootstrap locks the role's row for the length of its transaction, so
+// concurrent calls for the same role run one after another and the second
+// sees the first's assignment; under READ COMMITTED the NOT EXISTS check alone
+// would let both insert.
+func (r *roleRepo) Bootstrap(userID int, role string) (bool, error) {
+	tx, err := r.db.Beginx()
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback()
+
+	var roleID int
+	err = tx.Get(&roleID, `SELECT id FROM roles WHERE name=$1 FOR UPDATE`, role)
+	if errors.Is(err, sql.ErrNoRows) {
+		return false, model.ErrRoleNotFound
+	}
+	if err != nil {
+		return false, err
+	}
+
+	res, err := tx.Exec(
+		`INSERT INTO user_roles (user_id, role_id)
+		 SELECT $1, $2 WHERE NOT EXISTS (SELECT 1 FROM user_roles WHERE role_id = $2)
+		 ON CONFLICT DO NOTHING`,
+		userID, roleID)
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) && pqErr.Code == "23503" { // foreign_key_violation
+		return false, model.ErrUserNotFound
+	}
+	if err != nil {
+		return false, err
+	}
+	n, _ := res.RowsAffected()
+	if err := tx.Commit(); err != nil {
+		return false, err
+	}
+	return n > 0, nil
+}
+
+func (r *roleRepo) Revoke(userID int, role string) error {
+	roleID, err := r.roleID(role)
+	if err != nil {
+		return err
+	}
+
+	_, err = r.db.Exec(`DELETE FROM user_roles WHERE user_id=$1 AND role_id=$2`, userID, roleID)
+	return err
+}
+
+// ListByUser returns the user's roles with their permissions.
+func (r *roleRepo) ListByUser(userID int) ([]model.Role, error) {
+	var rows []struct {
+		Name        string         `db:"name"`
+		Description string         `db:"description"`
+		Permissions pq.StringArray `db:"permissions"`
+	}
+	err := r.db.Select(&rows, `
+		SELECT r.name, r.description,
+		       COALESCE(array_agg(p.name ORDER BY p.name) FILTER (WHERE p.name IS NOT NULL), '{}') AS permissions
+		FROM user_roles ur
+		JOIN roles r ON r.id = ur.role_id
+		LEFT JOIN role_permissions rp ON rp.role_id = r.id
+		LEFT JOIN permissions p ON p.id = rp.permission_id
+		WHERE ur.user_id = $1
+		GROUP BY r.id
+		ORDER BY r.name`, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	roles := make([]model.Role, 0, len(rows))
+	for _, row := range rows {
+		roles = append(roles, model.Role{
+			Name:        row.Name,
+			Description: row.Description,
+			Permissions: row.Permissions,
+		})
+	}
+	return roles, nil
+}
+
+func (r *roleRepo) roleID(name string) (int, error) {
+	var id int
+	err := r.db.Get(&id, `SELECT id FROM roles WHERE name=$1`, name)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, model.ErrRoleNotFound
+	}
+	return id, err
+}
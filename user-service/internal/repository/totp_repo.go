@@ -0,0 +1,134 @@
+package repository
+
+import (
+	"user-service/internal/model"
+
+	"github.com/jmoiron/sqlx"
+)
+
+type TOTPRepository interface {
+	// Get returns sql.ErrNoRows if the user has never enrolled.
+	Get(userID int) (*model.UserTOTP, error)
+	SavePending(userID int, secret string) error
+	Confirm(userID int, step int64, recoveryCodeHashes []string) (bool, error)
+	UseStep(userID int, step int64) (bool, error)
+	UseRecoveryCode(userID int, codeHash string) (bool, error)
+	CountRecoveryCodes(userID int) (int, error)
+	Delete(userID int) error
+}
+
+type totpRepo struct {
+	db *sqlx.DB
+}
+
+func NewTOTPRepository(db *sqlx.DB) TOTPRepository {
+	return &totpRepo{db: db}
+}
+
+func (r *totpRepo) Get(userID int) (*model.UserTOTP, error) {
+	var t model.UserTOTP
+	err := r.db.Get(&t, `SELECT * FROM user_totp WHERE user_id=$1`, userID)
+	if err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
+
+// SavePending starts an enrollment, replacing an unconfirmed one. It returns
+// model.ErrTOTPAlreadyEnabled if the user has confirmed an enrollment.
+func (r *totpRepo) SavePending(userID int, secret string) error {
+	res, err := r.db.Exec(`
+		INSERT INTO user_totp (user_id, secret) VALUES ($1, $2)
+		ON CONFLICT (user_id) DO UPDATE
+			SET secret=EXCLUDED.secret, last_used_step=0, created_at=CURRENT_TIMESTAMP
+			WHERE user_totp.confirmed_at IS NULL`, userID, secret)
+	if err != nil {
+		return err
+	}
+	if n, err := res.RowsAffected(); err != nil {
+		return err
+	} else if n == 0 {
+		return model.ErrTOTPAlreadyEnabled
+	}
+	return nil
+}
+
+// Confirm enables the pending enrollment with the step of the code that
+// proved it, and replaces the user's recovery codes. It returns false if
+// there is no pending enrollment or the step was used already.
+func (r *totpRepo) Confirm(userID int, step int64, recoveryCodeHashes []string) (bool, error) {
+	tx, err := r.db.Beginx()
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback()
+
+	res, err := tx.Exec(`
+		UPDATE user_totp SET confirmed_at=CURRENT_TIMESTAMP, last_used_step=$2
+		WHERE user_id=$1 AND confirmed_at IS NULL AND last_used_step < $2`, userID, step)
+	if err != nil {
+		return false, err
+	}
+	if n, err := res.RowsAffected(); err != nil || n == 0 {
+		return false, err
+	}
+
+	if _, err := tx.Exec(`DELETE FROM totp_recovery_codes WHERE user_id=$1`, userID); err != nil {
+		return false, err
+	}
+	for _, hash := range recoveryCodeHashes {
+		if _, err := tx.Exec(
+			`INSERT INTO totp_recovery_codes (user_id, code_hash) VALUES ($1, $2)`, userID, hash); err != nil {
+			return false, err
+		}
+	}
+	return true, tx.Commit()
+}
+
+// UseStep records that a code of the step was accepted. It returns false if
+// that step or a later one was accepted before, i.e. the code is replayed.
+func (r *totpRepo) UseStep(userID int, step int64) (bool, error) {
+	res, err := r.db.Exec(
+		`UPDATE user_totp SET last_used_step=$2 WHERE user_id=$1 AND last_used_step < $2`, userID, step)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	return n == 1, err
+}
+
+// UseRecoveryCode uses up a recovery code; false means it is unknown or used.
+func (r *totpRepo) UseRecoveryCode(userID int, codeHash string) (bool, error) {
+	res, err := r.db.Exec(
+		`UPDATE totp_recovery_codes SET used_at=CURRENT_TIMESTAMP
+		 WHERE user_id=$1 AND code_hash=$2 AND used_at IS NULL`, userID, codeHash)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	return n == 1, err
+}
+
+func (r *totpRepo) CountRecoveryCodes(userID int) (int, error) {
+	var n int
+	err := r.db.Get(&n,
+		`SELECT COUNT(*) FROM totp_recovery_codes WHERE user_id=$1 AND used_at IS NULL`, userID)
+	return n, err
+}
+
+// Delete turns two-factor authentication off and drops the recovery codes.
+func (r *totpRepo) Delete(userID int) error {
+	tx, err := r.db.Beginx()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`DELETE FROM totp_recovery_codes WHERE user_id=$1`, userID); err != nil {
+		return err
+	}
+	if _, err := tx.Exec(`DELETE FROM user_totp WHERE user_id=$1`, userID); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
@@ -1,9 +1,13 @@
 package repository
 
 import (
+	"database/sql"
+	"errors"
+	"time"
 	"user-service/internal/model"
 
 	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
 )
 
 type UserRepository interface {
@@ -11,10 +15,15 @@ type UserRepository interface {
 	GetUserByEmail(email string) (model.User, error)
 	GetUserByID(id int) (model.User, error)
 	UpdateUser(user model.User) error
+	GetPasswordHash(id int) (string, error)
+	UpdatePassword(id int, passwordHash string) error
 	DeleteUser(id int) error
-	CreatePendingUser(user *model.User, token string) error
-	GetPendingByToken(token string) (*model.User, error)
+	CreatePendingUser(user *model.User, token string, expiresAt time.Time) error
+	GetPendingByToken(token string) (*model.PendingUser, error)
+	GetPendingByEmail(email string) (*model.PendingUser, error)
+	RenewPendingToken(email, token string, expiresAt time.Time) error
 	DeletePending(token string) error
+	DeleteExpiredPending() (int64, error)
 }
 
 type userRepo struct {
@@ -26,10 +35,15 @@ func NewUserRepository(db *sqlx.DB) UserRepository {
 }
 
 func (r *userRepo) CreateUser(user *model.User) error {
-	return r.db.QueryRowx(
+	err := r.db.QueryRowx(
 		`INSERT INTO users (email, password, name) VALUES ($1, $2, $3) RETURNING id`,
 		user.Email, user.Password, user.Name,
 	).Scan(&user.ID)
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) && pqErr.Code == "23505" { // unique_violation
+		return model.ErrEmailTaken
+	}
+	return err
 }
 
 func (r *userRepo) GetUserByEmail(email string) (model.User, error) {
@@ -44,28 +58,70 @@ func (r *userRepo) GetUserByID(id int) (model.User, error) {
 	return user, err
 }
 
+// UpdateUser saves the profile fields. The email and the password have
+// their own flows, see EmailChangeRepository and UpdatePassword.
 func (r *userRepo) UpdateUser(user model.User) error {
-	_, err := r.db.Exec(`UPDATE users SET name=$1, email=$2 WHERE id=$3`, user.Name, user.Email, user.ID)
-	return err
+	res, err := r.db.Exec(`UPDATE users SET name=$1 WHERE id=$2`, user.Name, user.ID)
+	if err != nil {
+		return err
+	}
+	if n, err := res.RowsAffected(); err != nil {
+		return err
+	} else if n == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+func (r *userRepo) GetPasswordHash(id int) (string, error) {
+	var hash string
+	err := r.db.Get(&hash, `SELECT password FROM users WHERE id=$1`, id)
+	return hash, err
+}
+
+// UpdatePassword sets a new password hash and revokes the user's refresh
+// tokens, ending all sessions.
+func (r *userRepo) UpdatePassword(id int, passwordHash string) error {
+	tx, err := r.db.Beginx()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`UPDATE users SET password=$1 WHERE id=$2`, passwordHash, id); err != nil {
+		return err
+	}
+	if _, err := tx.Exec(
+		`UPDATE refresh_tokens SET revoked_at=CURRENT_TIMESTAMP WHERE user_id=$1 AND revoked_at IS NULL`,
+		id); err != nil {
+		return err
+	}
+	return tx.Commit()
 }
 
 func (r *userRepo) DeleteUser(id int) error {
 	_, err := r.db.Exec(`DELETE FROM users WHERE id=$1`, id)
 	return err
 }
-func (r *userRepo) CreatePendingUser(u *model.User, token string) error {
+
+// CreatePendingUser stores a registration; registering the same email again
+// replaces the earlier registration and its token.
+func (r *userRepo) CreatePendingUser(u *model.User, token string, expiresAt time.Time) error {
 	_, err := r.db.Exec(
-		`INSERT INTO pending_users (email, name, password, token)
-       VALUES ($1,$2,$3,$4)`,
-		u.Email, u.Name, u.Password, token,
+		`INSERT INTO pending_users (email, name, password, token, expires_at)
+       VALUES ($1,$2,$3,$4,$5)
+       ON CONFLICT (email) DO UPDATE SET
+         name=EXCLUDED.name, password=EXCLUDED.password, token=EXCLUDED.token,
+         created_at=CURRENT_TIMESTAMP, expires_at=EXCLUDED.expires_at`,
+		u.Email, u.Name, u.Password, token, expiresAt,
 	)
 	return err
 }
 
-func (r *userRepo) GetPendingByToken(token string) (*model.User, error) {
-	var u model.User
+func (r *userRepo) GetPendingByToken(token string) (*model.PendingUser, error) {
+	var u model.PendingUser
 	err := r.db.Get(&u,
-		`SELECT email, name, password FROM pending_users WHERE token=$1`,
+		`SELECT email, name, password, token, created_at, expires_at FROM pending_users WHERE token=$1`,
 		token,
 	)
 	if err != nil {
@@ -74,7 +130,38 @@ func (r *userRepo) GetPendingByToken(token string) (*model.User, error) {
 	return &u, nil
 }
 
+func (r *userRepo) GetPendingByEmail(email string) (*model.PendingUser, error) {
+	var u model.PendingUser
+	err := r.db.Get(&u,
+		`SELECT email, name, password, token, created_at, expires_at FROM pending_users WHERE email=$1`,
+		email,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &u, nil
+}
+
+// RenewPendingToken replaces the token of a registration, so links sent
+// earlier stop working.
+func (r *userRepo) RenewPendingToken(email, token string, expiresAt time.Time) error {
+	_, err := r.db.Exec(
+		`UPDATE pending_users SET token=$1, expires_at=$2 WHERE email=$3`,
+		token, expiresAt, email,
+	)
+	return err
+}
+
 func (r *userRepo) DeletePending(token string) error {
 	_, err := r.db.Exec(`DELETE FROM pending_users WHERE token=$1`, token)
 	return err
 }
+
+// DeleteExpiredPending removes registrations that were never verified.
+func (r *userRepo) DeleteExpiredPending() (int64, error) {
+	res, err := r.db.Exec(`DELETE FROM pending_users WHERE expires_at < CURRENT_TIMESTAMP`)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
@@ -0,0 +1,56 @@
+package totp
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+// The SHA1 test vectors of RFC 6238, appendix B.
+func TestHOTPMatchesRFC6238(t *testing.T) {
+	key := []byte("12345678901234567890")
+	vectors := map[int64]string{
+		59:          "94287082",
+		1111111109:  "07081804",
+		1111111111:  "14050471",
+		1234567890:  "89005924",
+		2000000000:  "69279037",
+		20000000000: "65353130",
+	}
+	for unix, want := range vectors {
+		if got := hotp(key, uint64(Step(time.Unix(unix, 0))), 8); got != want {
+			t.Errorf("t=%d: got %s, want %s", unix, got, want)
+		}
+	}
+}
+
+func TestValidateAcceptsNeighbouringSteps(t *testing.T) {
+	secret, err := GenerateSecret()
+	if err != nil {
+		t.Fatalf("generate: %v", err)
+	}
+	now := time.Unix(1700000000, 0)
+
+	for _, offset := range []int64{-1, 0, 1} {
+		code, _ := Code(secret, Step(now)+offset)
+		step, ok := Validate(secret, code, now)
+		if !ok || step != Step(now)+offset {
+			t.Errorf("offset %d: got step %d, ok %v", offset, step, ok)
+		}
+	}
+
+	stale, _ := Code(secret, Step(now)-2)
+	if _, ok := Validate(secret, stale, now); ok {
+		t.Error("accepted a code two steps old")
+	}
+	if _, ok := Validate(secret, "12345", now); ok {
+		t.Error("accepted a short code")
+	}
+}
+
+func TestURI(t *testing.T) {
+	uri := URI("Shop", "user@shop.test", "ABC")
+	if !strings.HasPrefix(uri, "otpauth://totp/Shop:user@shop.test?") || !strings.Contains(uri, "secret=ABC") {
+		t.Errorf("unexpected uri %s", uri)
+	}
+}
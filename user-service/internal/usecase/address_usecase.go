@@ -0,0 +1,69 @@
+package usecase
+
+import (
+	"user-service/internal/model"
+	"user-service/internal/repository"
+)
+
+// AddressUsecase manages a user's address book. Every method is scoped to
+// userID.
+type AddressUsecase interface {
+	Add(userID int, a model.Address) (model.Address, error)
+	List(userID int) ([]model.Address, error)
+	Get(userID, id int) (model.Address, error)
+	Update(userID int, a model.Address) (model.Address, error)
+	Delete(userID, id int) error
+	SetDefault(userID, id int) (model.Address, error)
+}
+
+type addressUsecase struct {
+	addresses repository.AddressRepository
+}
+
+func NewAddressUsecase(addresses repository.AddressRepository) AddressUsecase {
+	return &addressUsecase{addresses: addresses}
+}
+
+func (u *addressUsecase) Add(userID int, a model.Address) (model.Address, error) {
+	a.UserID = userID
+	a.Normalize()
+	if err := a.Validate(); err != nil {
+		return model.Address{}, err
+	}
+	if err := u.addresses.Create(&a); err != nil {
+		return model.Address{}, err
+	}
+	return a, nil
+}
+
+func (u *addressUsecase) List(userID int) ([]model.Address, error) {
+	return u.addresses.ListByUser(userID)
+}
+
+func (u *addressUsecase) Get(userID, id int) (model.Address, error) {
+	return u.addresses.Get(userID, id)
+}
+
+func (u *addressUsecase) Update(userID int, a model.Address) (model.Address, error) {
+	a.UserID = userID
+	a.Normalize()
+	if err := a.Validate(); err != nil {
+		return model.Address{}, err
+	}
+	if err := u.addresses.Update(&a); err != nil {
+		return model.Address{}, err
+	}
+	return a, nil
+}
+
+func (u *addressUsecase) Delete(userID, id int) error {
+	return u.addresses.Delete(userID, id)
+}
+
+// SetDefault makes the address the one preselected for new orders.
+func (u *addressUsecase) SetDefault(userID, id int) (model.Address, error) {
+	if err := u.addresses.SetDefault(userID, id); err != nil {
+		return model.Address{}, err
+	}
+	return u.addresses.Get(userID, id)
+}
@@ -0,0 +1,187 @@
+package usecase
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/pem"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"user-service/internal/model"
+	"user-service/logger"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const (
+	TokenIssuer   = "user-service"
+	TokenAudience = "ecommerce-platform"
+)
+
+// JWTSigner signs access tokens with Ed25519 (JWS alg EdDSA). Its key set
+// holds the signing key plus retired public keys, so tokens signed before a
+// key rotation stay verifiable until they expire.
+type JWTSigner struct {
+	key     ed25519.PrivateKey
+	kid     string
+	retired []ed25519.PublicKey
+}
+
+// NewJWTSignerFromEnv loads the PKCS#8 PEM private key from
+// JWT_PRIVATE_KEY_FILE and retired PEM public keys from the comma-separated
+// JWT_RETIRED_PUBLIC_KEY_FILES. Without a key file it generates a key, which
+// is only fit for development: tokens do not survive a restart.
+func NewJWTSignerFromEnv() (*JWTSigner, error) {
+	var s JWTSigner
+
+	if path := os.Getenv("JWT_PRIVATE_KEY_FILE"); path != "" {
+		key, err := readPEMKey(path)
+		if err != nil {
+			return nil, err
+		}
+		priv, ok := key.(ed25519.PrivateKey)
+		if !ok {
+			return nil, fmt.Errorf("%s: not an Ed25519 private key", path)
+		}
+		s.key = priv
+	} else {
+		logger.Log.Warn("JWT_PRIVATE_KEY_FILE not set, signing tokens with a temporary key")
+		_, priv, err := ed25519.GenerateKey(rand.Reader)
+		if err != nil {
+			return nil, err
+		}
+		s.key = priv
+	}
+	s.kid = keyID(s.key.Public().(ed25519.PublicKey))
+
+	for _, path := range strings.Split(os.Getenv("JWT_RETIRED_PUBLIC_KEY_FILES"), ",") {
+		if path = strings.TrimSpace(path); path == "" {
+			continue
+		}
+		key, err := readPEMKey(path)
+		if err != nil {
+			return nil, err
+		}
+		pub, ok := key.(ed25519.PublicKey)
+		if !ok {
+			return nil, fmt.Errorf("%s: not an Ed25519 public key", path)
+		}
+		s.retired = append(s.retired, pub)
+	}
+	return &s, nil
+}
+
+func (s *JWTSigner) Sign(c model.AccessClaims) (string, error) {
+	token := jwt.NewWithClaims(jwt.SigningMethodEdDSA, accessTokenClaims{
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        c.ID,
+			Issuer:    TokenIssuer,
+			Subject:   strconv.Itoa(c.UserID),
+			Audience:  jwt.ClaimStrings{TokenAudience},
+			IssuedAt:  jwt.NewNumericDate(c.IssuedAt),
+			NotBefore: jwt.NewNumericDate(c.IssuedAt),
+			ExpiresAt: jwt.NewNumericDate(c.ExpiresAt),
+		},
+		Email:       c.Email,
+		Roles:       c.Roles,
+		Permissions: c.Permissions,
+	})
+	token.Header["kid"] = s.kid
+	return token.SignedString(s.key)
+}
+
+// Verify checks a token signed with the signing key or a retired key.
+func (s *JWTSigner) Verify(token string) (model.AccessClaims, error) {
+	var claims accessTokenClaims
+	_, err := jwt.ParseWithClaims(token, &claims, s.verificationKey,
+		jwt.WithValidMethods([]string{jwt.SigningMethodEdDSA.Alg()}),
+		jwt.WithIssuer(TokenIssuer),
+		jwt.WithAudience(TokenAudience),
+		jwt.WithExpirationRequired(),
+	)
+	if err != nil {
+		return model.AccessClaims{}, fmt.Errorf("%w: %v", model.ErrInvalidAccessToken, err)
+	}
+
+	userID, err := strconv.Atoi(claims.Subject)
+	if err != nil {
+		return model.AccessClaims{}, fmt.Errorf("%w: subject %q", model.ErrInvalidAccessToken, claims.Subject)
+	}
+	return model.AccessClaims{
+		ID:          claims.ID,
+		UserID:      userID,
+		Email:       claims.Email,
+		Roles:       claims.Roles,
+		Permissions: claims.Permissions,
+		IssuedAt:    claims.IssuedAt.Time,
+		ExpiresAt:   claims.ExpiresAt.Time,
+	}, nil
+}
+
+func (s *JWTSigner) verificationKey(token *jwt.Token) (interface{}, error) {
+	kid, _ := token.Header["kid"].(string)
+	if kid == s.kid {
+		return s.key.Public(), nil
+	}
+	for _, pub := range s.retired {
+		if keyID(pub) == kid {
+			return pub, nil
+		}
+	}
+	return nil, fmt.Errorf("unknown key %q", kid)
+}
+
+func (s *JWTSigner) KeySet() model.KeySet {
+	set := model.KeySet{Keys: []model.JWK{jwk(s.key.Public().(ed25519.PublicKey))}}
+	for _, pub := range s.retired {
+		set.Keys = append(set.Keys, jwk(pub))
+	}
+	return set
+}
+
+type accessTokenClaims struct {
+	jwt.RegisteredClaims
+	Email       string   `json:"email"`
+	Roles       []string `json:"roles,omitempty"`
+	Permissions []string `json:"permissions,omitempty"`
+}
+
+func jwk(pub ed25519.PublicKey) model.JWK {
+	return model.JWK{
+		Kty: "OKP",
+		Crv: "Ed25519",
+		X:   base64.RawURLEncoding.EncodeToString(pub),
+		Kid: keyID(pub),
+		Use: "sig",
+		Alg: "EdDSA",
+	}
+}
+
+// keyID derives a stable kid from the public key.
+func keyID(pub ed25519.PublicKey) string {
+	sum := sha256.Sum256(pub)
+	return hex.EncodeToString(sum[:8])
+}
+
+func readPEMKey(path string) (interface{}, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("%s: no PEM data", path)
+	}
+	switch block.Type {
+	case "PRIVATE KEY":
+		return x509.ParsePKCS8PrivateKey(block.Bytes)
+	case "PUBLIC KEY":
+		return x509.ParsePKIXPublicKey(block.Bytes)
+	}
+	return nil, fmt.Errorf("%s: unexpected PEM block %q", path, block.Type)
+}
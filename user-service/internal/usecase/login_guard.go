@@ -0,0 +1,197 @@
+package usecase
+
+import (
+	"encoding/json"
+	"strings"
+	"time"
+	"user-service/internal/model"
+	"user-service/internal/repository"
+	"user-service/logger"
+
+	"github.com/google/uuid"
+)
+
+// EventPublisher publishes an event on a subject; eventID deduplicates
+// retries.
+type EventPublisher interface {
+	Publish(subject, eventID string, data []byte) error
+}
+
+// LoginPolicy decides how failed logins for one key are throttled.
+type LoginPolicy struct {
+	// Window is how long a failure is remembered; the count restarts after a
+	// quiet period this long.
+	Window time.Duration
+	// BackoffAfter failures make the key wait BaseDelay before the next
+	// attempt, doubling with every further failure.
+	BackoffAfter int
+	BaseDelay    time.Duration
+	// LockAfter failures lock the key for LockDuration.
+	LockAfter    int
+	LockDuration time.Duration
+}
+
+// delay returns how long the key has to wait after its failures-th failure.
+func (p LoginPolicy) delay(failures int) time.Duration {
+	if failures >= p.LockAfter {
+		return p.LockDuration
+	}
+	if failures < p.BackoffAfter {
+		return 0
+	}
+	d := p.BaseDelay << (failures - p.BackoffAfter)
+	if d <= 0 || d > p.LockDuration {
+		return p.LockDuration
+	}
+	return d
+}
+
+var (
+	// AccountLoginPolicy throttles guessing the password of one account.
+	AccountLoginPolicy = LoginPolicy{
+		Window:       15 * time.Minute,
+		BackoffAfter: 3,
+		BaseDelay:    time.Second,
+		LockAfter:    10,
+		LockDuration: 15 * time.Minute,
+	}
+	// IPLoginPolicy throttles one client trying many accounts. It is looser
+	// since several users can share an address.
+	IPLoginPolicy = LoginPolicy{
+		Window:       15 * time.Minute,
+		BackoffAfter: 20,
+		BaseDelay:    time.Second,
+		LockAfter:    100,
+		LockDuration: 15 * time.Minute,
+	}
+)
+
+// LoginGuard tracks failed logins per account and per client IP and rejects
+// attempts while either is backing off or locked.
+type LoginGuard interface {
+	// Check returns a *model.LoginLockedError if the attempt must wait.
+	Check(email, clientIP string) error
+	Failed(email, clientIP string)
+	Succeeded(email string)
+	Unlock(email string) error
+	// DeleteStale forgets failures older than the policy windows.
+	DeleteStale() (int64, error)
+}
+
+type loginGuard struct {
+	attempts repository.LoginAttemptRepository
+	events   EventPublisher
+	account  LoginPolicy
+	ip       LoginPolicy
+}
+
+func NewLoginGuard(attempts repository.LoginAttemptRepository, events EventPublisher, account, ip LoginPolicy) LoginGuard {
+	return &loginGuard{attempts: attempts, events: events, account: account, ip: ip}
+}
+
+func accountKey(email string) string {
+	return "account:" + strings.ToLower(strings.TrimSpace(email))
+}
+
+func ipKey(clientIP string) string {
+	return "ip:" + clientIP
+}
+
+func (g *loginGuard) Check(email, clientIP string) error {
+	keys := []string{accountKey(email)}
+	if clientIP != "" {
+		keys = append(keys, ipKey(clientIP))
+	}
+
+	var wait time.Duration
+	for _, key := range keys {
+		until, err := g.attempts.LockedUntil(key)
+		if err != nil {
+			return err
+		}
+		if d := time.Until(until); d > wait {
+			wait = d
+		}
+	}
+	if wait > 0 {
+		return &model.LoginLockedError{RetryAfter: wait}
+	}
+	return nil
+}
+
+// Failed counts a failed attempt against the account and the client IP.
+// Unknown emails are counted too, so throttling does not reveal which
+// accounts exist. Store errors are logged; the login has failed anyway.
+func (g *loginGuard) Failed(email, clientIP string) {
+	failures, lockedUntil := g.fail(accountKey(email), g.account)
+	if clientIP != "" {
+		g.fail(ipKey(clientIP), g.ip)
+	}
+
+	event := model.LoginEvent{
+		Email:      email,
+		ClientIP:   clientIP,
+		Failures:   failures,
+		OccurredAt: time.Now().UTC(),
+	}
+	g.publish(model.SubjectLoginFailed, event)
+	if failures >= g.account.LockAfter {
+		event.LockedUntil = &lockedUntil
+		g.publish(model.SubjectUserLocked, event)
+	}
+}
+
+// fail records a failure for key and applies the policy's delay. It returns
+// the failure count and when the key unlocks.
+func (g *loginGuard) fail(key string, policy LoginPolicy) (int, time.Time) {
+	failures, err := g.attempts.RecordFailure(key, policy.Window)
+	if err != nil {
+		logger.Log.Errorf("Failed to record failed login for %s: %v", key, err)
+		return 0, time.Time{}
+	}
+	delay := policy.delay(failures)
+	if delay == 0 {
+		return failures, time.Time{}
+	}
+
+	until := time.Now().Add(delay).UTC()
+	if err := g.attempts.Lock(key, until); err != nil {
+		logger.Log.Errorf("Failed to lock %s: %v", key, err)
+	}
+	if failures >= policy.LockAfter {
+		logger.Log.Warnf("Locked %s after %d failed logins until %s", key, failures, until.Format(time.RFC3339))
+	}
+	return failures, until
+}
+
+// Succeeded clears the account's failures. The client IP keeps its count,
+// so one valid login does not reset a sweep over other accounts.
+func (g *loginGuard) Succeeded(email string) {
+	if err := g.attempts.Reset(accountKey(email)); err != nil {
+		logger.Log.Errorf("Failed to reset failed logins: %v", err)
+	}
+}
+
+func (g *loginGuard) Unlock(email string) error {
+	return g.attempts.Reset(accountKey(email))
+}
+
+func (g *loginGuard) DeleteStale() (int64, error) {
+	return g.attempts.DeleteStale(max(g.account.Window, g.ip.Window))
+}
+
+// publish sends the event in the background; events are best-effort and
+// must not slow down the login response.
+func (g *loginGuard) publish(subject string, event model.LoginEvent) {
+	event.EventID = uuid.NewString()
+	data, err := json.Marshal(event)
+	if err != nil {
+		logger.Log.Errorf("Failed to encode %s event: %v", subject, err)
+		return
+	}
+	go func() {
+		if err := g.events.Publish(subject, event.EventID, data); err != nil {
+			logger.Log.Errorf("Failed to publish %s event: %v", subject, err)
+		}
+	}()
+}
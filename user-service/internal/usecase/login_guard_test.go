@@ -0,0 +1,116 @@
+package usecase
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+	"user-service/internal/model"
+)
+
+type memoryAttempts struct {
+	failures map[string]int
+	locked   map[string]time.Time
+}
+
+func newMemoryAttempts() *memoryAttempts {
+	return &memoryAttempts{failures: map[string]int{}, locked: map[string]time.Time{}}
+}
+
+func (m *memoryAttempts) RecordFailure(key string, _ time.Duration) (int, error) {
+	m.failures[key]++
+	return m.failures[key], nil
+}
+
+func (m *memoryAttempts) LockedUntil(key string) (time.Time, error) { return m.locked[key], nil }
+
+func (m *memoryAttempts) Lock(key string, until time.Time) error {
+	if until.After(m.locked[key]) {
+		m.locked[key] = until
+	}
+	return nil
+}
+
+func (m *memoryAttempts) Reset(key string) error {
+	delete(m.failures, key)
+	delete(m.locked, key)
+	return nil
+}
+
+func (m *memoryAttempts) DeleteStale(time.Duration) (int64, error) { return 0, nil }
+
+type recordingPublisher struct {
+	mu       sync.Mutex
+	subjects []string
+}
+
+func (p *recordingPublisher) Publish(subject, _ string, _ []byte) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.subjects = append(p.subjects, subject)
+	return nil
+}
+
+func TestLoginPolicyDelay(t *testing.T) {
+	p := AccountLoginPolicy
+	cases := map[int]time.Duration{
+		1:  0,
+		2:  0,
+		3:  time.Second,
+		4:  2 * time.Second,
+		9:  64 * time.Second,
+		10: 15 * time.Minute,
+		40: 15 * time.Minute,
+	}
+	for failures, want := range cases {
+		if got := p.delay(failures); got != want {
+			t.Errorf("delay(%d) = %s, want %s", failures, got, want)
+		}
+	}
+}
+
+func TestLoginGuardLocksAccountAndUnlocks(t *testing.T) {
+	attempts := newMemoryAttempts()
+	guard := NewLoginGuard(attempts, &recordingPublisher{}, AccountLoginPolicy, IPLoginPolicy)
+
+	if err := guard.Check("User@shop.test", "10.0.0.1"); err != nil {
+		t.Fatalf("fresh account rejected: %v", err)
+	}
+	for i := 0; i < AccountLoginPolicy.LockAfter; i++ {
+		guard.Failed("user@shop.test", "10.0.0.1")
+	}
+
+	var locked *model.LoginLockedError
+	if err := guard.Check("USER@shop.test", "10.0.0.2"); !errors.As(err, &locked) {
+		t.Fatalf("expected a lockout, got %v", err)
+	}
+	if locked.RetryAfter < 14*time.Minute {
+		t.Errorf("unexpected retry after %s", locked.RetryAfter)
+	}
+	if err := guard.Check("other@shop.test", "10.0.0.2"); err != nil {
+		t.Errorf("other account rejected: %v", err)
+	}
+
+	if err := guard.Unlock("user@shop.test"); err != nil {
+		t.Fatalf("unlock: %v", err)
+	}
+	if err := guard.Check("user@shop.test", "10.0.0.2"); err != nil {
+		t.Errorf("unlocked account rejected: %v", err)
+	}
+}
+
+func TestLoginGuardThrottlesClientIP(t *testing.T) {
+	attempts := newMemoryAttempts()
+	guard := NewLoginGuard(attempts, &recordingPublisher{}, AccountLoginPolicy, IPLoginPolicy)
+
+	// One failure each for many accounts only trips the IP policy.
+	for i := 0; i < IPLoginPolicy.BackoffAfter; i++ {
+		guard.Failed(string(rune('a'+i))+"@shop.test", "10.0.0.1")
+	}
+	if err := guard.Check("fresh@shop.test", "10.0.0.1"); err == nil {
+		t.Fatal("expected the client IP to back off")
+	}
+	if err := guard.Check("fresh@shop.test", "10.0.0.2"); err != nil {
+		t.Errorf("other client rejected: %v", err)
+	}
+}
@@ -0,0 +1,40 @@
+package usecase
+
+import (
+	"user-service/internal/model"
+	"user-service/internal/repository"
+)
+
+type RoleUsecase interface {
+	AssignRole(userID int, role string) ([]model.Role, error)
+	RevokeRole(userID int, role string) ([]model.Role, error)
+	ListUserRoles(userID int) ([]model.Role, error)
+}
+
+type roleUsecase struct {
+	roles repository.RoleRepository
+}
+
+func NewRoleUsecase(roles repository.RoleRepository) RoleUsecase {
+	return &roleUsecase{roles: roles}
+}
+
+// AssignRole gives the user a role and returns the user's roles afterwards.
+func (u *roleUsecase) AssignRole(userID int, role string) ([]model.Role, error) {
+	if err := u.roles.Assign(userID, role); err != nil {
+		return nil, err
+	}
+	return u.roles.ListByUser(userID)
+}
+
+// RevokeRole takes a role away and returns the user's remaining roles.
+func (u *roleUsecase) RevokeRole(userID int, role string) ([]model.Role, error) {
+	if err := u.roles.Revoke(userID, role); err != nil {
+		return nil, err
+	}
+	return u.roles.ListByUser(userID)
+}
+
+func (u *roleUsecase) ListUserRoles(userID int) ([]model.Role, error) {
+	return u.roles.ListByUser(userID)
+}
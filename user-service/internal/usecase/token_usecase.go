@@ -0,0 +1,206 @@
+package usecase
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"strings"
+	"time"
+	"user-service/internal/model"
+	"user-service/internal/repository"
+
+	"github.com/google/uuid"
+)
+
+const (
+	AccessTokenTTL  = 15 * time.Minute
+	RefreshTokenTTL = 30 * 24 * time.Hour
+)
+
+// TokenSigner signs access tokens and publishes the keys that verify them.
+type TokenSigner interface {
+	Sign(claims model.AccessClaims) (string, error)
+	Verify(token string) (model.AccessClaims, error)
+	KeySet() model.KeySet
+}
+
+type TokenUsecase interface {
+	Issue(user model.User) (model.TokenPair, error)
+	Refresh(refreshToken string) (model.TokenPair, error)
+	Logout(refreshToken string, allSessions bool) error
+	Verify(accessToken string) (model.AccessClaims, error)
+	KeySet() model.KeySet
+}
+
+type tokenUsecase struct {
+	users  repository.UserRepository
+	tokens repository.RefreshTokenRepository
+	roles  repository.RoleRepository
+	signer TokenSigner
+	admins map[string]bool
+}
+
+// NewTokenUsecase issues access tokens that carry the user's roles and
+// permissions. While nobody is an admin, a user whose email is in
+// adminEmails is made one on login, so a fresh installation has someone to
+// assign roles; once there is an admin, revoking the role sticks.
+func NewTokenUsecase(users repository.UserRepository, tokens repository.RefreshTokenRepository, roles repository.RoleRepository, signer TokenSigner, adminEmails []string) TokenUsecase {
+	admins := make(map[string]bool, len(adminEmails))
+	for _, email := range adminEmails {
+		if email = strings.TrimSpace(email); email != "" {
+			admins[strings.ToLower(email)] = true
+		}
+	}
+	return &tokenUsecase{users: users, tokens: tokens, roles: roles, signer: signer, admins: admins}
+}
+
+// Issue starts a new session for a user who just logged in.
+func (u *tokenUsecase) Issue(user model.User) (model.TokenPair, error) {
+	if u.admins[strings.ToLower(user.Email)] {
+		if _, err := u.roles.Bootstrap(user.ID, model.RoleAdmin); err != nil {
+			return model.TokenPair{}, err
+		}
+	}
+
+	refresh, raw, err := newRefreshToken(user.ID, uuid.NewString())
+	if err != nil {
+		return model.TokenPair{}, err
+	}
+	if err := u.tokens.Create(refresh); err != nil {
+		return model.TokenPair{}, err
+	}
+	return u.pair(user, raw)
+}
+
+// Refresh exchanges a refresh token for a new token pair. The presented
+// token is revoked; presenting it again revokes the whole session.
+func (u *tokenUsecase) Refresh(refreshToken string) (model.TokenPair, error) {
+	current, err := u.tokens.GetByHash(hashToken(refreshToken))
+	if errors.Is(err, sql.ErrNoRows) {
+		return model.TokenPair{}, model.ErrInvalidRefreshToken
+	}
+	if err != nil {
+		return model.TokenPair{}, err
+	}
+	if current.RevokedAt != nil {
+		if current.ReplacedBy != nil {
+			if err := u.tokens.RevokeFamily(current.FamilyID); err != nil {
+				return model.TokenPair{}, err
+			}
+			return model.TokenPair{}, model.ErrRefreshTokenReused
+		}
+		return model.TokenPair{}, model.ErrInvalidRefreshToken
+	}
+	if time.Now().After(current.ExpiresAt) {
+		return model.TokenPair{}, model.ErrInvalidRefreshToken
+	}
+
+	user, err := u.users.GetUserByID(current.UserID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return model.TokenPair{}, model.ErrInvalidRefreshToken
+	}
+	if err != nil {
+		return model.TokenPair{}, err
+	}
+
+	next, raw, err := newRefreshToken(user.ID, current.FamilyID)
+	if err != nil {
+		return model.TokenPair{}, err
+	}
+	rotated, err := u.tokens.Rotate(current.ID, next)
+	if err != nil {
+		return model.TokenPair{}, err
+	}
+	if !rotated {
+		// Lost a race against another refresh with the same token.
+		if err := u.tokens.RevokeFamily(current.FamilyID); err != nil {
+			return model.TokenPair{}, err
+		}
+		return model.TokenPair{}, model.ErrRefreshTokenReused
+	}
+	return u.pair(user, raw)
+}
+
+// Logout revokes the session the refresh token belongs to, or every session
+// of its user. Unknown tokens are ignored.
+func (u *tokenUsecase) Logout(refreshToken string, allSessions bool) error {
+	current, err := u.tokens.GetByHash(hashToken(refreshToken))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if allSessions {
+		return u.tokens.RevokeAllForUser(current.UserID)
+	}
+	return u.tokens.RevokeFamily(current.FamilyID)
+}
+
+// Verify checks an access token issued by this service.
+func (u *tokenUsecase) Verify(accessToken string) (model.AccessClaims, error) {
+	return u.signer.Verify(accessToken)
+}
+
+func (u *tokenUsecase) KeySet() model.KeySet {
+	return u.signer.KeySet()
+}
+
+func (u *tokenUsecase) pair(user model.User, refreshToken string) (model.TokenPair, error) {
+	roles, err := u.roles.ListByUser(user.ID)
+	if err != nil {
+		return model.TokenPair{}, err
+	}
+
+	now := time.Now()
+	access, err := u.signer.Sign(model.AccessClaims{
+		ID:          uuid.NewString(),
+		UserID:      user.ID,
+		Email:       user.Email,
+		Roles:       model.RoleNames(roles),
+		Permissions: model.PermissionsOf(roles),
+		IssuedAt:    now,
+		ExpiresAt:   now.Add(AccessTokenTTL),
+	})
+	if err != nil {
+		return model.TokenPair{}, err
+	}
+	return model.TokenPair{
+		AccessToken:      access,
+		ExpiresIn:        AccessTokenTTL,
+		RefreshToken:     refreshToken,
+		RefreshExpiresIn: RefreshTokenTTL,
+	}, nil
+}
+
+// newRefreshToken returns the record to store and the raw token for the
+// client; only its hash is kept.
+func newRefreshToken(userID int, familyID string) (*model.RefreshToken, string, error) {
+	raw, err := randomToken()
+	if err != nil {
+		return nil, "", err
+	}
+	return &model.RefreshToken{
+		UserID:    userID,
+		TokenHash: hashToken(raw),
+		FamilyID:  familyID,
+		ExpiresAt: time.Now().Add(RefreshTokenTTL),
+	}, raw, nil
+}
+
+// randomToken returns 256 random bits, URL-safe encoded.
+func randomToken() (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(buf), nil
+}
+
+func hashToken(raw string) string {
+	sum := sha256.Sum256([]byte(raw))
+	return hex.EncodeToString(sum[:])
+}
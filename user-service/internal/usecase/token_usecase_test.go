@@ -0,0 +1,81 @@
+package usecase
+
+import (
+	"testing"
+	"user-service/internal/model"
+	"user-service/internal/repository"
+)
+
+// memoryRoles keeps role assignments per user.
+type memoryRoles struct {
+	repository.RoleRepository
+	assigned map[int]map[string]bool
+}
+
+func (m *memoryRoles) Bootstrap(userID int, role string) (bool, error) {
+	for _, roles := range m.assigned {
+		if roles[role] {
+			return false, nil
+		}
+	}
+	if m.assigned[userID] == nil {
+		m.assigned[userID] = map[string]bool{}
+	}
+	m.assigned[userID][role] = true
+	return true, nil
+}
+
+func (m *memoryRoles) Revoke(userID int, role string) error {
+	delete(m.assigned[userID], role)
+	return nil
+}
+
+func (m *memoryRoles) ListByUser(userID int) ([]model.Role, error) {
+	var roles []model.Role
+	for name := range m.assigned[userID] {
+		roles = append(roles, model.Role{Name: name})
+	}
+	return roles, nil
+}
+
+type discardTokens struct {
+	repository.RefreshTokenRepository
+}
+
+func (discardTokens) Create(*model.RefreshToken) error { return nil }
+
+// claimsSigner returns the roles as the token, for inspection.
+type claimsSigner struct {
+	TokenSigner
+}
+
+func (claimsSigner) Sign(claims model.AccessClaims) (string, error) {
+	if len(claims.Roles) == 0 {
+		return "-", nil
+	}
+	return claims.Roles[0], nil
+}
+
+func TestIssueBootstrapsAdminOnce(t *testing.T) {
+	roles := &memoryRoles{assigned: map[int]map[string]bool{}}
+	u := NewTokenUsecase(nil, discardTokens{}, roles, claimsSigner{}, []string{" Root@Example.com", ""})
+
+	root := model.User{ID: 1, Email: "root@example.com"}
+	other := model.User{ID: 2, Email: "other@example.com"}
+
+	if pair, err := u.Issue(other); err != nil || pair.AccessToken != "-" {
+		t.Fatalf("user not in ADMIN_EMAILS: got %q, %v", pair.AccessToken, err)
+	}
+	if pair, err := u.Issue(root); err != nil || pair.AccessToken != model.RoleAdmin {
+		t.Fatalf("first login of a bootstrap admin: got %q, %v", pair.AccessToken, err)
+	}
+
+	// Another admin exists, so a revoked bootstrap admin stays revoked.
+	roles.assigned[3] = map[string]bool{model.RoleAdmin: true}
+	if err := roles.Revoke(root.ID, model.RoleAdmin); err != nil {
+		t.Fatal(err)
+	}
+	if pair, err := u.Issue(root); err != nil || pair.AccessToken != "-" {
+		t.Fatalf("login after revoke: got %q, %v", pair.AccessToken, err)
+	}
+}
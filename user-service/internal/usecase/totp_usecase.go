@@ -0,0 +1,271 @@
+package usecase
+
+import (
+	"crypto/rand"
+	"database/sql"
+	"encoding/base32"
+	"errors"
+	"strings"
+	"time"
+	"user-service/internal/model"
+	"user-service/internal/repository"
+	"user-service/internal/totp"
+)
+
+const (
+	// LoginChallengeTTL is how long a user has to enter the code after the
+	// password.
+	LoginChallengeTTL = 5 * time.Minute
+	// LoginChallengeAttempts is how many wrong codes a challenge survives.
+	LoginChallengeAttempts = 5
+	// RecoveryCodeCount recovery codes are issued when 2FA is turned on.
+	RecoveryCodeCount = 10
+)
+
+// TOTPUsecase manages authenticator-app two-factor authentication. Codes
+// are either a current TOTP code or an unused recovery code.
+type TOTPUsecase interface {
+	Status(userID int) (model.TOTPStatus, error)
+	Enroll(userID int) (model.TOTPEnrollment, error)
+	Confirm(userID int, code string) ([]string, error)
+	Disable(userID int, code string) error
+	// Challenge starts the second step of a login. The token is empty if
+	// the user has not enabled two-factor authentication.
+	Challenge(userID int) (string, error)
+	// ChallengeUser returns the user of a pending challenge without checking
+	// a code, or model.ErrInvalidLoginChallenge.
+	ChallengeUser(token string) (int, error)
+	// CompleteChallenge checks the code for a challenge and returns its
+	// user. The user is also returned with model.ErrInvalidTOTPCode, so the
+	// failure can be counted against the account.
+	CompleteChallenge(token, code string) (int, error)
+}
+
+type totpUsecase struct {
+	users      repository.UserRepository
+	totp       repository.TOTPRepository
+	challenges repository.LoginChallengeRepository
+	issuer     string
+}
+
+// NewTOTPUsecase names issuer as the account's issuer in authenticator apps.
+func NewTOTPUsecase(users repository.UserRepository, totp repository.TOTPRepository, challenges repository.LoginChallengeRepository, issuer string) TOTPUsecase {
+	return &totpUsecase{users: users, totp: totp, challenges: challenges, issuer: issuer}
+}
+
+func (u *totpUsecase) Status(userID int) (model.TOTPStatus, error) {
+	t, err := u.enabled(userID)
+	if errors.Is(err, model.ErrTOTPNotEnabled) {
+		return model.TOTPStatus{}, nil
+	}
+	if err != nil {
+		return model.TOTPStatus{}, err
+	}
+	left, err := u.totp.CountRecoveryCodes(t.UserID)
+	if err != nil {
+		return model.TOTPStatus{}, err
+	}
+	return model.TOTPStatus{Enabled: true, RecoveryCodesLeft: left}, nil
+}
+
+// Enroll creates a new secret; it takes effect once Confirm proves the
+// authenticator app has it. Enrolling again before that replaces the secret.
+func (u *totpUsecase) Enroll(userID int) (model.TOTPEnrollment, error) {
+	user, err := u.users.GetUserByID(userID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return model.TOTPEnrollment{}, model.ErrUserNotFound
+	}
+	if err != nil {
+		return model.TOTPEnrollment{}, err
+	}
+
+	secret, err := totp.GenerateSecret()
+	if err != nil {
+		return model.TOTPEnrollment{}, err
+	}
+	if err := u.totp.SavePending(userID, secret); err != nil {
+		return model.TOTPEnrollment{}, err
+	}
+	return model.TOTPEnrollment{Secret: secret, URI: totp.URI(u.issuer, user.Email, secret)}, nil
+}
+
+// Confirm turns two-factor authentication on with a code from the
+// authenticator app and returns the recovery codes. They are shown once;
+// only their hashes are kept.
+func (u *totpUsecase) Confirm(userID int, code string) ([]string, error) {
+	t, err := u.totp.Get(userID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, model.ErrTOTPNotEnrolled
+	}
+	if err != nil {
+		return nil, err
+	}
+	if t.Enabled() {
+		return nil, model.ErrTOTPAlreadyEnabled
+	}
+
+	step, ok := totp.Validate(t.Secret, code, time.Now())
+	if !ok {
+		return nil, model.ErrInvalidTOTPCode
+	}
+
+	codes := make([]string, RecoveryCodeCount)
+	hashes := make([]string, RecoveryCodeCount)
+	for i := range codes {
+		if codes[i], err = recoveryCode(); err != nil {
+			return nil, err
+		}
+		hashes[i] = hashToken(normalizeRecoveryCode(codes[i]))
+	}
+	ok, err = u.totp.Confirm(userID, step, hashes)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, model.ErrInvalidTOTPCode
+	}
+	return codes, nil
+}
+
+// Disable turns two-factor authentication off. An enabled setup needs a
+// valid code; an unconfirmed enrollment is simply dropped.
+func (u *totpUsecase) Disable(userID int, code string) error {
+	t, err := u.totp.Get(userID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return model.ErrTOTPNotEnabled
+	}
+	if err != nil {
+		return err
+	}
+	if t.Enabled() {
+		if err := u.check(t, code); err != nil {
+			return err
+		}
+	}
+	return u.totp.Delete(userID)
+}
+
+func (u *totpUsecase) Challenge(userID int) (string, error) {
+	_, err := u.enabled(userID)
+	if errors.Is(err, model.ErrTOTPNotEnabled) {
+		return "", nil
+	}
+	if err != nil {
+		return "", err
+	}
+
+	raw, err := randomToken()
+	if err != nil {
+		return "", err
+	}
+	err = u.challenges.Create(&model.LoginChallenge{
+		TokenHash: hashToken(raw),
+		UserID:    userID,
+		ExpiresAt: time.Now().Add(LoginChallengeTTL),
+	})
+	if err != nil {
+		return "", err
+	}
+	return raw, nil
+}
+
+func (u *totpUsecase) ChallengeUser(token string) (int, error) {
+	c, err := u.challenges.Get(hashToken(token))
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, model.ErrInvalidLoginChallenge
+	}
+	if err != nil {
+		return 0, err
+	}
+	return c.UserID, nil
+}
+
+func (u *totpUsecase) CompleteChallenge(token, code string) (int, error) {
+	tokenHash := hashToken(token)
+	c, err := u.challenges.Get(tokenHash)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, model.ErrInvalidLoginChallenge
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	t, err := u.enabled(c.UserID)
+	if errors.Is(err, model.ErrTOTPNotEnabled) {
+		// Turned off since the password was checked; start over.
+		return 0, model.ErrInvalidLoginChallenge
+	}
+	if err != nil {
+		return 0, err
+	}
+	if err := u.check(t, code); err != nil {
+		if errors.Is(err, model.ErrInvalidTOTPCode) {
+			if ferr := u.challenges.Fail(tokenHash, LoginChallengeAttempts); ferr != nil {
+				return 0, ferr
+			}
+		}
+		return c.UserID, err
+	}
+
+	ok, err := u.challenges.Consume(tokenHash)
+	if err != nil {
+		return 0, err
+	}
+	if !ok {
+		return 0, model.ErrInvalidLoginChallenge
+	}
+	return c.UserID, nil
+}
+
+// enabled returns the user's confirmed setup or model.ErrTOTPNotEnabled.
+func (u *totpUsecase) enabled(userID int) (*model.UserTOTP, error) {
+	t, err := u.totp.Get(userID)
+	if errors.Is(err, sql.ErrNoRows) || (err == nil && !t.Enabled()) {
+		return nil, model.ErrTOTPNotEnabled
+	}
+	return t, err
+}
+
+// check accepts a TOTP code once, or uses up a recovery code.
+func (u *totpUsecase) check(t *model.UserTOTP, code string) error {
+	code = strings.TrimSpace(code)
+	if len(code) == totp.Digits {
+		step, ok := totp.Validate(t.Secret, code, time.Now())
+		if !ok {
+			return model.ErrInvalidTOTPCode
+		}
+		ok, err := u.totp.UseStep(t.UserID, step)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			return model.ErrInvalidTOTPCode
+		}
+		return nil
+	}
+
+	ok, err := u.totp.UseRecoveryCode(t.UserID, hashToken(normalizeRecoveryCode(code)))
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return model.ErrInvalidTOTPCode
+	}
+	return nil
+}
+
+var recoveryEncoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// recoveryCode returns a code like "k3vq7-x2m4p" (50 random bits).
+func recoveryCode() (string, error) {
+	b := make([]byte, 7)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	s := strings.ToLower(recoveryEncoding.EncodeToString(b))[:10]
+	return s[:5] + "-" + s[5:], nil
+}
+
+func normalizeRecoveryCode(code string) string {
+	return strings.ToLower(strings.NewReplacer("-", "", " ", "").Replace(code))
+}
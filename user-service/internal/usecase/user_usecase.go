@@ -1,38 +1,70 @@
 package usecase
 
 import (
+	"database/sql"
+	"errors"
+	"net/mail"
+	"strings"
+	"time"
 	"user-service/internal/model"
 	"user-service/internal/repository"
+	"user-service/logger"
 
 	"github.com/google/uuid"
 	"golang.org/x/crypto/bcrypt"
-	"google.golang.org/grpc/codes"
-	"google.golang.org/grpc/status"
 )
 
 type UserUsecase interface {
 	Register(user *model.User) error
-	Login(email, password string) (model.User, error)
+	Login(email, password, clientIP string) (model.LoginResult, error)
+	VerifySecondFactor(challengeToken, code, clientIP string) (model.User, error)
 	GetProfile(id int) (model.User, error)
-	UpdateUser(user model.User) error
+	UpdateUser(user model.User) (model.User, error)
+	ChangeEmail(userID int, newEmail string) error
+	ConfirmEmailChange(token string) (model.User, error)
+	ChangePassword(userID int, currentPassword, newPassword string) error
 	DeleteUser(id int) error
 	Verify(token string) error
+	ResendVerification(email string) error
+	RequestPasswordReset(email string) error
+	ResetPassword(token, newPassword string) error
+	UnlockUser(id int) error
 }
 
-// Mailer умеет отправлять письма с верификацией
+const (
+	// PendingRegistrationTTL is how long a verification link stays valid;
+	// unverified registrations are purged afterwards.
+	PendingRegistrationTTL = 24 * time.Hour
+	// PasswordResetTTL is how long a password reset link stays valid.
+	PasswordResetTTL = time.Hour
+	// EmailChangeTTL is how long the link confirming a new email stays valid.
+	EmailChangeTTL = 24 * time.Hour
+)
+
+// Mailer умеет отправлять письма с верификацией, сбросом пароля и сменой email
 type Mailer interface {
 	SendVerification(email, token string) error
+	SendPasswordReset(email, token string) error
+	SendEmailChange(email, token string) error
 }
 
 type userUsecase struct {
 	repo   repository.UserRepository
+	resets repository.PasswordResetRepository
+	emails repository.EmailChangeRepository
 	mailer Mailer
+	guard  LoginGuard
+	totp   TOTPUsecase
 }
 
-func NewUserUsecase(repo repository.UserRepository, mailer Mailer) UserUsecase {
+func NewUserUsecase(repo repository.UserRepository, resets repository.PasswordResetRepository, emails repository.EmailChangeRepository, mailer Mailer, guard LoginGuard, totp TOTPUsecase) UserUsecase {
 	return &userUsecase{
 		repo:   repo,
+		resets: resets,
+		emails: emails,
 		mailer: mailer,
+		guard:  guard,
+		totp:   totp,
 	}
 }
 
@@ -47,8 +79,8 @@ func (u *userUsecase) Register(user *model.User) error {
 	// 2. Генерируем токен
 	token := uuid.NewString()
 
-	// 3. Сохраняем в pending_users
-	if err := u.repo.CreatePendingUser(user, token); err != nil {
+	// 3. Сохраняем в pending_users (повторная регистрация заменяет прежнюю)
+	if err := u.repo.CreatePendingUser(user, token, time.Now().Add(PendingRegistrationTTL)); err != nil {
 		return err
 	}
 
@@ -56,14 +88,66 @@ func (u *userUsecase) Register(user *model.User) error {
 	return u.mailer.SendVerification(user.Email, token)
 }
 
-func (u *userUsecase) Login(email, password string) (model.User, error) {
+// Login checks the credentials. Repeated failures for the account or from
+// clientIP make further attempts wait, see LoginGuard. Users with two-factor
+// authentication get a challenge to complete with VerifySecondFactor.
+func (u *userUsecase) Login(email, password, clientIP string) (model.LoginResult, error) {
+	if err := u.guard.Check(email, clientIP); err != nil {
+		return model.LoginResult{}, err
+	}
+
 	user, err := u.repo.GetUserByEmail(email)
+	if errors.Is(err, sql.ErrNoRows) {
+		u.guard.Failed(email, clientIP)
+		return model.LoginResult{}, model.ErrInvalidCredentials
+	}
 	if err != nil {
-		return model.User{}, err
+		return model.LoginResult{}, err
 	}
 	if bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(password)) != nil {
-		return model.User{}, bcrypt.ErrMismatchedHashAndPassword
+		u.guard.Failed(email, clientIP)
+		return model.LoginResult{}, model.ErrInvalidCredentials
+	}
+
+	challenge, err := u.totp.Challenge(user.ID)
+	if err != nil {
+		return model.LoginResult{}, err
 	}
+	if challenge != "" {
+		// Failures are kept until the second factor passes, so wrong codes
+		// and the lockout still add up across challenges.
+		return model.LoginResult{User: user, ChallengeToken: challenge}, nil
+	}
+	u.guard.Succeeded(email)
+	return model.LoginResult{User: user}, nil
+}
+
+// VerifySecondFactor completes a login with the challenge from Login and a
+// TOTP or recovery code. The account and clientIP are throttled as in Login
+// before the code is checked, and wrong codes count as failed logins.
+func (u *userUsecase) VerifySecondFactor(challengeToken, code, clientIP string) (model.User, error) {
+	userID, err := u.totp.ChallengeUser(challengeToken)
+	if err != nil {
+		return model.User{}, err
+	}
+	user, err := u.repo.GetUserByID(userID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return model.User{}, model.ErrInvalidLoginChallenge
+	}
+	if err != nil {
+		return model.User{}, err
+	}
+	if err := u.guard.Check(user.Email, clientIP); err != nil {
+		return model.User{}, err
+	}
+
+	if _, err := u.totp.CompleteChallenge(challengeToken, code); err != nil {
+		if errors.Is(err, model.ErrInvalidTOTPCode) {
+			u.guard.Failed(user.Email, clientIP)
+		}
+		return model.User{}, err
+	}
+	u.guard.Succeeded(user.Email)
 	return user, nil
 }
 
@@ -71,8 +155,87 @@ func (u *userUsecase) GetProfile(id int) (model.User, error) {
 	return u.repo.GetUserByID(id)
 }
 
-func (u *userUsecase) UpdateUser(user model.User) error {
-	return u.repo.UpdateUser(user)
+// UpdateUser saves the profile fields of user and returns the updated
+// profile. ChangeEmail and ChangePassword cover the credentials.
+func (u *userUsecase) UpdateUser(user model.User) (model.User, error) {
+	err := u.repo.UpdateUser(user)
+	if errors.Is(err, sql.ErrNoRows) {
+		return model.User{}, model.ErrUserNotFound
+	}
+	if err != nil {
+		return model.User{}, err
+	}
+	return u.repo.GetUserByID(user.ID)
+}
+
+// ChangeEmail sends a confirmation link to newEmail. The account keeps its
+// current email until ConfirmEmailChange is called with the link's token.
+func (u *userUsecase) ChangeEmail(userID int, newEmail string) error {
+	addr, err := mail.ParseAddress(newEmail)
+	if err != nil || addr.Address != strings.TrimSpace(newEmail) {
+		return model.ErrInvalidEmail
+	}
+	newEmail = addr.Address
+
+	if _, err := u.repo.GetUserByID(userID); errors.Is(err, sql.ErrNoRows) {
+		return model.ErrUserNotFound
+	} else if err != nil {
+		return err
+	}
+	if _, err := u.repo.GetUserByEmail(newEmail); err == nil {
+		return model.ErrEmailTaken
+	} else if !errors.Is(err, sql.ErrNoRows) {
+		return err
+	}
+
+	raw, err := randomToken()
+	if err != nil {
+		return err
+	}
+	req := &model.EmailChangeRequest{
+		UserID:    userID,
+		NewEmail:  newEmail,
+		TokenHash: hashToken(raw),
+		ExpiresAt: time.Now().Add(EmailChangeTTL),
+	}
+	if err := u.emails.Create(req); err != nil {
+		return err
+	}
+	return u.mailer.SendEmailChange(newEmail, raw)
+}
+
+// ConfirmEmailChange applies the change the token was sent for and returns
+// the updated profile.
+func (u *userUsecase) ConfirmEmailChange(token string) (model.User, error) {
+	userID, err := u.emails.Confirm(hashToken(token))
+	if err != nil {
+		return model.User{}, err
+	}
+	return u.repo.GetUserByID(userID)
+}
+
+// ChangePassword sets a new password after checking the current one. All
+// of the user's sessions are ended.
+func (u *userUsecase) ChangePassword(userID int, currentPassword, newPassword string) error {
+	hash, err := u.repo.GetPasswordHash(userID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return model.ErrUserNotFound
+	}
+	if err != nil {
+		return err
+	}
+	if bcrypt.CompareHashAndPassword([]byte(hash), []byte(currentPassword)) != nil {
+		return model.ErrWrongPassword
+	}
+	if len(newPassword) < model.MinPasswordLength {
+		return model.ErrPasswordTooShort
+	}
+
+	hashed, err := bcrypt.GenerateFromPassword([]byte(newPassword), bcrypt.DefaultCost)
+	if err != nil {
+		return err
+	}
+	return u.repo.UpdatePassword(userID, string(hashed))
 }
 
 func (u *userUsecase) DeleteUser(id int) error {
@@ -82,10 +245,25 @@ func (u *userUsecase) DeleteUser(id int) error {
 func (u *userUsecase) Verify(token string) error {
 	// 1. Достаём pending по токену
 	pu, err := u.repo.GetPendingByToken(token)
+	if errors.Is(err, sql.ErrNoRows) {
+		return model.ErrInvalidVerificationToken
+	}
 	if err != nil {
-		return status.Errorf(codes.NotFound, "invalid token")
+		return err
+	}
+	if time.Now().After(pu.ExpiresAt) {
+		return model.ErrInvalidVerificationToken
+	}
+	// 2. Email мог быть зарегистрирован, пока письмо ждало подтверждения
+	if _, err := u.repo.GetUserByEmail(pu.Email); err == nil {
+		if err := u.repo.DeletePending(token); err != nil {
+			return err
+		}
+		return model.ErrEmailTaken
+	} else if !errors.Is(err, sql.ErrNoRows) {
+		return err
 	}
-	// 2. Создаём реального пользователя
+	// 3. Создаём реального пользователя
 	full := &model.User{
 		Email:    pu.Email,
 		Name:     pu.Name,
@@ -94,6 +272,99 @@ func (u *userUsecase) Verify(token string) error {
 	if err := u.repo.CreateUser(full); err != nil {
 		return err
 	}
-	// 3. Удаляем pending
+	// 4. Удаляем pending
 	return u.repo.DeletePending(token)
 }
+
+// ResendVerification sends a new verification link for a pending
+// registration; the previous link stops working. Like RequestPasswordReset
+// it succeeds whether or not the email has a pending registration, and email
+// delivery is asynchronous.
+func (u *userUsecase) ResendVerification(email string) error {
+	pu, err := u.repo.GetPendingByEmail(email)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	token := uuid.NewString()
+	if err := u.repo.RenewPendingToken(pu.Email, token, time.Now().Add(PendingRegistrationTTL)); err != nil {
+		return err
+	}
+
+	go func() {
+		if err := u.mailer.SendVerification(pu.Email, token); err != nil {
+			logger.Log.Errorf("Failed to resend verification email: %v", err)
+		}
+	}()
+	return nil
+}
+
+// RequestPasswordReset emails a reset link if the email is registered. It
+// succeeds either way; email delivery is asynchronous, so sending does not
+// delay the response. Looking up a registered address and storing its token
+// still takes longer than an unknown one.
+func (u *userUsecase) RequestPasswordReset(email string) error {
+	user, err := u.repo.GetUserByEmail(email)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	raw, err := randomToken()
+	if err != nil {
+		return err
+	}
+	token := &model.PasswordResetToken{
+		UserID:    user.ID,
+		TokenHash: hashToken(raw),
+		ExpiresAt: time.Now().Add(PasswordResetTTL),
+	}
+	if err := u.resets.Create(token); err != nil {
+		return err
+	}
+
+	go func() {
+		if err := u.mailer.SendPasswordReset(user.Email, raw); err != nil {
+			logger.Log.Errorf("Failed to send password reset email to user %d: %v", user.ID, err)
+		}
+	}()
+	return nil
+}
+
+// ResetPassword sets a new password with a token from RequestPasswordReset.
+// The token works once; all of the user's sessions are ended.
+func (u *userUsecase) ResetPassword(token, newPassword string) error {
+	if len(newPassword) < model.MinPasswordLength {
+		return model.ErrPasswordTooShort
+	}
+	hashed, err := bcrypt.GenerateFromPassword([]byte(newPassword), bcrypt.DefaultCost)
+	if err != nil {
+		return err
+	}
+
+	ok, err := u.resets.Reset(hashToken(token), string(hashed))
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return model.ErrInvalidResetToken
+	}
+	return nil
+}
+
+// UnlockUser lifts a lockout of the user's account after failed logins.
+func (u *userUsecase) UnlockUser(id int) error {
+	user, err := u.repo.GetUserByID(id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return model.ErrUserNotFound
+	}
+	if err != nil {
+		return err
+	}
+	return u.guard.Unlock(user.Email)
+}
@@ -0,0 +1,106 @@
+package usecase
+
+import (
+	"errors"
+	"testing"
+	"time"
+	"user-service/internal/model"
+	"user-service/internal/repository"
+)
+
+type singleUserRepo struct {
+	repository.UserRepository
+	user model.User
+}
+
+func (r *singleUserRepo) GetUserByID(id int) (model.User, error) {
+	return r.user, nil
+}
+
+// fakeChallenges accepts "123456" for the challenge "challenge".
+type fakeChallenges struct {
+	TOTPUsecase
+	userID    int
+	completed int
+}
+
+func (f *fakeChallenges) ChallengeUser(token string) (int, error) {
+	if token != "challenge" {
+		return 0, model.ErrInvalidLoginChallenge
+	}
+	return f.userID, nil
+}
+
+func (f *fakeChallenges) CompleteChallenge(token, code string) (int, error) {
+	f.completed++
+	if code != "123456" {
+		return f.userID, model.ErrInvalidTOTPCode
+	}
+	return f.userID, nil
+}
+
+type fakeGuard struct {
+	LoginGuard
+	locked    bool
+	failed    []string
+	succeeded []string
+}
+
+func (g *fakeGuard) Check(email, clientIP string) error {
+	if g.locked {
+		return &model.LoginLockedError{RetryAfter: time.Minute}
+	}
+	return nil
+}
+
+func (g *fakeGuard) Failed(email, clientIP string) { g.failed = append(g.failed, email) }
+
+func (g *fakeGuard) Succeeded(email string) { g.succeeded = append(g.succeeded, email) }
+
+func TestVerifySecondFactor(t *testing.T) {
+	tests := []struct {
+		name          string
+		locked        bool
+		code          string
+		wantErr       error
+		wantCompleted int
+		wantFailed    int
+		wantSucceeded int
+	}{
+		{"right code", false, "123456", nil, 1, 0, 1},
+		{"wrong code", false, "000000", model.ErrInvalidTOTPCode, 1, 1, 0},
+		{"locked account", true, "123456", nil, 0, 0, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			challenges := &fakeChallenges{userID: 7}
+			guard := &fakeGuard{locked: tt.locked}
+			u := &userUsecase{
+				repo:  &singleUserRepo{user: model.User{ID: 7, Email: "ada@example.com"}},
+				guard: guard,
+				totp:  challenges,
+			}
+
+			_, err := u.VerifySecondFactor("challenge", tt.code, "203.0.113.7")
+			var locked *model.LoginLockedError
+			switch {
+			case tt.locked && !errors.As(err, &locked):
+				t.Fatalf("err = %v, want a LoginLockedError", err)
+			case !tt.locked && !errors.Is(err, tt.wantErr):
+				t.Fatalf("err = %v, want %v", err, tt.wantErr)
+			}
+			if challenges.completed != tt.wantCompleted {
+				t.Errorf("codes checked %d times, want %d", challenges.completed, tt.wantCompleted)
+			}
+			if len(guard.failed) != tt.wantFailed || len(guard.succeeded) != tt.wantSucceeded {
+				t.Errorf("failed %v, succeeded %v; want %d and %d", guard.failed, guard.succeeded, tt.wantFailed, tt.wantSucceeded)
+			}
+		})
+	}
+
+	u := &userUsecase{totp: &fakeChallenges{}}
+	if _, err := u.VerifySecondFactor("expired", "123456", ""); !errors.Is(err, model.ErrInvalidLoginChallenge) {
+		t.Errorf("unknown challenge: err = %v, want ErrInvalidLoginChallenge", err)
+	}
+}
@@ -0,0 +1,41 @@
+CREATE TABLE IF NOT EXISTS roles (
+  id SERIAL PRIMARY KEY,
+  name VARCHAR(50) UNIQUE NOT NULL,
+  description TEXT NOT NULL DEFAULT ''
+);
+
+CREATE TABLE IF NOT EXISTS permissions (
+  id SERIAL PRIMARY KEY,
+  name VARCHAR(100) UNIQUE NOT NULL,
+  description TEXT NOT NULL DEFAULT ''
+);
+
+CREATE TABLE IF NOT EXISTS role_permissions (
+  role_id INT NOT NULL REFERENCES roles(id) ON DELETE CASCADE,
+  permission_id INT NOT NULL REFERENCES permissions(id) ON DELETE CASCADE,
+  PRIMARY KEY (role_id, permission_id)
+);
+
+CREATE TABLE IF NOT EXISTS user_roles (
+  user_id INT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+  role_id INT NOT NULL REFERENCES roles(id) ON DELETE CASCADE,
+  assigned_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  PRIMARY KEY (user_id, role_id)
+);
+
+INSERT INTO roles (name, description) VALUES
+  ('admin', 'Shop administrator'),
+  ('warehouse', 'Warehouse staff')
+ON CONFLICT (name) DO NOTHING;
+
+INSERT INTO permissions (name, description) VALUES
+  ('inventory.write', 'Create, update and delete products'),
+  ('orders.manage', 'See and change every order'),
+  ('users.manage', 'Manage user accounts and their roles')
+ON CONFLICT (name) DO NOTHING;
+
+INSERT INTO role_permissions (role_id, permission_id)
+SELECT r.id, p.id FROM roles r JOIN permissions p ON
+  (r.name = 'admin') OR
+  (r.name = 'warehouse' AND p.name IN ('inventory.write', 'orders.manage'))
+ON CONFLICT DO NOTHING;
@@ -0,0 +1,19 @@
+CREATE TABLE IF NOT EXISTS pending_users (
+  id SERIAL PRIMARY KEY,
+  email VARCHAR(100) NOT NULL,
+  name VARCHAR(100) NOT NULL,
+  password VARCHAR(100) NOT NULL,
+  token VARCHAR(100) UNIQUE NOT NULL
+);
+
+-- The table used to be created by hand, without these columns.
+ALTER TABLE pending_users ADD COLUMN IF NOT EXISTS created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP;
+ALTER TABLE pending_users ADD COLUMN IF NOT EXISTS expires_at TIMESTAMP NOT NULL DEFAULT (CURRENT_TIMESTAMP + INTERVAL '24 hours');
+
+-- Keep only the latest registration of every email.
+DELETE FROM pending_users p
+USING pending_users newer
+WHERE p.email = newer.email AND p.ctid < newer.ctid;
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_pending_users_email ON pending_users (email);
+CREATE INDEX IF NOT EXISTS idx_pending_users_expires_at ON pending_users (expires_at);
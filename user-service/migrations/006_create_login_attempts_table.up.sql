@@ -0,0 +1,9 @@
+-- Failed logins per account ("account:<email>") and per client IP ("ip:<addr>").
+CREATE TABLE IF NOT EXISTS login_attempts (
+  key VARCHAR(320) PRIMARY KEY,
+  failures INT NOT NULL DEFAULT 0,
+  last_failed_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  locked_until TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_login_attempts_last_failed_at ON login_attempts (last_failed_at);
@@ -0,0 +1,26 @@
+CREATE TABLE IF NOT EXISTS user_totp (
+  user_id INT PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+  secret VARCHAR(64) NOT NULL,
+  confirmed_at TIMESTAMP,
+  last_used_step BIGINT NOT NULL DEFAULT 0,
+  created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+-- One-time codes for when the authenticator is lost; stored by hash only.
+CREATE TABLE IF NOT EXISTS totp_recovery_codes (
+  id BIGSERIAL PRIMARY KEY,
+  user_id INT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+  code_hash VARCHAR(64) NOT NULL,
+  used_at TIMESTAMP,
+  UNIQUE (user_id, code_hash)
+);
+
+CREATE TABLE IF NOT EXISTS login_challenges (
+  token_hash VARCHAR(64) PRIMARY KEY,
+  user_id INT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+  attempts INT NOT NULL DEFAULT 0,
+  expires_at TIMESTAMP NOT NULL,
+  created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_login_challenges_expires_at ON login_challenges (expires_at);
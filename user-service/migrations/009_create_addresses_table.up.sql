@@ -0,0 +1,20 @@
+CREATE TABLE IF NOT EXISTS addresses (
+  id SERIAL PRIMARY KEY,
+  user_id INT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+  label VARCHAR(64) NOT NULL DEFAULT '',
+  recipient_name VARCHAR(255) NOT NULL,
+  line1 VARCHAR(255) NOT NULL,
+  line2 VARCHAR(255) NOT NULL DEFAULT '',
+  city VARCHAR(255) NOT NULL,
+  region VARCHAR(255) NOT NULL DEFAULT '',
+  postal_code VARCHAR(16) NOT NULL DEFAULT '',
+  country CHAR(2) NOT NULL,
+  phone VARCHAR(32) NOT NULL DEFAULT '',
+  is_default BOOLEAN NOT NULL DEFAULT FALSE,
+  created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_addresses_user ON addresses (user_id);
+-- At most one default address per user.
+CREATE UNIQUE INDEX IF NOT EXISTS idx_addresses_user_default ON addresses (user_id) WHERE is_default;